@@ -0,0 +1,103 @@
+// Command dlq inspects dead-letter topics and replays their messages to the original topics.
+//
+//	dlq [-brokers host:port] list   -topic library.dlq
+//	dlq [-brokers host:port] replay -topic library.dlq [-partition 0 -offset 42]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/IBM/sarama"
+)
+
+func main() {
+	brokers := flag.String("brokers", os.Getenv("KAFKA_BROKERS"), "comma separated kafka brokers")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-brokers addrs] list|replay -topic <topic>.dlq [-partition p -offset o]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || *brokers == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cmd := flag.NewFlagSet(flag.Arg(0), flag.ExitOnError)
+	topic := cmd.String("topic", "", "dead-letter topic")
+	partition := cmd.Int("partition", -1, "only this partition")
+	offset := cmd.Int64("offset", -1, "only this offset")
+	if err := cmd.Parse(flag.Args()[1:]); err != nil {
+		log.Fatal(err)
+	}
+	if *topic == "" {
+		cmd.Usage()
+		os.Exit(2)
+	}
+	match := func(dl kafka.DeadLetter) bool {
+		return (*partition < 0 || dl.Partition == int32(*partition)) && (*offset < 0 || dl.Offset == *offset)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	client, err := kafka.NewClient(kafka.Config{Addrs: *brokers})
+	if err != nil {
+		log.Fatal("kafka client ", err)
+	}
+	defer client.Close()
+
+	switch cmd.Name() {
+	case "list":
+		err = kafka.ReadDLQ(ctx, client, *topic, func(dl kafka.DeadLetter) error {
+			if match(dl) {
+				printDeadLetter(dl)
+			}
+			return nil
+		})
+	case "replay":
+		var producer sarama.SyncProducer
+		producer, err = sarama.NewSyncProducerFromClient(client)
+		if err != nil {
+			log.Fatal("kafka producer ", err)
+		}
+		defer producer.Close()
+		var replayed int
+		err = kafka.ReadDLQ(ctx, client, *topic, func(dl kafka.DeadLetter) error {
+			if !match(dl) {
+				return nil
+			}
+			if err := kafka.Replay(producer, dl); err != nil {
+				return fmt.Errorf("replay %d/%d: %w", dl.Partition, dl.Offset, err)
+			}
+			replayed++
+			fmt.Printf("replayed %d/%d to %s\n", dl.Partition, dl.Offset, dl.OriginalTopic)
+			return nil
+		})
+		fmt.Printf("%d messages replayed\n", replayed)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printDeadLetter(dl kafka.DeadLetter) {
+	fmt.Printf("%d/%d\t%s\toriginal=%s/%d/%d group=%s attempts=%d\n",
+		dl.Partition, dl.Offset, dl.FailedAt.Format(time.RFC3339), dl.OriginalTopic, dl.OriginalPartition,
+		dl.OriginalOffset, dl.ConsumerGroup, dl.Attempts)
+	fmt.Printf("\terror: %s\n", dl.Error)
+	if len(dl.Key) > 0 {
+		fmt.Printf("\tkey: %s\n", dl.Key)
+	}
+	fmt.Printf("\tvalue: %s\n", dl.Value)
+}
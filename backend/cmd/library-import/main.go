@@ -0,0 +1,47 @@
+// Command library-import imports the books of a CSV or MARC21 (ISO 2709) file into a library.
+// It reads the database config of the library service from the environment or .env.
+//
+//	library-import -library 83575e12-7ce0-48ee-9931-51919ff3c9ee [-format CSV|MARC21] [-actor name] books.csv
+//
+// Running it again on the same file resumes an interrupted import.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Astemirdum/library-service/backend/library/app"
+	"github.com/Astemirdum/library-service/backend/library/config"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+)
+
+func main() {
+	libraryUid := flag.String("library", "", "uid of the library the books are imported into")
+	format := flag.String("format", "", "CSV or MARC21, guessed by the file extension if empty")
+	actor := flag.String("actor", os.Getenv("USER"), "name of the librarian recorded in the audit trail")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -library <uid> [-format CSV|MARC21] [-actor name] <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *libraryUid == "" || *actor == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	cfg := config.NewConfig(config.WithLogLevel(zapcore.InfoLevel))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if err := app.Import(ctx, cfg, *libraryUid, strings.ToUpper(*format), *actor, flag.Arg(0), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
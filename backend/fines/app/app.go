@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/fines/config"
+	"github.com/Astemirdum/library-service/backend/fines/internal/handler"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/fines/internal/payment"
+	"github.com/Astemirdum/library-service/backend/fines/internal/repository"
+	"github.com/Astemirdum/library-service/backend/fines/internal/server"
+	"github.com/Astemirdum/library-service/backend/fines/internal/service"
+	"github.com/Astemirdum/library-service/backend/fines/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
+	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+	"go.uber.org/zap"
+)
+
+func Run(cfg *config.Config) error {
+	log := logger.NewLogger(cfg.Log, "fines")
+	db, err := postgres.NewPostgresDB(context.Background(), &cfg.Database, migrations.MigrationFiles)
+	if err != nil {
+		return fmt.Errorf("db init %w", err)
+	}
+	repo, err := repository.NewRepository(db, log)
+	if err != nil {
+		return fmt.Errorf("repo fines %w", err)
+	}
+	svc := service.NewService(repo, payment.NewStub(cfg.Payment.DeclineOver), model.Tariff{
+		DayRate:       cfg.Tariff.DayRate,
+		ConditionRate: cfg.Tariff.ConditionRate,
+		MaxOverdue:    cfg.Tariff.MaxOverdue,
+	}, cfg.Tariff.Currency, log)
+
+	if _, err := kafka.EnsureTopics(cfg.Kafka, log, kafka.FinesTopic, kafka.DLQTopic(kafka.FinesTopic)); err != nil {
+		log.Error("ensure topics", zap.Error(err))
+	}
+	producer, err := kafka.NewSyncProducer(cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("kafka.NewSyncProducer %w", err)
+	}
+	defer producer.Close()
+
+	consumer, err := kafka.NewConsumer(cfg.Kafka, kafka.FinesConsumerGroup)
+	if err != nil {
+		return fmt.Errorf("kafka.NewConsumer %w", err)
+	}
+	workers := lifecycle.New(log)
+	workers.Go("consumer", func(ctx context.Context, ready func()) error {
+		return kafka.Consume(ctx, consumer, handler.NewConsumer(svc.AssessFine, producer, cfg.Kafka, log), ready, kafka.FinesTopic)
+	})
+	workers.Go("payments reconciler", lifecycle.Loop(func(ctx context.Context) {
+		svc.ReconcilePayments(ctx, cfg.Payment.ReconcileInterval, cfg.Payment.PendingTimeout, cfg.Payment.ReconcileBatch)
+	}))
+
+	h := handler.New(svc, log)
+	srv := server.NewServer(cfg.Server, h.NewRouter(workers.Check))
+	log.Info("http server start ON: ",
+		zap.String("addr",
+			net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)))
+	go func() {
+		if err := srv.Run(); err != nil {
+			log.Error("server run", zap.Error(err))
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	var fatal error
+	select {
+	case termSig := <-sig:
+		log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	case fatal = <-workers.Fatal():
+		log.Error("Graceful shutdown", zap.Error(fatal))
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err = srv.Stop(closeCtx); err != nil {
+		log.DPanic("srv.Stop", zap.Error(err))
+	}
+	if err := workers.Stop(closeCtx); err != nil {
+		log.Error("workers.Stop", zap.Error(err))
+	}
+	db.Close()
+	log.Info("Graceful shutdown finished")
+	return fatal
+}
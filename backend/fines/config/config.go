@@ -0,0 +1,69 @@
+package config
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+	"github.com/kelseyhightower/envconfig"
+)
+
+type HTTPServer struct {
+	Host         string        `yaml:"host" envconfig:"FINES_HTTP_HOST"`
+	Port         string        `yaml:"port" envconfig:"FINES_HTTP_PORT"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" envconfig:"HTTP_READ"`
+	WriteTimeout time.Duration
+}
+
+// Tariff is the tariff of the libraries without one of their own. Amounts are in minor units of Currency.
+type Tariff struct {
+	Currency      string `yaml:"currency" envconfig:"FINES_CURRENCY" default:"RUB"`
+	DayRate       int64  `yaml:"dayRate" envconfig:"FINES_DAY_RATE" default:"1000"`
+	ConditionRate int64  `yaml:"conditionRate" envconfig:"FINES_CONDITION_RATE" default:"50000"`
+	// MaxOverdue caps the overdue part of a fine, 0 means no cap.
+	MaxOverdue int64 `yaml:"maxOverdue" envconfig:"FINES_MAX_OVERDUE" default:"100000"`
+}
+
+// Payment configures the local payment provider stub.
+type Payment struct {
+	// DeclineOver makes the stub decline charges over the amount, 0 means it approves every charge.
+	DeclineOver int64 `yaml:"declineOver" envconfig:"FINES_PAYMENT_DECLINE_OVER" default:"0"`
+	// PendingTimeout is how long a payment stays PENDING before it is reconciled with the provider.
+	PendingTimeout    time.Duration `yaml:"pendingTimeout" envconfig:"FINES_PAYMENT_PENDING_TIMEOUT" default:"5m"`
+	ReconcileInterval time.Duration `yaml:"reconcileInterval" envconfig:"FINES_PAYMENT_RECONCILE_INTERVAL" default:"1m"`
+	ReconcileBatch    int           `yaml:"reconcileBatch" envconfig:"FINES_PAYMENT_RECONCILE_BATCH" default:"100"`
+}
+
+type Config struct {
+	Server   HTTPServer   `yaml:"server"`
+	Kafka    kafka.Config `yaml:"kafka"`
+	Database postgres.DB  `yaml:"db"`
+	Tariff   Tariff       `yaml:"tariff"`
+	Payment  Payment      `yaml:"payment"`
+	Log      logger.Log   `yaml:"log"`
+}
+
+var (
+	once sync.Once
+	cfg  *Config
+)
+
+// NewConfig reads config from environment.
+func NewConfig(ops ...Option) *Config {
+	once.Do(func() {
+		var config Config
+		for _, op := range ops {
+			op(&config)
+		}
+		err := envconfig.Process("", &config)
+		if err != nil {
+			log.Fatal("NewConfig ", err)
+		}
+		cfg = &config
+	})
+
+	return cfg
+}
@@ -0,0 +1,19 @@
+package errs
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrOverpayment     = errors.New("payment exceeds the outstanding fines")
+	ErrPaymentDeclined = errors.New("payment is declined")
+	ErrPaymentPending  = errors.New("payment with the idempotency key is in progress")
+)
+
+type ValidationErrorResponse struct {
+	Message string `json:"message"`
+	Errors  struct {
+		AdditionalProperties string `json:"additionalProperties"`
+	} `json:"errors"`
+}
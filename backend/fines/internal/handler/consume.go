@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+type assess func(ctx context.Context, eventID string, req model.AssessFineRequest) (model.Fine, bool, error)
+
+func NewConsumer(assess assess, producer sarama.SyncProducer, cfg kafka.Config, log *zap.Logger) *kafka.TypedConsumer[kafka.Event[kafka.FineAssess]] {
+	return kafka.NewTypedConsumer(kafka.Events[kafka.FineAssess](kafka.EventFineAssess),
+		func(ctx context.Context, e kafka.Event[kafka.FineAssess]) error {
+			_, _, err := assess(ctx, e.EventID, model.AssessFineRequest{
+				ReservationUid: e.Data.ReservationUid,
+				UserName:       e.Data.UserName,
+				LibraryUid:     e.Data.LibraryUid,
+				TillDate:       model.Date{Time: e.Data.TillDate},
+				ReturnDate:     model.Date{Time: e.Data.ReturnDate},
+				ConditionFrom:  model.Condition(e.Data.ConditionFrom),
+				ConditionTo:    model.Condition(e.Data.ConditionTo),
+			})
+			return err
+		}, producer, kafka.FinesConsumerGroup, cfg, log)
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/errs"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	md "github.com/Astemirdum/library-service/backend/pkg/middleware"
+	"github.com/Astemirdum/library-service/backend/pkg/validate"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	finesSvc FinesService
+	log      *zap.Logger
+}
+
+func New(finesSvc FinesService, log *zap.Logger) *Handler {
+	return &Handler{
+		finesSvc: finesSvc,
+		log:      log,
+	}
+}
+
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
+	e := echo.New()
+	const (
+		baseRPS = 10
+		apiRPS  = 100
+	)
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{StackSize: 4 << 10}))
+	e.Use(middleware.Recover())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{http.MethodGet, http.MethodOptions, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
+		AllowCredentials: true,
+	}))
+
+	base := e.Group("", md.NewRateLimiter(baseRPS))
+	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
+
+	e.Validator = validate.NewCustomValidator()
+	api := e.Group("/api/v1",
+		middleware.RequestLoggerWithConfig(md.RequestLoggerConfig()),
+		middleware.RequestID(),
+		md.TraceContext,
+		md.NewRateLimiter(apiRPS),
+	)
+	api.POST("/fines", h.AssessFine)
+
+	api = api.Group("", md.AuthContext)
+	api.GET("/fines", h.GetFines)
+	api.POST("/payments", h.Pay)
+	api.GET("/tariffs/:libraryUid", h.GetTariff)
+	api.PUT("/tariffs/:libraryUid", h.PutTariff)
+	return e
+}
+
+func (h *Handler) Health(c echo.Context) error {
+	return c.String(http.StatusOK, "OK")
+}
+
+// AssessFine fines a returned reservation, the gateway calls it on return.
+func (h *Handler) AssessFine(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.AssessFineRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	fine, ok, err := h.finesSvc.AssessFine(ctx, req.EventID, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if !ok {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, fine)
+}
+
+func (h *Handler) GetFines(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	fines, err := h.finesSvc.GetFines(ctx, userName)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, fines)
+}
+
+func (h *Handler) Pay(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.PaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	req.UserName = userName
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	payment, err := h.finesSvc.Pay(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrOverpayment):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, errs.ErrPaymentDeclined):
+			return echo.NewHTTPError(http.StatusPaymentRequired, err.Error())
+		case errors.Is(err, errs.ErrPaymentPending):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusCreated, payment)
+}
+
+func (h *Handler) GetTariff(c echo.Context) error {
+	tariff, err := h.finesSvc.GetTariff(c.Request().Context(), c.Param("libraryUid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, tariff)
+}
+
+func (h *Handler) PutTariff(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsAdmin(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no admin")
+	}
+	var tariff model.Tariff
+	if err := c.Bind(&tariff); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	tariff.LibraryUid = c.Param("libraryUid")
+	if err := c.Validate(tariff); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.finesSvc.PutTariff(ctx, tariff); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, tariff)
+}
@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/errs"
+	"github.com/Astemirdum/library-service/backend/fines/internal/handler"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	md "github.com/Astemirdum/library-service/backend/pkg/middleware"
+	"github.com/Astemirdum/library-service/backend/pkg/validate"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/fines/internal/handler/mocks"
+)
+
+func TestHandler_Pay(t *testing.T) {
+	t.Parallel()
+	type response struct {
+		expectedCode int
+		expectedBody string
+	}
+	type mockBehavior func(r *service_mocks.MockFinesService)
+
+	req := model.PaymentRequest{Amount: 5000, IdempotencyKey: "key", UserName: "Test Max"}
+	var tests = []struct {
+		name         string
+		mockBehavior mockBehavior
+		body         string
+		response     response
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(r *service_mocks.MockFinesService) {
+				r.EXPECT().Pay(gomock.Any(), req).Return(model.Payment{
+					PaymentUid: "0b3e8a4c-a5d2-4c6a-9d3c-1f3a2b4c5d6e",
+					Username:   req.UserName,
+					Amount:     req.Amount,
+					Status:     model.PaymentCompleted,
+				}, nil)
+			},
+			body: `{"amount":5000,"idempotencyKey":"key"}`,
+			response: response{
+				expectedCode: http.StatusCreated,
+				expectedBody: `{"paymentUid":"0b3e8a4c-a5d2-4c6a-9d3c-1f3a2b4c5d6e","username":"Test Max","amount":5000,"status":"COMPLETED","createdAt":"0001-01-01T00:00:00Z"}`,
+			},
+		},
+		{
+			name:         "err. amount required",
+			mockBehavior: func(r *service_mocks.MockFinesService) {},
+			body:         `{"idempotencyKey":"key"}`,
+			response: response{
+				expectedCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "err. overpayment",
+			mockBehavior: func(r *service_mocks.MockFinesService) {
+				r.EXPECT().Pay(gomock.Any(), req).Return(model.Payment{}, errs.ErrOverpayment)
+			},
+			body: `{"amount":5000,"idempotencyKey":"key"}`,
+			response: response{
+				expectedCode: http.StatusBadRequest,
+				expectedBody: `{"message":"` + errs.ErrOverpayment.Error() + `"}`,
+			},
+		},
+		{
+			name: "err. declined",
+			mockBehavior: func(r *service_mocks.MockFinesService) {
+				r.EXPECT().Pay(gomock.Any(), req).Return(model.Payment{}, errs.ErrPaymentDeclined)
+			},
+			body: `{"amount":5000,"idempotencyKey":"key"}`,
+			response: response{
+				expectedCode: http.StatusPaymentRequired,
+				expectedBody: `{"message":"` + errs.ErrPaymentDeclined.Error() + `"}`,
+			},
+		},
+		{
+			name: "err. pending",
+			mockBehavior: func(r *service_mocks.MockFinesService) {
+				r.EXPECT().Pay(gomock.Any(), req).Return(model.Payment{}, errs.ErrPaymentPending)
+			},
+			body: `{"amount":5000,"idempotencyKey":"key"}`,
+			response: response{
+				expectedCode: http.StatusConflict,
+				expectedBody: `{"message":"` + errs.ErrPaymentPending.Error() + `"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			defer c.Finish()
+			svc := service_mocks.NewMockFinesService(c)
+			log := zap.NewExample().Named("test")
+			h := handler.New(svc, log)
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.POST("/payments", h.Pay, md.AuthContext)
+
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(tt.body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			r.Header.Set(auth.XUserNameHeader, req.UserName)
+			r.Header.Set(auth.XUserRoleHeader, "user")
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.response.expectedCode, w.Code)
+			if tt.response.expectedBody != "" {
+				require.Equal(t, tt.response.expectedBody, strings.Trim(w.Body.String(), "\n"))
+			}
+		})
+	}
+}
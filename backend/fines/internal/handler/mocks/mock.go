@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_handler is a generated GoMock package.
+package mock_handler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Astemirdum/library-service/backend/fines/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockFinesService is a mock of FinesService interface.
+type MockFinesService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFinesServiceMockRecorder
+}
+
+// MockFinesServiceMockRecorder is the mock recorder for MockFinesService.
+type MockFinesServiceMockRecorder struct {
+	mock *MockFinesService
+}
+
+// NewMockFinesService creates a new mock instance.
+func NewMockFinesService(ctrl *gomock.Controller) *MockFinesService {
+	mock := &MockFinesService{ctrl: ctrl}
+	mock.recorder = &MockFinesServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFinesService) EXPECT() *MockFinesServiceMockRecorder {
+	return m.recorder
+}
+
+// AssessFine mocks base method.
+func (m *MockFinesService) AssessFine(ctx context.Context, eventID string, req model.AssessFineRequest) (model.Fine, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssessFine", ctx, eventID, req)
+	ret0, _ := ret[0].(model.Fine)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AssessFine indicates an expected call of AssessFine.
+func (mr *MockFinesServiceMockRecorder) AssessFine(ctx, eventID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssessFine", reflect.TypeOf((*MockFinesService)(nil).AssessFine), ctx, eventID, req)
+}
+
+// GetFines mocks base method.
+func (m *MockFinesService) GetFines(ctx context.Context, username string) (model.Fines, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFines", ctx, username)
+	ret0, _ := ret[0].(model.Fines)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFines indicates an expected call of GetFines.
+func (mr *MockFinesServiceMockRecorder) GetFines(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFines", reflect.TypeOf((*MockFinesService)(nil).GetFines), ctx, username)
+}
+
+// GetTariff mocks base method.
+func (m *MockFinesService) GetTariff(ctx context.Context, libraryUID string) (model.Tariff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTariff", ctx, libraryUID)
+	ret0, _ := ret[0].(model.Tariff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTariff indicates an expected call of GetTariff.
+func (mr *MockFinesServiceMockRecorder) GetTariff(ctx, libraryUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTariff", reflect.TypeOf((*MockFinesService)(nil).GetTariff), ctx, libraryUID)
+}
+
+// Pay mocks base method.
+func (m *MockFinesService) Pay(ctx context.Context, req model.PaymentRequest) (model.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pay", ctx, req)
+	ret0, _ := ret[0].(model.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pay indicates an expected call of Pay.
+func (mr *MockFinesServiceMockRecorder) Pay(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pay", reflect.TypeOf((*MockFinesService)(nil).Pay), ctx, req)
+}
+
+// PutTariff mocks base method.
+func (m *MockFinesService) PutTariff(ctx context.Context, tariff model.Tariff) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutTariff", ctx, tariff)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutTariff indicates an expected call of PutTariff.
+func (mr *MockFinesServiceMockRecorder) PutTariff(ctx, tariff interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutTariff", reflect.TypeOf((*MockFinesService)(nil).PutTariff), ctx, tariff)
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/fines/internal/service"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -source=service.go -destination=mocks/mock.go
+
+type FinesService interface {
+	GetTariff(ctx context.Context, libraryUID string) (model.Tariff, error)
+	PutTariff(ctx context.Context, tariff model.Tariff) error
+	AssessFine(ctx context.Context, eventID string, req model.AssessFineRequest) (model.Fine, bool, error)
+	GetFines(ctx context.Context, username string) (model.Fines, error)
+	Pay(ctx context.Context, req model.PaymentRequest) (model.Payment, error)
+}
+
+var _ FinesService = (*service.Service)(nil)
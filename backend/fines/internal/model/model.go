@@ -0,0 +1,129 @@
+package model
+
+import "time"
+
+type Date struct {
+	time.Time `json:",inline"`
+}
+
+type Condition string
+
+const (
+	ConditionExcellent Condition = "EXCELLENT"
+	ConditionGood      Condition = "GOOD"
+	ConditionBad       Condition = "BAD"
+)
+
+// Rank orders conditions from the worst one up.
+func (c Condition) Rank() int {
+	switch c {
+	case ConditionExcellent:
+		return 2
+	case ConditionGood:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Tariff is what a library charges. Amounts are in minor units of the currency of the service.
+type Tariff struct {
+	LibraryUid    string `json:"libraryUid" db:"library_uid" validate:"required,uuid"`
+	DayRate       int64  `json:"dayRate" db:"day_rate" validate:"gte=0"`
+	ConditionRate int64  `json:"conditionRate" db:"condition_rate" validate:"gte=0"`
+	// MaxOverdue caps the overdue part of a fine, 0 means no cap.
+	MaxOverdue int64 `json:"maxOverdue" db:"max_overdue" validate:"gte=0"`
+}
+
+// Assess returns the fine for days overdue and for every step the condition went down from to.
+func (t Tariff) Assess(days int, from, to Condition) int64 {
+	var amount int64
+	if days > 0 {
+		amount = int64(days) * t.DayRate
+		if t.MaxOverdue > 0 && amount > t.MaxOverdue {
+			amount = t.MaxOverdue
+		}
+	}
+	if steps := from.Rank() - to.Rank(); steps > 0 {
+		amount += int64(steps) * t.ConditionRate
+	}
+	return amount
+}
+
+type AssessFineRequest struct {
+	// EventID is shared with the Kafka fallback of the caller, so a fine is assessed once.
+	EventID        string    `json:"eventID,omitempty"`
+	ReservationUid string    `json:"reservationUid" validate:"required,uuid"`
+	UserName       string    `json:"username" validate:"required"`
+	LibraryUid     string    `json:"libraryUid" validate:"required,uuid"`
+	TillDate       Date      `json:"tillDate" validate:"required"`
+	ReturnDate     Date      `json:"returnDate" validate:"required"`
+	ConditionFrom  Condition `json:"conditionFrom" validate:"required,oneof=EXCELLENT GOOD BAD"`
+	ConditionTo    Condition `json:"conditionTo" validate:"required,oneof=EXCELLENT GOOD BAD"`
+}
+
+// DaysOverdue counts the days between the till date and the return date.
+func (r AssessFineRequest) DaysOverdue() int {
+	till := r.TillDate.Truncate(24 * time.Hour)
+	returned := r.ReturnDate.Truncate(24 * time.Hour)
+	if !returned.After(till) {
+		return 0
+	}
+	return int(returned.Sub(till).Hours() / 24)
+}
+
+type FineStatus string
+
+const (
+	FineOutstanding FineStatus = "OUTSTANDING"
+	FinePaid        FineStatus = "PAID"
+)
+
+type Fine struct {
+	ID             int        `json:"-" db:"id"`
+	FineUid        string     `json:"fineUid" db:"fine_uid"`
+	Username       string     `json:"username" db:"username"`
+	ReservationUid string     `json:"reservationUid" db:"reservation_uid"`
+	LibraryUid     string     `json:"libraryUid" db:"library_uid"`
+	DaysOverdue    int        `json:"daysOverdue" db:"days_overdue"`
+	ConditionFrom  Condition  `json:"conditionFrom" db:"condition_from"`
+	ConditionTo    Condition  `json:"conditionTo" db:"condition_to"`
+	Amount         int64      `json:"amount" db:"amount"`
+	Paid           int64      `json:"paid" db:"paid"`
+	Status         FineStatus `json:"status" db:"status"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Fines are the outstanding fines of a user. Outstanding is the balance of the user in the ledger.
+type Fines struct {
+	Fines       []Fine `json:"fines"`
+	Outstanding int64  `json:"outstanding"`
+	Currency    string `json:"currency"`
+}
+
+type PaymentRequest struct {
+	Amount int64 `json:"amount" validate:"required,gt=0"`
+	// IdempotencyKey makes a retried payment return the first one instead of charging again.
+	IdempotencyKey string `json:"idempotencyKey" validate:"omitempty,max=64"`
+	UserName       string `json:"-" validate:"required"`
+}
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "PENDING"
+	PaymentCompleted PaymentStatus = "COMPLETED"
+	PaymentFailed    PaymentStatus = "FAILED"
+)
+
+type Payment struct {
+	ID             int           `json:"-" db:"id"`
+	PaymentUid     string        `json:"paymentUid" db:"payment_uid"`
+	Username       string        `json:"username" db:"username"`
+	Amount         int64         `json:"amount" db:"amount"`
+	Status         PaymentStatus `json:"status" db:"status"`
+	ProviderRef    *string       `json:"providerRef,omitempty" db:"provider_ref"`
+	Error          *string       `json:"error,omitempty" db:"error"`
+	IdempotencyKey *string       `json:"-" db:"idempotency_key"`
+	CreatedAt      time.Time     `json:"createdAt" db:"created_at"`
+}
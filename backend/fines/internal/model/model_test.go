@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTariff_Assess(t *testing.T) {
+	t.Parallel()
+	tariff := Tariff{DayRate: 1000, ConditionRate: 50000, MaxOverdue: 10000}
+	tests := []struct {
+		name     string
+		days     int
+		from, to Condition
+		want     int64
+	}{
+		{name: "on time", days: 0, from: ConditionGood, to: ConditionGood, want: 0},
+		{name: "overdue", days: 3, from: ConditionGood, to: ConditionGood, want: 3000},
+		{name: "overdue capped", days: 30, from: ConditionGood, to: ConditionGood, want: 10000},
+		{name: "damaged", days: 0, from: ConditionExcellent, to: ConditionBad, want: 100000},
+		{name: "better condition", days: 1, from: ConditionBad, to: ConditionGood, want: 1000},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, tariff.Assess(tt.days, tt.from, tt.to), tt.name)
+	}
+}
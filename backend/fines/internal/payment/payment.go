@@ -0,0 +1,32 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/google/uuid"
+)
+
+// Provider charges the user of a payment and returns the reference of the charge at the provider.
+// Charging the same payment again must not charge twice.
+type Provider interface {
+	Charge(ctx context.Context, payment model.Payment) (string, error)
+}
+
+// Stub is a local provider that approves charges up to a limit without moving any money.
+type Stub struct {
+	declineOver int64
+}
+
+// NewStub returns a stub that declines charges over declineOver, 0 means it approves every charge.
+func NewStub(declineOver int64) *Stub {
+	return &Stub{declineOver: declineOver}
+}
+
+func (s *Stub) Charge(_ context.Context, payment model.Payment) (string, error) {
+	if s.declineOver > 0 && payment.Amount > s.declineOver {
+		return "", fmt.Errorf("amount %d is over the limit %d", payment.Amount, s.declineOver)
+	}
+	return "stub-" + uuid.NewSHA1(uuid.NameSpaceOID, []byte(payment.PaymentUid)).String(), nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Ledger accounts. A user owes the balance of the receivable account of the user.
+const (
+	accountRevenue = "revenue:fines"
+	accountCash    = "cash:payments"
+)
+
+func receivable(username string) string {
+	return "receivable:" + username
+}
+
+// Kinds of ledger transactions, the reference of a transaction is the uid of the fine or of the payment.
+const (
+	kindFine    = "FINE"
+	kindPayment = "PAYMENT"
+)
+
+type entry struct {
+	account string
+	amount  int64
+}
+
+// post records a ledger transaction. The entries must sum up to zero, the database checks it on commit.
+func post(ctx context.Context, tx pgx.Tx, kind, reference string, entries ...entry) error {
+	var sum int64
+	for _, e := range entries {
+		sum += e.amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("ledger %s %s is not balanced: %d", kind, reference, sum)
+	}
+	var id int64
+	err := tx.QueryRow(ctx, `insert into ledger_transactions (kind, reference) values ($1, $2) returning id`, kind, reference).
+		Scan(&id)
+	if err != nil {
+		return err
+	}
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(`insert into ledger_entries (transaction_id, account, amount) values ($1, $2, $3)`, id, e.account, e.amount)
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func balance(ctx context.Context, db querier, account string) (int64, error) {
+	var sum int64
+	err := db.QueryRow(ctx, `select coalesce(sum(amount), 0) from ledger_entries where account = $1`, account).Scan(&sum)
+	return sum, err
+}
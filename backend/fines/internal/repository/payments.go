@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/errs"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreatePayment stores a PENDING payment. If the idempotency key was used before, it returns that payment
+// and false instead. A payment over the outstanding fines of the user is rejected.
+func (r *repository) CreatePayment(ctx context.Context, req model.PaymentRequest) (model.Payment, bool, error) {
+	var key *string
+	if req.IdempotencyKey != "" {
+		key = &req.IdempotencyKey
+	}
+	q := fmt.Sprintf(`insert into %s (payment_uid, username, amount, status, idempotency_key)
+	values (@payment_uid, @username, @amount, @status, @idempotency_key)
+	on conflict (username, idempotency_key) do nothing
+	returning *`, paymentsTableName)
+	var (
+		payment model.Payment
+		created bool
+	)
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// serializes the payments of the user.
+		if _, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext($1))`, receivable(req.UserName)); err != nil {
+			return err
+		}
+		if key != nil {
+			rows, err := tx.Query(ctx, fmt.Sprintf(`select * from %s where idempotency_key = $1 and username = $2`, paymentsTableName),
+				*key, req.UserName)
+			if err != nil {
+				return err
+			}
+			payment, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Payment])
+			if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+		// pending payments count as paid already, so concurrent payments can not overpay together.
+		var due int64
+		err := tx.QueryRow(ctx, fmt.Sprintf(`select
+			(select coalesce(sum(amount), 0) from ledger_entries where account = $1) -
+			(select coalesce(sum(amount), 0) from %s where username = $2 and status = $3)`, paymentsTableName),
+			receivable(req.UserName), req.UserName, model.PaymentPending).Scan(&due)
+		if err != nil {
+			return err
+		}
+		if req.Amount > due {
+			return errs.ErrOverpayment
+		}
+		rows, err := tx.Query(ctx, q, pgx.NamedArgs{
+			"payment_uid":     uuid.New(),
+			"username":        req.UserName,
+			"amount":          req.Amount,
+			"status":          model.PaymentPending,
+			"idempotency_key": key,
+		})
+		if err != nil {
+			return err
+		}
+		payment, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Payment])
+		if errors.Is(err, pgx.ErrNoRows) {
+			// a concurrent payment took the key, the caller retries.
+			return errs.ErrPaymentPending
+		}
+		created = err == nil
+		return err
+	})
+	return payment, created, err
+}
+
+// PendingPayments lists the payments PENDING for longer than pendingFor, the oldest first.
+func (r *repository) PendingPayments(ctx context.Context, pendingFor time.Duration, limit int) ([]model.Payment, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`select * from %s
+	where status = $1 and created_at < now() - $2::interval
+	order by created_at, id
+	limit $3`, paymentsTableName), model.PaymentPending, pendingFor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Payment])
+}
+
+// CompletePayment posts the charged payment to the ledger and pays off the outstanding fines of the user, the oldest first.
+func (r *repository) CompletePayment(ctx context.Context, payment model.Payment, providerRef string) (model.Payment, error) {
+	var completed model.Payment
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		completed, err = r.changePayment(ctx, tx, payment.PaymentUid, model.PaymentCompleted, &providerRef, nil)
+		if err != nil {
+			return err
+		}
+		if err := post(ctx, tx, kindPayment, completed.PaymentUid,
+			entry{accountCash, completed.Amount},
+			entry{receivable(completed.Username), -completed.Amount},
+		); err != nil {
+			return err
+		}
+		return r.payOff(ctx, tx, completed.Username, completed.Amount)
+	})
+	return completed, err
+}
+
+func (r *repository) FailPayment(ctx context.Context, payment model.Payment, reason string) (model.Payment, error) {
+	var failed model.Payment
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		failed, err = r.changePayment(ctx, tx, payment.PaymentUid, model.PaymentFailed, nil, &reason)
+		return err
+	})
+	return failed, err
+}
+
+// changePayment moves a PENDING payment to status.
+func (r *repository) changePayment(ctx context.Context, tx pgx.Tx, paymentUID string, status model.PaymentStatus, providerRef, reason *string) (model.Payment, error) {
+	q := fmt.Sprintf(`update %s set status = @status, provider_ref = @provider_ref, error = @error
+	where payment_uid = @payment_uid and status = @pending
+	returning *`, paymentsTableName)
+	rows, err := tx.Query(ctx, q, pgx.NamedArgs{
+		"status":       status,
+		"provider_ref": providerRef,
+		"error":        reason,
+		"payment_uid":  paymentUID,
+		"pending":      model.PaymentPending,
+	})
+	if err != nil {
+		return model.Payment{}, err
+	}
+	payment, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Payment])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Payment{}, errs.ErrNotFound
+	}
+	return payment, err
+}
+
+// payOff spreads amount over the outstanding fines of the user, the oldest first.
+func (r *repository) payOff(ctx context.Context, tx pgx.Tx, username string, amount int64) error {
+	rows, err := tx.Query(ctx, fmt.Sprintf(`select id, amount - paid from %s
+	where username = $1 and status = $2 order by created_at, id for update`, finesTableName),
+		username, model.FineOutstanding)
+	if err != nil {
+		return err
+	}
+	type due struct {
+		id     int
+		amount int64
+	}
+	dues, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (due, error) {
+		var d due
+		err := row.Scan(&d.id, &d.amount)
+		return d, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range dues {
+		if amount == 0 {
+			break
+		}
+		pay := min(amount, d.amount)
+		_, err := tx.Exec(ctx, fmt.Sprintf(`update %s set paid = paid + $1,
+			status = case when paid + $1 = amount then $2 else status end
+		where id = $3`, finesTableName), pay, model.FinePaid, d.id)
+		if err != nil {
+			return err
+		}
+		amount -= pay
+	}
+	return nil
+}
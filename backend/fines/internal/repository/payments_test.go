@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/fines/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_Payments(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, username := range []string{"first", "second"} {
+		_, err := r.CreateFine(ctx, uuid.NewString(), model.Fine{
+			Username:       username,
+			ReservationUid: uuid.NewString(),
+			LibraryUid:     uuid.NewString(),
+			ConditionFrom:  model.ConditionExcellent,
+			ConditionTo:    model.ConditionBad,
+			Amount:         100,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("idempotency key", func(t *testing.T) {
+		first, created, err := r.CreatePayment(ctx, model.PaymentRequest{Amount: 10, IdempotencyKey: "key", UserName: "first"})
+		require.NoError(t, err)
+		require.True(t, created)
+
+		again, created, err := r.CreatePayment(ctx, model.PaymentRequest{Amount: 10, IdempotencyKey: "key", UserName: "first"})
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, first.PaymentUid, again.PaymentUid)
+
+		second, created, err := r.CreatePayment(ctx, model.PaymentRequest{Amount: 10, IdempotencyKey: "key", UserName: "second"})
+		require.NoError(t, err)
+		require.True(t, created, "the key of another user is not taken")
+		require.NotEqual(t, first.PaymentUid, second.PaymentUid)
+	})
+	t.Run("pending", func(t *testing.T) {
+		_, err := db.Exec(ctx, `update payments set created_at = now() - interval '1 hour' where username = 'first'`)
+		require.NoError(t, err)
+
+		pending, err := r.PendingPayments(ctx, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1, "the payment of second is pending for less than a minute")
+		require.Equal(t, "first", pending[0].Username)
+
+		_, err = r.CompletePayment(ctx, pending[0], "ref")
+		require.NoError(t, err)
+		pending, err = r.PendingPayments(ctx, time.Minute, 10)
+		require.NoError(t, err)
+		require.Empty(t, pending)
+	})
+}
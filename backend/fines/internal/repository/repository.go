@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/errs"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type Repository interface {
+	GetTariff(ctx context.Context, libraryUID string) (model.Tariff, error)
+	PutTariff(ctx context.Context, tariff model.Tariff) error
+
+	CreateFine(ctx context.Context, eventID string, fine model.Fine) (model.Fine, error)
+	GetFines(ctx context.Context, username string) ([]model.Fine, error)
+	Outstanding(ctx context.Context, username string) (int64, error)
+
+	CreatePayment(ctx context.Context, req model.PaymentRequest) (model.Payment, bool, error)
+	CompletePayment(ctx context.Context, payment model.Payment, providerRef string) (model.Payment, error)
+	FailPayment(ctx context.Context, payment model.Payment, reason string) (model.Payment, error)
+	PendingPayments(ctx context.Context, pendingFor time.Duration, limit int) ([]model.Payment, error)
+}
+
+type repository struct {
+	db  *pgxpool.Pool
+	log *zap.Logger
+}
+
+func NewRepository(db *pgxpool.Pool, log *zap.Logger) (*repository, error) {
+	return &repository{
+		db:  db,
+		log: log.Named("repo"),
+	}, nil
+}
+
+const (
+	tariffsTableName  = `tariffs`
+	finesTableName    = `fines`
+	paymentsTableName = `payments`
+)
+
+func (r *repository) GetTariff(ctx context.Context, libraryUID string) (model.Tariff, error) {
+	q := fmt.Sprintf(`select library_uid, day_rate, condition_rate, max_overdue from %s where library_uid = $1`, tariffsTableName)
+	rows, err := r.db.Query(ctx, q, libraryUID)
+	if err != nil {
+		return model.Tariff{}, err
+	}
+	tariff, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Tariff])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Tariff{}, errs.ErrNotFound
+		}
+		return model.Tariff{}, err
+	}
+	return tariff, nil
+}
+
+func (r *repository) PutTariff(ctx context.Context, tariff model.Tariff) error {
+	q := fmt.Sprintf(`insert into %s (library_uid, day_rate, condition_rate, max_overdue)
+	values (@library_uid, @day_rate, @condition_rate, @max_overdue)
+	on conflict (library_uid) do update set day_rate = excluded.day_rate, condition_rate = excluded.condition_rate,
+		max_overdue = excluded.max_overdue, updated_at = now()`, tariffsTableName)
+	_, err := r.db.Exec(ctx, q, pgx.NamedArgs{
+		"library_uid":    tariff.LibraryUid,
+		"day_rate":       tariff.DayRate,
+		"condition_rate": tariff.ConditionRate,
+		"max_overdue":    tariff.MaxOverdue,
+	})
+	return err
+}
+
+// CreateFine stores the fine and posts it to the ledger. A reservation is fined at most once,
+// fining it again returns the first fine.
+func (r *repository) CreateFine(ctx context.Context, eventID string, fine model.Fine) (model.Fine, error) {
+	q := fmt.Sprintf(`insert into %s (fine_uid, username, reservation_uid, library_uid, days_overdue,
+		condition_from, condition_to, amount, status)
+	values (@fine_uid, @username, @reservation_uid, @library_uid, @days_overdue,
+		@condition_from, @condition_to, @amount, @status)
+	on conflict (reservation_uid) do nothing
+	returning *`, finesTableName)
+	args := pgx.NamedArgs{
+		"fine_uid":        uuid.New(),
+		"username":        fine.Username,
+		"reservation_uid": fine.ReservationUid,
+		"library_uid":     fine.LibraryUid,
+		"days_overdue":    fine.DaysOverdue,
+		"condition_from":  fine.ConditionFrom,
+		"condition_to":    fine.ConditionTo,
+		"amount":          fine.Amount,
+		"status":          model.FineOutstanding,
+	}
+	var created model.Fine
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if ok, err := postgres.MarkProcessed(ctx, tx, eventID); err != nil || !ok {
+			return err
+		}
+		rows, err := tx.Query(ctx, q, args)
+		if err != nil {
+			return err
+		}
+		created, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Fine])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return post(ctx, tx, kindFine, created.FineUid,
+			entry{receivable(created.Username), created.Amount},
+			entry{accountRevenue, -created.Amount},
+		)
+	})
+	if err != nil {
+		return model.Fine{}, err
+	}
+	if created.FineUid != "" {
+		return created, nil
+	}
+	return r.getFineByReservation(ctx, fine.ReservationUid)
+}
+
+func (r *repository) getFineByReservation(ctx context.Context, reservationUID string) (model.Fine, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`select * from %s where reservation_uid = $1`, finesTableName), reservationUID)
+	if err != nil {
+		return model.Fine{}, err
+	}
+	fine, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Fine])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Fine{}, errs.ErrNotFound
+	}
+	return fine, err
+}
+
+func (r *repository) GetFines(ctx context.Context, username string) ([]model.Fine, error) {
+	q := fmt.Sprintf(`select * from %s where username = $1 and status = $2 order by created_at, id`, finesTableName)
+	rows, err := r.db.Query(ctx, q, username, model.FineOutstanding)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Fine])
+}
+
+// Outstanding returns the balance of the receivable account of the user.
+func (r *repository) Outstanding(ctx context.Context, username string) (int64, error) {
+	return balance(ctx, r.db, receivable(username))
+}
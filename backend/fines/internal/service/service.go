@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/fines/internal/errs"
+	"github.com/Astemirdum/library-service/backend/fines/internal/model"
+	"github.com/Astemirdum/library-service/backend/fines/internal/payment"
+	"github.com/Astemirdum/library-service/backend/fines/internal/repository"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	log      *zap.Logger
+	repo     repository.Repository
+	provider payment.Provider
+	// tariff applies to the libraries without a tariff of their own.
+	tariff   model.Tariff
+	currency string
+}
+
+func NewService(repo repository.Repository, provider payment.Provider, tariff model.Tariff, currency string, log *zap.Logger) *Service {
+	return &Service{
+		log:      log,
+		repo:     repo,
+		provider: provider,
+		tariff:   tariff,
+		currency: currency,
+	}
+}
+
+func (s *Service) GetTariff(ctx context.Context, libraryUID string) (model.Tariff, error) {
+	tariff, err := s.repo.GetTariff(ctx, libraryUID)
+	if errors.Is(err, errs.ErrNotFound) {
+		tariff = s.tariff
+		tariff.LibraryUid = libraryUID
+		return tariff, nil
+	}
+	return tariff, err
+}
+
+func (s *Service) PutTariff(ctx context.Context, tariff model.Tariff) error {
+	return s.repo.PutTariff(ctx, tariff)
+}
+
+// AssessFine fines the user by the tariff of the library. It reports false if there is nothing to fine.
+func (s *Service) AssessFine(ctx context.Context, eventID string, req model.AssessFineRequest) (model.Fine, bool, error) {
+	tariff, err := s.GetTariff(ctx, req.LibraryUid)
+	if err != nil {
+		return model.Fine{}, false, err
+	}
+	days := req.DaysOverdue()
+	amount := tariff.Assess(days, req.ConditionFrom, req.ConditionTo)
+	if amount == 0 {
+		return model.Fine{}, false, nil
+	}
+	fine, err := s.repo.CreateFine(ctx, eventID, model.Fine{
+		Username:       req.UserName,
+		ReservationUid: req.ReservationUid,
+		LibraryUid:     req.LibraryUid,
+		DaysOverdue:    days,
+		ConditionFrom:  req.ConditionFrom,
+		ConditionTo:    req.ConditionTo,
+		Amount:         amount,
+	})
+	if err != nil {
+		return model.Fine{}, false, err
+	}
+	return fine, true, nil
+}
+
+func (s *Service) GetFines(ctx context.Context, username string) (model.Fines, error) {
+	fines, err := s.repo.GetFines(ctx, username)
+	if err != nil {
+		return model.Fines{}, err
+	}
+	outstanding, err := s.repo.Outstanding(ctx, username)
+	if err != nil {
+		return model.Fines{}, err
+	}
+	return model.Fines{Fines: fines, Outstanding: outstanding, Currency: s.currency}, nil
+}
+
+// Pay charges the user through the payment provider and pays off the outstanding fines with it.
+// A payment with a used idempotency key returns the payment made with the key.
+func (s *Service) Pay(ctx context.Context, req model.PaymentRequest) (model.Payment, error) {
+	p, created, err := s.repo.CreatePayment(ctx, req)
+	if err != nil {
+		return model.Payment{}, err
+	}
+	if !created {
+		switch p.Status {
+		case model.PaymentPending:
+			return model.Payment{}, errs.ErrPaymentPending
+		case model.PaymentFailed:
+			return p, errs.ErrPaymentDeclined
+		}
+		return p, nil
+	}
+	ref, err := s.provider.Charge(ctx, p)
+	if err != nil {
+		s.log.Warn("payment declined", zap.String("payment_uid", p.PaymentUid), zap.Error(err))
+		// the request may be gone already, the payment must not stay pending.
+		if p, err = s.repo.FailPayment(context.WithoutCancel(ctx), p, err.Error()); err != nil {
+			return model.Payment{}, err
+		}
+		return p, errs.ErrPaymentDeclined
+	}
+	completed, err := s.repo.CompletePayment(context.WithoutCancel(ctx), p, ref)
+	if err != nil {
+		return model.Payment{}, fmt.Errorf("complete payment %s charged as %s: %w", p.PaymentUid, ref, err)
+	}
+	return completed, nil
+}
+
+// ReconcilePayments settles the payments PENDING for longer than pendingFor every interval until ctx
+// is done. The process that created them died between the charge and its completion, so they are
+// charged again, which the provider does not charge twice, and completed or failed by the outcome.
+func (s *Service) ReconcilePayments(ctx context.Context, interval, pendingFor time.Duration, batch int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.reconcile(ctx, pendingFor, batch)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) reconcile(ctx context.Context, pendingFor time.Duration, batch int) {
+	payments, err := s.repo.PendingPayments(ctx, pendingFor, batch)
+	if err != nil {
+		s.log.Error("pending payments", zap.Error(err))
+		return
+	}
+	for _, p := range payments {
+		if err := s.settle(ctx, p); err != nil && !errors.Is(err, errs.ErrNotFound) {
+			s.log.Error("reconcile payment", zap.String("payment_uid", p.PaymentUid), zap.Error(err))
+		}
+	}
+}
+
+// settle completes or fails the PENDING payment by the outcome of its charge. It fails with
+// errs.ErrNotFound if the payment was settled meanwhile.
+func (s *Service) settle(ctx context.Context, p model.Payment) error {
+	ref, err := s.provider.Charge(ctx, p)
+	if err != nil {
+		s.log.Warn("payment declined", zap.String("payment_uid", p.PaymentUid), zap.Error(err))
+		_, err = s.repo.FailPayment(ctx, p, err.Error())
+		return err
+	}
+	_, err = s.repo.CompletePayment(ctx, p, ref)
+	return err
+}
@@ -0,0 +1,102 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tariffs
+(
+    library_uid    uuid PRIMARY KEY,
+    day_rate       BIGINT    NOT NULL CHECK (day_rate >= 0),
+    condition_rate BIGINT    NOT NULL CHECK (condition_rate >= 0),
+    max_overdue    BIGINT    NOT NULL DEFAULT 0 CHECK (max_overdue >= 0),
+    updated_at     TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS fines
+(
+    id              SERIAL PRIMARY KEY,
+    fine_uid        uuid UNIQUE NOT NULL,
+    username        VARCHAR(80) NOT NULL,
+    reservation_uid uuid UNIQUE NOT NULL,
+    library_uid     uuid        NOT NULL,
+    days_overdue    INT         NOT NULL CHECK (days_overdue >= 0),
+    condition_from  VARCHAR(20) NOT NULL,
+    condition_to    VARCHAR(20) NOT NULL,
+    amount          BIGINT      NOT NULL CHECK (amount > 0),
+    paid            BIGINT      NOT NULL DEFAULT 0 CHECK (paid >= 0 AND paid <= amount),
+    status          VARCHAR(20) NOT NULL
+        CHECK (status IN ('OUTSTANDING', 'PAID')),
+    created_at      TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS fines_outstanding_idx ON fines (username, created_at, id)
+    WHERE status = 'OUTSTANDING';
+
+CREATE TABLE IF NOT EXISTS payments
+(
+    id              SERIAL PRIMARY KEY,
+    payment_uid     uuid UNIQUE NOT NULL,
+    username        VARCHAR(80) NOT NULL,
+    amount          BIGINT      NOT NULL CHECK (amount > 0),
+    status          VARCHAR(20) NOT NULL
+        CHECK (status IN ('PENDING', 'COMPLETED', 'FAILED')),
+    provider_ref    VARCHAR(255),
+    error           TEXT,
+    idempotency_key VARCHAR(64),
+    created_at      TIMESTAMP   NOT NULL DEFAULT now(),
+    UNIQUE (username, idempotency_key)
+);
+
+-- a payment left PENDING by a crash between the charge and its completion is reconciled.
+CREATE INDEX IF NOT EXISTS payments_pending_idx ON payments (created_at)
+    WHERE status = 'PENDING';
+
+-- the ledger is append-only, every transaction moves money between accounts and sums up to zero.
+-- A debit is a positive amount, a credit a negative one.
+CREATE TABLE IF NOT EXISTS ledger_transactions
+(
+    id         BIGSERIAL PRIMARY KEY,
+    kind       VARCHAR(20) NOT NULL
+        CHECK (kind IN ('FINE', 'PAYMENT')),
+    reference  uuid        NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT now(),
+    UNIQUE (kind, reference)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT       NOT NULL REFERENCES ledger_transactions (id),
+    account        VARCHAR(120) NOT NULL,
+    amount         BIGINT       NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_account_idx ON ledger_entries (account);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION ledger_check_balanced() RETURNS trigger AS
+$$
+BEGIN
+    IF (SELECT sum(amount) FROM ledger_entries WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % is not balanced', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+
+CREATE TABLE IF NOT EXISTS processed_events
+(
+    event_id     VARCHAR(64) PRIMARY KEY,
+    processed_at TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS processed_events;
+DROP TABLE IF EXISTS ledger_entries;
+DROP FUNCTION IF EXISTS ledger_check_balanced();
+DROP TABLE IF EXISTS ledger_transactions;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS fines;
+DROP TABLE IF EXISTS tariffs;
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
 	"os/signal"
@@ -10,17 +11,33 @@ import (
 
 	"github.com/Astemirdum/library-service/backend/gateway/config"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/handler"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/repository"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/server"
+	"github.com/Astemirdum/library-service/backend/gateway/migrations"
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"go.uber.org/zap"
 )
 
 func Run(cfg config.Config) error {
 	log := logger.NewLogger(cfg.Log, "gateway")
+	db, err := postgres.NewPostgresDB(context.Background(), &cfg.Database, migrations.MigrationFiles)
+	if err != nil {
+		return fmt.Errorf("db init %w", err)
+	}
+	defer db.Close()
+	repo, err := repository.NewRepository(db, cfg.Saga.Lease, log)
+	if err != nil {
+		return fmt.Errorf("repo %w", err)
+	}
+	orchestrator := saga.NewOrchestrator(repo, cfg.Saga, log)
 
-	if err := kafka.CreateTopics(cfg.Kafka); err != nil {
-		log.Error("create topics", zap.Error(err))
+	if _, err := kafka.EnsureTopics(cfg.Kafka, log, kafka.LibraryTopic, kafka.RatingTopic, kafka.StatsTopic); err != nil {
+		log.Error("ensure topics", zap.Error(err))
 	}
 	producer, err := kafka.NewSyncProducer(cfg.Kafka)
 	if err != nil {
@@ -29,16 +46,13 @@ func Run(cfg config.Config) error {
 	}
 	defer producer.Close()
 
-	asyncProducer, err := kafka.NewAsyncProducer(cfg.Kafka)
-	if err != nil {
-		log.DPanic("kafka", zap.Error(err))
-		return err
-	}
-	defer asyncProducer.Close()
+	h := handler.New(log, cfg, db, orchestrator)
 
-	h := handler.New(log, cfg, producer, asyncProducer)
+	workers := lifecycle.New(log)
+	workers.Go("saga recovery", lifecycle.Loop(orchestrator.Run))
+	workers.Go("outbox relay", lifecycle.Loop(outbox.NewRelay(db, producer, cfg.Outbox, log).Run))
 
-	srv := server.NewServer(cfg.Server, h.NewRouter())
+	srv := server.NewServer(cfg.Server, h.NewRouter(workers.Check))
 	log.Info("http server start ON: ",
 		zap.String("addr",
 			net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)))
@@ -50,9 +64,13 @@ func Run(cfg config.Config) error {
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	termSig := <-sig
-
-	log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	var fatal error
+	select {
+	case termSig := <-sig:
+		log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	case fatal = <-workers.Fatal():
+		log.Error("Graceful shutdown", zap.Error(fatal))
+	}
 
 	closeCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -60,7 +78,10 @@ func Run(cfg config.Config) error {
 	if err := srv.Stop(closeCtx); err != nil {
 		log.Error("srv.Stop", zap.Error(err))
 	}
+	if err := workers.Stop(closeCtx); err != nil {
+		log.Error("workers.Stop", zap.Error(err))
+	}
 
 	log.Info("Graceful shutdown finished")
-	return nil
+	return fatal
 }
@@ -7,9 +7,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
 	"github.com/Astemirdum/library-service/backend/pkg/auth0"
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -30,6 +33,17 @@ type StatsHTTPServer struct {
 	Port string `envconfig:"STATS_HTTP_PORT"`
 }
 
+type FinesHTTPServer struct {
+	Host string `envconfig:"FINES_HTTP_HOST"`
+	Port string `envconfig:"FINES_HTTP_PORT"`
+}
+
+// Fines is how the gateway treats the unpaid fines of a user.
+type Fines struct {
+	// BlockThreshold is the outstanding amount over which new loans are refused.
+	BlockThreshold int64 `envconfig:"FINES_BLOCK_THRESHOLD" default:"50000"`
+}
+
 type ProviderHTTPServer struct {
 	Host string `envconfig:"PROVIDER_HTTP_HOST"`
 	Port string `envconfig:"PROVIDER_HTTP_PORT"`
@@ -48,11 +62,16 @@ type ReservationHTTPServer struct {
 type Config struct {
 	Server                HTTPServer `yaml:"server"`
 	Kafka                 kafka.Config
+	Database              postgres.DB `yaml:"db"`
+	Saga                  saga.Config
+	Outbox                outbox.Config
 	Auth0                 auth0.Config
 	ReservationHTTPServer ReservationHTTPServer
 	LibraryHTTPServer     LibraryHTTPServer
 	RatingHTTPServer      RatingHTTPServer
 	StatsHTTPServer       StatsHTTPServer
+	FinesHTTPServer       FinesHTTPServer
+	Fines                 Fines
 	ProviderHTTPServer    ProviderHTTPServer
 	Log                   logger.Log `yaml:"log"`
 }
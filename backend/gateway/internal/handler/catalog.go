@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/labstack/echo/v4"
+)
+
+// CreateLibrary adds a library to the catalog.
+func (h *Handler) CreateLibrary(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.LibraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Library
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.CreateLibrary(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateLibrary changes the name, city or address of a library.
+func (h *Handler) UpdateLibrary(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.UpdateLibraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.LibraryUid = c.Param("libraryUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Library
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.UpdateLibrary(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ArchiveLibrary archives a library, it lends no more books but its reservations can be returned.
+func (h *Handler) ArchiveLibrary(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	if err := h.librarySvc.CB().Call(func() error {
+		code, err := h.librarySvc.ArchiveLibrary(ctx, c.Param("libraryUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetSchedule replaces the opening hours and the exception dates of a library.
+func (h *Handler) SetSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.LibraryUid = c.Param("libraryUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Schedule.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp calendar.Schedule
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.SetSchedule(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateBook adds a book to the catalog, libraries get its copies with SetStock.
+func (h *Handler) CreateBook(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.BookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.GetBook
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.CreateBook(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateBook changes the metadata of a book.
+func (h *Handler) UpdateBook(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.UpdateBookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.BookUid = c.Param("bookUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.GetBook
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.UpdateBook(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// EnrichBook fills a book from the metadata of its ISBN.
+func (h *Handler) EnrichBook(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.EnrichBookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.BookUid = c.Param("bookUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.GetBook
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.EnrichBook(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetStock sets the number of copies of a book a library holds.
+func (h *Handler) SetStock(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.StockRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.LibraryUid, req.BookUid = c.Param("libraryUid"), c.Param("bookUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Stock
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.SetStock(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetAudit lists the changes librarians made to a library or a book, the latest first.
+func (h *Handler) GetAudit(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var resp []model.AuditRecord
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.GetAudit(ctx, c.Param("entityUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
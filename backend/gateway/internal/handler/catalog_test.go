@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/validate"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+func TestHandler_SetStock(t *testing.T) {
+	t.Parallel()
+	const (
+		libraryUid = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+		bookUid    = "f7cdc58f-2caf-4b15-9727-f89dcc629b27"
+	)
+	tests := []struct {
+		name         string
+		role         string
+		body         string
+		mockBehavior func(lib *service_mocks.MockLibraryService)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok",
+			role: "librarian",
+			body: `{"stock":5,"condition":"GOOD"}`,
+			mockBehavior: func(lib *service_mocks.MockLibraryService) {
+				lib.EXPECT().SetStock(gomock.Any(), model.StockRequest{
+					LibraryUid: libraryUid,
+					BookUid:    bookUid,
+					Stock:      5,
+					Condition:  "GOOD",
+				}).Return(model.Stock{LibraryUid: libraryUid, BookUid: bookUid, Stock: 5, AvailableCount: 4}, http.StatusOK, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"libraryUid":"` + libraryUid + `","bookUid":"` + bookUid + `","stock":5,"availableCount":4}`,
+		},
+		{
+			name: "err. copies on loan",
+			role: "admin",
+			body: `{"stock":0}`,
+			mockBehavior: func(lib *service_mocks.MockLibraryService) {
+				lib.EXPECT().SetStock(gomock.Any(), gomock.Any()).
+					Return(model.Stock{}, http.StatusConflict, errors.New("copies on loan exceed the stock"))
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "err. negative stock",
+			role:         "librarian",
+			body:         `{"stock":-1}`,
+			mockBehavior: func(*service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. no librarian",
+			role:         "user",
+			body:         `{"stock":5}`,
+			mockBehavior: func(*service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			lib := service_mocks.NewMockLibraryService(ctrl)
+			lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			tt.mockBehavior(lib)
+			h := &Handler{librarySvc: lib, log: zap.NewNop()}
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.PUT("/libraries/:libraryUid/books/:bookUid/stock", h.SetStock)
+			r := httptest.NewRequest(http.MethodPut, "/libraries/"+libraryUid+"/books/"+bookUid+"/stock", strings.NewReader(tt.body))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			r = r.WithContext(auth.SetAuthContext(r.Context(), "librarian", tt.role))
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
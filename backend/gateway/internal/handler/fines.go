@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// GetFines lists the outstanding fines of the user.
+func (h *Handler) GetFines(c echo.Context) error {
+	ctx := c.Request().Context()
+	var resp model.Fines
+	if err := h.finesSvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.finesSvc.GetFines(ctx)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// PayFines records a payment of the user against the outstanding fines, the oldest are paid off first.
+func (h *Handler) PayFines(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.PaymentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Payment
+	if err := h.finesSvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.finesSvc.Pay(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// PutTariff sets the fines tariff of a library.
+func (h *Handler) PutTariff(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsAdmin(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no admin")
+	}
+	var req model.Tariff
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.LibraryUid = c.Param("libraryUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Tariff
+	if err := h.finesSvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.finesSvc.PutTariff(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+func TestHandler_ReserveBlockedByFines(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	lib := service_mocks.NewMockLibraryService(ctrl)
+	rat := service_mocks.NewMockRatingService(ctrl)
+	fin := service_mocks.NewMockFinesService(ctrl)
+	cb := circuit_breaker.New(10, time.Second, 0.5, 1)
+	lib.EXPECT().CB().Return(cb).AnyTimes()
+	rat.EXPECT().CB().Return(cb).AnyTimes()
+	fin.EXPECT().CB().Return(cb).AnyTimes()
+
+	req := model.CreateReservationRequest{
+		BookUid:    "f7cdc58f-2caf-4b15-9727-f89dcc629b27",
+		LibraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
+		UserName:   "user",
+	}
+	lib.EXPECT().GetLibrary(gomock.Any(), req.LibraryUid).Return(model.GetLibrary{ID: 1}, http.StatusOK, nil)
+	lib.EXPECT().GetBook(gomock.Any(), req.LibraryUid, req.BookUid).Return(model.GetBook{ID: 2}, http.StatusOK, nil)
+	rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{Stars: 75}, http.StatusOK, nil)
+	fin.EXPECT().GetFines(gomock.Any()).Return(model.Fines{Outstanding: 50001, Currency: "RUB"}, http.StatusOK, nil)
+
+	// the saga is never started, so the handler has no reservation service.
+	h := &Handler{librarySvc: lib, ratingSvc: rat, finesSvc: fin, log: zap.NewNop(), finesBlockThreshold: 50000}
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/reservations", http.NoBody), httptest.NewRecorder())
+	err := h.reserve(c, req)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusForbidden, httpErr.Code)
+}
+
+func TestHandler_ReserveOutOfStock(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	lib := service_mocks.NewMockLibraryService(ctrl)
+	rat := service_mocks.NewMockRatingService(ctrl)
+	fin := service_mocks.NewMockFinesService(ctrl)
+	cb := circuit_breaker.New(10, time.Second, 0.5, 1)
+	lib.EXPECT().CB().Return(cb).AnyTimes()
+	rat.EXPECT().CB().Return(cb).AnyTimes()
+	fin.EXPECT().CB().Return(cb).AnyTimes()
+
+	req := model.CreateReservationRequest{
+		BookUid:    "f7cdc58f-2caf-4b15-9727-f89dcc629b27",
+		LibraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
+		UserName:   "user",
+	}
+	lib.EXPECT().GetLibrary(gomock.Any(), req.LibraryUid).Return(model.GetLibrary{ID: 1}, http.StatusOK, nil)
+	lib.EXPECT().GetBook(gomock.Any(), req.LibraryUid, req.BookUid).Return(model.GetBook{ID: 2, AvailableCount: 0}, http.StatusOK, nil)
+	rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{Stars: 75}, http.StatusOK, nil)
+	fin.EXPECT().GetFines(gomock.Any()).Return(model.Fines{}, http.StatusOK, nil)
+
+	// no copy is claimed and no reservation is created.
+	h := &Handler{librarySvc: lib, ratingSvc: rat, finesSvc: fin, log: zap.NewNop(), finesBlockThreshold: 50000}
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/reservations", http.NoBody), httptest.NewRecorder())
+	err := h.reserve(c, req)
+
+	var httpErr *echo.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusConflict, httpErr.Code)
+}
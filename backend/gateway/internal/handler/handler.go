@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/Astemirdum/library-service/backend/gateway/config"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/service/fines"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/library"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/provider"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/rating"
@@ -20,9 +23,11 @@ import (
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/stats"
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
 	"github.com/Astemirdum/library-service/backend/pkg/openid"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/pkg/validate"
 	_ "github.com/Astemirdum/library-service/swagger"
-	"github.com/IBM/sarama"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/pkg/errors"
@@ -37,27 +42,43 @@ type Handler struct {
 	reservationSvc ReservationService
 	statsSvc       StatsService
 	providerSvc    ProviderService
+	finesSvc       FinesService
 	enqueuer       Enqueuer
 	logStat        StatsLog
 	provider       openid.Provider
 	log            *zap.Logger
+	// finesBlockThreshold is the outstanding fines amount over which new loans are refused.
+	finesBlockThreshold int64
+
+	saga                  *saga.Orchestrator
+	createReservationSaga saga.Definition[createReservationData]
+	returnReservationSaga saga.Definition[returnReservationData]
 }
 
-func New(log *zap.Logger, cfg config.Config, producer sarama.SyncProducer, asyncProducer sarama.AsyncProducer) *Handler {
+func New(log *zap.Logger, cfg config.Config, db outbox.Execer, orchestrator *saga.Orchestrator) *Handler {
 	h := &Handler{
 		librarySvc:     library.NewService(log, cfg.LibraryHTTPServer),
 		ratingSvc:      rating.NewService(log, cfg.RatingHTTPServer),
 		reservationSvc: reservation.NewService(log, cfg.ReservationHTTPServer),
 		statsSvc:       stats.NewService(log, cfg.StatsHTTPServer),
 		providerSvc:    provider.NewService(log, cfg.ProviderHTTPServer),
-		enqueuer:       NewEnqueuer(producer),
-		logStat:        NewStatsLog(asyncProducer, kafka.StatsTopic),
+		finesSvc:       fines.NewService(log, cfg.FinesHTTPServer),
+		enqueuer:       NewEnqueuer(db),
+		logStat:        NewStatsLog(db, kafka.StatsTopic),
 		log:            log,
+		saga:           orchestrator,
+
+		finesBlockThreshold: cfg.Fines.BlockThreshold,
 	}
+	h.createReservationSaga = h.newCreateReservationSaga()
+	h.returnReservationSaga = h.newReturnReservationSaga()
+	saga.Register(orchestrator, h.createReservationSaga)
+	saga.Register(orchestrator, h.returnReservationSaga)
 	return h
 }
 
-func (h *Handler) NewRouter() *echo.Echo {
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
 	e := echo.New()
 	const (
 		baseRPS = 10
@@ -77,6 +98,7 @@ func (h *Handler) NewRouter() *echo.Echo {
 	//e.GET("/assets", echo.WrapHandler(http.StripPrefix("/assets", http.FileServer(http.FS(frontend.FS)))))
 
 	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
 	base.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	//auth, err := auth0.NewValidator(auth0Cfg)
@@ -87,6 +109,7 @@ func (h *Handler) NewRouter() *echo.Echo {
 	api := e.Group("/api/v1",
 		middleware.RequestLoggerWithConfig(md.RequestLoggerConfig()),
 		middleware.RequestID(),
+		md.TraceContext,
 		md.NewRateLimiter(apiRPS),
 	)
 
@@ -100,11 +123,46 @@ func (h *Handler) NewRouter() *echo.Echo {
 	api.GET("/rating", h.GetRating)
 
 	api.GET("/libraries", h.GetLibraries)
+	api.GET("/libraries/nearby", h.NearbyLibraries)
 	api.GET("/libraries/:libraryUid/books", h.GetBooks)
+	api.GET("/books/search", h.SearchBooks)
+	api.GET("/authors", h.GetAuthors)
+	api.GET("/authors/:authorUid/books", h.GetAuthorBooks)
+	api.GET("/genres", h.GetGenres)
+	api.GET("/genres/:genreUid/books", h.GetGenreBooks)
+
+	api.POST("/libraries", h.CreateLibrary)
+	api.PATCH("/libraries/:libraryUid", h.UpdateLibrary)
+	api.DELETE("/libraries/:libraryUid", h.ArchiveLibrary)
+	api.PUT("/libraries/:libraryUid/schedule", h.SetSchedule)
+	api.POST("/books", h.CreateBook)
+	api.PATCH("/books/:bookUid", h.UpdateBook)
+	api.POST("/books/:bookUid/metadata", h.EnrichBook)
+	api.PUT("/libraries/:libraryUid/books/:bookUid/stock", h.SetStock)
+	api.POST("/authors/:authorUid/aliases", h.AddAuthorAlias)
+	api.POST("/authors/:authorUid/merge", h.MergeAuthors)
+	api.POST("/genres", h.CreateGenre)
+	api.PATCH("/genres/:genreUid", h.UpdateGenre)
+	api.GET("/audit/:entityUid", h.GetAudit)
+	api.POST("/transfers", h.RequestTransfer)
+	api.GET("/transfers", h.GetTransfers)
+	api.GET("/transfers/:transferUid", h.GetTransfer)
+	api.POST("/transfers/:transferUid/receive", h.ReceiveTransfer)
+	api.POST("/transfers/:transferUid/cancel", h.CancelTransfer)
 
 	api.POST("/reservations", h.CreateReservation)
 	api.GET("/reservations", h.GetReservations)
 	api.POST("/reservations/:reservationUid/return", h.ReservationReturn)
+	api.POST("/reservations/:reservationUid/renew", h.RenewReservation)
+
+	api.GET("/holds", h.GetHolds)
+	api.POST("/holds", h.CreateHold)
+	api.DELETE("/holds/:holdUid", h.CancelHold)
+	api.POST("/holds/:holdUid/confirm", h.ConfirmHold)
+
+	api.GET("/fines", h.GetFines)
+	api.POST("/fines/payments", h.PayFines)
+	api.PUT("/libraries/:libraryUid/tariff", h.PutTariff)
 
 	api.GET("/stats", h.GetStats)
 
@@ -151,13 +209,24 @@ func (h *Handler) GetReservations(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
 	}
 
-	var reserves []model.GetReservation
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var (
+		reserves []model.GetReservation
+		page     paging.Page
+	)
 	if err := h.reservationSvc.CB().Call(func() error {
-		list, code, err := h.reservationSvc.GetReservation(ctx, userName)
+		list, pg, code, err := h.reservationSvc.GetReservation(ctx, userName, req)
 		if err != nil {
 			return echo.NewHTTPError(code, err.Error())
 		}
-		reserves = list
+		reserves, page = list, pg
 		return nil
 	}); err != nil {
 		return err
@@ -201,6 +270,7 @@ func (h *Handler) GetReservations(c echo.Context) error {
 		return err
 	}
 
+	page.SetHeaders(c.Response().Header())
 	return c.JSON(http.StatusOK, getReservationResponse(reserves, books, libs))
 }
 
@@ -213,6 +283,7 @@ func getReservationResponse(reserves []model.GetReservation, books []model.Book,
 				Status:         reserves[i].Status,
 				StartDate:      reserves[i].StartDate,
 				TillDate:       reserves[i].TillDate,
+				ReturnDetails:  reserves[i].ReturnDetails,
 			},
 			Library: libs[i],
 			Book:    books[i],
@@ -236,10 +307,19 @@ func (h *Handler) CreateReservation(c echo.Context) error {
 	if err := c.Validate(createReservationRequest); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	return h.reserve(c, createReservationRequest)
+}
+
+// reserve creates the reservation of a validated request and writes the response.
+func (h *Handler) reserve(c echo.Context, createReservationRequest model.CreateReservationRequest) error {
+	ctx := c.Request().Context()
+	userName := createReservationRequest.UserName
 	var (
+		err  error
 		lib  model.GetLibrary
 		book model.GetBook
 		rat  model.Rating
+		fin  model.Fines
 	)
 	gg, ctxCancel := errgroup.WithContext(ctx)
 	gg.Go(func() error {
@@ -275,28 +355,51 @@ func (h *Handler) CreateReservation(c echo.Context) error {
 		})
 	})
 
+	gg.Go(func() error {
+		return h.finesSvc.CB().Call(func() error {
+			var (
+				code int
+				err  error
+			)
+			fin, code, err = h.finesSvc.GetFines(ctxCancel)
+			if err != nil {
+				return echo.NewHTTPError(code, err.Error())
+			}
+			return nil
+		})
+	})
+
 	if err := gg.Wait(); err != nil {
 		return err
 	}
-	createReservationRequest.Stars = rat.Stars
-	rsv, code, err := h.reservationSvc.CreateReservation(ctx, createReservationRequest)
-	if err != nil {
-		return echo.NewHTTPError(code, err.Error())
+	if fin.Outstanding > h.finesBlockThreshold {
+		return echo.NewHTTPError(http.StatusForbidden,
+			fmt.Sprintf("unpaid fines of %d %s are over the limit of %d", fin.Outstanding, fin.Currency, h.finesBlockThreshold))
 	}
-
-	if code, err := h.librarySvc.AvailableCount(ctx, model.AvailableCountRequest{
-		LibraryID: lib.ID,
-		BookID:    book.ID,
-		IsReturn:  false,
-	}); err != nil {
-		if _, err := h.reservationSvc.RollbackReservation(ctx, rsv.ReservationUid); err != nil {
-			h.log.Warn("RollbackReservation", zap.Error(err))
-			return echo.NewHTTPError(code, err.Error())
-		}
-		return nil
+	// the saga claims a copy before it creates the reservation, a book seen out of stock is not worth starting it for.
+	if book.AvailableCount == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "no copy of the book is on the shelf")
 	}
-
-	_ = h.logStat.Log(kafka.EventStats{ //nolint:errcheck
+	if lib.Schedule != nil {
+		createReservationRequest.TillDate.Time = lib.Schedule.NextOpenDay(createReservationRequest.TillDate.Time)
+	}
+	createReservationRequest.Stars = rat.Stars
+	createReservationRequest.OnShelf = book.AvailableCount
+	userRole, _ := auth.GetUserRole(ctx) //nolint:errcheck
+	data := createReservationData{
+		Request:        createReservationRequest,
+		UserName:       userName,
+		UserRole:       userRole,
+		ReservationUid: uuid.NewString(),
+		LibraryID:      lib.ID,
+		BookID:         book.ID,
+	}
+	if err := saga.Execute(ctx, h.saga, h.createReservationSaga, &data); err != nil {
+		return sagaHTTPError(err)
+	}
+	rsv := data.Reservation
+
+	_ = h.logStat.Log(ctx, kafka.EventStats{ //nolint:errcheck
 		Timestamp:     time.Now(),
 		UserName:      userName,
 		ReservationID: rsv.ReservationUid,
@@ -331,77 +434,22 @@ func (h *Handler) ReservationReturn(c echo.Context) error {
 	if err := c.Validate(req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	res, code, err := h.reservationSvc.ReservationReturn(ctx, req, userName, reservationUID)
-	if err != nil {
-		return echo.NewHTTPError(code, err.Error())
-	}
-	var (
-		lib  model.GetLibrary
-		book model.GetBook
-	)
-	gg, ctxCancel := errgroup.WithContext(ctx)
-	gg.Go(func() error {
-		return h.librarySvc.CB().Call(func() error {
-			var code int
-			lib, code, err = h.librarySvc.GetLibrary(ctxCancel, res.LibraryUid)
-			if err != nil {
-				return echo.NewHTTPError(code, err.Error())
-			}
-			return nil
-		})
-	})
-
-	gg.Go(func() error {
-		return h.librarySvc.CB().Call(func() error {
-			var code int
-			book, code, err = h.librarySvc.GetBook(ctxCancel, res.LibraryUid, res.BookUid)
-			if err != nil {
-				return echo.NewHTTPError(code, err.Error())
-			}
-			return nil
-		})
-	})
-	if err := gg.Wait(); err != nil {
-		return err
-	}
-
-	availableCountReq := model.AvailableCountRequest{
-		LibraryID: lib.ID,
-		BookID:    book.ID,
-		IsReturn:  true,
+	userRole, _ := auth.GetUserRole(ctx) //nolint:errcheck
+	data := returnReservationData{
+		Request:        req,
+		RunID:          uuid.NewString(),
+		UserName:       userName,
+		UserRole:       userRole,
+		ReservationUid: reservationUID,
 	}
-	if code, err := h.librarySvc.AvailableCount(ctx, availableCountReq); err != nil {
-		if code == http.StatusServiceUnavailable {
-			if err := h.enqueuer.Enqueue(kafka.LibraryTopic, availableCountReq); err != nil {
-				h.log.Warn("availableCount h.enqueuer.Enqueue()", zap.Error(err))
-			}
-		} else {
-			return echo.NewHTTPError(code, err.Error())
-		}
+	if err := saga.Execute(ctx, h.saga, h.returnReservationSaga, &data); err != nil {
+		return sagaHTTPError(err)
 	}
+	lib, book := data.Library, data.Book
 
-	stars := 1
-	if book.Condition != req.Condition {
-		stars = -10
-	}
-
-	if code, err := h.ratingSvc.Rating(ctx, stars); err != nil {
-		if code == http.StatusServiceUnavailable {
-			ratingMsg := model.RatingMsg{
-				Name:  userName,
-				Stars: stars,
-			}
-			if err := h.enqueuer.Enqueue(kafka.RatingTopic, ratingMsg); err != nil {
-				h.log.Warn("Rating h.enqueuer.Enqueue()", zap.Error(err))
-			}
-		} else {
-			return echo.NewHTTPError(code, err.Error())
-		}
-	}
-
-	_ = h.logStat.Log(kafka.EventStats{ //nolint:errcheck
+	_ = h.logStat.Log(ctx, kafka.EventStats{ //nolint:errcheck
 		Timestamp:     time.Now(),
-		UserName:      userName,
+		UserName:      data.borrower(),
 		ReservationID: reservationUID,
 		BookID:        book.BookUid,
 		LibraryID:     lib.LibraryUid,
@@ -430,6 +478,46 @@ func (h *Handler) GetBooks(c echo.Context) error {
 	return c.JSONBlob(code, data)
 }
 
+// SearchBooks searches the books of all libraries, the query is passed to the library service as is.
+func (h *Handler) SearchBooks(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.SearchBooks(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
+// NearbyLibraries finds the libraries near a point, the query is passed to the library service as is.
+func (h *Handler) NearbyLibraries(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.NearbyLibraries(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
 func (h *Handler) GetLibraries(c echo.Context) error {
 	var (
 		code int
@@ -482,13 +570,28 @@ func (h *Handler) GetStats(c echo.Context) error {
 	if !auth.IsAdmin(ctx) {
 		return echo.NewHTTPError(http.StatusUnauthorized, "no admin")
 	}
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if !req.Paged() {
+		// the admins that ask for no page get the stats of every user, as before the stats were paged.
+		resp, code, err := h.allStats(ctx, userName)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return c.JSON(code, resp)
+	}
 	var (
 		code int
 		resp model.StatsInfo
 	)
 	if err := h.statsSvc.CB().Call(func() error {
 		var err error
-		resp, code, err = h.statsSvc.GetStats(ctx, userName)
+		resp, code, err = h.statsSvc.GetStats(ctx, userName, req)
 		return err
 	}); err != nil {
 		return echo.NewHTTPError(code, err.Error())
@@ -497,6 +600,32 @@ func (h *Handler) GetStats(c echo.Context) error {
 	return c.JSON(code, resp)
 }
 
+// allStats follows the pages of the stats to their end.
+func (h *Handler) allStats(ctx context.Context, userName string) (model.StatsInfo, int, error) {
+	var (
+		code int
+		all  model.StatsInfo
+	)
+	req := paging.Request{Size: paging.MaxSize}
+	for {
+		var page model.StatsInfo
+		if err := h.statsSvc.CB().Call(func() error {
+			var err error
+			page, code, err = h.statsSvc.GetStats(ctx, userName, req)
+			return err
+		}); err != nil {
+			return model.StatsInfo{}, code, err
+		}
+		all.Data = append(all.Data, page.Data...)
+		if page.Next == "" {
+			break
+		}
+		req.Cursor = page.Next
+	}
+	all.PageSize = len(all.Data)
+	return all, code, nil
+}
+
 func (h *Handler) Register(c echo.Context) error {
 	var (
 		code int
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetHolds(c echo.Context) error {
+	ctx := c.Request().Context()
+	var holds []model.Hold
+	if err := h.reservationSvc.CB().Call(func() error {
+		list, code, err := h.reservationSvc.GetHolds(ctx)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		holds = list
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, holds)
+}
+
+// CreateHold queues the user for a book that has no copy left but those kept for READY holds.
+func (h *Handler) CreateHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CreateHoldRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var book model.GetBook
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		book, code, err = h.librarySvc.GetBook(ctx, req.LibraryUid, req.BookUid)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	req.OnShelf = book.AvailableCount
+
+	var hold model.Hold
+	if err := h.reservationSvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		hold, code, err = h.reservationSvc.CreateHold(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, hold)
+}
+
+func (h *Handler) CancelHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := h.reservationSvc.CB().Call(func() error {
+		code, err := h.reservationSvc.CancelHold(ctx, c.Param("holdUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ConfirmHold turns a READY hold into a reservation of the copy kept for it.
+func (h *Handler) ConfirmHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	var req model.ConfirmHoldRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var hold model.Hold
+	if err := h.reservationSvc.CB().Call(func() error {
+		var code int
+		hold, code, err = h.reservationSvc.GetHold(ctx, c.Param("holdUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if hold.Status != model.HoldReady {
+		return echo.NewHTTPError(http.StatusConflict, "the hold is not ready for pickup")
+	}
+
+	return h.reserve(c, model.CreateReservationRequest{
+		HoldUid:    hold.HoldUid,
+		BookUid:    hold.BookUid,
+		LibraryUid: hold.LibraryUid,
+		TillDate:   req.TillDate,
+		UserName:   userName,
+	})
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/validate"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+func TestHandler_CreateHold(t *testing.T) {
+	t.Parallel()
+	const (
+		libraryUid = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+		bookUid    = "f7cdc58f-2caf-4b15-9727-f89dcc629b27"
+	)
+	req := model.CreateHoldRequest{LibraryUid: libraryUid, BookUid: bookUid}
+	type mockBehavior func(lib *service_mocks.MockLibraryService, rsv *service_mocks.MockReservationService)
+
+	tests := []struct {
+		name         string
+		body         string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok",
+			body: `{"libraryUid":"` + libraryUid + `","bookUid":"` + bookUid + `"}`,
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rsv *service_mocks.MockReservationService) {
+				lib.EXPECT().GetBook(gomock.Any(), libraryUid, bookUid).Return(model.GetBook{AvailableCount: 0}, http.StatusOK, nil)
+				rsv.EXPECT().CreateHold(gomock.Any(), req).
+					Return(model.Hold{HoldUid: "h1", LibraryUid: libraryUid, BookUid: bookUid, Status: "WAITING", Position: 2}, http.StatusCreated, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"holdUid":"h1","bookUid":"` + bookUid + `","libraryUid":"` + libraryUid + `","status":"WAITING","createdAt":"0001-01-01T00:00:00Z","position":2}`,
+		},
+		{
+			name: "err. book is available",
+			body: `{"libraryUid":"` + libraryUid + `","bookUid":"` + bookUid + `"}`,
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rsv *service_mocks.MockReservationService) {
+				lib.EXPECT().GetBook(gomock.Any(), libraryUid, bookUid).Return(model.GetBook{AvailableCount: 2}, http.StatusOK, nil)
+				available := req
+				available.OnShelf = 2
+				rsv.EXPECT().CreateHold(gomock.Any(), available).Return(model.Hold{}, http.StatusConflict, errors.New("the book is available, reserve it instead"))
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"message":"the book is available, reserve it instead"}`,
+		},
+		{
+			name: "ok. the copy on the shelf is kept for a hold",
+			body: `{"libraryUid":"` + libraryUid + `","bookUid":"` + bookUid + `"}`,
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rsv *service_mocks.MockReservationService) {
+				lib.EXPECT().GetBook(gomock.Any(), libraryUid, bookUid).Return(model.GetBook{AvailableCount: 1}, http.StatusOK, nil)
+				kept := req
+				kept.OnShelf = 1
+				rsv.EXPECT().CreateHold(gomock.Any(), kept).
+					Return(model.Hold{HoldUid: "h1", LibraryUid: libraryUid, BookUid: bookUid, Status: "WAITING", Position: 2}, http.StatusCreated, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "err. invalid uid",
+			body:         `{"libraryUid":"1","bookUid":"` + bookUid + `"}`,
+			mockBehavior: func(*service_mocks.MockLibraryService, *service_mocks.MockReservationService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			lib := service_mocks.NewMockLibraryService(ctrl)
+			rsv := service_mocks.NewMockReservationService(ctrl)
+			lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			rsv.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			tt.mockBehavior(lib, rsv)
+			h := &Handler{librarySvc: lib, reservationSvc: rsv, log: zap.NewNop()}
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.POST("/holds", h.CreateHold)
+			r := httptest.NewRequest(http.MethodPost, "/holds", strings.NewReader(tt.body)).WithContext(context.Background())
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
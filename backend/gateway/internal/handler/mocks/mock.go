@@ -0,0 +1,1018 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_handler is a generated GoMock package.
+package mock_handler
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	calendar "github.com/Astemirdum/library-service/backend/pkg/calendar"
+	circuit_breaker "github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	paging "github.com/Astemirdum/library-service/backend/pkg/paging"
+	gomock "github.com/golang/mock/gomock"
+	echo "github.com/labstack/echo/v4"
+)
+
+// MockProviderService is a mock of ProviderService interface.
+type MockProviderService struct {
+	ctrl     *gomock.Controller
+	recorder *MockProviderServiceMockRecorder
+}
+
+// MockProviderServiceMockRecorder is the mock recorder for MockProviderService.
+type MockProviderServiceMockRecorder struct {
+	mock *MockProviderService
+}
+
+// NewMockProviderService creates a new mock instance.
+func NewMockProviderService(ctrl *gomock.Controller) *MockProviderService {
+	mock := &MockProviderService{ctrl: ctrl}
+	mock.recorder = &MockProviderServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProviderService) EXPECT() *MockProviderServiceMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockProviderService) Authorize(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockProviderServiceMockRecorder) Authorize(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockProviderService)(nil).Authorize), c)
+}
+
+// CB mocks base method.
+func (m *MockProviderService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockProviderServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockProviderService)(nil).CB))
+}
+
+// Register mocks base method.
+func (m *MockProviderService) Register(c echo.Context, body []byte) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", c, body)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockProviderServiceMockRecorder) Register(c, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockProviderService)(nil).Register), c, body)
+}
+
+// MockLibraryService is a mock of LibraryService interface.
+type MockLibraryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLibraryServiceMockRecorder
+}
+
+// MockLibraryServiceMockRecorder is the mock recorder for MockLibraryService.
+type MockLibraryServiceMockRecorder struct {
+	mock *MockLibraryService
+}
+
+// NewMockLibraryService creates a new mock instance.
+func NewMockLibraryService(ctrl *gomock.Controller) *MockLibraryService {
+	mock := &MockLibraryService{ctrl: ctrl}
+	mock.recorder = &MockLibraryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLibraryService) EXPECT() *MockLibraryServiceMockRecorder {
+	return m.recorder
+}
+
+// AddAuthorAlias mocks base method.
+func (m *MockLibraryService) AddAuthorAlias(ctx context.Context, request model.AuthorAliasRequest) (model.Author, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthorAlias", ctx, request)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddAuthorAlias indicates an expected call of AddAuthorAlias.
+func (mr *MockLibraryServiceMockRecorder) AddAuthorAlias(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthorAlias", reflect.TypeOf((*MockLibraryService)(nil).AddAuthorAlias), ctx, request)
+}
+
+// ArchiveLibrary mocks base method.
+func (m *MockLibraryService) ArchiveLibrary(ctx context.Context, libraryUid string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveLibrary", ctx, libraryUid)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveLibrary indicates an expected call of ArchiveLibrary.
+func (mr *MockLibraryServiceMockRecorder) ArchiveLibrary(ctx, libraryUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveLibrary", reflect.TypeOf((*MockLibraryService)(nil).ArchiveLibrary), ctx, libraryUid)
+}
+
+// AvailableCount mocks base method.
+func (m *MockLibraryService) AvailableCount(ctx context.Context, request model.AvailableCountRequest) (model.BookCopy, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvailableCount", ctx, request)
+	ret0, _ := ret[0].(model.BookCopy)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AvailableCount indicates an expected call of AvailableCount.
+func (mr *MockLibraryServiceMockRecorder) AvailableCount(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailableCount", reflect.TypeOf((*MockLibraryService)(nil).AvailableCount), ctx, request)
+}
+
+// CB mocks base method.
+func (m *MockLibraryService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockLibraryServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockLibraryService)(nil).CB))
+}
+
+// CancelTransfer mocks base method.
+func (m *MockLibraryService) CancelTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTransfer", ctx, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CancelTransfer indicates an expected call of CancelTransfer.
+func (mr *MockLibraryServiceMockRecorder) CancelTransfer(ctx, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTransfer", reflect.TypeOf((*MockLibraryService)(nil).CancelTransfer), ctx, transferUid)
+}
+
+// CreateBook mocks base method.
+func (m *MockLibraryService) CreateBook(ctx context.Context, request model.BookRequest) (model.GetBook, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBook", ctx, request)
+	ret0, _ := ret[0].(model.GetBook)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateBook indicates an expected call of CreateBook.
+func (mr *MockLibraryServiceMockRecorder) CreateBook(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBook", reflect.TypeOf((*MockLibraryService)(nil).CreateBook), ctx, request)
+}
+
+// CreateGenre mocks base method.
+func (m *MockLibraryService) CreateGenre(ctx context.Context, request model.GenreRequest) (model.Genre, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGenre", ctx, request)
+	ret0, _ := ret[0].(model.Genre)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateGenre indicates an expected call of CreateGenre.
+func (mr *MockLibraryServiceMockRecorder) CreateGenre(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGenre", reflect.TypeOf((*MockLibraryService)(nil).CreateGenre), ctx, request)
+}
+
+// CreateLibrary mocks base method.
+func (m *MockLibraryService) CreateLibrary(ctx context.Context, request model.LibraryRequest) (model.Library, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLibrary", ctx, request)
+	ret0, _ := ret[0].(model.Library)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateLibrary indicates an expected call of CreateLibrary.
+func (mr *MockLibraryServiceMockRecorder) CreateLibrary(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLibrary", reflect.TypeOf((*MockLibraryService)(nil).CreateLibrary), ctx, request)
+}
+
+// EnrichBook mocks base method.
+func (m *MockLibraryService) EnrichBook(ctx context.Context, request model.EnrichBookRequest) (model.GetBook, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrichBook", ctx, request)
+	ret0, _ := ret[0].(model.GetBook)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EnrichBook indicates an expected call of EnrichBook.
+func (mr *MockLibraryServiceMockRecorder) EnrichBook(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrichBook", reflect.TypeOf((*MockLibraryService)(nil).EnrichBook), ctx, request)
+}
+
+// GetAudit mocks base method.
+func (m *MockLibraryService) GetAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudit", ctx, entityUid)
+	ret0, _ := ret[0].([]model.AuditRecord)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAudit indicates an expected call of GetAudit.
+func (mr *MockLibraryServiceMockRecorder) GetAudit(ctx, entityUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudit", reflect.TypeOf((*MockLibraryService)(nil).GetAudit), ctx, entityUid)
+}
+
+// GetAuthorBooks mocks base method.
+func (m *MockLibraryService) GetAuthorBooks(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthorBooks", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuthorBooks indicates an expected call of GetAuthorBooks.
+func (mr *MockLibraryServiceMockRecorder) GetAuthorBooks(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthorBooks", reflect.TypeOf((*MockLibraryService)(nil).GetAuthorBooks), c)
+}
+
+// GetAuthors mocks base method.
+func (m *MockLibraryService) GetAuthors(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuthors indicates an expected call of GetAuthors.
+func (mr *MockLibraryServiceMockRecorder) GetAuthors(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockLibraryService)(nil).GetAuthors), c)
+}
+
+// GetBook mocks base method.
+func (m *MockLibraryService) GetBook(ctx context.Context, libUid, bookUid string) (model.GetBook, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBook", ctx, libUid, bookUid)
+	ret0, _ := ret[0].(model.GetBook)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBook indicates an expected call of GetBook.
+func (mr *MockLibraryServiceMockRecorder) GetBook(ctx, libUid, bookUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockLibraryService)(nil).GetBook), ctx, libUid, bookUid)
+}
+
+// GetBooks mocks base method.
+func (m *MockLibraryService) GetBooks(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBooks", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBooks indicates an expected call of GetBooks.
+func (mr *MockLibraryServiceMockRecorder) GetBooks(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockLibraryService)(nil).GetBooks), c)
+}
+
+// GetGenreBooks mocks base method.
+func (m *MockLibraryService) GetGenreBooks(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenreBooks", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetGenreBooks indicates an expected call of GetGenreBooks.
+func (mr *MockLibraryServiceMockRecorder) GetGenreBooks(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenreBooks", reflect.TypeOf((*MockLibraryService)(nil).GetGenreBooks), c)
+}
+
+// GetGenres mocks base method.
+func (m *MockLibraryService) GetGenres(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenres", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetGenres indicates an expected call of GetGenres.
+func (mr *MockLibraryServiceMockRecorder) GetGenres(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenres", reflect.TypeOf((*MockLibraryService)(nil).GetGenres), c)
+}
+
+// GetLibraries mocks base method.
+func (m *MockLibraryService) GetLibraries(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLibraries", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLibraries indicates an expected call of GetLibraries.
+func (mr *MockLibraryServiceMockRecorder) GetLibraries(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLibraries", reflect.TypeOf((*MockLibraryService)(nil).GetLibraries), c)
+}
+
+// GetLibrary mocks base method.
+func (m *MockLibraryService) GetLibrary(ctx context.Context, libUid string) (model.GetLibrary, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLibrary", ctx, libUid)
+	ret0, _ := ret[0].(model.GetLibrary)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLibrary indicates an expected call of GetLibrary.
+func (mr *MockLibraryServiceMockRecorder) GetLibrary(ctx, libUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLibrary", reflect.TypeOf((*MockLibraryService)(nil).GetLibrary), ctx, libUid)
+}
+
+// GetTransfer mocks base method.
+func (m *MockLibraryService) GetTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", ctx, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockLibraryServiceMockRecorder) GetTransfer(ctx, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockLibraryService)(nil).GetTransfer), ctx, transferUid)
+}
+
+// ListTransfers mocks base method.
+func (m *MockLibraryService) ListTransfers(ctx context.Context, request model.ListTransfersRequest) (model.ListTransfers, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", ctx, request)
+	ret0, _ := ret[0].(model.ListTransfers)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockLibraryServiceMockRecorder) ListTransfers(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockLibraryService)(nil).ListTransfers), ctx, request)
+}
+
+// MergeAuthors mocks base method.
+func (m *MockLibraryService) MergeAuthors(ctx context.Context, request model.MergeAuthorsRequest) (model.Author, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeAuthors", ctx, request)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MergeAuthors indicates an expected call of MergeAuthors.
+func (mr *MockLibraryServiceMockRecorder) MergeAuthors(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeAuthors", reflect.TypeOf((*MockLibraryService)(nil).MergeAuthors), ctx, request)
+}
+
+// NearbyLibraries mocks base method.
+func (m *MockLibraryService) NearbyLibraries(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NearbyLibraries", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// NearbyLibraries indicates an expected call of NearbyLibraries.
+func (mr *MockLibraryServiceMockRecorder) NearbyLibraries(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NearbyLibraries", reflect.TypeOf((*MockLibraryService)(nil).NearbyLibraries), c)
+}
+
+// ReceiveTransfer mocks base method.
+func (m *MockLibraryService) ReceiveTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveTransfer", ctx, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReceiveTransfer indicates an expected call of ReceiveTransfer.
+func (mr *MockLibraryServiceMockRecorder) ReceiveTransfer(ctx, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveTransfer", reflect.TypeOf((*MockLibraryService)(nil).ReceiveTransfer), ctx, transferUid)
+}
+
+// RequestTransfer mocks base method.
+func (m *MockLibraryService) RequestTransfer(ctx context.Context, request model.TransferRequest) (model.Transfer, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestTransfer", ctx, request)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RequestTransfer indicates an expected call of RequestTransfer.
+func (mr *MockLibraryServiceMockRecorder) RequestTransfer(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestTransfer", reflect.TypeOf((*MockLibraryService)(nil).RequestTransfer), ctx, request)
+}
+
+// SearchBooks mocks base method.
+func (m *MockLibraryService) SearchBooks(c echo.Context) ([]byte, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchBooks", c)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchBooks indicates an expected call of SearchBooks.
+func (mr *MockLibraryServiceMockRecorder) SearchBooks(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchBooks", reflect.TypeOf((*MockLibraryService)(nil).SearchBooks), c)
+}
+
+// SetSchedule mocks base method.
+func (m *MockLibraryService) SetSchedule(ctx context.Context, request model.ScheduleRequest) (calendar.Schedule, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, request)
+	ret0, _ := ret[0].(calendar.Schedule)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockLibraryServiceMockRecorder) SetSchedule(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockLibraryService)(nil).SetSchedule), ctx, request)
+}
+
+// SetStock mocks base method.
+func (m *MockLibraryService) SetStock(ctx context.Context, request model.StockRequest) (model.Stock, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStock", ctx, request)
+	ret0, _ := ret[0].(model.Stock)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetStock indicates an expected call of SetStock.
+func (mr *MockLibraryServiceMockRecorder) SetStock(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStock", reflect.TypeOf((*MockLibraryService)(nil).SetStock), ctx, request)
+}
+
+// UpdateBook mocks base method.
+func (m *MockLibraryService) UpdateBook(ctx context.Context, request model.UpdateBookRequest) (model.GetBook, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, request)
+	ret0, _ := ret[0].(model.GetBook)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockLibraryServiceMockRecorder) UpdateBook(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockLibraryService)(nil).UpdateBook), ctx, request)
+}
+
+// UpdateGenre mocks base method.
+func (m *MockLibraryService) UpdateGenre(ctx context.Context, request model.UpdateGenreRequest) (model.Genre, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenre", ctx, request)
+	ret0, _ := ret[0].(model.Genre)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateGenre indicates an expected call of UpdateGenre.
+func (mr *MockLibraryServiceMockRecorder) UpdateGenre(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenre", reflect.TypeOf((*MockLibraryService)(nil).UpdateGenre), ctx, request)
+}
+
+// UpdateLibrary mocks base method.
+func (m *MockLibraryService) UpdateLibrary(ctx context.Context, request model.UpdateLibraryRequest) (model.Library, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLibrary", ctx, request)
+	ret0, _ := ret[0].(model.Library)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateLibrary indicates an expected call of UpdateLibrary.
+func (mr *MockLibraryServiceMockRecorder) UpdateLibrary(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLibrary", reflect.TypeOf((*MockLibraryService)(nil).UpdateLibrary), ctx, request)
+}
+
+// MockRatingService is a mock of RatingService interface.
+type MockRatingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRatingServiceMockRecorder
+}
+
+// MockRatingServiceMockRecorder is the mock recorder for MockRatingService.
+type MockRatingServiceMockRecorder struct {
+	mock *MockRatingService
+}
+
+// NewMockRatingService creates a new mock instance.
+func NewMockRatingService(ctrl *gomock.Controller) *MockRatingService {
+	mock := &MockRatingService{ctrl: ctrl}
+	mock.recorder = &MockRatingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRatingService) EXPECT() *MockRatingServiceMockRecorder {
+	return m.recorder
+}
+
+// CB mocks base method.
+func (m *MockRatingService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockRatingServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockRatingService)(nil).CB))
+}
+
+// CreateRating mocks base method.
+func (m *MockRatingService) CreateRating(ctx context.Context, userName string, stars int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRating", ctx, userName, stars)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRating indicates an expected call of CreateRating.
+func (mr *MockRatingServiceMockRecorder) CreateRating(ctx, userName, stars interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRating", reflect.TypeOf((*MockRatingService)(nil).CreateRating), ctx, userName, stars)
+}
+
+// GetRating mocks base method.
+func (m *MockRatingService) GetRating(ctx context.Context) (model.Rating, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRating", ctx)
+	ret0, _ := ret[0].(model.Rating)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRating indicates an expected call of GetRating.
+func (mr *MockRatingServiceMockRecorder) GetRating(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRating", reflect.TypeOf((*MockRatingService)(nil).GetRating), ctx)
+}
+
+// Rating mocks base method.
+func (m *MockRatingService) Rating(ctx context.Context, eventID string, stars int) (model.RatingChange, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rating", ctx, eventID, stars)
+	ret0, _ := ret[0].(model.RatingChange)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Rating indicates an expected call of Rating.
+func (mr *MockRatingServiceMockRecorder) Rating(ctx, eventID, stars interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rating", reflect.TypeOf((*MockRatingService)(nil).Rating), ctx, eventID, stars)
+}
+
+// MockStatsService is a mock of StatsService interface.
+type MockStatsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsServiceMockRecorder
+}
+
+// MockStatsServiceMockRecorder is the mock recorder for MockStatsService.
+type MockStatsServiceMockRecorder struct {
+	mock *MockStatsService
+}
+
+// NewMockStatsService creates a new mock instance.
+func NewMockStatsService(ctrl *gomock.Controller) *MockStatsService {
+	mock := &MockStatsService{ctrl: ctrl}
+	mock.recorder = &MockStatsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsService) EXPECT() *MockStatsServiceMockRecorder {
+	return m.recorder
+}
+
+// CB mocks base method.
+func (m *MockStatsService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockStatsServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockStatsService)(nil).CB))
+}
+
+// GetStats mocks base method.
+func (m *MockStatsService) GetStats(ctx context.Context, userName string, page paging.Request) (model.StatsInfo, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ctx, userName, page)
+	ret0, _ := ret[0].(model.StatsInfo)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockStatsServiceMockRecorder) GetStats(ctx, userName, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockStatsService)(nil).GetStats), ctx, userName, page)
+}
+
+// MockFinesService is a mock of FinesService interface.
+type MockFinesService struct {
+	ctrl     *gomock.Controller
+	recorder *MockFinesServiceMockRecorder
+}
+
+// MockFinesServiceMockRecorder is the mock recorder for MockFinesService.
+type MockFinesServiceMockRecorder struct {
+	mock *MockFinesService
+}
+
+// NewMockFinesService creates a new mock instance.
+func NewMockFinesService(ctrl *gomock.Controller) *MockFinesService {
+	mock := &MockFinesService{ctrl: ctrl}
+	mock.recorder = &MockFinesServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFinesService) EXPECT() *MockFinesServiceMockRecorder {
+	return m.recorder
+}
+
+// AssessFine mocks base method.
+func (m *MockFinesService) AssessFine(ctx context.Context, request model.AssessFineRequest) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssessFine", ctx, request)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssessFine indicates an expected call of AssessFine.
+func (mr *MockFinesServiceMockRecorder) AssessFine(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssessFine", reflect.TypeOf((*MockFinesService)(nil).AssessFine), ctx, request)
+}
+
+// CB mocks base method.
+func (m *MockFinesService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockFinesServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockFinesService)(nil).CB))
+}
+
+// GetFines mocks base method.
+func (m *MockFinesService) GetFines(ctx context.Context) (model.Fines, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFines", ctx)
+	ret0, _ := ret[0].(model.Fines)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetFines indicates an expected call of GetFines.
+func (mr *MockFinesServiceMockRecorder) GetFines(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFines", reflect.TypeOf((*MockFinesService)(nil).GetFines), ctx)
+}
+
+// Pay mocks base method.
+func (m *MockFinesService) Pay(ctx context.Context, request model.PaymentRequest) (model.Payment, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pay", ctx, request)
+	ret0, _ := ret[0].(model.Payment)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Pay indicates an expected call of Pay.
+func (mr *MockFinesServiceMockRecorder) Pay(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pay", reflect.TypeOf((*MockFinesService)(nil).Pay), ctx, request)
+}
+
+// PutTariff mocks base method.
+func (m *MockFinesService) PutTariff(ctx context.Context, tariff model.Tariff) (model.Tariff, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutTariff", ctx, tariff)
+	ret0, _ := ret[0].(model.Tariff)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PutTariff indicates an expected call of PutTariff.
+func (mr *MockFinesServiceMockRecorder) PutTariff(ctx, tariff interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutTariff", reflect.TypeOf((*MockFinesService)(nil).PutTariff), ctx, tariff)
+}
+
+// MockReservationService is a mock of ReservationService interface.
+type MockReservationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReservationServiceMockRecorder
+}
+
+// MockReservationServiceMockRecorder is the mock recorder for MockReservationService.
+type MockReservationServiceMockRecorder struct {
+	mock *MockReservationService
+}
+
+// NewMockReservationService creates a new mock instance.
+func NewMockReservationService(ctrl *gomock.Controller) *MockReservationService {
+	mock := &MockReservationService{ctrl: ctrl}
+	mock.recorder = &MockReservationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReservationService) EXPECT() *MockReservationServiceMockRecorder {
+	return m.recorder
+}
+
+// CB mocks base method.
+func (m *MockReservationService) CB() circuit_breaker.CircuitBreaker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CB")
+	ret0, _ := ret[0].(circuit_breaker.CircuitBreaker)
+	return ret0
+}
+
+// CB indicates an expected call of CB.
+func (mr *MockReservationServiceMockRecorder) CB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CB", reflect.TypeOf((*MockReservationService)(nil).CB))
+}
+
+// CancelHold mocks base method.
+func (m *MockReservationService) CancelHold(ctx context.Context, holdUid string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelHold", ctx, holdUid)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelHold indicates an expected call of CancelHold.
+func (mr *MockReservationServiceMockRecorder) CancelHold(ctx, holdUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelHold", reflect.TypeOf((*MockReservationService)(nil).CancelHold), ctx, holdUid)
+}
+
+// CreateHold mocks base method.
+func (m *MockReservationService) CreateHold(ctx context.Context, request model.CreateHoldRequest) (model.Hold, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, request)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockReservationServiceMockRecorder) CreateHold(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockReservationService)(nil).CreateHold), ctx, request)
+}
+
+// CreateReservation mocks base method.
+func (m *MockReservationService) CreateReservation(ctx context.Context, request model.CreateReservationRequest) (model.Reservation, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReservation", ctx, request)
+	ret0, _ := ret[0].(model.Reservation)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateReservation indicates an expected call of CreateReservation.
+func (mr *MockReservationServiceMockRecorder) CreateReservation(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReservation", reflect.TypeOf((*MockReservationService)(nil).CreateReservation), ctx, request)
+}
+
+// GetHold mocks base method.
+func (m *MockReservationService) GetHold(ctx context.Context, holdUid string) (model.Hold, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, holdUid)
+	ret0, _ := ret[0].(model.Hold)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockReservationServiceMockRecorder) GetHold(ctx, holdUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockReservationService)(nil).GetHold), ctx, holdUid)
+}
+
+// GetHolds mocks base method.
+func (m *MockReservationService) GetHolds(ctx context.Context) ([]model.Hold, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHolds", ctx)
+	ret0, _ := ret[0].([]model.Hold)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetHolds indicates an expected call of GetHolds.
+func (mr *MockReservationServiceMockRecorder) GetHolds(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHolds", reflect.TypeOf((*MockReservationService)(nil).GetHolds), ctx)
+}
+
+// GetReservation mocks base method.
+func (m *MockReservationService) GetReservation(ctx context.Context, username string, page paging.Request) ([]model.GetReservation, paging.Page, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservation", ctx, username, page)
+	ret0, _ := ret[0].([]model.GetReservation)
+	ret1, _ := ret[1].(paging.Page)
+	ret2, _ := ret[2].(int)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// GetReservation indicates an expected call of GetReservation.
+func (mr *MockReservationServiceMockRecorder) GetReservation(ctx, username, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservation", reflect.TypeOf((*MockReservationService)(nil).GetReservation), ctx, username, page)
+}
+
+// RenewReservation mocks base method.
+func (m *MockReservationService) RenewReservation(ctx context.Context, reservationUid string, req model.RenewReservationRequest) (model.RenewReservationResponse, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewReservation", ctx, reservationUid, req)
+	ret0, _ := ret[0].(model.RenewReservationResponse)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RenewReservation indicates an expected call of RenewReservation.
+func (mr *MockReservationServiceMockRecorder) RenewReservation(ctx, reservationUid, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewReservation", reflect.TypeOf((*MockReservationService)(nil).RenewReservation), ctx, reservationUid, req)
+}
+
+// ReservationReturn mocks base method.
+func (m *MockReservationService) ReservationReturn(ctx context.Context, req model.ReservationReturnRequest, username, reservationUid string) (model.ReservationReturnResponse, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReservationReturn", ctx, req, username, reservationUid)
+	ret0, _ := ret[0].(model.ReservationReturnResponse)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReservationReturn indicates an expected call of ReservationReturn.
+func (mr *MockReservationServiceMockRecorder) ReservationReturn(ctx, req, username, reservationUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReservationReturn", reflect.TypeOf((*MockReservationService)(nil).ReservationReturn), ctx, req, username, reservationUid)
+}
+
+// RollbackReservation mocks base method.
+func (m *MockReservationService) RollbackReservation(ctx context.Context, uuid string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackReservation", ctx, uuid)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackReservation indicates an expected call of RollbackReservation.
+func (mr *MockReservationServiceMockRecorder) RollbackReservation(ctx, uuid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackReservation", reflect.TypeOf((*MockReservationService)(nil).RollbackReservation), ctx, uuid)
+}
+
+// RollbackReturn mocks base method.
+func (m *MockReservationService) RollbackReturn(ctx context.Context, uuid string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollbackReturn", ctx, uuid)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollbackReturn indicates an expected call of RollbackReturn.
+func (mr *MockReservationServiceMockRecorder) RollbackReturn(ctx, uuid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollbackReturn", reflect.TypeOf((*MockReservationService)(nil).RollbackReturn), ctx, uuid)
+}
@@ -1,36 +1,37 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 
-	"github.com/IBM/sarama"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 )
 
+// producerName is the producer of the events of the gateway.
+const producerName = "gateway"
+
 type Enqueuer interface {
-	Enqueue(topic string, v any) error
+	Enqueue(ctx context.Context, topic, eventType, eventID string, v any) error
 }
 
-func NewEnqueuer(producer sarama.SyncProducer) Enqueuer {
+// NewEnqueuer stores messages in the outbox, the relay publishes them to Kafka.
+func NewEnqueuer(db outbox.Execer) Enqueuer {
 	return &enqueuerImpl{
-		producer: producer,
+		db: db,
 	}
 }
 
 type enqueuerImpl struct {
-	producer sarama.SyncProducer
+	db outbox.Execer
 }
 
-func (q *enqueuerImpl) Enqueue(topic string, v any) error {
-	if q.producer == nil {
+func (q *enqueuerImpl) Enqueue(ctx context.Context, topic, eventType, eventID string, v any) error {
+	if q.db == nil {
 		return nil
 	}
-	data, err := json.Marshal(v)
+	env, err := kafka.NewEnvelope(ctx, eventType, producerName, eventID, v)
 	if err != nil {
 		return err
 	}
-	msg := &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder(data)}
-	if _, _, err = q.producer.SendMessage(msg); err != nil {
-		return err
-	}
-	return nil
+	return outbox.PutEvent(ctx, q.db, topic, "", env)
 }
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+// RenewReservation extends a reservation. The reservation service checks the renewal policy,
+// including the minimal rating, and moves the due date to a day the library is open, so the rating
+// and the schedule of the library are passed along with the request.
+func (h *Handler) RenewReservation(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	reservationUid := c.Param("reservationUid")
+	if reservationUid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "reservationUid is empty")
+	}
+
+	var (
+		rat          model.Rating
+		reservations []model.GetReservation
+	)
+	gg, ctxCancel := errgroup.WithContext(ctx)
+	gg.Go(func() error {
+		return h.ratingSvc.CB().Call(func() error {
+			var (
+				code int
+				err  error
+			)
+			rat, code, err = h.ratingSvc.GetRating(ctxCancel)
+			if err != nil {
+				return echo.NewHTTPError(code, err.Error())
+			}
+			return nil
+		})
+	})
+	gg.Go(func() error {
+		return h.reservationSvc.CB().Call(func() error {
+			var (
+				code int
+				err  error
+			)
+			reservations, _, code, err = h.reservationSvc.GetReservation(ctxCancel, userName, paging.Request{})
+			if err != nil {
+				return echo.NewHTTPError(code, err.Error())
+			}
+			return nil
+		})
+	})
+	if err := gg.Wait(); err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(reservations, func(r model.GetReservation) bool {
+		return r.ReservationUid == reservationUid
+	})
+	if idx < 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "reservation not found")
+	}
+
+	var lib model.GetLibrary
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		lib, code, err = h.librarySvc.GetLibrary(ctx, reservations[idx].LibraryUid)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var resp model.RenewReservationResponse
+	if err := h.reservationSvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.reservationSvc.RenewReservation(ctx, reservationUid, model.RenewReservationRequest{Stars: rat.Stars, Schedule: lib.Schedule})
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+func TestHandler_RenewReservation(t *testing.T) {
+	t.Parallel()
+	const reservationUid = "9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e"
+	const libraryUid = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+	type mockBehavior func(rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, lib *service_mocks.MockLibraryService)
+	reservations := []model.GetReservation{{ReservationUid: reservationUid, LibraryUid: libraryUid, Status: "RENTED"}}
+	schedule := &calendar.Schedule{OpeningHours: []calendar.OpeningHours{{Weekday: 1, Opens: "10:00", Closes: "20:00"}}}
+
+	tests := []struct {
+		name         string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, lib *service_mocks.MockLibraryService) {
+				rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{Stars: 75}, http.StatusOK, nil)
+				rsv.EXPECT().GetReservation(gomock.Any(), "user", paging.Request{}).Return(reservations, paging.Page{}, http.StatusOK, nil)
+				lib.EXPECT().GetLibrary(gomock.Any(), libraryUid).Return(model.GetLibrary{ID: 1, Schedule: schedule}, http.StatusOK, nil)
+				rsv.EXPECT().RenewReservation(gomock.Any(), reservationUid, model.RenewReservationRequest{Stars: 75, Schedule: schedule}).
+					Return(model.RenewReservationResponse{
+						ReservationUid: reservationUid,
+						Status:         "RENTED",
+						StartDate:      model.Date2{Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)},
+						TillDate:       model.Date2{Time: time.Date(2024, 1, 18, 0, 0, 0, 0, time.Local)},
+						Renewals:       1,
+					}, http.StatusOK, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"reservationUid":"` + reservationUid + `","status":"RENTED","startDate":"2024-01-01","tillDate":"2024-01-18","renewals":1}`,
+		},
+		{
+			name: "err. policy denies the renewal",
+			mockBehavior: func(rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, lib *service_mocks.MockLibraryService) {
+				rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{Stars: 75}, http.StatusOK, nil)
+				rsv.EXPECT().GetReservation(gomock.Any(), "user", paging.Request{}).Return(reservations, paging.Page{}, http.StatusOK, nil)
+				lib.EXPECT().GetLibrary(gomock.Any(), libraryUid).Return(model.GetLibrary{ID: 1}, http.StatusOK, nil)
+				rsv.EXPECT().RenewReservation(gomock.Any(), reservationUid, gomock.Any()).
+					Return(model.RenewReservationResponse{}, http.StatusConflict, errors.New("conflict"))
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "err. rating is unavailable",
+			mockBehavior: func(rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, _ *service_mocks.MockLibraryService) {
+				rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{}, http.StatusServiceUnavailable, errors.New("unavailable"))
+				rsv.EXPECT().GetReservation(gomock.Any(), "user", paging.Request{}).Return(reservations, paging.Page{}, http.StatusOK, nil).AnyTimes()
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "err. reservation of another user",
+			mockBehavior: func(rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, _ *service_mocks.MockLibraryService) {
+				rat.EXPECT().GetRating(gomock.Any()).Return(model.Rating{Stars: 75}, http.StatusOK, nil)
+				rsv.EXPECT().GetReservation(gomock.Any(), "user", paging.Request{}).Return(nil, paging.Page{}, http.StatusOK, nil)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			rat := service_mocks.NewMockRatingService(ctrl)
+			rsv := service_mocks.NewMockReservationService(ctrl)
+			rat.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			lib := service_mocks.NewMockLibraryService(ctrl)
+			rsv.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			tt.mockBehavior(rat, rsv, lib)
+			h := &Handler{ratingSvc: rat, reservationSvc: rsv, librarySvc: lib, log: zap.NewNop()}
+
+			e := echo.New()
+			e.POST("/reservations/:reservationUid/renew", h.RenewReservation)
+			r := httptest.NewRequest(http.MethodPost, "/reservations/"+reservationUid+"/renew", http.NoBody).
+				WithContext(auth.SetAuthContext(context.Background(), "user", "user"))
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
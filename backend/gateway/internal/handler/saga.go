@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	createReservationSagaName = "create_reservation"
+	returnReservationSagaName = "return_reservation"
+)
+
+// createReservationData is persisted after every step, so it holds everything compensations need.
+// Request is only used by forward steps, which never run during recovery.
+type createReservationData struct {
+	Request        model.CreateReservationRequest `json:"-"`
+	UserName       string                         `json:"userName"`
+	UserRole       string                         `json:"userRole"`
+	ReservationUid string                         `json:"reservationUid"`
+	LibraryID      int                            `json:"libraryId"`
+	BookID         int                            `json:"bookId"`
+	Copy           model.BookCopy                 `json:"copy"`
+	Reservation    model.Reservation              `json:"reservation"`
+}
+
+type returnReservationData struct {
+	Request        model.ReservationReturnRequest  `json:"-"`
+	UserName       string                          `json:"userName"`
+	UserRole       string                          `json:"userRole"`
+	ReservationUid string                          `json:"reservationUid"`
+	Returned       model.ReservationReturnResponse `json:"returned"`
+	Library        model.GetLibrary                `json:"library"`
+	Book           model.GetBook                   `json:"book"`
+	Stars          int                             `json:"stars"`
+	// RatingDelta is the change of the stars that took effect, the compensation takes back exactly it.
+	// The change of a rating queued to Kafka is not known, the stars asked for are taken back then.
+	RatingDelta int `json:"ratingDelta"`
+	// RunID tells the runs of the saga apart: a return retried after a compensated run is not
+	// deduplicated against it.
+	RunID string `json:"runId"`
+	// Copy is the copy the library took back, it is empty if the return was queued to Kafka.
+	Copy   model.BookCopy `json:"copy"`
+	Queued bool           `json:"queued"`
+}
+
+func (h *Handler) newCreateReservationSaga() saga.Definition[createReservationData] {
+	return saga.Definition[createReservationData]{
+		Name: createReservationSagaName,
+		Steps: []saga.Step[createReservationData]{
+			{
+				Name: "available_count",
+				Action: func(ctx context.Context, d *createReservationData) error {
+					bookCopy, code, err := h.librarySvc.AvailableCount(ctx, model.AvailableCountRequest{
+						EventID:        sagaEventID(createReservationSagaName, d.ReservationUid, "available_count"),
+						LibraryID:      d.LibraryID,
+						BookID:         d.BookID,
+						IsReturn:       false,
+						ReservationUid: d.ReservationUid,
+					})
+					if err != nil {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					d.Copy = bookCopy
+					return nil
+				},
+				Compensate: func(ctx context.Context, d *createReservationData) error {
+					_, _, err := h.librarySvc.AvailableCount(ctx, model.AvailableCountRequest{
+						EventID:        sagaEventID(createReservationSagaName, d.ReservationUid, "available_count", "compensate"),
+						LibraryID:      d.LibraryID,
+						BookID:         d.BookID,
+						IsReturn:       true,
+						ReservationUid: d.ReservationUid,
+					})
+					return err
+				},
+				// the copy is lent on the reservation uid, so taking back a copy that was never lent is a no-op.
+				Idempotent: true,
+			},
+			{
+				Name: "reserve",
+				Action: func(ctx context.Context, d *createReservationData) error {
+					req := d.Request
+					req.ReservationUid = d.ReservationUid
+					req.CopyUid, req.Condition = d.Copy.CopyUid, d.Copy.Condition
+					rsv, code, err := h.reservationSvc.CreateReservation(auth.SetAuthContext(ctx, d.UserName, d.UserRole), req)
+					if err != nil {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					d.Reservation = rsv
+					return nil
+				},
+				Compensate: func(ctx context.Context, d *createReservationData) error {
+					_, err := h.reservationSvc.RollbackReservation(ctx, d.ReservationUid)
+					return err
+				},
+				// the reservation uid is chosen up front, so a rollback of a reservation that was never created is a no-op.
+				Idempotent: true,
+			},
+		},
+	}
+}
+
+func (h *Handler) newReturnReservationSaga() saga.Definition[returnReservationData] {
+	return saga.Definition[returnReservationData]{
+		Name: returnReservationSagaName,
+		Steps: []saga.Step[returnReservationData]{
+			{
+				Name: "return",
+				Action: func(ctx context.Context, d *returnReservationData) error {
+					ctx = auth.SetAuthContext(ctx, d.UserName, d.UserRole)
+					res, code, err := h.reservationSvc.ReservationReturn(ctx, d.Request, d.UserName, d.ReservationUid)
+					if err != nil {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					d.Returned = res
+					return nil
+				},
+				Compensate: func(ctx context.Context, d *returnReservationData) error {
+					_, err := h.reservationSvc.RollbackReturn(ctx, d.ReservationUid)
+					return err
+				},
+				// not Idempotent but the Pivot: a return that did take effect is not undone by recovery,
+				// the borrower has handed the book in, so the rest of the saga is rolled forward.
+				Pivot: true,
+			},
+			{
+				Name:   "resolve",
+				Action: h.resolveReturnedBook,
+			},
+			{
+				Name: "available_count",
+				Action: func(ctx context.Context, d *returnReservationData) error {
+					req := model.AvailableCountRequest{
+						EventID:        sagaEventID(returnReservationSagaName, d.RunID, "available_count"),
+						LibraryID:      d.Library.ID,
+						BookID:         d.Book.ID,
+						IsReturn:       true,
+						ReservationUid: d.ReservationUid,
+						Condition:      d.Returned.ReturnCondition,
+					}
+					bookCopy, code, err := h.librarySvc.AvailableCount(ctx, req)
+					if err == nil {
+						d.Copy = bookCopy
+						return nil
+					}
+					if code != http.StatusServiceUnavailable {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					d.Queued = true
+					payload := kafka.AvailableCount{
+						LibraryID:      req.LibraryID,
+						BookID:         req.BookID,
+						Delta:          1,
+						ReservationUid: req.ReservationUid,
+						Condition:      req.Condition,
+					}
+					if err := h.enqueuer.Enqueue(ctx, kafka.LibraryTopic, kafka.EventAvailableCount, req.EventID, payload); err != nil {
+						h.log.Warn("availableCount h.enqueuer.Enqueue()", zap.Error(err))
+					}
+					return nil
+				},
+				// Compensate lends again only the copy the library took back. A return queued to Kafka
+				// is not applied yet, and a return that found no copy lent changed nothing.
+				Compensate: func(ctx context.Context, d *returnReservationData) error {
+					if d.Copy.CopyUid == "" {
+						if d.Queued {
+							h.log.Warn("return queued to the library is not undone", zap.String("reservationUid", d.ReservationUid))
+						}
+						return nil
+					}
+					_, code, err := h.librarySvc.AvailableCount(ctx, model.AvailableCountRequest{
+						EventID:        sagaEventID(returnReservationSagaName, d.RunID, "available_count", "compensate"),
+						LibraryID:      d.Library.ID,
+						BookID:         d.Book.ID,
+						IsReturn:       false,
+						ReservationUid: d.ReservationUid,
+						CopyUid:        d.Copy.CopyUid,
+					})
+					if code == http.StatusConflict {
+						// the copy was lent to somebody else meanwhile, there is nothing to lend again.
+						h.log.Warn("returned copy is no longer on the shelf",
+							zap.String("reservationUid", d.ReservationUid), zap.String("copyUid", d.Copy.CopyUid))
+						return nil
+					}
+					return err
+				},
+			},
+			{
+				Name: "rating",
+				Action: func(ctx context.Context, d *returnReservationData) error {
+					eventID := sagaEventID(returnReservationSagaName, d.RunID, "rating")
+					change, code, err := h.ratingSvc.Rating(auth.SetAuthContext(ctx, d.borrower(), d.UserRole), eventID, d.Stars)
+					if err == nil {
+						d.RatingDelta = change.Delta
+						return nil
+					}
+					if code != http.StatusServiceUnavailable {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					payload := kafka.RatingChange{
+						Name:  d.borrower(),
+						Stars: d.Stars,
+					}
+					if err := h.enqueuer.Enqueue(ctx, kafka.RatingTopic, kafka.EventRatingChange, eventID, payload); err != nil {
+						h.log.Warn("Rating h.enqueuer.Enqueue()", zap.Error(err))
+					}
+					d.RatingDelta = d.Stars
+					return nil
+				},
+				// Compensate takes the stars back, so a return retried after this run rates once.
+				Compensate: func(ctx context.Context, d *returnReservationData) error {
+					eventID := sagaEventID(returnReservationSagaName, d.RunID, "rating", "compensate")
+					_, _, err := h.ratingSvc.Rating(auth.SetAuthContext(ctx, d.borrower(), d.UserRole), eventID, -d.RatingDelta)
+					return err
+				},
+			},
+			{
+				Name: "fine",
+				Action: func(ctx context.Context, d *returnReservationData) error {
+					req := model.AssessFineRequest{
+						EventID:        sagaEventID(returnReservationSagaName, d.RunID, "fine"),
+						ReservationUid: d.ReservationUid,
+						UserName:       d.borrower(),
+						LibraryUid:     d.Returned.LibraryUid,
+						TillDate:       d.Returned.TillDate,
+						ReturnDate:     d.Returned.ReturnDate,
+						ConditionFrom:  d.checkoutCondition(),
+						ConditionTo:    d.Returned.ReturnCondition,
+					}
+					code, err := h.finesSvc.AssessFine(ctx, req)
+					if err == nil {
+						return nil
+					}
+					if code != http.StatusServiceUnavailable {
+						return echo.NewHTTPError(code, err.Error())
+					}
+					payload := kafka.FineAssess{
+						ReservationUid: req.ReservationUid,
+						UserName:       req.UserName,
+						LibraryUid:     req.LibraryUid,
+						TillDate:       req.TillDate,
+						ReturnDate:     req.ReturnDate,
+						ConditionFrom:  req.ConditionFrom,
+						ConditionTo:    req.ConditionTo,
+					}
+					if err := h.enqueuer.Enqueue(ctx, kafka.FinesTopic, kafka.EventFineAssess, req.EventID, payload); err != nil {
+						h.log.Warn("AssessFine h.enqueuer.Enqueue()", zap.Error(err))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func (h *Handler) resolveReturnedBook(ctx context.Context, d *returnReservationData) error {
+	gg, ctxCancel := errgroup.WithContext(ctx)
+	gg.Go(func() error {
+		return h.librarySvc.CB().Call(func() error {
+			lib, code, err := h.librarySvc.GetLibrary(ctxCancel, d.Returned.LibraryUid)
+			if err != nil {
+				return echo.NewHTTPError(code, err.Error())
+			}
+			d.Library = lib
+			return nil
+		})
+	})
+	gg.Go(func() error {
+		return h.librarySvc.CB().Call(func() error {
+			book, code, err := h.librarySvc.GetBook(ctxCancel, d.Returned.LibraryUid, d.Returned.BookUid)
+			if err != nil {
+				return echo.NewHTTPError(code, err.Error())
+			}
+			d.Book = book
+			return nil
+		})
+	})
+	if err := gg.Wait(); err != nil {
+		return err
+	}
+
+	d.Stars = 1
+	if d.checkoutCondition() != d.Returned.ReturnCondition {
+		d.Stars = -10
+	}
+	return nil
+}
+
+// borrower is the user the returned reservation is of, a librarian returns the reservations of others.
+func (d *returnReservationData) borrower() string {
+	if d.Returned.Username != "" {
+		return d.Returned.Username
+	}
+	return d.UserName
+}
+
+// checkoutCondition is the condition stored at checkout. The loans made before it was stored
+// fall back to the condition the library has for the book.
+func (d *returnReservationData) checkoutCondition() string {
+	if d.Returned.CheckoutCondition != "" {
+		return d.Returned.CheckoutCondition
+	}
+	return d.Book.Condition
+}
+
+// sagaEventID derives the event ID of a saga step from the saga run, so a retried call and its
+// Kafka fallback are deduplicated by the consumer, but the calls of another run are not. A
+// reservation is created with a new uid in every run, so it is the run ID of its saga.
+func sagaEventID(sagaName, runID string, step ...string) string {
+	name := strings.Join(append([]string{sagaName, runID}, step...), "/")
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+// sagaHTTPError unwraps the HTTP error of the failed step.
+func sagaHTTPError(err error) error {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,420 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+// brokerOutbox publishes outbox rows straight to the broker, as the relay would after the commit.
+type brokerOutbox struct {
+	producer sarama.SyncProducer
+}
+
+func (o brokerOutbox) Exec(_ context.Context, _ string, arguments ...any) (pgconn.CommandTag, error) {
+	args := arguments[0].(pgx.NamedArgs)
+	msg := &sarama.ProducerMessage{
+		Topic: args["topic"].(string),
+		Value: sarama.ByteEncoder(args["payload"].([]byte)),
+	}
+	if key := args["key"].(string); key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+	_, _, err := o.producer.SendMessage(msg)
+	return pgconn.NewCommandTag("INSERT 0 1"), err
+}
+
+type nopStore struct{}
+
+func (nopStore) Create(context.Context, saga.Record) error                 { return nil }
+func (nopStore) Save(context.Context, saga.Record, *saga.StepRecord) error { return nil }
+func (nopStore) ClaimStale(context.Context, int) ([]saga.Record, error)    { return nil, nil }
+
+func TestReturnReservationSaga_KafkaFallback(t *testing.T) {
+	t.Parallel()
+	const (
+		userName       = "user"
+		reservationUid = "9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e"
+		libraryUid     = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+		runID          = "run"
+	)
+	var (
+		tillDate    = time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+		returnDate  = time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+		unavailable = errors.New("service unavailable")
+	)
+	type mockBehavior func(lib *service_mocks.MockLibraryService, rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, fin *service_mocks.MockFinesService)
+
+	tests := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantCode     int
+		wantLibrary  []kafka.AvailableCount
+		wantRating   []kafka.RatingChange
+		wantFines    []kafka.FineAssess
+	}{
+		{
+			name: "library and rating are down",
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rat *service_mocks.MockRatingService, _ *service_mocks.MockReservationService, fin *service_mocks.MockFinesService) {
+				lib.EXPECT().AvailableCount(gomock.Any(), model.AvailableCountRequest{
+					EventID:        sagaEventID(returnReservationSagaName, runID, "available_count"),
+					LibraryID:      1,
+					BookID:         2,
+					IsReturn:       true,
+					ReservationUid: reservationUid,
+					Condition:      "BAD",
+				}).Return(model.BookCopy{}, http.StatusServiceUnavailable, unavailable)
+				rat.EXPECT().Rating(gomock.Any(), sagaEventID(returnReservationSagaName, runID, "rating"), -10).
+					Return(model.RatingChange{}, http.StatusServiceUnavailable, unavailable)
+				fin.EXPECT().AssessFine(gomock.Any(), model.AssessFineRequest{
+					EventID:        sagaEventID(returnReservationSagaName, runID, "fine"),
+					ReservationUid: reservationUid,
+					UserName:       userName,
+					LibraryUid:     libraryUid,
+					TillDate:       tillDate,
+					ReturnDate:     returnDate,
+					ConditionFrom:  "GOOD",
+					ConditionTo:    "BAD",
+				}).Return(http.StatusServiceUnavailable, unavailable)
+			},
+			wantLibrary: []kafka.AvailableCount{{LibraryID: 1, BookID: 2, Delta: 1, ReservationUid: reservationUid, Condition: "BAD"}},
+			wantRating:  []kafka.RatingChange{{Name: userName, Stars: -10}},
+			wantFines: []kafka.FineAssess{{
+				ReservationUid: reservationUid,
+				UserName:       userName,
+				LibraryUid:     libraryUid,
+				TillDate:       tillDate,
+				ReturnDate:     returnDate,
+				ConditionFrom:  "GOOD",
+				ConditionTo:    "BAD",
+			}},
+		},
+		{
+			name: "only rating is down",
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rat *service_mocks.MockRatingService, _ *service_mocks.MockReservationService, fin *service_mocks.MockFinesService) {
+				lib.EXPECT().AvailableCount(gomock.Any(), gomock.Any()).Return(model.BookCopy{}, http.StatusOK, nil)
+				rat.EXPECT().Rating(gomock.Any(), gomock.Any(), -10).Return(model.RatingChange{}, http.StatusServiceUnavailable, unavailable)
+				fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).Return(http.StatusOK, nil)
+			},
+			wantRating: []kafka.RatingChange{{Name: userName, Stars: -10}},
+		},
+		{
+			name: "library rejects the request",
+			mockBehavior: func(lib *service_mocks.MockLibraryService, _ *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, _ *service_mocks.MockFinesService) {
+				lib.EXPECT().AvailableCount(gomock.Any(), gomock.Any()).Return(model.BookCopy{}, http.StatusBadRequest, errors.New("bad request"))
+				rsv.EXPECT().RollbackReturn(gomock.Any(), reservationUid).Return(http.StatusOK, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "fine is rejected after the library took the copy back",
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, fin *service_mocks.MockFinesService) {
+				lib.EXPECT().AvailableCount(gomock.Any(), model.AvailableCountRequest{
+					EventID:        sagaEventID(returnReservationSagaName, runID, "available_count"),
+					LibraryID:      1,
+					BookID:         2,
+					IsReturn:       true,
+					ReservationUid: reservationUid,
+					Condition:      "BAD",
+				}).Return(model.BookCopy{CopyUid: "copy"}, http.StatusOK, nil)
+				// the user had 5 stars only, so 5 are taken back and not the 10 asked for.
+				rat.EXPECT().Rating(gomock.Any(), gomock.Any(), -10).Return(model.RatingChange{Delta: -5}, http.StatusOK, nil)
+				fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).Return(http.StatusBadRequest, errors.New("bad request"))
+				rat.EXPECT().Rating(gomock.Any(), sagaEventID(returnReservationSagaName, runID, "rating", "compensate"), 5).
+					Return(model.RatingChange{Delta: 5}, http.StatusOK, nil)
+				// the very copy taken back is lent again.
+				lib.EXPECT().AvailableCount(gomock.Any(), model.AvailableCountRequest{
+					EventID:        sagaEventID(returnReservationSagaName, runID, "available_count", "compensate"),
+					LibraryID:      1,
+					BookID:         2,
+					ReservationUid: reservationUid,
+					CopyUid:        "copy",
+				}).Return(model.BookCopy{CopyUid: "copy"}, http.StatusOK, nil)
+				rsv.EXPECT().RollbackReturn(gomock.Any(), reservationUid).Return(http.StatusOK, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "fine is rejected after the return was queued",
+			mockBehavior: func(lib *service_mocks.MockLibraryService, rat *service_mocks.MockRatingService, rsv *service_mocks.MockReservationService, fin *service_mocks.MockFinesService) {
+				// no copy is lent again for a return the library has not taken yet.
+				lib.EXPECT().AvailableCount(gomock.Any(), gomock.Any()).Return(model.BookCopy{}, http.StatusServiceUnavailable, unavailable)
+				rat.EXPECT().Rating(gomock.Any(), gomock.Any(), -10).Return(model.RatingChange{Delta: -10}, http.StatusOK, nil)
+				fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).Return(http.StatusBadRequest, errors.New("bad request"))
+				rat.EXPECT().Rating(gomock.Any(), sagaEventID(returnReservationSagaName, runID, "rating", "compensate"), 10).
+					Return(model.RatingChange{Delta: 10}, http.StatusOK, nil)
+				rsv.EXPECT().RollbackReturn(gomock.Any(), reservationUid).Return(http.StatusOK, nil)
+			},
+			wantCode:    http.StatusBadRequest,
+			wantLibrary: []kafka.AvailableCount{{LibraryID: 1, BookID: 2, Delta: 1, ReservationUid: reservationUid, Condition: "BAD"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			lib := service_mocks.NewMockLibraryService(ctrl)
+			rat := service_mocks.NewMockRatingService(ctrl)
+			rsv := service_mocks.NewMockReservationService(ctrl)
+			fin := service_mocks.NewMockFinesService(ctrl)
+
+			req := model.ReservationReturnRequest{Condition: "BAD", Date: model.Date{Time: returnDate}}
+			rsv.EXPECT().ReservationReturn(gomock.Any(), req, userName, reservationUid).
+				Return(model.ReservationReturnResponse{
+					LibraryUid:      libraryUid,
+					BookUid:         "book",
+					Status:          "EXPIRED",
+					TillDate:        tillDate,
+					ReturnDate:      returnDate,
+					ReturnCondition: "BAD",
+				}, http.StatusOK, nil)
+			lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			lib.EXPECT().GetLibrary(gomock.Any(), libraryUid).Return(model.GetLibrary{ID: 1}, http.StatusOK, nil)
+			lib.EXPECT().GetBook(gomock.Any(), libraryUid, "book").Return(model.GetBook{ID: 2, Condition: "GOOD"}, http.StatusOK, nil)
+			tt.mockBehavior(lib, rat, rsv, fin)
+
+			broker := kafkatest.NewBroker(kafkatest.WithPartitions(3))
+			producer := broker.SyncProducer()
+			h := &Handler{
+				librarySvc:     lib,
+				ratingSvc:      rat,
+				reservationSvc: rsv,
+				finesSvc:       fin,
+				enqueuer:       NewEnqueuer(brokerOutbox{producer: producer}),
+				log:            zap.NewNop(),
+				saga:           saga.NewOrchestrator(nopStore{}, saga.Config{MaxAttempts: 1}, zap.NewNop()),
+			}
+			data := returnReservationData{
+				Request:        req,
+				RunID:          runID,
+				UserName:       userName,
+				ReservationUid: reservationUid,
+			}
+			err := saga.Execute(context.Background(), h.saga, h.newReturnReservationSaga(), &data)
+			if tt.wantCode != 0 {
+				var httpErr *echo.HTTPError
+				require.ErrorAs(t, sagaHTTPError(err), &httpErr)
+				require.Equal(t, tt.wantCode, httpErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// consume both topics the way the library and rating services do.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			libraryEvents := make(chan kafka.Event[kafka.AvailableCount], 10)
+			ratingEvents := make(chan kafka.Event[kafka.RatingChange], 10)
+			finesEvents := make(chan kafka.Event[kafka.FineAssess], 10)
+			libraryConsumer := kafka.NewTypedConsumer(kafka.Events[kafka.AvailableCount](kafka.EventAvailableCount),
+				func(_ context.Context, e kafka.Event[kafka.AvailableCount]) error {
+					libraryEvents <- e
+					return nil
+				}, producer, kafka.LibraryConsumerGroup, kafka.Config{}, zap.NewNop())
+			ratingConsumer := kafka.NewTypedConsumer(kafka.Events[kafka.RatingChange](kafka.EventRatingChange),
+				func(_ context.Context, e kafka.Event[kafka.RatingChange]) error {
+					ratingEvents <- e
+					return nil
+				}, producer, kafka.RatingConsumerGroup, kafka.Config{}, zap.NewNop())
+			finesConsumer := kafka.NewTypedConsumer(kafka.Events[kafka.FineAssess](kafka.EventFineAssess),
+				func(_ context.Context, e kafka.Event[kafka.FineAssess]) error {
+					finesEvents <- e
+					return nil
+				}, producer, kafka.FinesConsumerGroup, kafka.Config{}, zap.NewNop())
+			libraryGroup := broker.ConsumerGroup(kafka.LibraryConsumerGroup)
+			ratingGroup := broker.ConsumerGroup(kafka.RatingConsumerGroup)
+			finesGroup := broker.ConsumerGroup(kafka.FinesConsumerGroup)
+			kafkatest.Consume(ctx, libraryGroup, []string{kafka.LibraryTopic}, libraryConsumer)
+			kafkatest.Consume(ctx, ratingGroup, []string{kafka.RatingTopic}, ratingConsumer)
+			kafkatest.Consume(ctx, finesGroup, []string{kafka.FinesTopic}, finesConsumer)
+
+			for _, want := range tt.wantLibrary {
+				e := receive(t, libraryEvents)
+				require.Equal(t, want, e.Data)
+				require.Equal(t, sagaEventID(returnReservationSagaName, runID, "available_count"), e.EventID)
+				require.Equal(t, producerName, e.Producer)
+			}
+			for _, want := range tt.wantRating {
+				e := receive(t, ratingEvents)
+				require.Equal(t, want, e.Data)
+				require.Equal(t, sagaEventID(returnReservationSagaName, runID, "rating"), e.EventID)
+			}
+			for _, want := range tt.wantFines {
+				e := receive(t, finesEvents)
+				require.Equal(t, want, e.Data)
+				require.Equal(t, sagaEventID(returnReservationSagaName, runID, "fine"), e.EventID)
+			}
+			require.NoError(t, libraryGroup.Close())
+			require.NoError(t, ratingGroup.Close())
+			require.NoError(t, finesGroup.Close())
+			require.Len(t, libraryEvents, 0)
+			require.Len(t, ratingEvents, 0)
+			require.Len(t, finesEvents, 0)
+			require.Empty(t, broker.Messages(kafka.DLQTopic(kafka.LibraryTopic)))
+			require.Empty(t, broker.Messages(kafka.DLQTopic(kafka.RatingTopic)))
+			require.Empty(t, broker.Messages(kafka.DLQTopic(kafka.FinesTopic)))
+		})
+	}
+}
+
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		var zero T
+		return zero
+	}
+}
+
+func TestReturnReservationData_CheckoutCondition(t *testing.T) {
+	t.Parallel()
+	d := returnReservationData{Book: model.GetBook{Condition: "EXCELLENT"}}
+	require.Equal(t, "EXCELLENT", d.checkoutCondition(), "loans made before the condition was stored")
+
+	d.Returned.CheckoutCondition = "GOOD"
+	require.Equal(t, "GOOD", d.checkoutCondition())
+}
+
+func TestReturnReservationSaga_RetryAfterCompensation(t *testing.T) {
+	t.Parallel()
+	const reservationUid = "9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e"
+	ctrl := gomock.NewController(t)
+	lib := service_mocks.NewMockLibraryService(ctrl)
+	rat := service_mocks.NewMockRatingService(ctrl)
+	rsv := service_mocks.NewMockReservationService(ctrl)
+	fin := service_mocks.NewMockFinesService(ctrl)
+
+	// the services drop the events they have processed already, none of the retry may be one of them.
+	processed := make(map[string]bool)
+	process := func(eventID string) {
+		require.False(t, processed[eventID], "event %s is deduplicated", eventID)
+		processed[eventID] = true
+	}
+	rsv.EXPECT().ReservationReturn(gomock.Any(), gomock.Any(), "user", reservationUid).
+		Return(model.ReservationReturnResponse{LibraryUid: "library", BookUid: "book", ReturnCondition: "GOOD"}, http.StatusOK, nil).Times(2)
+	lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+	lib.EXPECT().GetLibrary(gomock.Any(), "library").Return(model.GetLibrary{ID: 1}, http.StatusOK, nil).Times(2)
+	lib.EXPECT().GetBook(gomock.Any(), "library", "book").Return(model.GetBook{ID: 2, Condition: "GOOD"}, http.StatusOK, nil).Times(2)
+	// the return, the lend that compensates it, and the return again.
+	lib.EXPECT().AvailableCount(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req model.AvailableCountRequest) (model.BookCopy, int, error) {
+			process(req.EventID)
+			return model.BookCopy{CopyUid: "copy"}, http.StatusOK, nil
+		}).Times(3)
+	rat.EXPECT().Rating(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, eventID string, stars int) (model.RatingChange, int, error) {
+			process(eventID)
+			return model.RatingChange{Delta: stars}, http.StatusOK, nil
+		}).Times(3)
+	gomock.InOrder(
+		fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).Return(http.StatusBadRequest, errors.New("bad request")),
+		fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, req model.AssessFineRequest) (int, error) {
+				process(req.EventID)
+				return http.StatusOK, nil
+			}),
+	)
+	rsv.EXPECT().RollbackReturn(gomock.Any(), reservationUid).Return(http.StatusOK, nil)
+
+	h := &Handler{
+		librarySvc:     lib,
+		ratingSvc:      rat,
+		reservationSvc: rsv,
+		finesSvc:       fin,
+		log:            zap.NewNop(),
+		saga:           saga.NewOrchestrator(nopStore{}, saga.Config{MaxAttempts: 1}, zap.NewNop()),
+	}
+	first := returnReservationData{RunID: "first", UserName: "user", ReservationUid: reservationUid}
+	require.Error(t, saga.Execute(context.Background(), h.saga, h.newReturnReservationSaga(), &first))
+
+	second := returnReservationData{RunID: "second", UserName: "user", ReservationUid: reservationUid}
+	require.NoError(t, saga.Execute(context.Background(), h.saga, h.newReturnReservationSaga(), &second))
+}
+
+// staleStore hands out its records to the recovery loop once.
+type staleStore struct {
+	nopStore
+	recs []saga.Record
+}
+
+func (s *staleStore) ClaimStale(context.Context, int) ([]saga.Record, error) {
+	recs := s.recs
+	s.recs = nil
+	return recs, nil
+}
+
+func TestReturnReservationSaga_RecoverAfterReturn(t *testing.T) {
+	t.Parallel()
+	const (
+		reservationUid = "9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e"
+		runID          = "run"
+	)
+	ctrl := gomock.NewController(t)
+	lib := service_mocks.NewMockLibraryService(ctrl)
+	rat := service_mocks.NewMockRatingService(ctrl)
+	rsv := service_mocks.NewMockReservationService(ctrl)
+	fin := service_mocks.NewMockFinesService(ctrl)
+
+	// the return is not rolled back, the rest of the saga is run.
+	lib.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+	lib.EXPECT().GetLibrary(gomock.Any(), "library").Return(model.GetLibrary{ID: 1}, http.StatusOK, nil)
+	lib.EXPECT().GetBook(gomock.Any(), "library", "book").Return(model.GetBook{ID: 2, Condition: "GOOD"}, http.StatusOK, nil)
+	lib.EXPECT().AvailableCount(gomock.Any(), model.AvailableCountRequest{
+		EventID:        sagaEventID(returnReservationSagaName, runID, "available_count"),
+		LibraryID:      1,
+		BookID:         2,
+		IsReturn:       true,
+		ReservationUid: reservationUid,
+		Condition:      "GOOD",
+	}).Return(model.BookCopy{CopyUid: "copy"}, http.StatusOK, nil)
+	rat.EXPECT().Rating(gomock.Any(), sagaEventID(returnReservationSagaName, runID, "rating"), 1).Return(model.RatingChange{Delta: 1}, http.StatusOK, nil)
+	fin.EXPECT().AssessFine(gomock.Any(), gomock.Any()).Return(http.StatusOK, nil)
+
+	// the process died after the return committed.
+	payload, err := json.Marshal(returnReservationData{
+		RunID:          runID,
+		UserName:       "user",
+		UserRole:       "user",
+		ReservationUid: reservationUid,
+		Returned:       model.ReservationReturnResponse{LibraryUid: "library", BookUid: "book", ReturnCondition: "GOOD"},
+	})
+	require.NoError(t, err)
+	store := &staleStore{recs: []saga.Record{{
+		ID:      "1",
+		Name:    returnReservationSagaName,
+		Status:  saga.StatusRunning,
+		Applied: 1,
+		Payload: payload,
+	}}}
+	h := &Handler{
+		librarySvc:     lib,
+		ratingSvc:      rat,
+		reservationSvc: rsv,
+		finesSvc:       fin,
+		log:            zap.NewNop(),
+		saga:           saga.NewOrchestrator(store, saga.Config{MaxAttempts: 1}, zap.NewNop()),
+	}
+	saga.Register(h.saga, h.newReturnReservationSaga())
+	require.NoError(t, h.saga.Recover(context.Background()))
+}
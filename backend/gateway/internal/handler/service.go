@@ -4,12 +4,15 @@ import (
 	"context"
 
 	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/service/fines"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/library"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/provider"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/rating"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/reservation"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/service/stats"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
 	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/labstack/echo/v4"
 )
 
@@ -21,6 +24,7 @@ var (
 	_ ReservationService = (*reservation.Service)(nil)
 	_ StatsService       = (*stats.Service)(nil)
 	_ ProviderService    = (*provider.Service)(nil)
+	_ FinesService       = (*fines.Service)(nil)
 )
 
 type ProviderService interface {
@@ -33,27 +37,65 @@ type LibraryService interface {
 	GetLibraries(c echo.Context) ([]byte, int, error)
 	GetLibrary(ctx context.Context, libUid string) (model.GetLibrary, int, error)
 	GetBooks(c echo.Context) ([]byte, int, error)
+	SearchBooks(c echo.Context) ([]byte, int, error)
+	NearbyLibraries(c echo.Context) ([]byte, int, error)
+	GetAuthors(c echo.Context) ([]byte, int, error)
+	GetAuthorBooks(c echo.Context) ([]byte, int, error)
+	GetGenres(c echo.Context) ([]byte, int, error)
+	GetGenreBooks(c echo.Context) ([]byte, int, error)
 	GetBook(ctx context.Context, libUid, bookUid string) (model.GetBook, int, error)
-	AvailableCount(ctx context.Context, request model.AvailableCountRequest) (status int, err error)
+	AvailableCount(ctx context.Context, request model.AvailableCountRequest) (model.BookCopy, int, error)
+	CreateLibrary(ctx context.Context, request model.LibraryRequest) (model.Library, int, error)
+	UpdateLibrary(ctx context.Context, request model.UpdateLibraryRequest) (model.Library, int, error)
+	ArchiveLibrary(ctx context.Context, libraryUid string) (int, error)
+	SetSchedule(ctx context.Context, request model.ScheduleRequest) (calendar.Schedule, int, error)
+	CreateBook(ctx context.Context, request model.BookRequest) (model.GetBook, int, error)
+	UpdateBook(ctx context.Context, request model.UpdateBookRequest) (model.GetBook, int, error)
+	EnrichBook(ctx context.Context, request model.EnrichBookRequest) (model.GetBook, int, error)
+	SetStock(ctx context.Context, request model.StockRequest) (model.Stock, int, error)
+	AddAuthorAlias(ctx context.Context, request model.AuthorAliasRequest) (model.Author, int, error)
+	MergeAuthors(ctx context.Context, request model.MergeAuthorsRequest) (model.Author, int, error)
+	CreateGenre(ctx context.Context, request model.GenreRequest) (model.Genre, int, error)
+	UpdateGenre(ctx context.Context, request model.UpdateGenreRequest) (model.Genre, int, error)
+	GetAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, int, error)
+	RequestTransfer(ctx context.Context, request model.TransferRequest) (model.Transfer, int, error)
+	ReceiveTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error)
+	CancelTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error)
+	GetTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error)
+	ListTransfers(ctx context.Context, request model.ListTransfersRequest) (model.ListTransfers, int, error)
 	CB() circuit_breaker.CircuitBreaker
 }
 
 type RatingService interface {
 	GetRating(ctx context.Context) (model.Rating, int, error)
-	Rating(ctx context.Context, stars int) (int, error)
+	Rating(ctx context.Context, eventID string, stars int) (model.RatingChange, int, error)
 	CreateRating(ctx context.Context, userName string, stars int) (int, error)
 	CB() circuit_breaker.CircuitBreaker
 }
 
 type StatsService interface {
-	GetStats(ctx context.Context, userName string) (model.StatsInfo, int, error)
+	GetStats(ctx context.Context, userName string, page paging.Request) (model.StatsInfo, int, error)
+	CB() circuit_breaker.CircuitBreaker
+}
+
+type FinesService interface {
+	GetFines(ctx context.Context) (model.Fines, int, error)
+	Pay(ctx context.Context, request model.PaymentRequest) (model.Payment, int, error)
+	AssessFine(ctx context.Context, request model.AssessFineRequest) (int, error)
+	PutTariff(ctx context.Context, tariff model.Tariff) (model.Tariff, int, error)
 	CB() circuit_breaker.CircuitBreaker
 }
 
 type ReservationService interface {
-	GetReservation(ctx context.Context, username string) ([]model.GetReservation, int, error)
+	GetReservation(ctx context.Context, username string, page paging.Request) ([]model.GetReservation, paging.Page, int, error)
 	CreateReservation(ctx context.Context, request model.CreateReservationRequest) (model.Reservation, int, error)
 	RollbackReservation(ctx context.Context, uuid string) (int, error)
+	RollbackReturn(ctx context.Context, uuid string) (int, error)
 	ReservationReturn(ctx context.Context, req model.ReservationReturnRequest, username, reservationUid string) (model.ReservationReturnResponse, int, error)
+	RenewReservation(ctx context.Context, reservationUid string, req model.RenewReservationRequest) (model.RenewReservationResponse, int, error)
+	CreateHold(ctx context.Context, request model.CreateHoldRequest) (model.Hold, int, error)
+	GetHolds(ctx context.Context) ([]model.Hold, int, error)
+	GetHold(ctx context.Context, holdUid string) (model.Hold, int, error)
+	CancelHold(ctx context.Context, holdUid string) (int, error)
 	CB() circuit_breaker.CircuitBreaker
 }
@@ -1,37 +1,35 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
-	"github.com/IBM/sarama"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 )
 
 type statsLog struct {
-	producer sarama.AsyncProducer
-	topic    string
+	db    outbox.Execer
+	topic string
 }
 
 type StatsLog interface {
-	Log(sl kafka.EventStats) error
+	Log(ctx context.Context, sl kafka.EventStats) error
 }
 
-func NewStatsLog(producer sarama.AsyncProducer, topic string) *statsLog {
+func NewStatsLog(db outbox.Execer, topic string) *statsLog {
 	return &statsLog{
-		producer: producer,
-		topic:    topic,
+		db:    db,
+		topic: topic,
 	}
 }
 
-func (l *statsLog) Log(sl kafka.EventStats) error {
+func (l *statsLog) Log(ctx context.Context, sl kafka.EventStats) error {
 	if l == nil {
 		return nil
 	}
-	data, err := json.Marshal(sl)
+	env, err := kafka.NewEnvelope(ctx, kafka.EventReservationStats, producerName, "", sl)
 	if err != nil {
 		return err
 	}
-	msg := &sarama.ProducerMessage{Topic: l.topic, Value: sarama.StringEncoder(data)}
-	l.producer.Input() <- msg
-	return nil
+	return outbox.PutEvent(ctx, l.db, l.topic, sl.UserName, env)
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/Astemirdum/library-service/backend/pkg/validate"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	service_mocks "github.com/Astemirdum/library-service/backend/gateway/internal/handler/mocks"
+)
+
+func TestHandler_GetStats(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		query        string
+		mockBehavior func(st *service_mocks.MockStatsService)
+		expectedBody string
+	}{
+		{
+			name: "all pages",
+			mockBehavior: func(st *service_mocks.MockStatsService) {
+				gomock.InOrder(
+					st.EXPECT().GetStats(gomock.Any(), "admin", paging.Request{Size: paging.MaxSize}).
+						Return(model.StatsInfo{Page: paging.Page{PageSize: paging.MaxSize, Next: "next"}, Data: []model.Stats{{UserName: "a"}}}, http.StatusOK, nil),
+					st.EXPECT().GetStats(gomock.Any(), "admin", paging.Request{Size: paging.MaxSize, Cursor: "next"}).
+						Return(model.StatsInfo{Page: paging.Page{PageSize: paging.MaxSize, Prev: "prev"}, Data: []model.Stats{{UserName: "b"}}}, http.StatusOK, nil),
+				)
+			},
+			expectedBody: `{"pageSize":2,"data":[
+				{"username":"a","last_updated":"0001-01-01T00:00:00Z","rating":0,"cnt_reserv":0,"cnt_books":0,"cnt_libs":0},
+				{"username":"b","last_updated":"0001-01-01T00:00:00Z","rating":0,"cnt_reserv":0,"cnt_books":0,"cnt_libs":0}]}`,
+		},
+		{
+			name:  "one page",
+			query: "?size=1",
+			mockBehavior: func(st *service_mocks.MockStatsService) {
+				st.EXPECT().GetStats(gomock.Any(), "admin", paging.Request{Size: 1}).
+					Return(model.StatsInfo{Page: paging.Page{PageSize: 1, Next: "next"}, Data: []model.Stats{{UserName: "a"}}}, http.StatusOK, nil)
+			},
+			expectedBody: `{"pageSize":1,"next":"next","data":[
+				{"username":"a","last_updated":"0001-01-01T00:00:00Z","rating":0,"cnt_reserv":0,"cnt_books":0,"cnt_libs":0}]}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			st := service_mocks.NewMockStatsService(ctrl)
+			st.EXPECT().CB().Return(circuit_breaker.New(10, time.Second, 0.5, 1)).AnyTimes()
+			tt.mockBehavior(st)
+			h := &Handler{statsSvc: st, log: zap.NewNop()}
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.GET("/stats", h.GetStats)
+			r := httptest.NewRequest(http.MethodGet, "/stats"+tt.query, http.NoBody)
+			r = r.WithContext(auth.SetAuthContext(r.Context(), "admin", "admin"))
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// GetAuthors lists the authors with their aliases, the query is passed to the library service as is.
+func (h *Handler) GetAuthors(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.GetAuthors(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
+// GetAuthorBooks lists the books of an author across the libraries, the query is passed to the library service as is.
+func (h *Handler) GetAuthorBooks(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.GetAuthorBooks(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
+// GetGenres lists all the genres, the subgenres refer to their parents, the query is passed to the library service as is.
+func (h *Handler) GetGenres(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.GetGenres(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
+// GetGenreBooks lists the books of a genre and of its subgenres across the libraries, the query is passed to the library service as is.
+func (h *Handler) GetGenreBooks(c echo.Context) error {
+	var (
+		code int
+		data []byte
+	)
+	if err := h.librarySvc.CB().Call(func() error {
+		var err error
+		data, code, err = h.librarySvc.GetGenreBooks(c)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.JSONBlob(code, data)
+}
+
+// AddAuthorAlias adds a name the author is known by, in another script for one.
+func (h *Handler) AddAuthorAlias(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.AuthorAliasRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.AuthorUid = c.Param("authorUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Author
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.AddAuthorAlias(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// MergeAuthors merges a duplicate author into the author of the path.
+func (h *Handler) MergeAuthors(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.MergeAuthorsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.AuthorUid = c.Param("authorUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Author
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.MergeAuthors(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateGenre adds a genre, under a parent genre if it is set.
+func (h *Handler) CreateGenre(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.GenreRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Genre
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.CreateGenre(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateGenre renames a genre or moves it in the hierarchy.
+func (h *Handler) UpdateGenre(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.UpdateGenreRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.GenreUid = c.Param("genreUid")
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Genre
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.UpdateGenre(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTransfer sends copies of a book on the shelf of a library to another library.
+func (h *Handler) RequestTransfer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.TransferRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.Transfer
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.RequestTransfer(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// GetTransfers lists the transfers from or to a library, the latest first.
+func (h *Handler) GetTransfers(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var req model.ListTransfersRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	var resp model.ListTransfers
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.ListTransfers(ctx, req)
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetTransfer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var resp model.Transfer
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.GetTransfer(ctx, c.Param("transferUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ReceiveTransfer puts the copies of a transfer on the shelf of the library they are sent to.
+func (h *Handler) ReceiveTransfer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var resp model.Transfer
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.ReceiveTransfer(ctx, c.Param("transferUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelTransfer puts the copies of a transfer back on the shelf of the library they are sent from.
+func (h *Handler) CancelTransfer(c echo.Context) error {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	var resp model.Transfer
+	if err := h.librarySvc.CB().Call(func() error {
+		var (
+			code int
+			err  error
+		)
+		resp, code, err = h.librarySvc.CancelTransfer(ctx, c.Param("transferUid"))
+		if err != nil {
+			return echo.NewHTTPError(code, err.Error())
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
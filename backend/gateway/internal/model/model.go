@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 )
 
 type CreateReservationResponse struct {
@@ -23,11 +26,18 @@ type GetReservationResponse struct {
 }
 
 type CreateReservationRequest struct {
-	BookUid    string `json:"bookUid" validate:"required"`
-	LibraryUid string `json:"libraryUid" validate:"required"`
-	TillDate   Date   `json:"tillDate" validate:"required"`
-	UserName   string `json:"-"`
-	Stars      int    `json:"rating"`
+	ReservationUid string `json:"reservationUid"`
+	HoldUid        string `json:"holdUid,omitempty"`
+	BookUid        string `json:"bookUid" validate:"required"`
+	LibraryUid     string `json:"libraryUid" validate:"required"`
+	TillDate       Date   `json:"tillDate" validate:"required"`
+	// CopyUid is the copy lent on the reservation, Condition is its condition at checkout.
+	CopyUid   string `json:"copyUid,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	UserName  string `json:"-"`
+	Stars     int    `json:"rating"`
+	// OnShelf is the number of copies the library had on the shelf, the copies kept for READY holds among them.
+	OnShelf int `json:"onShelf"`
 }
 
 type Date struct {
@@ -53,10 +63,12 @@ func (d Date2) MarshalJSON() ([]byte, error) {
 }
 
 type Library struct {
-	LibraryUid string `json:"libraryUid"`
-	Name       string `json:"name"`
-	Address    string `json:"address"`
-	City       string `json:"city"`
+	LibraryUid string   `json:"libraryUid"`
+	Name       string   `json:"name"`
+	Address    string   `json:"address"`
+	City       string   `json:"city"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
 }
 
 type Book struct {
@@ -67,14 +79,21 @@ type Book struct {
 }
 
 type GetBook struct {
-	ID        int `json:"id"`
-	Book      `json:",inline"`
-	Condition string `json:"condition"`
+	ID             int `json:"id"`
+	Book           `json:",inline"`
+	Condition      string `json:"condition"`
+	ISBN           string `json:"isbn,omitempty"`
+	ISBN10         string `json:"isbn10,omitempty"`
+	CoverURL       string `json:"coverUrl,omitempty"`
+	Description    string `json:"description,omitempty"`
+	AvailableCount int    `json:"availableCount"`
 }
 
 type GetLibrary struct {
 	ID      int `json:"id"`
 	Library `json:",inline"`
+	// Schedule is the opening hours of the library, the due dates of its reservations fall on open days.
+	Schedule *calendar.Schedule `json:"schedule,omitempty"`
 }
 
 type Reservation struct {
@@ -82,6 +101,16 @@ type Reservation struct {
 	Status         string    `json:"status"`
 	StartDate      time.Time `json:"startDate"`
 	TillDate       time.Time `json:"tillDate"`
+	ReturnDetails
+}
+
+// ReturnDetails are stored by the reservation service when a reservation is returned.
+type ReturnDetails struct {
+	CopyUid           string     `json:"copyUid,omitempty"`
+	CheckoutCondition string     `json:"checkoutCondition,omitempty"`
+	ReturnCondition   string     `json:"returnCondition,omitempty"`
+	ReturnDate        *time.Time `json:"returnDate,omitempty"`
+	ProcessedBy       string     `json:"processedBy,omitempty"`
 }
 
 type GetReservation struct {
@@ -91,36 +120,102 @@ type GetReservation struct {
 	Status         string    `json:"status"`
 	StartDate      time.Time `json:"startDate"`
 	TillDate       time.Time `json:"tillDate"`
+	ReturnDetails
 }
 
 type Rating struct {
-	Stars int `json:"stars"`
+	EventID string `json:"eventID,omitempty"`
+	Stars   int    `json:"stars"`
 }
 
-type CreateRating struct {
-	Name  string `json:"name"`
-	Stars int    `json:"stars"`
+// RatingChange is the change of the stars that took effect, the stars are kept within 0 to 100.
+type RatingChange struct {
+	Delta int `json:"delta"`
 }
 
-type RatingMsg struct {
+type CreateRating struct {
 	Name  string `json:"name"`
 	Stars int    `json:"stars"`
 }
 
 type ReservationReturnRequest struct {
 	Condition string `json:"condition" validate:"required,oneof=EXCELLENT GOOD BAD"`
-	Date      Date   `json:"date" validate:"required"`
+	// Date and ProcessedBy are taken only from a librarian, the return of a borrower is dated the day
+	// it is made and processed by the borrower.
+	Date        Date   `json:"date"`
+	ProcessedBy string `json:"processedBy,omitempty"`
 }
 
 type ReservationReturnResponse struct {
-	BookUid    string `json:"bookUid" db:"book_uid"`
-	LibraryUid string `json:"libraryUid" db:"library_uid"`
+	// Username is the borrower, a librarian returns the reservations of others.
+	Username          string    `json:"username"`
+	BookUid           string    `json:"bookUid" db:"book_uid"`
+	LibraryUid        string    `json:"libraryUid" db:"library_uid"`
+	Status            string    `json:"status"`
+	TillDate          time.Time `json:"tillDate" db:"till_date"`
+	ReturnDate        time.Time `json:"returnDate"`
+	CheckoutCondition string    `json:"checkoutCondition,omitempty"`
+	ReturnCondition   string    `json:"returnCondition"`
+}
+
+type CreateHoldRequest struct {
+	BookUid    string `json:"bookUid" validate:"required,uuid"`
+	LibraryUid string `json:"libraryUid" validate:"required,uuid"`
+	// OnShelf is set from the library, the reservation service tells if the copies are all kept for holds.
+	OnShelf int `json:"onShelf"`
+}
+
+// HoldReady is the status of a hold whose copy waits for pickup.
+const HoldReady = "READY"
+
+type Hold struct {
+	HoldUid        string     `json:"holdUid"`
+	BookUid        string     `json:"bookUid"`
+	LibraryUid     string     `json:"libraryUid"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ReadyAt        *time.Time `json:"readyAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	ReservationUid *string    `json:"reservationUid,omitempty"`
+	Position       int        `json:"position"`
+}
+
+type ConfirmHoldRequest struct {
+	TillDate Date `json:"tillDate" validate:"required"`
+}
+
+type RenewReservationRequest struct {
+	Stars int `json:"rating"`
+	// Schedule of the library moves the renewed due date off the days it is closed.
+	Schedule *calendar.Schedule `json:"schedule,omitempty"`
+}
+
+type RenewReservationResponse struct {
+	ReservationUid string `json:"reservationUid"`
+	Status         string `json:"status"`
+	StartDate      Date2  `json:"startDate"`
+	TillDate       Date2  `json:"tillDate"`
+	Renewals       int    `json:"renewals"`
 }
 
 type AvailableCountRequest struct {
-	LibraryID int  `json:"libraryID"`
-	BookID    int  `json:"bookID"`
-	IsReturn  bool `json:"isReturn"`
+	EventID        string `json:"eventID,omitempty"`
+	LibraryID      int    `json:"libraryID"`
+	BookID         int    `json:"bookID"`
+	IsReturn       bool   `json:"isReturn"`
+	ReservationUid string `json:"reservationUid,omitempty"`
+	// Condition is the condition of a returned copy.
+	Condition string `json:"condition,omitempty"`
+	// CopyUid is the copy to lend, a lend of any copy on the shelf if empty.
+	CopyUid string `json:"copyUid,omitempty"`
+}
+
+// BookCopy is the physical copy of a book the library lent or took back.
+type BookCopy struct {
+	CopyUid   string `json:"copyUid"`
+	Barcode   string `json:"barcode"`
+	Condition string `json:"condition"`
+	Status    string `json:"status"`
 }
 
 type Stats struct {
@@ -133,7 +228,8 @@ type Stats struct {
 }
 
 type StatsInfo struct {
-	Data []Stats `json:"data"`
+	paging.Page `json:",inline"`
+	Data        []Stats `json:"data"`
 }
 
 type AuthRequest struct {
@@ -151,3 +247,209 @@ type UserCreateRequest struct {
 	Password string `json:"password"`
 	Email    string `json:"email"`
 }
+
+type AssessFineRequest struct {
+	EventID        string    `json:"eventID,omitempty"`
+	ReservationUid string    `json:"reservationUid"`
+	UserName       string    `json:"username"`
+	LibraryUid     string    `json:"libraryUid"`
+	TillDate       time.Time `json:"tillDate"`
+	ReturnDate     time.Time `json:"returnDate"`
+	ConditionFrom  string    `json:"conditionFrom"`
+	ConditionTo    string    `json:"conditionTo"`
+}
+
+type Fine struct {
+	FineUid        string    `json:"fineUid"`
+	ReservationUid string    `json:"reservationUid"`
+	LibraryUid     string    `json:"libraryUid"`
+	DaysOverdue    int       `json:"daysOverdue"`
+	ConditionFrom  string    `json:"conditionFrom"`
+	ConditionTo    string    `json:"conditionTo"`
+	Amount         int64     `json:"amount"`
+	Paid           int64     `json:"paid"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type Fines struct {
+	Fines       []Fine `json:"fines"`
+	Outstanding int64  `json:"outstanding"`
+	Currency    string `json:"currency"`
+}
+
+type PaymentRequest struct {
+	Amount         int64  `json:"amount" validate:"required,gt=0"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty" validate:"omitempty,max=64"`
+}
+
+type Payment struct {
+	PaymentUid  string    `json:"paymentUid"`
+	Amount      int64     `json:"amount"`
+	Status      string    `json:"status"`
+	ProviderRef string    `json:"providerRef,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Tariff struct {
+	LibraryUid    string `json:"libraryUid"`
+	DayRate       int64  `json:"dayRate" validate:"gte=0"`
+	ConditionRate int64  `json:"conditionRate" validate:"gte=0"`
+	MaxOverdue    int64  `json:"maxOverdue" validate:"gte=0"`
+}
+
+type LibraryRequest struct {
+	Name    string `json:"name" validate:"required,max=80"`
+	City    string `json:"city" validate:"required,max=255"`
+	Address string `json:"address" validate:"required,max=255"`
+	// Latitude and Longitude locate the library, they are set together.
+	Latitude  *float64 `json:"latitude,omitempty" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude,omitempty" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+}
+
+// UpdateLibraryRequest changes the fields that are set.
+type UpdateLibraryRequest struct {
+	LibraryUid string   `json:"-" validate:"required,uuid"`
+	Name       *string  `json:"name,omitempty" validate:"omitempty,min=1,max=80"`
+	City       *string  `json:"city,omitempty" validate:"omitempty,min=1,max=255"`
+	Address    *string  `json:"address,omitempty" validate:"omitempty,min=1,max=255"`
+	Latitude   *float64 `json:"latitude,omitempty" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude  *float64 `json:"longitude,omitempty" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+}
+
+// ScheduleRequest replaces the opening hours and the exception dates of a library.
+type ScheduleRequest struct {
+	LibraryUid        string `json:"-" validate:"required,uuid"`
+	calendar.Schedule `json:",inline"`
+}
+
+// BookRequest adds a book. With the ISBN set the fields left empty are filled from the metadata
+// of the book, the name is then not required.
+type BookRequest struct {
+	ISBN        string `json:"isbn,omitempty" validate:"omitempty,max=17"`
+	Name        string `json:"name" validate:"required_without=ISBN,max=255"`
+	Author      string `json:"author" validate:"max=255"`
+	Genre       string `json:"genre" validate:"max=255"`
+	Condition   string `json:"condition,omitempty" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	CoverURL    string `json:"coverUrl,omitempty" validate:"omitempty,url,max=1024"`
+	Description string `json:"description,omitempty" validate:"max=10000"`
+}
+
+// UpdateBookRequest changes the fields that are set, an empty ISBN, cover or description is removed.
+type UpdateBookRequest struct {
+	BookUid     string  `json:"-" validate:"required,uuid"`
+	ISBN        *string `json:"isbn,omitempty" validate:"omitempty,max=17"`
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Author      *string `json:"author,omitempty" validate:"omitempty,max=255"`
+	Genre       *string `json:"genre,omitempty" validate:"omitempty,max=255"`
+	Condition   *string `json:"condition,omitempty" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	CoverURL    *string `json:"coverUrl,omitempty" validate:"omitempty,len=0|url,max=1024"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=10000"`
+}
+
+// EnrichBookRequest fills the book from the metadata of its ISBN. Only the empty fields are filled
+// unless Overwrite is set.
+type EnrichBookRequest struct {
+	BookUid   string `json:"-" validate:"required,uuid"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// Author is a writer of books, known by the aliases in any script and word order.
+type Author struct {
+	AuthorUid string   `json:"authorUid"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases"`
+	BookCount int      `json:"bookCount"`
+}
+
+// AuthorAliasRequest adds a name the author is known by.
+type AuthorAliasRequest struct {
+	AuthorUid string `json:"-" validate:"required,uuid"`
+	Alias     string `json:"alias" validate:"required,max=255"`
+}
+
+// MergeAuthorsRequest merges the author of SourceUid into the author of AuthorUid.
+type MergeAuthorsRequest struct {
+	AuthorUid string `json:"-" validate:"required,uuid"`
+	SourceUid string `json:"authorUid" validate:"required,uuid,nefield=AuthorUid"`
+}
+
+type Genre struct {
+	GenreUid  string  `json:"genreUid"`
+	Name      string  `json:"name"`
+	ParentUid *string `json:"parentUid,omitempty"`
+	BookCount int     `json:"bookCount"`
+}
+
+type GenreRequest struct {
+	Name      string `json:"name" validate:"required,max=255"`
+	ParentUid string `json:"parentUid,omitempty" validate:"omitempty,uuid"`
+}
+
+// UpdateGenreRequest changes the fields that are set, an empty ParentUid makes the genre a top one.
+type UpdateGenreRequest struct {
+	GenreUid  string  `json:"-" validate:"required,uuid"`
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	ParentUid *string `json:"parentUid,omitempty" validate:"omitempty,len=0|uuid"`
+}
+
+// StockRequest sets the number of copies of a book a library holds, new copies are registered in Condition.
+type StockRequest struct {
+	LibraryUid string `json:"-" validate:"required,uuid"`
+	BookUid    string `json:"-" validate:"required,uuid"`
+	Stock      int    `json:"stock" validate:"gte=0,lte=1000"`
+	Condition  string `json:"condition,omitempty" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+}
+
+type Stock struct {
+	LibraryUid     string `json:"libraryUid"`
+	BookUid        string `json:"bookUid"`
+	Stock          int    `json:"stock"`
+	AvailableCount int    `json:"availableCount"`
+}
+
+// AuditRecord is a change a librarian made to the catalog.
+type AuditRecord struct {
+	ID        int64          `json:"id"`
+	Entity    string         `json:"entity"`
+	EntityUid string         `json:"entityUid"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor"`
+	Changes   map[string]any `json:"changes"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// TransferRequest sends Copies copies of a book on the shelf of a library to another library.
+type TransferRequest struct {
+	BookUid        string `json:"bookUid" validate:"required,uuid"`
+	FromLibraryUid string `json:"fromLibraryUid" validate:"required,uuid"`
+	ToLibraryUid   string `json:"toLibraryUid" validate:"required,uuid,nefield=FromLibraryUid"`
+	Copies         int    `json:"copies" validate:"min=1,max=1000"`
+}
+
+// Transfer moves copies of a book between libraries, the copies in transit are counted in neither.
+type Transfer struct {
+	TransferUid    string     `json:"transferUid"`
+	BookUid        string     `json:"bookUid"`
+	FromLibraryUid string     `json:"fromLibraryUid"`
+	ToLibraryUid   string     `json:"toLibraryUid"`
+	Copies         int        `json:"copies"`
+	Status         string     `json:"status"`
+	RequestedBy    string     `json:"requestedBy"`
+	RequestedAt    time.Time  `json:"requestedAt"`
+	ClosedBy       *string    `json:"closedBy,omitempty"`
+	ClosedAt       *time.Time `json:"closedAt,omitempty"`
+}
+
+// ListTransfersRequest lists the transfers from or to the library, all of them without one.
+type ListTransfersRequest struct {
+	LibraryUid string `query:"libraryUid" validate:"omitempty,uuid"`
+	Status     string `query:"status" validate:"omitempty,oneof=IN_TRANSIT RECEIVED CANCELLED"`
+	paging.Request
+}
+
+type ListTransfers struct {
+	paging.Page `json:",inline"`
+	Items       []Transfer `json:"items"`
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
+	"go.uber.org/zap"
+)
+
+var _ saga.Store = (*repository)(nil)
+
+type repository struct {
+	db    *pgxpool.Pool
+	log   *zap.Logger
+	lease time.Duration
+}
+
+func NewRepository(db *pgxpool.Pool, lease time.Duration, log *zap.Logger) (*repository, error) {
+	return &repository{
+		db:    db,
+		log:   log.Named("repo"),
+		lease: lease,
+	}, nil
+}
+
+func (r *repository) Create(ctx context.Context, rec saga.Record) error {
+	q := `
+insert into saga (id, name, status, applied, payload, error, locked_until)
+values (@id, @name, @status, @applied, @payload, @error, now() + make_interval(secs => @lease))`
+	_, err := r.db.Exec(ctx, q, r.recordArgs(rec))
+	return err
+}
+
+func (r *repository) Save(ctx context.Context, rec saga.Record, step *saga.StepRecord) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		q := `
+update saga
+set status       = @status,
+    applied      = @applied,
+    payload      = @payload,
+    error        = @error,
+    locked_until = now() + make_interval(secs => @lease),
+    updated_at   = now()
+where id = @id`
+		if _, err := tx.Exec(ctx, q, r.recordArgs(rec)); err != nil {
+			return err
+		}
+		if step == nil {
+			return nil
+		}
+		q = `
+insert into saga_step (saga_id, idx, name, status, attempts, error)
+values (@saga_id, @idx, @name, @status, @attempts, @error)
+on conflict (saga_id, idx) do update
+    set status     = excluded.status,
+        attempts   = saga_step.attempts + excluded.attempts,
+        error      = excluded.error,
+        updated_at = now()`
+		_, err := tx.Exec(ctx, q, pgx.NamedArgs{
+			"saga_id":  rec.ID,
+			"idx":      step.Index,
+			"name":     step.Name,
+			"status":   step.Status,
+			"attempts": step.Attempts,
+			"error":    step.Error,
+		})
+		return err
+	})
+}
+
+func (r *repository) ClaimStale(ctx context.Context, limit int) ([]saga.Record, error) {
+	q := `
+update saga
+set locked_until = now() + make_interval(secs => @lease),
+    updated_at   = now()
+where id in (select id
+             from saga
+             where status in ('RUNNING', 'COMPENSATING')
+               and locked_until < now()
+             order by created_at
+             limit @limit for update skip locked)
+returning id, name, status, applied, payload, error`
+	rows, err := r.db.Query(ctx, q, pgx.NamedArgs{
+		"lease": r.lease.Seconds(),
+		"limit": limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []saga.Record
+	for rows.Next() {
+		var rec saga.Record
+		if err := rows.Scan(&rec.ID, &rec.Name, &rec.Status, &rec.Applied, &rec.Payload, &rec.Error); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+func (r *repository) recordArgs(rec saga.Record) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"id":      rec.ID,
+		"name":    rec.Name,
+		"status":  rec.Status,
+		"applied": rec.Applied,
+		"payload": rec.Payload,
+		"error":   rec.Error,
+		"lease":   r.lease.Seconds(),
+	}
+}
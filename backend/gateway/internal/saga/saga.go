@@ -0,0 +1,343 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Status string
+
+const (
+	StatusRunning      Status = "RUNNING"
+	StatusCompensating Status = "COMPENSATING"
+	StatusCompleted    Status = "COMPLETED"
+	StatusCompensated  Status = "COMPENSATED"
+)
+
+type StepStatus string
+
+const (
+	StepStarted     StepStatus = "STARTED"
+	StepDone        StepStatus = "DONE"
+	StepFailed      StepStatus = "FAILED"
+	StepCompensated StepStatus = "COMPENSATED"
+)
+
+// Record is the durable state of one saga run.
+// Applied is the number of leading steps whose actions took effect and are not compensated yet.
+type Record struct {
+	ID      string
+	Name    string
+	Status  Status
+	Applied int
+	Payload []byte
+	Error   string
+}
+
+type StepRecord struct {
+	Index    int
+	Name     string
+	Status   StepStatus
+	Attempts int
+	Error    string
+}
+
+type Store interface {
+	Create(ctx context.Context, rec Record) error
+	// Save persists the saga record together with the log entry of one step, if any.
+	Save(ctx context.Context, rec Record, step *StepRecord) error
+	// ClaimStale leases sagas that are still RUNNING or COMPENSATING but no longer owned by anybody.
+	ClaimStale(ctx context.Context, limit int) ([]Record, error)
+}
+
+type Step[T any] struct {
+	Name       string
+	Action     func(ctx context.Context, data *T) error
+	Compensate func(ctx context.Context, data *T) error
+	// Idempotent marks a step whose compensation is safe even if the action never took effect,
+	// so recovery undoes it when the process died in the middle of the action.
+	Idempotent bool
+	// Pivot marks a step recovery does not undo once it took effect. A saga that died after it is
+	// rolled forward instead: recovery retries the remaining steps until they succeed.
+	Pivot bool
+}
+
+type Definition[T any] struct {
+	Name  string
+	Steps []Step[T]
+}
+
+// Error is returned by Execute when a step fails.
+type Error struct {
+	Saga            string
+	Step            string
+	Err             error
+	CompensationErr error
+}
+
+func (e *Error) Error() string {
+	if e.CompensationErr != nil {
+		return fmt.Sprintf("saga %s: step %s: %v (compensation: %v)", e.Saga, e.Step, e.Err, e.CompensationErr)
+	}
+	return fmt.Sprintf("saga %s: step %s: %v", e.Saga, e.Step, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Config struct {
+	MaxAttempts      int           `envconfig:"SAGA_MAX_ATTEMPTS" default:"5"`
+	Backoff          time.Duration `envconfig:"SAGA_BACKOFF" default:"200ms"`
+	RecoveryInterval time.Duration `envconfig:"SAGA_RECOVERY_INTERVAL" default:"10s"`
+	RecoveryBatch    int           `envconfig:"SAGA_RECOVERY_BATCH" default:"20"`
+	// Lease is how long a saga stays owned by the process that touched it last.
+	Lease time.Duration `envconfig:"SAGA_LEASE" default:"2m"`
+}
+
+type resumer interface {
+	resume(ctx context.Context, o *Orchestrator, rec Record) error
+}
+
+type Orchestrator struct {
+	store Store
+	cfg   Config
+	log   *zap.Logger
+
+	mu   sync.RWMutex
+	defs map[string]resumer
+}
+
+func NewOrchestrator(store Store, cfg Config, log *zap.Logger) *Orchestrator {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.RecoveryInterval <= 0 {
+		cfg.RecoveryInterval = 10 * time.Second
+	}
+	return &Orchestrator{
+		store: store,
+		cfg:   cfg,
+		log:   log.Named("saga"),
+		defs:  make(map[string]resumer),
+	}
+}
+
+// Register makes the definition known to the recovery loop.
+func Register[T any](o *Orchestrator, def Definition[T]) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.defs[def.Name] = def
+}
+
+// Execute runs all steps of def in order. When a step fails, the already applied steps are
+// compensated in reverse order and the step error is returned wrapped into *Error.
+func Execute[T any](ctx context.Context, o *Orchestrator, def Definition[T], data *T) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("saga %s: marshal payload: %w", def.Name, err)
+	}
+	rec := Record{
+		ID:      uuid.NewString(),
+		Name:    def.Name,
+		Status:  StatusRunning,
+		Payload: payload,
+	}
+	if err := o.store.Create(ctx, rec); err != nil {
+		return fmt.Errorf("saga %s: create: %w", def.Name, err)
+	}
+	return def.forward(ctx, o, rec, data)
+}
+
+func (def Definition[T]) forward(ctx context.Context, o *Orchestrator, rec Record, data *T) error {
+	for i := rec.Applied; i < len(def.Steps); i++ {
+		step := def.Steps[i]
+		if err := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepStarted, Attempts: 1}); err != nil {
+			return fmt.Errorf("saga %s: save step %s: %w", def.Name, step.Name, err)
+		}
+		if err := step.Action(ctx, data); err != nil {
+			rec.Status = StatusCompensating
+			rec.Error = err.Error()
+			if saveErr := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepFailed, Attempts: 1, Error: err.Error()}); saveErr != nil {
+				o.log.Error("save failed step", zap.String("saga", rec.ID), zap.Error(saveErr))
+			}
+			return &Error{
+				Saga:            def.Name,
+				Step:            step.Name,
+				Err:             err,
+				CompensationErr: def.compensate(context.WithoutCancel(ctx), o, rec, data),
+			}
+		}
+		if err := def.done(ctx, o, &rec, i, 1, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollForward runs the steps left after a pivot. A step that keeps failing is logged as FAILED and
+// leaves the saga RUNNING, so the recovery loop retries it later.
+func (def Definition[T]) rollForward(ctx context.Context, o *Orchestrator, rec Record, data *T) error {
+	for i := rec.Applied; i < len(def.Steps); i++ {
+		step := def.Steps[i]
+		if err := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepStarted, Attempts: 1}); err != nil {
+			return fmt.Errorf("saga %s: save step %s: %w", def.Name, step.Name, err)
+		}
+		attempts, err := o.retry(ctx, func() error { return step.Action(ctx, data) })
+		if err != nil {
+			rec.Error = err.Error()
+			if saveErr := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepFailed, Attempts: attempts, Error: err.Error()}); saveErr != nil {
+				o.log.Error("save failed step", zap.String("saga", rec.ID), zap.Error(saveErr))
+			}
+			return fmt.Errorf("saga %s: step %s: %w", def.Name, step.Name, err)
+		}
+		rec.Error = ""
+		if err := def.done(ctx, o, &rec, i, attempts, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// done records that the step i took effect, along with the payload it changed.
+func (def Definition[T]) done(ctx context.Context, o *Orchestrator, rec *Record, i, attempts int, data *T) error {
+	step := def.Steps[i]
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("saga %s: marshal payload: %w", def.Name, err)
+	}
+	rec.Payload = payload
+	rec.Applied = i + 1
+	if i == len(def.Steps)-1 {
+		rec.Status = StatusCompleted
+	}
+	if err := o.store.Save(ctx, *rec, &StepRecord{Index: i, Name: step.Name, Status: StepDone, Attempts: attempts}); err != nil {
+		return fmt.Errorf("saga %s: save step %s: %w", def.Name, step.Name, err)
+	}
+	return nil
+}
+
+// pivoted reports whether one of the first applied steps is a pivot.
+func (def Definition[T]) pivoted(applied int) bool {
+	for _, step := range def.Steps[:min(applied, len(def.Steps))] {
+		if step.Pivot {
+			return true
+		}
+	}
+	return false
+}
+
+// compensate undoes the applied steps in reverse order. A compensation that keeps failing is
+// logged as FAILED and leaves the saga COMPENSATING, so the recovery loop retries it later.
+func (def Definition[T]) compensate(ctx context.Context, o *Orchestrator, rec Record, data *T) error {
+	for rec.Applied > 0 {
+		i := rec.Applied - 1
+		step := def.Steps[i]
+		if step.Compensate != nil {
+			attempts, err := o.retry(ctx, func() error { return step.Compensate(ctx, data) })
+			if err != nil {
+				rec.Error = err.Error()
+				if saveErr := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepFailed, Attempts: attempts, Error: err.Error()}); saveErr != nil {
+					o.log.Error("save compensation", zap.String("saga", rec.ID), zap.Error(saveErr))
+				}
+				o.log.Warn("compensation failed",
+					zap.String("saga", rec.ID), zap.String("name", def.Name), zap.String("step", step.Name), zap.Error(err))
+				return err
+			}
+		}
+		rec.Applied = i
+		if rec.Applied == 0 {
+			rec.Status = StatusCompensated
+		}
+		if err := o.store.Save(ctx, rec, &StepRecord{Index: i, Name: step.Name, Status: StepCompensated, Attempts: 1}); err != nil {
+			return err
+		}
+	}
+	if rec.Status != StatusCompensated {
+		rec.Status = StatusCompensated
+		return o.store.Save(ctx, rec, nil)
+	}
+	return nil
+}
+
+func (def Definition[T]) resume(ctx context.Context, o *Orchestrator, rec Record) error {
+	var data T
+	if err := json.Unmarshal(rec.Payload, &data); err != nil {
+		return fmt.Errorf("saga %s: unmarshal payload: %w", def.Name, err)
+	}
+	if rec.Status == StatusRunning && def.pivoted(rec.Applied) {
+		return def.rollForward(ctx, o, rec, &data)
+	}
+	if rec.Status == StatusRunning {
+		// The process died in the middle of step rec.Applied; nobody waits for the result anymore.
+		if rec.Applied < len(def.Steps) && def.Steps[rec.Applied].Idempotent {
+			rec.Applied++
+		}
+		rec.Status = StatusCompensating
+	}
+	return def.compensate(ctx, o, rec, &data)
+}
+
+func (o *Orchestrator) retry(ctx context.Context, fn func() error) (int, error) {
+	backoff := o.cfg.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return attempt, nil
+		}
+		if attempt >= o.cfg.MaxAttempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Recover resumes sagas left unfinished by a crashed or stopped process.
+func (o *Orchestrator) Recover(ctx context.Context) error {
+	recs, err := o.store.ClaimStale(ctx, o.cfg.RecoveryBatch)
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		o.mu.RLock()
+		def, ok := o.defs[rec.Name]
+		o.mu.RUnlock()
+		if !ok {
+			o.log.Warn("unknown saga", zap.String("saga", rec.ID), zap.String("name", rec.Name))
+			continue
+		}
+		o.log.Info("resume saga", zap.String("saga", rec.ID), zap.String("name", rec.Name), zap.String("status", string(rec.Status)))
+		if err := def.resume(ctx, o, rec); err != nil {
+			o.log.Error("resume saga", zap.String("saga", rec.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Run calls Recover every RecoveryInterval until ctx is done.
+func (o *Orchestrator) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.cfg.RecoveryInterval)
+	defer ticker.Stop()
+	for {
+		if err := o.Recover(ctx); err != nil {
+			o.log.Error("recover", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
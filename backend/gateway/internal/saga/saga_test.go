@@ -0,0 +1,254 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/saga"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type memStore struct {
+	mu    sync.Mutex
+	recs  map[string]saga.Record
+	steps []saga.StepRecord
+}
+
+func newMemStore() *memStore {
+	return &memStore{recs: make(map[string]saga.Record)}
+}
+
+func (s *memStore) Create(_ context.Context, rec saga.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs[rec.ID] = rec
+	return nil
+}
+
+func (s *memStore) Save(_ context.Context, rec saga.Record, step *saga.StepRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recs[rec.ID] = rec
+	if step != nil {
+		s.steps = append(s.steps, *step)
+	}
+	return nil
+}
+
+// last is the last log entry of the step idx.
+func (s *memStore) last(idx int) saga.StepRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		if s.steps[i].Index == idx {
+			return s.steps[i]
+		}
+	}
+	return saga.StepRecord{}
+}
+
+func (s *memStore) ClaimStale(_ context.Context, _ int) ([]saga.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var recs []saga.Record
+	for _, rec := range s.recs {
+		if rec.Status == saga.StatusRunning || rec.Status == saga.StatusCompensating {
+			recs = append(recs, rec)
+		}
+	}
+	return recs, nil
+}
+
+func (s *memStore) only(t *testing.T) saga.Record {
+	t.Helper()
+	require.Len(t, s.recs, 1)
+	for _, rec := range s.recs {
+		return rec
+	}
+	return saga.Record{}
+}
+
+type data struct {
+	Log []string `json:"log"`
+}
+
+type journal struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (j *journal) add(e string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, e)
+}
+
+func step(j *journal, name string, actionErr error, compensateFails int) saga.Step[data] {
+	return saga.Step[data]{
+		Name: name,
+		Action: func(_ context.Context, d *data) error {
+			j.add("do " + name)
+			d.Log = append(d.Log, name)
+			return actionErr
+		},
+		Compensate: func(_ context.Context, _ *data) error {
+			j.add("undo " + name)
+			if compensateFails > 0 {
+				compensateFails--
+				return errors.New("compensation failed")
+			}
+			return nil
+		},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	t.Parallel()
+	errStep := errors.New("step failed")
+	tests := []struct {
+		name       string
+		steps      func(j *journal) []saga.Step[data]
+		wantErr    error
+		wantStatus saga.Status
+		wantLog    []string
+	}{
+		{
+			name: "ok",
+			steps: func(j *journal) []saga.Step[data] {
+				return []saga.Step[data]{step(j, "a", nil, 0), step(j, "b", nil, 0)}
+			},
+			wantStatus: saga.StatusCompleted,
+			wantLog:    []string{"do a", "do b"},
+		},
+		{
+			name: "compensate in reverse order",
+			steps: func(j *journal) []saga.Step[data] {
+				return []saga.Step[data]{step(j, "a", nil, 0), step(j, "b", nil, 1), step(j, "c", errStep, 0)}
+			},
+			wantErr:    errStep,
+			wantStatus: saga.StatusCompensated,
+			wantLog:    []string{"do a", "do b", "do c", "undo b", "undo b", "undo a"},
+		},
+		{
+			name: "compensation exhausted",
+			steps: func(j *journal) []saga.Step[data] {
+				return []saga.Step[data]{step(j, "a", nil, 10), step(j, "b", errStep, 0)}
+			},
+			wantErr:    errStep,
+			wantStatus: saga.StatusCompensating,
+			wantLog:    []string{"do a", "do b", "undo a", "undo a", "undo a"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store := newMemStore()
+			o := saga.NewOrchestrator(store, saga.Config{MaxAttempts: 3}, zap.NewNop())
+			j := &journal{}
+			def := saga.Definition[data]{Name: "test", Steps: tt.steps(j)}
+
+			err := saga.Execute(context.Background(), o, def, &data{})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				var sagaErr *saga.Error
+				require.ErrorAs(t, err, &sagaErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantStatus, store.only(t).Status)
+			require.Equal(t, tt.wantLog, j.entries)
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	o := saga.NewOrchestrator(store, saga.Config{MaxAttempts: 1}, zap.NewNop())
+	j := &journal{}
+	first := step(j, "a", nil, 0)
+	first.Idempotent = true
+	def := saga.Definition[data]{Name: "test", Steps: []saga.Step[data]{first, step(j, "b", nil, 0)}}
+	saga.Register(o, def)
+
+	// the process died while running step "a".
+	require.NoError(t, store.Create(context.Background(), saga.Record{
+		ID:      "1",
+		Name:    "test",
+		Status:  saga.StatusRunning,
+		Payload: []byte(`{"log":[]}`),
+	}))
+
+	require.NoError(t, o.Recover(context.Background()))
+	require.Equal(t, saga.StatusCompensated, store.only(t).Status)
+	require.Equal(t, []string{"undo a"}, j.entries)
+}
+
+func TestRecover_RetriesFailedCompensation(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	o := saga.NewOrchestrator(store, saga.Config{MaxAttempts: 2}, zap.NewNop())
+	j := &journal{}
+	def := saga.Definition[data]{Name: "test", Steps: []saga.Step[data]{step(j, "a", nil, 2), step(j, "b", errors.New("step failed"), 0)}}
+	saga.Register(o, def)
+
+	err := saga.Execute(context.Background(), o, def, &data{})
+	var sagaErr *saga.Error
+	require.ErrorAs(t, err, &sagaErr)
+	require.Error(t, sagaErr.CompensationErr)
+	require.Equal(t, saga.StatusCompensating, store.only(t).Status)
+	require.Equal(t, 1, store.only(t).Applied)
+	require.Equal(t, saga.StepFailed, store.last(0).Status)
+
+	require.NoError(t, o.Recover(context.Background()))
+	require.Equal(t, saga.StatusCompensated, store.only(t).Status)
+	require.Equal(t, saga.StepCompensated, store.last(0).Status)
+	require.Equal(t, []string{"do a", "do b", "undo a", "undo a", "undo a"}, j.entries)
+}
+
+func TestRecover_RollsForwardAfterPivot(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	o := saga.NewOrchestrator(store, saga.Config{MaxAttempts: 1}, zap.NewNop())
+	j := &journal{}
+	pivot := step(j, "a", nil, 0)
+	pivot.Pivot = true
+	failing := errors.New("unavailable")
+	flaky := step(j, "c", nil, 0)
+	flaky.Action = func(_ context.Context, d *data) error {
+		j.add("do c")
+		if failing != nil {
+			return failing
+		}
+		d.Log = append(d.Log, "c")
+		return nil
+	}
+	def := saga.Definition[data]{Name: "test", Steps: []saga.Step[data]{pivot, step(j, "b", nil, 0), flaky}}
+	saga.Register(o, def)
+
+	// the process died after the pivot took effect.
+	require.NoError(t, store.Create(context.Background(), saga.Record{
+		ID:      "1",
+		Name:    "test",
+		Status:  saga.StatusRunning,
+		Applied: 1,
+		Payload: []byte(`{"log":["a"]}`),
+	}))
+
+	require.NoError(t, o.Recover(context.Background()))
+	require.Equal(t, saga.StatusRunning, store.only(t).Status, "a step after the pivot is retried, not compensated")
+	require.Equal(t, 2, store.only(t).Applied)
+	require.Equal(t, saga.StepFailed, store.last(2).Status)
+
+	failing = nil
+	require.NoError(t, o.Recover(context.Background()))
+	rec := store.only(t)
+	require.Equal(t, saga.StatusCompleted, rec.Status)
+	require.Equal(t, 3, rec.Applied)
+	require.JSONEq(t, `{"log":["a","b","c"]}`, string(rec.Payload))
+	require.Equal(t, []string{"do b", "do c", "do c"}, j.entries)
+}
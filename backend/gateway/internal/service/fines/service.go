@@ -0,0 +1,105 @@
+package fines
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/gateway/config"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/errs"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	log    *zap.Logger
+	client *http.Client
+	cfg    config.FinesHTTPServer
+	cb     circuit_breaker.CircuitBreaker
+}
+
+func NewService(log *zap.Logger, cfg config.FinesHTTPServer) *Service {
+	return &Service{
+		log:    log,
+		client: &http.Client{Timeout: time.Minute},
+		cfg:    cfg,
+		cb:     circuit_breaker.New(100, time.Second, 0.2, 2),
+	}
+}
+
+func (s *Service) CB() circuit_breaker.CircuitBreaker {
+	return s.cb
+}
+
+func (s *Service) GetFines(ctx context.Context) (model.Fines, int, error) {
+	var fines model.Fines
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/fines", nil, &fines)
+	return fines, code, err
+}
+
+func (s *Service) Pay(ctx context.Context, request model.PaymentRequest) (model.Payment, int, error) {
+	var payment model.Payment
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/payments", request, &payment)
+	return payment, code, err
+}
+
+// AssessFine fines a returned reservation, the fines service answers 204 if there is nothing to fine.
+func (s *Service) AssessFine(ctx context.Context, request model.AssessFineRequest) (int, error) {
+	return s.do(ctx, http.MethodPost, "/api/v1/fines", request, nil)
+}
+
+func (s *Service) PutTariff(ctx context.Context, tariff model.Tariff) (model.Tariff, int, error) {
+	var resp model.Tariff
+	code, err := s.do(ctx, http.MethodPut, "/api/v1/tariffs/"+tariff.LibraryUid, tariff, &resp)
+	return resp, code, err
+}
+
+// do sends body as JSON on behalf of the user of ctx and decodes the response into out, if any.
+// The message of a failed response is returned as the error.
+func (s *Service) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		b := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(b).Encode(body); err != nil {
+			return http.StatusBadRequest, err
+		}
+		reqBody = b
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://%s%s", net.JoinHostPort(s.cfg.Host, s.cfg.Port), path), reqBody)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	auth.SetAuthHeader(req)
+	req.Header.Set("Content-Type", echo.MIMEApplicationJSONCharsetUTF8)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return http.StatusServiceUnavailable, errors.New("fines unavailable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil || msg.Message == "" {
+			return resp.StatusCode, errs.ErrDefault
+		}
+		return resp.StatusCode, errors.New(msg.Message)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return resp.StatusCode, nil
+}
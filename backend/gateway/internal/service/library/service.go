@@ -10,12 +10,15 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
 	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/pkg/errors"
 
 	"github.com/Astemirdum/library-service/backend/gateway/internal/errs"
 
 	"github.com/Astemirdum/library-service/backend/gateway/config"
 	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
 	"github.com/labstack/echo/v4"
 
 	"go.uber.org/zap"
@@ -65,10 +68,11 @@ func (s *Service) GetBook(ctx context.Context, libUid, bookUid string) (model.Ge
 	return book, resp.StatusCode, err
 }
 
-func (s *Service) AvailableCount(ctx context.Context, inp model.AvailableCountRequest) (status int, err error) {
+// AvailableCount lends a copy of the book or takes it back, it returns the changed copy.
+func (s *Service) AvailableCount(ctx context.Context, inp model.AvailableCountRequest) (model.BookCopy, int, error) {
 	b := bytes.NewBuffer(nil)
 	if err := json.NewEncoder(b).Encode(inp); err != nil {
-		return http.StatusBadRequest, err
+		return model.BookCopy{}, http.StatusBadRequest, err
 	}
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -76,18 +80,22 @@ func (s *Service) AvailableCount(ctx context.Context, inp model.AvailableCountRe
 		fmt.Sprintf("http://%s/api/v1/libraries/books", net.JoinHostPort(s.cfg.Host, s.cfg.Port)),
 		b)
 	if err != nil {
-		return http.StatusBadRequest, err
+		return model.BookCopy{}, http.StatusBadRequest, err
 	}
 	req.Header.Set("Content-Type", echo.MIMEApplicationJSONCharsetUTF8)
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return http.StatusServiceUnavailable, err
+		return model.BookCopy{}, http.StatusServiceUnavailable, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		err = errs.ErrDefault
+		return model.BookCopy{}, resp.StatusCode, errs.ErrDefault
 	}
-	return resp.StatusCode, err
+	var bookCopy model.BookCopy
+	if err := json.NewDecoder(resp.Body).Decode(&bookCopy); err != nil {
+		return model.BookCopy{}, http.StatusBadRequest, err
+	}
+	return bookCopy, resp.StatusCode, nil
 }
 
 func (s *Service) GetLibrary(ctx context.Context, libUid string) (model.GetLibrary, int, error) {
@@ -118,6 +126,30 @@ func (s *Service) GetBooks(c echo.Context) (data []byte, statusCode int, err err
 	return s.proxy(c)
 }
 
+func (s *Service) SearchBooks(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
+func (s *Service) NearbyLibraries(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
+func (s *Service) GetAuthors(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
+func (s *Service) GetAuthorBooks(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
+func (s *Service) GetGenres(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
+func (s *Service) GetGenreBooks(c echo.Context) (data []byte, statusCode int, err error) {
+	return s.proxy(c)
+}
+
 func (s *Service) GetLibraries(c echo.Context) (data []byte, statusCode int, err error) {
 	return s.proxy(c)
 }
@@ -143,3 +175,157 @@ func (s *Service) proxy(c echo.Context) (data []byte, statusCode int, err error)
 	}
 	return data, resp.StatusCode, nil
 }
+
+func (s *Service) CreateLibrary(ctx context.Context, request model.LibraryRequest) (model.Library, int, error) {
+	var lib model.Library
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/libraries", request, &lib)
+	return lib, code, err
+}
+
+func (s *Service) UpdateLibrary(ctx context.Context, request model.UpdateLibraryRequest) (model.Library, int, error) {
+	var lib model.Library
+	code, err := s.do(ctx, http.MethodPatch, "/api/v1/libraries/"+request.LibraryUid, request, &lib)
+	return lib, code, err
+}
+
+func (s *Service) ArchiveLibrary(ctx context.Context, libraryUid string) (int, error) {
+	return s.do(ctx, http.MethodDelete, "/api/v1/libraries/"+libraryUid, nil, nil)
+}
+
+func (s *Service) SetSchedule(ctx context.Context, request model.ScheduleRequest) (calendar.Schedule, int, error) {
+	var schedule calendar.Schedule
+	code, err := s.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/libraries/%s/schedule", request.LibraryUid), request, &schedule)
+	return schedule, code, err
+}
+
+func (s *Service) CreateBook(ctx context.Context, request model.BookRequest) (model.GetBook, int, error) {
+	var book model.GetBook
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/books", request, &book)
+	return book, code, err
+}
+
+func (s *Service) UpdateBook(ctx context.Context, request model.UpdateBookRequest) (model.GetBook, int, error) {
+	var book model.GetBook
+	code, err := s.do(ctx, http.MethodPatch, "/api/v1/books/"+request.BookUid, request, &book)
+	return book, code, err
+}
+
+func (s *Service) EnrichBook(ctx context.Context, request model.EnrichBookRequest) (model.GetBook, int, error) {
+	var book model.GetBook
+	code, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/books/%s/metadata", request.BookUid), request, &book)
+	return book, code, err
+}
+
+func (s *Service) SetStock(ctx context.Context, request model.StockRequest) (model.Stock, int, error) {
+	var stock model.Stock
+	code, err := s.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/libraries/%s/books/%s/stock", request.LibraryUid, request.BookUid), request, &stock)
+	return stock, code, err
+}
+
+func (s *Service) AddAuthorAlias(ctx context.Context, request model.AuthorAliasRequest) (model.Author, int, error) {
+	var author model.Author
+	code, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/authors/%s/aliases", request.AuthorUid), request, &author)
+	return author, code, err
+}
+
+func (s *Service) MergeAuthors(ctx context.Context, request model.MergeAuthorsRequest) (model.Author, int, error) {
+	var author model.Author
+	code, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/authors/%s/merge", request.AuthorUid), request, &author)
+	return author, code, err
+}
+
+func (s *Service) CreateGenre(ctx context.Context, request model.GenreRequest) (model.Genre, int, error) {
+	var genre model.Genre
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/genres", request, &genre)
+	return genre, code, err
+}
+
+func (s *Service) UpdateGenre(ctx context.Context, request model.UpdateGenreRequest) (model.Genre, int, error) {
+	var genre model.Genre
+	code, err := s.do(ctx, http.MethodPatch, "/api/v1/genres/"+request.GenreUid, request, &genre)
+	return genre, code, err
+}
+
+func (s *Service) RequestTransfer(ctx context.Context, request model.TransferRequest) (model.Transfer, int, error) {
+	var transfer model.Transfer
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/transfers", request, &transfer)
+	return transfer, code, err
+}
+
+func (s *Service) ReceiveTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	var transfer model.Transfer
+	code, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/transfers/%s/receive", transferUid), nil, &transfer)
+	return transfer, code, err
+}
+
+func (s *Service) CancelTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	var transfer model.Transfer
+	code, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/transfers/%s/cancel", transferUid), nil, &transfer)
+	return transfer, code, err
+}
+
+func (s *Service) GetTransfer(ctx context.Context, transferUid string) (model.Transfer, int, error) {
+	var transfer model.Transfer
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/transfers/"+transferUid, nil, &transfer)
+	return transfer, code, err
+}
+
+func (s *Service) ListTransfers(ctx context.Context, request model.ListTransfersRequest) (model.ListTransfers, int, error) {
+	query := request.Query()
+	if request.LibraryUid != "" {
+		query.Set("libraryUid", request.LibraryUid)
+	}
+	if request.Status != "" {
+		query.Set("status", request.Status)
+	}
+	var transfers model.ListTransfers
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/transfers?"+query.Encode(), nil, &transfers)
+	return transfers, code, err
+}
+
+func (s *Service) GetAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, int, error) {
+	var records []model.AuditRecord
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/audit/"+entityUid, nil, &records)
+	return records, code, err
+}
+
+// do sends body as JSON on behalf of the user of ctx and decodes the response into out, if any.
+// The message of a failed response is returned as the error.
+func (s *Service) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		b := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(b).Encode(body); err != nil {
+			return http.StatusBadRequest, err
+		}
+		reqBody = b
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://%s%s", net.JoinHostPort(s.cfg.Host, s.cfg.Port), path), reqBody)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	auth.SetAuthHeader(req)
+	req.Header.Set("Content-Type", echo.MIMEApplicationJSONCharsetUTF8)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return http.StatusServiceUnavailable, errors.New("library unavailable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil || msg.Message == "" {
+			return resp.StatusCode, errs.ErrDefault
+		}
+		return resp.StatusCode, errors.New(msg.Message)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return resp.StatusCode, nil
+}
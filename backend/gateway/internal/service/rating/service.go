@@ -72,29 +72,36 @@ func (s *Service) GetRating(ctx context.Context) (model.Rating, int, error) {
 	return rat, resp.StatusCode, err
 }
 
-func (s *Service) Rating(ctx context.Context, stars int) (int, error) {
+// Rating changes the stars of the user by stars and returns the change that took effect.
+func (s *Service) Rating(ctx context.Context, eventID string, stars int) (model.RatingChange, int, error) {
 	b := bytes.NewBuffer(nil)
-	var ratingReq model.Rating
-	ratingReq.Stars = stars
+	ratingReq := model.Rating{
+		EventID: eventID,
+		Stars:   stars,
+	}
 	if err := json.NewEncoder(b).Encode(ratingReq); err != nil {
-		return http.StatusBadRequest, err
+		return model.RatingChange{}, http.StatusBadRequest, err
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, s.endpoint, b)
 	if err != nil {
-		return http.StatusBadRequest, err
+		return model.RatingChange{}, http.StatusBadRequest, err
 	}
 	auth.SetAuthHeader(req)
 	req.Header.Set("Content-Type", echo.MIMEApplicationJSON)
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return http.StatusServiceUnavailable, err
+		return model.RatingChange{}, http.StatusServiceUnavailable, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		err = errs.ErrDefault
+		return model.RatingChange{}, resp.StatusCode, errs.ErrDefault
 	}
-	return resp.StatusCode, err
+	var change model.RatingChange
+	if err := json.NewDecoder(resp.Body).Decode(&change); err != nil {
+		return model.RatingChange{}, http.StatusBadGateway, err
+	}
+	return change, resp.StatusCode, nil
 }
 
 func (s *Service) CreateRating(ctx context.Context, userName string, stars int) (int, error) {
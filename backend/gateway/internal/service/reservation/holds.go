@@ -0,0 +1,81 @@
+package reservation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/gateway/internal/errs"
+	"github.com/Astemirdum/library-service/backend/gateway/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func (s *Service) CreateHold(ctx context.Context, request model.CreateHoldRequest) (model.Hold, int, error) {
+	var hold model.Hold
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/holds", request, &hold)
+	return hold, code, err
+}
+
+func (s *Service) GetHolds(ctx context.Context) ([]model.Hold, int, error) {
+	var holds []model.Hold
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/holds", nil, &holds)
+	return holds, code, err
+}
+
+func (s *Service) GetHold(ctx context.Context, holdUid string) (model.Hold, int, error) {
+	var hold model.Hold
+	code, err := s.do(ctx, http.MethodGet, "/api/v1/holds/"+holdUid, nil, &hold)
+	return hold, code, err
+}
+
+func (s *Service) CancelHold(ctx context.Context, holdUid string) (int, error) {
+	return s.do(ctx, http.MethodDelete, "/api/v1/holds/"+holdUid, nil, nil)
+}
+
+func (s *Service) RenewReservation(ctx context.Context, reservationUid string, request model.RenewReservationRequest) (model.RenewReservationResponse, int, error) {
+	var resp model.RenewReservationResponse
+	code, err := s.do(ctx, http.MethodPost, "/api/v1/reservations/"+reservationUid+"/renew", request, &resp)
+	return resp, code, err
+}
+
+// do sends body as JSON on behalf of the user of ctx and decodes the response into out, if any.
+func (s *Service) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		b := bytes.NewBuffer(nil)
+		if err := json.NewEncoder(b).Encode(body); err != nil {
+			return http.StatusBadRequest, err
+		}
+		reqBody = b
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("http://%s%s", net.JoinHostPort(s.cfg.Host, s.cfg.Port), path), reqBody)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	auth.SetAuthHeader(req)
+	req.Header.Set("Content-Type", echo.MIMEApplicationJSONCharsetUTF8)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body) //nolint:errcheck
+		s.log.Debug(path, zap.String("data", string(data)))
+		return resp.StatusCode, errs.ErrDefault
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return resp.StatusCode, nil
+}
@@ -8,11 +8,13 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Astemirdum/library-service/backend/pkg/auth"
 
 	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 
 	"github.com/Astemirdum/library-service/backend/gateway/internal/errs"
 
@@ -43,28 +45,35 @@ func (s *Service) CB() circuit_breaker.CircuitBreaker {
 	return s.cb
 }
 
-func (s *Service) GetReservation(ctx context.Context, username string) ([]model.GetReservation, int, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/api/v1/reservations", net.JoinHostPort(s.cfg.Host, s.cfg.Port)), http.NoBody)
+// GetReservation lists the reservations of the user, as a page of them if the request asks for one.
+func (s *Service) GetReservation(ctx context.Context, username string, page paging.Request) ([]model.GetReservation, paging.Page, int, error) {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(s.cfg.Host, s.cfg.Port),
+		Path:     "/api/v1/reservations",
+		RawQuery: page.Query().Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
 	if err != nil {
-		return nil, http.StatusBadRequest, err
+		return nil, paging.Page{}, http.StatusBadRequest, err
 	}
 	auth.SetAuthHeader(req)
 	req.Header.Set("Content-Type", echo.MIMEApplicationJSONCharsetUTF8)
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, http.StatusServiceUnavailable, err
+		return nil, paging.Page{}, http.StatusServiceUnavailable, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		return nil, resp.StatusCode, errs.ErrDefault
+		return nil, paging.Page{}, resp.StatusCode, errs.ErrDefault
 	}
 
 	var rsv []model.GetReservation
 	if err := json.NewDecoder(resp.Body).Decode(&rsv); err != nil {
-		return nil, http.StatusBadRequest, err
+		return nil, paging.Page{}, http.StatusBadRequest, err
 	}
-	return rsv, resp.StatusCode, nil
+	return rsv, paging.FromHeaders(resp.Header), resp.StatusCode, nil
 }
 
 func (s *Service) CreateReservation(ctx context.Context, request model.CreateReservationRequest) (model.Reservation, int, error) {
@@ -97,6 +106,14 @@ func (s *Service) CreateReservation(ctx context.Context, request model.CreateRes
 }
 
 func (s *Service) RollbackReservation(ctx context.Context, uuid string) (int, error) {
+	return s.rollback(ctx, "rollback", uuid)
+}
+
+func (s *Service) RollbackReturn(ctx context.Context, uuid string) (int, error) {
+	return s.rollback(ctx, "rollback-return", uuid)
+}
+
+func (s *Service) rollback(ctx context.Context, action, uuid string) (int, error) {
 	b := bytes.NewBuffer(nil)
 	type request struct {
 		Uuid string `json:"uuid"`
@@ -104,7 +121,7 @@ func (s *Service) RollbackReservation(ctx context.Context, uuid string) (int, er
 	if err := json.NewEncoder(b).Encode(request{Uuid: uuid}); err != nil {
 		return http.StatusBadRequest, err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/api/v1/reservations/rollback", net.JoinHostPort(s.cfg.Host, s.cfg.Port)), b)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("http://%s/api/v1/reservations/%s", net.JoinHostPort(s.cfg.Host, s.cfg.Port), action), b)
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
@@ -117,7 +134,7 @@ func (s *Service) RollbackReservation(ctx context.Context, uuid string) (int, er
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
 		data, _ := io.ReadAll(resp.Body) //nolint:errcheck
-		s.log.Debug("RollbackReservation", zap.String("data", string(data)))
+		s.log.Debug(action, zap.String("data", string(data)))
 		return resp.StatusCode, errs.ErrDefault
 	}
 	return resp.StatusCode, nil
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Astemirdum/library-service/backend/pkg/auth"
@@ -14,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/Astemirdum/library-service/backend/pkg/circuit_breaker"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 
 	"github.com/labstack/echo/v4"
 
@@ -45,8 +47,14 @@ func (s *Service) CB() circuit_breaker.CircuitBreaker {
 	return s.cb
 }
 
-func (s *Service) GetStats(ctx context.Context, userName string) (model.StatsInfo, int, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/api/v1/stats", net.JoinHostPort(s.cfg.Host, s.cfg.Port)), http.NoBody)
+func (s *Service) GetStats(ctx context.Context, userName string, page paging.Request) (model.StatsInfo, int, error) {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(s.cfg.Host, s.cfg.Port),
+		Path:     "/api/v1/stats",
+		RawQuery: page.Query().Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
 	if err != nil {
 		return model.StatsInfo{}, http.StatusBadRequest, err
 	}
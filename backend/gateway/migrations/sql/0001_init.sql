@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS saga
+(
+    id           uuid PRIMARY KEY,
+    name         VARCHAR(80) NOT NULL,
+    status       VARCHAR(20) NOT NULL
+        CHECK (status IN ('RUNNING', 'COMPENSATING', 'COMPLETED', 'COMPENSATED')),
+    applied      INT         NOT NULL DEFAULT 0,
+    payload      jsonb       NOT NULL,
+    error        TEXT        NOT NULL DEFAULT '',
+    locked_until TIMESTAMP   NOT NULL,
+    created_at   TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS saga_unfinished_idx ON saga (locked_until)
+    WHERE status IN ('RUNNING', 'COMPENSATING');
+
+CREATE TABLE IF NOT EXISTS saga_step
+(
+    saga_id    uuid        NOT NULL REFERENCES saga (id) ON DELETE CASCADE,
+    idx        INT         NOT NULL,
+    name       VARCHAR(80) NOT NULL,
+    status     VARCHAR(20) NOT NULL
+        CHECK (status IN ('STARTED', 'DONE', 'FAILED', 'COMPENSATED')),
+    attempts   INT         NOT NULL DEFAULT 0,
+    error      TEXT        NOT NULL DEFAULT '',
+    updated_at TIMESTAMP   NOT NULL DEFAULT now(),
+    PRIMARY KEY (saga_id, idx)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS saga_step CASCADE;
+DROP TABLE IF EXISTS saga CASCADE;
@@ -11,12 +11,15 @@ import (
 
 	"github.com/Astemirdum/library-service/backend/library/config"
 	"github.com/Astemirdum/library-service/backend/library/internal/handler"
+	"github.com/Astemirdum/library-service/backend/library/internal/metadata"
 	"github.com/Astemirdum/library-service/backend/library/internal/repository"
 	"github.com/Astemirdum/library-service/backend/library/internal/server"
 	"github.com/Astemirdum/library-service/backend/library/internal/service"
 	"github.com/Astemirdum/library-service/backend/library/migrations"
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"go.uber.org/zap"
 )
@@ -31,16 +34,33 @@ func Run(cfg *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("repo %v", err)
 	}
-	svc := service.NewService(repo, log)
+	meta, err := newMetadataProvider(cfg.Metadata)
+	if err != nil {
+		return fmt.Errorf("metadata provider %v", err)
+	}
+	svc := service.NewService(repo, meta, log)
+
+	if _, err := kafka.EnsureTopics(cfg.Kafka, log, kafka.LibraryTopic, kafka.DLQTopic(kafka.LibraryTopic), kafka.LibraryEventsTopic); err != nil {
+		log.Error("ensure topics", zap.Error(err))
+	}
+	producer, err := kafka.NewSyncProducer(cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("kafka.NewSyncProducer %v", err)
+	}
+	defer producer.Close()
 
 	consumer, err := kafka.NewConsumer(cfg.Kafka, kafka.LibraryConsumerGroup)
 	if err != nil {
 		return fmt.Errorf("kafka.NewConsumer %v", err)
 	}
-	go kafka.Consume(consumer, handler.NewConsumer(svc.AvailableCount, log), kafka.LibraryTopic)
+	workers := lifecycle.New(log)
+	workers.Go("consumer", func(ctx context.Context, ready func()) error {
+		return kafka.Consume(ctx, consumer, handler.NewConsumer(svc.AvailableCount, producer, cfg.Kafka, log), ready, kafka.LibraryTopic)
+	})
+	workers.Go("outbox relay", lifecycle.Loop(outbox.NewRelay(db, producer, cfg.Outbox, log).Run))
 
 	h := handler.New(svc, log)
-	srv := server.NewServer(cfg.Server, h.NewRouter())
+	srv := server.NewServer(cfg.Server, h.NewRouter(workers.Check))
 	log.Info("http server start ON: ",
 		zap.String("addr",
 			net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)))
@@ -52,9 +72,13 @@ func Run(cfg *config.Config) error {
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	termSig := <-sig
-
-	log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	var fatal error
+	select {
+	case termSig := <-sig:
+		log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	case fatal = <-workers.Fatal():
+		log.Error("Graceful shutdown", zap.Error(fatal))
+	}
 
 	closeCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -62,7 +86,23 @@ func Run(cfg *config.Config) error {
 	if err = srv.Stop(closeCtx); err != nil {
 		log.Error("srv.Stop", zap.Error(err))
 	}
+	if err := workers.Stop(closeCtx); err != nil {
+		log.Error("workers.Stop", zap.Error(err))
+	}
 	db.Close()
 	log.Info("Graceful shutdown finished")
-	return nil
+	return fatal
+}
+
+// newMetadataProvider makes the provider of cfg, there is none if it is not set.
+func newMetadataProvider(cfg config.Metadata) (service.MetadataProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "file":
+		return metadata.NewFileProvider(cfg.File)
+	case "http":
+		return metadata.NewHTTPProvider(cfg.URL, cfg.Timeout)
+	}
+	return nil, fmt.Errorf("unknown metadata provider %q", cfg.Provider)
 }
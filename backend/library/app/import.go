@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Astemirdum/library-service/backend/library/config"
+	"github.com/Astemirdum/library-service/backend/library/internal/importer"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/library/internal/repository"
+	"github.com/Astemirdum/library-service/backend/library/internal/service"
+	"github.com/Astemirdum/library-service/backend/library/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+)
+
+// Import imports the books of the file at path into the library and writes the report to out.
+// The format is guessed by the file name if it is empty. Running it again on the same file resumes
+// an interrupted import.
+func Import(ctx context.Context, cfg *config.Config, libraryUid, format, actor, path string, out io.Writer) error {
+	importFormat := model.ImportFormat(format)
+	if importFormat == "" {
+		var ok bool
+		if importFormat, ok = importer.FormatOf(path); !ok {
+			return fmt.Errorf("unknown format of %s", filepath.Base(path))
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	log := logger.NewLogger(cfg.Log, "library-import")
+	db, err := postgres.NewPostgresDB(ctx, &cfg.Database, migrations.MigrationFiles)
+	if err != nil {
+		return fmt.Errorf("db init %v", err)
+	}
+	defer db.Close()
+	repo, err := repository.NewRepository(db, log)
+	if err != nil {
+		return fmt.Errorf("repo %v", err)
+	}
+	report, err := service.NewService(repo, nil, log).Import(ctx, actor, libraryUid, importFormat, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "import %s %s: %d rows done, %d failed\n", report.ImportUid, report.Status, report.RowsDone, report.RowsFailed)
+	for _, e := range report.Errors {
+		fmt.Fprintf(out, "\trow %d: %s\n", e.Row, e.Error)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 
@@ -21,11 +22,23 @@ type HTTPServer struct {
 	WriteTimeout time.Duration
 }
 
+// Metadata is the provider the books are enriched from by ISBN: "file", "http" or none.
+type Metadata struct {
+	Provider string `yaml:"provider" envconfig:"LIBRARY_METADATA_PROVIDER"`
+	// File is the JSON file of the file provider.
+	File string `yaml:"file" envconfig:"LIBRARY_METADATA_FILE"`
+	// URL is the address of the http provider, {isbn} in it stands for the ISBN-13 of the book.
+	URL     string        `yaml:"url" envconfig:"LIBRARY_METADATA_URL"`
+	Timeout time.Duration `yaml:"timeout" envconfig:"LIBRARY_METADATA_TIMEOUT" default:"5s"`
+}
+
 type Config struct {
-	Server   HTTPServer   `yaml:"server"`
-	Kafka    kafka.Config `yaml:"kafka"`
-	Database postgres.DB  `yaml:"db"`
-	Log      logger.Log   `yaml:"log"`
+	Server   HTTPServer    `yaml:"server"`
+	Kafka    kafka.Config  `yaml:"kafka"`
+	Database postgres.DB   `yaml:"db"`
+	Outbox   outbox.Config `yaml:"outbox"`
+	Metadata Metadata      `yaml:"metadata"`
+	Log      logger.Log    `yaml:"log"`
 }
 
 var (
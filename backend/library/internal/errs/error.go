@@ -6,6 +6,27 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+
+	ErrOutOfStock   = errors.New("no copy of the book is on the shelf")
+	ErrStockChanged = errors.New("stock of the book kept changing, try again")
+	ErrCopyOnLoan   = errors.New("copy is on loan")
+
+	ErrCopyInTransit   = errors.New("copy is in transit")
+	ErrNotEnoughCopies = errors.New("not enough copies of the book are on the shelf")
+	ErrTransferClosed  = errors.New("transfer is received or cancelled")
+
+	ErrLibraryArchived = errors.New("library is archived")
+	ErrStockOnLoan     = errors.New("copies on loan exceed the stock")
+
+	ErrImportConflict = errors.New("import is running elsewhere")
+
+	ErrAliasTaken  = errors.New("alias names another author")
+	ErrGenreCycle  = errors.New("genre can not be a subgenre of itself")
+	ErrGenreExists = errors.New("genre of the name exists")
+
+	ErrISBNTaken  = errors.New("isbn names another book")
+	ErrNoISBN     = errors.New("book has no isbn")
+	ErrNoMetadata = errors.New("no metadata of the isbn")
 )
 
 type ValidationErrorResponse struct {
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/importer"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/isbn"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// librarian returns the name of the librarian changing the catalog.
+func librarian(c echo.Context) (string, error) {
+	ctx := c.Request().Context()
+	if !auth.IsLibrarian(ctx) {
+		return "", echo.NewHTTPError(http.StatusForbidden, "no librarian")
+	}
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	return userName, nil
+}
+
+// catalogError maps the errors of the catalog changes to the HTTP errors.
+func catalogError(err error) error {
+	switch {
+	case errors.Is(err, isbn.ErrInvalid), errors.Is(err, paging.ErrInvalid):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, errs.ErrNotFound), errors.Is(err, errs.ErrNoMetadata):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, errs.ErrLibraryArchived), errors.Is(err, errs.ErrStockOnLoan), errors.Is(err, errs.ErrImportConflict),
+		errors.Is(err, errs.ErrAliasTaken), errors.Is(err, errs.ErrGenreCycle), errors.Is(err, errs.ErrGenreExists),
+		errors.Is(err, errs.ErrISBNTaken), errors.Is(err, errs.ErrNoISBN),
+		errors.Is(err, errs.ErrNotEnoughCopies), errors.Is(err, errs.ErrTransferClosed):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+func (h *Handler) CreateLibrary(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.LibraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	lib, err := h.librarySvc.CreateLibrary(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusCreated, lib)
+}
+
+func (h *Handler) UpdateLibrary(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.UpdateLibraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	lib, err := h.librarySvc.UpdateLibrary(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, lib)
+}
+
+// ArchiveLibrary soft deletes the library, it is kept for the reservations made in it.
+func (h *Handler) ArchiveLibrary(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	if err := h.librarySvc.ArchiveLibrary(c.Request().Context(), actor, c.Param("libraryUid")); err != nil {
+		return catalogError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// SetSchedule replaces the opening hours and the exception dates of the library.
+func (h *Handler) SetSchedule(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.ScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Schedule.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	schedule, err := h.librarySvc.SetSchedule(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) CreateBook(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.BookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	book, err := h.librarySvc.CreateBook(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusCreated, book)
+}
+
+func (h *Handler) UpdateBook(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.UpdateBookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	book, err := h.librarySvc.UpdateBook(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, book)
+}
+
+// EnrichBook fills the book from the metadata of its ISBN.
+func (h *Handler) EnrichBook(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.EnrichBookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	book, err := h.librarySvc.EnrichBook(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, book)
+}
+
+// SetStock sets the number of copies of a book the library holds.
+func (h *Handler) SetStock(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.StockRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	stock, err := h.librarySvc.SetStock(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, stock)
+}
+
+// GetAudit lists the changes librarians made to a library or a book.
+func (h *Handler) GetAudit(c echo.Context) error {
+	if _, err := librarian(c); err != nil {
+		return err
+	}
+	records, err := h.librarySvc.ListAudit(c.Request().Context(), c.Param("entityUid"))
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, records)
+}
+
+// Import imports the books of an uploaded CSV or MARC21 file into the library. The format is taken
+// from the format form field or guessed by the file name. Uploading the file again resumes an
+// interrupted import.
+func (h *Handler) Import(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	format := model.ImportFormat(strings.ToUpper(c.FormValue("format")))
+	if format == "" {
+		var ok bool
+		if format, ok = importer.FormatOf(fh.Filename); !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown format of "+fh.Filename)
+		}
+	}
+	if format != model.ImportCSV && format != model.ImportMARC21 {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be CSV or MARC21")
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	defer file.Close()
+
+	report, err := h.librarySvc.Import(c.Request().Context(), actor, c.Param("libraryUid"), format, file)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) GetImport(c echo.Context) error {
+	if _, err := librarian(c); err != nil {
+		return err
+	}
+	report, err := h.librarySvc.GetImport(c.Request().Context(), c.Param("importUid"))
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
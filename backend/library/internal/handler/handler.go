@@ -8,6 +8,8 @@ import (
 	md "github.com/Astemirdum/library-service/backend/pkg/middleware"
 
 	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/pkg/validate"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -32,7 +34,8 @@ func New(librarySrv LibraryService, log *zap.Logger) *Handler {
 	return h
 }
 
-func (h *Handler) NewRouter() *echo.Echo {
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
 	e := echo.New()
 	const (
 		baseRPS = 10
@@ -50,21 +53,53 @@ func (h *Handler) NewRouter() *echo.Echo {
 
 	base := e.Group("", md.NewRateLimiter(baseRPS))
 	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
 
 	e.Validator = validate.NewCustomValidator()
 	api := e.Group("/api/v1",
 		middleware.RequestLoggerWithConfig(md.RequestLoggerConfig()),
 		middleware.RequestID(),
+		md.TraceContext,
 		md.NewRateLimiter(apiRPS),
 	)
 
 	api.GET("/libraries/:libraryUid/books", h.GetBooks)
 	api.GET("/libraries/:libraryUid/books/:bookUid", h.GetBook)
 	api.PATCH("/libraries/books", h.AvailableCount)
+	api.GET("/libraries/:libraryUid/books/:bookUid/copies", h.GetCopies)
+	api.PATCH("/copies/:copyUid", h.SetCopyStatus)
 
+	api.GET("/books/search", h.SearchBooks)
 	api.GET("/libraries", h.GetLibraries)
+	api.GET("/authors", h.GetAuthors)
+	api.GET("/authors/:authorUid/books", h.GetAuthorBooks)
+	api.GET("/genres", h.GetGenres)
+	api.GET("/genres/:genreUid/books", h.GetGenreBooks)
+	api.GET("/libraries/nearby", h.NearbyLibraries)
 	api.GET("/libraries/:libraryUid", h.GetLibrary)
 
+	catalog := api.Group("", md.AuthContext)
+	catalog.POST("/libraries", h.CreateLibrary)
+	catalog.PATCH("/libraries/:libraryUid", h.UpdateLibrary)
+	catalog.DELETE("/libraries/:libraryUid", h.ArchiveLibrary)
+	catalog.PUT("/libraries/:libraryUid/schedule", h.SetSchedule)
+	catalog.POST("/books", h.CreateBook)
+	catalog.PATCH("/books/:bookUid", h.UpdateBook)
+	catalog.POST("/books/:bookUid/metadata", h.EnrichBook)
+	catalog.POST("/authors/:authorUid/aliases", h.AddAuthorAlias)
+	catalog.POST("/authors/:authorUid/merge", h.MergeAuthors)
+	catalog.POST("/genres", h.CreateGenre)
+	catalog.PATCH("/genres/:genreUid", h.UpdateGenre)
+	catalog.PUT("/libraries/:libraryUid/books/:bookUid/stock", h.SetStock)
+	catalog.POST("/transfers", h.RequestTransfer)
+	catalog.GET("/transfers", h.GetTransfers)
+	catalog.GET("/transfers/:transferUid", h.GetTransfer)
+	catalog.POST("/transfers/:transferUid/receive", h.ReceiveTransfer)
+	catalog.POST("/transfers/:transferUid/cancel", h.CancelTransfer)
+	catalog.GET("/audit/:entityUid", h.GetAudit)
+	catalog.POST("/libraries/:libraryUid/imports", h.Import)
+	catalog.GET("/imports/:importUid", h.GetImport)
+
 	return e
 }
 
@@ -72,20 +107,52 @@ func (h *Handler) Health(c echo.Context) error {
 	return c.String(http.StatusOK, "OK")
 }
 
+// AvailableCount lends a copy of a book or takes it back, it returns the changed copy.
 func (h *Handler) AvailableCount(c echo.Context) error {
-	type Req struct {
-		LibraryID int  `json:"libraryID"`
-		BookID    int  `json:"bookID"`
-		IsReturn  bool `json:"isReturn"`
+	var req model.AvailableCountRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	var req Req
+	bookCopy, err := h.librarySvc.AvailableCount(c.Request().Context(), req)
+	if err != nil {
+		if errors.Is(err, errs.ErrOutOfStock) || errors.Is(err, errs.ErrStockChanged) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, bookCopy)
+}
+
+func (h *Handler) GetCopies(c echo.Context) error {
+	copies, err := h.librarySvc.ListCopies(c.Request().Context(), c.Param("libraryUid"), c.Param("bookUid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, copies)
+}
+
+func (h *Handler) SetCopyStatus(c echo.Context) error {
+	var req model.CopyStatusRequest
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	if err := h.librarySvc.AvailableCount(c.Request().Context(), req.LibraryID, req.BookID, req.IsReturn); err != nil {
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	bookCopy, err := h.librarySvc.SetCopyStatus(c.Request().Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, errs.ErrCopyOnLoan), errors.Is(err, errs.ErrCopyInTransit):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, bookCopy)
 }
 
 func (h *Handler) GetBook(c echo.Context) error {
@@ -120,35 +187,65 @@ func (h *Handler) GetBooks(c echo.Context) error {
 	if libraryUid == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, errors.New("empty libraryUid"))
 	}
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	var (
 		err     error
-		page    int
-		size    int
 		showAll bool
 	)
-	if pageParam := c.QueryParam("page"); pageParam != "" {
-		if page, err = strconv.Atoi(pageParam); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.New("page is invalid"))
-		}
-	}
-	if sizeParam := c.QueryParam("size"); sizeParam != "" {
-		if size, err = strconv.Atoi(sizeParam); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.New("size is invalid"))
-		}
-	}
 	if showAllParam := c.QueryParam("showAll"); showAllParam != "" {
 		if showAll, err = strconv.ParseBool(showAllParam); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, errors.New("showAll is invalid"))
 		}
 	}
 
-	books, err := h.librarySvc.ListBooks(ctx, libraryUid, showAll, page, size)
+	books, err := h.librarySvc.ListBooks(ctx, libraryUid, showAll, req)
 	if err != nil {
+		if errors.Is(err, paging.ErrInvalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, books)
 }
 
+// SearchBooks searches the books of all libraries by title, author and genre.
+func (h *Handler) SearchBooks(c echo.Context) error {
+	var req model.SearchBooksRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	hits, err := h.librarySvc.SearchBooks(c.Request().Context(), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, hits)
+}
+
+// NearbyLibraries finds the libraries near a point, the nearest first.
+func (h *Handler) NearbyLibraries(c echo.Context) error {
+	var req model.NearbyLibrariesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	libs, err := h.librarySvc.NearbyLibraries(c.Request().Context(), req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, libs)
+}
+
 func (h *Handler) GetLibraries(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -156,24 +253,19 @@ func (h *Handler) GetLibraries(c echo.Context) error {
 	if city == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, errors.New("city is required"))
 	}
-	var (
-		err  error
-		page int
-		size int
-	)
-	if pageParam := c.QueryParam("page"); pageParam != "" {
-		if page, err = strconv.Atoi(pageParam); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.New("page is invalid"))
-		}
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	if sizeParam := c.QueryParam("size"); sizeParam != "" {
-		if size, err = strconv.Atoi(sizeParam); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.New("size is invalid"))
-		}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	library, err := h.librarySvc.ListLibrary(ctx, city, page, size)
+	library, err := h.librarySvc.ListLibrary(ctx, city, req)
 	if err != nil {
+		if errors.Is(err, paging.ErrInvalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, library)
@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
 	"github.com/Astemirdum/library-service/backend/library/internal/handler"
 	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/isbn"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/pkg/validate"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
@@ -24,7 +30,7 @@ func TestHandler_GetBooks(t *testing.T) {
 	t.Parallel()
 	type input struct {
 		libraryUid string
-		page, size int
+		req        paging.Request
 		showAll    bool
 	}
 	type response struct {
@@ -32,6 +38,7 @@ func TestHandler_GetBooks(t *testing.T) {
 		expectedBody string
 	}
 	type mockBehavior func(r *service_mocks.MockLibraryService, req input)
+	total := 1
 
 	var tests = []struct {
 		name         string
@@ -44,12 +51,12 @@ func TestHandler_GetBooks(t *testing.T) {
 			name: "ok",
 			mockBehavior: func(r *service_mocks.MockLibraryService, req input) {
 				r.EXPECT().
-					ListBooks(context.Background(), req.libraryUid, req.showAll, req.page, req.size).
+					ListBooks(context.Background(), req.libraryUid, req.showAll, req.req).
 					Return(model.ListBooks{
-						Paging: model.Paging{
-							Page:          req.page,
-							PageSize:      req.size,
-							TotalElements: 1,
+						Page: paging.Page{
+							Number:        req.req.Page,
+							PageSize:      req.req.Size,
+							TotalElements: &total,
 						},
 						Items: []model.Book{
 							{
@@ -65,13 +72,12 @@ func TestHandler_GetBooks(t *testing.T) {
 			},
 			input: input{
 				libraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
-				page:       0,
-				size:       0,
+				req:        paging.Request{Page: 1, Size: 10},
 				showAll:    false,
 			},
 			response: response{
 				expectedCode: http.StatusOK,
-				expectedBody: `{"page":0,"pageSize":0,"totalElements":1,"items":[{"id":0,"bookUid":"f7cdc58f-2caf-4b15-9727-f89dcc629b27","name":"Краткий курс C++ в 7 томах","author":"Бьерн Страуструп","genre":"Научная фантастика","condition":"EXCELLENT","availableCount":1}]}`,
+				expectedBody: `{"page":1,"pageSize":10,"totalElements":1,"items":[{"id":0,"bookUid":"f7cdc58f-2caf-4b15-9727-f89dcc629b27","name":"Краткий курс C++ в 7 томах","author":"Бьерн Страуструп","genre":"Научная фантастика","condition":"EXCELLENT","availableCount":1}]}`,
 			},
 			wantErr: false,
 		},
@@ -80,8 +86,6 @@ func TestHandler_GetBooks(t *testing.T) {
 			mockBehavior: func(r *service_mocks.MockLibraryService, inp input) {},
 			input: input{
 				libraryUid: "",
-				page:       0,
-				size:       0,
 				showAll:    false,
 			},
 			response: response{
@@ -90,17 +94,32 @@ func TestHandler_GetBooks(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "err. sort",
+			mockBehavior: func(r *service_mocks.MockLibraryService, inp input) {
+				r.EXPECT().
+					ListBooks(context.Background(), inp.libraryUid, inp.showAll, inp.req).
+					Return(model.ListBooks{}, fmt.Errorf("%w: can not sort by \"genre\"", paging.ErrInvalid))
+			},
+			input: input{
+				libraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
+				req:        paging.Request{Sort: "genre"},
+			},
+			response: response{
+				expectedCode: http.StatusBadRequest,
+				expectedBody: `{"message":"invalid paging: can not sort by \"genre\""}`,
+			},
+			wantErr: true,
+		},
 		{
 			name: "err. internal",
 			mockBehavior: func(r *service_mocks.MockLibraryService, inp input) {
 				r.EXPECT().
-					ListBooks(context.Background(), inp.libraryUid, inp.showAll, inp.page, inp.size).
+					ListBooks(context.Background(), inp.libraryUid, inp.showAll, inp.req).
 					Return(model.ListBooks{}, errors.New("db internal"))
 			},
 			input: input{
 				libraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
-				page:       0,
-				size:       0,
 				showAll:    false,
 			},
 			response: response{
@@ -125,7 +144,7 @@ func TestHandler_GetBooks(t *testing.T) {
 			e.GET("/libraries/:libraryUid/books", h.GetBooks)
 
 			r := httptest.NewRequest(
-				http.MethodGet, fmt.Sprintf("/libraries/%s/books?page=%d&size=%d&showAll=%v", tt.input.libraryUid, tt.input.page, tt.input.size, tt.input.showAll), http.NoBody)
+				http.MethodGet, fmt.Sprintf("/libraries/%s/books?%s&showAll=%v", tt.input.libraryUid, tt.input.req.Query().Encode(), tt.input.showAll), http.NoBody)
 			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			w := httptest.NewRecorder()
 
@@ -137,3 +156,436 @@ func TestHandler_GetBooks(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_SearchBooks(t *testing.T) {
+	t.Parallel()
+	type mockBehavior func(r *service_mocks.MockLibraryService)
+
+	var tests = []struct {
+		name         string
+		query        url.Values
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "ok",
+			query: url.Values{"q": {"страуструп"}, "city": {"Москва"}, "available": {"true"}, "page": {"1"}, "size": {"10"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					SearchBooks(gomock.Any(), model.SearchBooksRequest{Query: "страуструп", City: "Москва", Available: true, Page: 1, Size: 10}).
+					Return(model.ListBookHits{
+						Paging: model.Paging{Page: 1, PageSize: 10, TotalElements: 1},
+						Items: []model.BookHit{{
+							BookUid:   "f7cdc58f-2caf-4b15-9727-f89dcc629b27",
+							Name:      "Краткий курс C++ в 7 томах",
+							Author:    "Бьерн Страуструп",
+							Genre:     "Научная фантастика",
+							Condition: model.ConditionExcellent,
+							Rank:      0.5,
+							Highlight: "Краткий курс C++ в 7 томах · Бьерн <mark>Страуструп</mark> · Научная фантастика",
+							Libraries: []model.BookHolding{{
+								LibraryUid:     "83575e12-7ce0-48ee-9931-51919ff3c9ee",
+								Name:           "Библиотека имени 7 Непьющих",
+								City:           "Москва",
+								Address:        "2-я Бауманская ул., д.5, стр.1",
+								AvailableCount: 3,
+							}},
+						}},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"page":1,"pageSize":10,"totalElements":1,"items":[{"bookUid":"f7cdc58f-2caf-4b15-9727-f89dcc629b27","name":"Краткий курс C++ в 7 томах","author":"Бьерн Страуструп","genre":"Научная фантастика","condition":"EXCELLENT","rank":0.5,"highlight":"Краткий курс C++ в 7 томах · Бьерн <mark>Страуструп</mark> · Научная фантастика","libraries":[{"libraryUid":"83575e12-7ce0-48ee-9931-51919ff3c9ee","name":"Библиотека имени 7 Непьющих","city":"Москва","address":"2-я Бауманская ул., д.5, стр.1","availableCount":3}]}]}`,
+		},
+		{
+			name:         "err. query is too short",
+			query:        url.Values{"q": {"c"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. size is too big",
+			query:        url.Values{"q": {"c++"}, "size": {"1000"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			svc := service_mocks.NewMockLibraryService(c)
+			h := handler.New(svc, zap.NewNop())
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.GET("/books/search", h.SearchBooks)
+
+			r := httptest.NewRequest(http.MethodGet, "/books/search?"+tt.query.Encode(), http.NoBody)
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_NearbyLibraries(t *testing.T) {
+	t.Parallel()
+	type mockBehavior func(r *service_mocks.MockLibraryService)
+	lat, lon, count := 55.7658, 37.6851, 3
+
+	var tests = []struct {
+		name         string
+		query        url.Values
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:  "ok",
+			query: url.Values{"lat": {"55.7658"}, "lon": {"37.6851"}, "radius": {"2.5"}, "bookUid": {"f7cdc58f-2caf-4b15-9727-f89dcc629b27"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					NearbyLibraries(gomock.Any(), model.NearbyLibrariesRequest{Lat: &lat, Lon: &lon, Radius: 2.5, BookUid: "f7cdc58f-2caf-4b15-9727-f89dcc629b27"}).
+					Return(model.ListNearbyLibraries{Items: []model.NearbyLibrary{{
+						Library: model.Library{
+							ID:         1,
+							LibraryUid: "83575e12-7ce0-48ee-9931-51919ff3c9ee",
+							Name:       "Библиотека имени 7 Непьющих",
+							City:       "Москва",
+							Address:    "2-я Бауманская ул., д.5, стр.1",
+							Latitude:   &lat,
+							Longitude:  &lon,
+						},
+						DistanceKm:     0.04,
+						AvailableCount: &count,
+					}}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"items":[{"id":1,"libraryUid":"83575e12-7ce0-48ee-9931-51919ff3c9ee","name":"Библиотека имени 7 Непьющих","address":"2-я Бауманская ул., д.5, стр.1","city":"Москва","latitude":55.7658,"longitude":37.6851,"distanceKm":0.04,"availableCount":3}]}`,
+		},
+		{
+			name:         "err. no lon",
+			query:        url.Values{"lat": {"55.7658"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. lat out of range",
+			query:        url.Values{"lat": {"91"}, "lon": {"37.6851"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. radius is too big",
+			query:        url.Values{"lat": {"55.7658"}, "lon": {"37.6851"}, "radius": {"1000"}},
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			svc := service_mocks.NewMockLibraryService(c)
+			h := handler.New(svc, zap.NewNop())
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.GET("/libraries/nearby", h.NearbyLibraries)
+			e.GET("/libraries/:libraryUid", h.GetLibrary)
+
+			r := httptest.NewRequest(http.MethodGet, "/libraries/nearby?"+tt.query.Encode(), http.NoBody)
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateGenre(t *testing.T) {
+	t.Parallel()
+	const genreUid = "0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f"
+	type mockBehavior func(r *service_mocks.MockLibraryService)
+	root, parentUid := "", "5f6e7d8c-9b0a-4f1e-8d2c-3b4a5c6d7e8f"
+
+	var tests = []struct {
+		name         string
+		role         string
+		body         string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok. top genre",
+			role: "librarian",
+			body: `{"parentUid":""}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					UpdateGenre(gomock.Any(), "librarian", model.UpdateGenreRequest{GenreUid: genreUid, ParentUid: &root}).
+					Return(model.Genre{GenreUid: genreUid, Name: "Научная фантастика", BookCount: 1}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"genreUid":"` + genreUid + `","name":"Научная фантастика","bookCount":1}`,
+		},
+		{
+			name: "err. subgenre of itself",
+			role: "librarian",
+			body: `{"parentUid":"` + parentUid + `"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					UpdateGenre(gomock.Any(), "librarian", model.UpdateGenreRequest{GenreUid: genreUid, ParentUid: &parentUid}).
+					Return(model.Genre{}, errs.ErrGenreCycle)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "err. parent is no uuid",
+			role:         "librarian",
+			body:         `{"parentUid":"fiction"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. no librarian",
+			role:         "user",
+			body:         `{"name":"Фантастика"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			svc := service_mocks.NewMockLibraryService(c)
+			h := handler.New(svc, zap.NewNop())
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.PATCH("/genres/:genreUid", h.UpdateGenre)
+
+			r := httptest.NewRequest(http.MethodPatch, "/genres/"+genreUid, strings.NewReader(tt.body)).
+				WithContext(auth.SetAuthContext(context.Background(), tt.role, tt.role))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_CreateBook(t *testing.T) {
+	t.Parallel()
+	const bookUid = "7a8b9c0d-1e2f-4a3b-8c4d-5e6f7a8b9c0d"
+	type mockBehavior func(r *service_mocks.MockLibraryService)
+
+	var tests = []struct {
+		name         string
+		body         string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok. by isbn",
+			body: `{"isbn":"0-306-40615-2"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					CreateBook(gomock.Any(), "librarian", model.BookRequest{ISBN: "0-306-40615-2"}).
+					Return(model.Book{BookUid: bookUid, Name: "Signals", Condition: model.ConditionExcellent,
+						ISBN: "9780306406157", ISBN10: "0306406152"}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":0,"bookUid":"` + bookUid + `","name":"Signals","author":"","genre":"","condition":"EXCELLENT",` +
+				`"isbn":"9780306406157","isbn10":"0306406152","availableCount":0}`,
+		},
+		{
+			name: "err. isbn check digit",
+			body: `{"isbn":"0-306-40615-3","name":"Signals"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				_, err := isbn.Normalize("0-306-40615-3")
+				r.EXPECT().
+					CreateBook(gomock.Any(), "librarian", gomock.Any()).
+					Return(model.Book{}, err)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "err. no metadata",
+			body: `{"isbn":"9780306406157"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					CreateBook(gomock.Any(), "librarian", gomock.Any()).
+					Return(model.Book{}, errs.ErrNoMetadata)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name: "err. isbn taken",
+			body: `{"isbn":"9780306406157","name":"Signals"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					CreateBook(gomock.Any(), "librarian", gomock.Any()).
+					Return(model.Book{}, errs.ErrISBNTaken)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "err. no name nor isbn",
+			body:         `{"author":"Someone"}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			svc := service_mocks.NewMockLibraryService(c)
+			h := handler.New(svc, zap.NewNop())
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.POST("/books", h.CreateBook)
+
+			r := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(tt.body)).
+				WithContext(auth.SetAuthContext(context.Background(), "librarian", "librarian"))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RequestTransfer(t *testing.T) {
+	t.Parallel()
+	const (
+		bookUid   = "f7cdc58f-2caf-4b15-9727-f89dcc629b27"
+		fromUid   = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+		toUid     = "93575e12-7ce0-48ee-9931-51919ff3c9ee"
+		transfer  = "2b3c4d5e-6f70-4a81-9b2c-3d4e5f607182"
+		requested = "2024-03-01T10:00:00Z"
+	)
+	type mockBehavior func(r *service_mocks.MockLibraryService)
+	req := model.TransferRequest{BookUid: bookUid, FromLibraryUid: fromUid, ToLibraryUid: toUid, Copies: 2}
+	requestedAt, err := time.Parse(time.RFC3339, requested)
+	require.NoError(t, err)
+
+	var tests = []struct {
+		name         string
+		body         string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "ok",
+			body: `{"bookUid":"` + bookUid + `","fromLibraryUid":"` + fromUid + `","toLibraryUid":"` + toUid + `","copies":2}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					RequestTransfer(gomock.Any(), "librarian", req).
+					Return(model.Transfer{TransferUid: transfer, BookUid: bookUid, FromLibraryUid: fromUid, ToLibraryUid: toUid,
+						Copies: 2, Status: model.TransferInTransit, RequestedBy: "librarian", RequestedAt: requestedAt}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"transferUid":"` + transfer + `","bookUid":"` + bookUid + `","fromLibraryUid":"` + fromUid +
+				`","toLibraryUid":"` + toUid + `","copies":2,"status":"IN_TRANSIT","requestedBy":"librarian","requestedAt":"` + requested + `"}`,
+		},
+		{
+			name: "err. not enough copies",
+			body: `{"bookUid":"` + bookUid + `","fromLibraryUid":"` + fromUid + `","toLibraryUid":"` + toUid + `","copies":2}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {
+				r.EXPECT().
+					RequestTransfer(gomock.Any(), "librarian", req).
+					Return(model.Transfer{}, errs.ErrNotEnoughCopies)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "err. same library",
+			body:         `{"bookUid":"` + bookUid + `","fromLibraryUid":"` + fromUid + `","toLibraryUid":"` + fromUid + `","copies":2}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "err. no copies",
+			body:         `{"bookUid":"` + bookUid + `","fromLibraryUid":"` + fromUid + `","toLibraryUid":"` + toUid + `","copies":0}`,
+			mockBehavior: func(r *service_mocks.MockLibraryService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := gomock.NewController(t)
+			svc := service_mocks.NewMockLibraryService(c)
+			h := handler.New(svc, zap.NewNop())
+
+			e := echo.New()
+			e.Validator = validate.NewCustomValidator()
+			e.POST("/transfers", h.RequestTransfer)
+
+			r := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(tt.body)).
+				WithContext(auth.SetAuthContext(context.Background(), "librarian", "librarian"))
+			r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			w := httptest.NewRecorder()
+
+			tt.mockBehavior(svc)
+			e.ServeHTTP(w, r)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				require.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Ready(t *testing.T) {
+	t.Parallel()
+	var ready error = errors.New("not ready: consumer")
+	h := handler.New(service_mocks.NewMockLibraryService(gomock.NewController(t)), zap.NewNop())
+	e := h.NewRouter(func() error { return ready })
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "not ready: consumer", w.Body.String())
+
+	ready = nil
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+	require.Equal(t, http.StatusOK, w.Code)
+}
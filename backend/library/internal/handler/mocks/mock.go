@@ -6,9 +6,12 @@ package mock_handler
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	model "github.com/Astemirdum/library-service/backend/library/internal/model"
+	calendar "github.com/Astemirdum/library-service/backend/pkg/calendar"
+	paging "github.com/Astemirdum/library-service/backend/pkg/paging"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,18 +38,153 @@ func (m *MockLibraryService) EXPECT() *MockLibraryServiceMockRecorder {
 	return m.recorder
 }
 
-// AvailableCount mocks base method.
-func (m *MockLibraryService) AvailableCount(ctx context.Context, libraryID, bookID int, isReturn bool) error {
+// AddAuthorAlias mocks base method.
+func (m *MockLibraryService) AddAuthorAlias(ctx context.Context, actor string, req model.AuthorAliasRequest) (model.Author, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AvailableCount", ctx, libraryID, bookID, isReturn)
+	ret := m.ctrl.Call(m, "AddAuthorAlias", ctx, actor, req)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAuthorAlias indicates an expected call of AddAuthorAlias.
+func (mr *MockLibraryServiceMockRecorder) AddAuthorAlias(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthorAlias", reflect.TypeOf((*MockLibraryService)(nil).AddAuthorAlias), ctx, actor, req)
+}
+
+// ArchiveLibrary mocks base method.
+func (m *MockLibraryService) ArchiveLibrary(ctx context.Context, actor, libraryUid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveLibrary", ctx, actor, libraryUid)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// ArchiveLibrary indicates an expected call of ArchiveLibrary.
+func (mr *MockLibraryServiceMockRecorder) ArchiveLibrary(ctx, actor, libraryUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveLibrary", reflect.TypeOf((*MockLibraryService)(nil).ArchiveLibrary), ctx, actor, libraryUid)
+}
+
+// AvailableCount mocks base method.
+func (m *MockLibraryService) AvailableCount(ctx context.Context, req model.AvailableCountRequest) (model.BookCopy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvailableCount", ctx, req)
+	ret0, _ := ret[0].(model.BookCopy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // AvailableCount indicates an expected call of AvailableCount.
-func (mr *MockLibraryServiceMockRecorder) AvailableCount(ctx, libraryID, bookID, isReturn interface{}) *gomock.Call {
+func (mr *MockLibraryServiceMockRecorder) AvailableCount(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailableCount", reflect.TypeOf((*MockLibraryService)(nil).AvailableCount), ctx, req)
+}
+
+// BooksByAuthor mocks base method.
+func (m *MockLibraryService) BooksByAuthor(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BooksByAuthor", ctx, req)
+	ret0, _ := ret[0].(model.ListCatalogBooks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BooksByAuthor indicates an expected call of BooksByAuthor.
+func (mr *MockLibraryServiceMockRecorder) BooksByAuthor(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BooksByAuthor", reflect.TypeOf((*MockLibraryService)(nil).BooksByAuthor), ctx, req)
+}
+
+// BooksByGenre mocks base method.
+func (m *MockLibraryService) BooksByGenre(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BooksByGenre", ctx, req)
+	ret0, _ := ret[0].(model.ListCatalogBooks)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BooksByGenre indicates an expected call of BooksByGenre.
+func (mr *MockLibraryServiceMockRecorder) BooksByGenre(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BooksByGenre", reflect.TypeOf((*MockLibraryService)(nil).BooksByGenre), ctx, req)
+}
+
+// CancelTransfer mocks base method.
+func (m *MockLibraryService) CancelTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTransfer", ctx, actor, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelTransfer indicates an expected call of CancelTransfer.
+func (mr *MockLibraryServiceMockRecorder) CancelTransfer(ctx, actor, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTransfer", reflect.TypeOf((*MockLibraryService)(nil).CancelTransfer), ctx, actor, transferUid)
+}
+
+// CreateBook mocks base method.
+func (m *MockLibraryService) CreateBook(ctx context.Context, actor string, req model.BookRequest) (model.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBook", ctx, actor, req)
+	ret0, _ := ret[0].(model.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBook indicates an expected call of CreateBook.
+func (mr *MockLibraryServiceMockRecorder) CreateBook(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBook", reflect.TypeOf((*MockLibraryService)(nil).CreateBook), ctx, actor, req)
+}
+
+// CreateGenre mocks base method.
+func (m *MockLibraryService) CreateGenre(ctx context.Context, actor string, req model.GenreRequest) (model.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGenre", ctx, actor, req)
+	ret0, _ := ret[0].(model.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGenre indicates an expected call of CreateGenre.
+func (mr *MockLibraryServiceMockRecorder) CreateGenre(ctx, actor, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvailableCount", reflect.TypeOf((*MockLibraryService)(nil).AvailableCount), ctx, libraryID, bookID, isReturn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGenre", reflect.TypeOf((*MockLibraryService)(nil).CreateGenre), ctx, actor, req)
+}
+
+// CreateLibrary mocks base method.
+func (m *MockLibraryService) CreateLibrary(ctx context.Context, actor string, req model.LibraryRequest) (model.Library, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLibrary", ctx, actor, req)
+	ret0, _ := ret[0].(model.Library)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLibrary indicates an expected call of CreateLibrary.
+func (mr *MockLibraryServiceMockRecorder) CreateLibrary(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLibrary", reflect.TypeOf((*MockLibraryService)(nil).CreateLibrary), ctx, actor, req)
+}
+
+// EnrichBook mocks base method.
+func (m *MockLibraryService) EnrichBook(ctx context.Context, actor string, req model.EnrichBookRequest) (model.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrichBook", ctx, actor, req)
+	ret0, _ := ret[0].(model.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrichBook indicates an expected call of EnrichBook.
+func (mr *MockLibraryServiceMockRecorder) EnrichBook(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrichBook", reflect.TypeOf((*MockLibraryService)(nil).EnrichBook), ctx, actor, req)
 }
 
 // GetBook mocks base method.
@@ -64,6 +202,21 @@ func (mr *MockLibraryServiceMockRecorder) GetBook(ctx, libraryUid, bookUid inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockLibraryService)(nil).GetBook), ctx, libraryUid, bookUid)
 }
 
+// GetImport mocks base method.
+func (m *MockLibraryService) GetImport(ctx context.Context, importUid string) (model.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImport", ctx, importUid)
+	ret0, _ := ret[0].(model.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImport indicates an expected call of GetImport.
+func (mr *MockLibraryServiceMockRecorder) GetImport(ctx, importUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImport", reflect.TypeOf((*MockLibraryService)(nil).GetImport), ctx, importUid)
+}
+
 // GetLibrary mocks base method.
 func (m *MockLibraryService) GetLibrary(ctx context.Context, libraryUid string) (model.Library, error) {
 	m.ctrl.T.Helper()
@@ -79,32 +232,302 @@ func (mr *MockLibraryServiceMockRecorder) GetLibrary(ctx, libraryUid interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLibrary", reflect.TypeOf((*MockLibraryService)(nil).GetLibrary), ctx, libraryUid)
 }
 
+// GetTransfer mocks base method.
+func (m *MockLibraryService) GetTransfer(ctx context.Context, transferUid string) (model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", ctx, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockLibraryServiceMockRecorder) GetTransfer(ctx, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockLibraryService)(nil).GetTransfer), ctx, transferUid)
+}
+
+// Import mocks base method.
+func (m *MockLibraryService) Import(ctx context.Context, actor, libraryUid string, format model.ImportFormat, file io.ReadSeeker) (model.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, actor, libraryUid, format, file)
+	ret0, _ := ret[0].(model.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockLibraryServiceMockRecorder) Import(ctx, actor, libraryUid, format, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockLibraryService)(nil).Import), ctx, actor, libraryUid, format, file)
+}
+
+// ListAudit mocks base method.
+func (m *MockLibraryService) ListAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAudit", ctx, entityUid)
+	ret0, _ := ret[0].([]model.AuditRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAudit indicates an expected call of ListAudit.
+func (mr *MockLibraryServiceMockRecorder) ListAudit(ctx, entityUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAudit", reflect.TypeOf((*MockLibraryService)(nil).ListAudit), ctx, entityUid)
+}
+
+// ListAuthors mocks base method.
+func (m *MockLibraryService) ListAuthors(ctx context.Context, req model.ListAuthorsRequest) (model.ListAuthors, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuthors", ctx, req)
+	ret0, _ := ret[0].(model.ListAuthors)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuthors indicates an expected call of ListAuthors.
+func (mr *MockLibraryServiceMockRecorder) ListAuthors(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuthors", reflect.TypeOf((*MockLibraryService)(nil).ListAuthors), ctx, req)
+}
+
 // ListBooks mocks base method.
-func (m *MockLibraryService) ListBooks(ctx context.Context, libraryUid string, showAll bool, page, size int) (model.ListBooks, error) {
+func (m *MockLibraryService) ListBooks(ctx context.Context, libraryUid string, showAll bool, req paging.Request) (model.ListBooks, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBooks", ctx, libraryUid, showAll, page, size)
+	ret := m.ctrl.Call(m, "ListBooks", ctx, libraryUid, showAll, req)
 	ret0, _ := ret[0].(model.ListBooks)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListBooks indicates an expected call of ListBooks.
-func (mr *MockLibraryServiceMockRecorder) ListBooks(ctx, libraryUid, showAll, page, size interface{}) *gomock.Call {
+func (mr *MockLibraryServiceMockRecorder) ListBooks(ctx, libraryUid, showAll, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBooks", reflect.TypeOf((*MockLibraryService)(nil).ListBooks), ctx, libraryUid, showAll, page, size)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBooks", reflect.TypeOf((*MockLibraryService)(nil).ListBooks), ctx, libraryUid, showAll, req)
+}
+
+// ListCopies mocks base method.
+func (m *MockLibraryService) ListCopies(ctx context.Context, libraryUid, bookUid string) ([]model.BookCopy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCopies", ctx, libraryUid, bookUid)
+	ret0, _ := ret[0].([]model.BookCopy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCopies indicates an expected call of ListCopies.
+func (mr *MockLibraryServiceMockRecorder) ListCopies(ctx, libraryUid, bookUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCopies", reflect.TypeOf((*MockLibraryService)(nil).ListCopies), ctx, libraryUid, bookUid)
+}
+
+// ListGenres mocks base method.
+func (m *MockLibraryService) ListGenres(ctx context.Context) ([]model.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGenres", ctx)
+	ret0, _ := ret[0].([]model.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGenres indicates an expected call of ListGenres.
+func (mr *MockLibraryServiceMockRecorder) ListGenres(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGenres", reflect.TypeOf((*MockLibraryService)(nil).ListGenres), ctx)
 }
 
 // ListLibrary mocks base method.
-func (m *MockLibraryService) ListLibrary(ctx context.Context, city string, page, size int) (model.ListLibraries, error) {
+func (m *MockLibraryService) ListLibrary(ctx context.Context, city string, req paging.Request) (model.ListLibraries, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListLibrary", ctx, city, page, size)
+	ret := m.ctrl.Call(m, "ListLibrary", ctx, city, req)
 	ret0, _ := ret[0].(model.ListLibraries)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListLibrary indicates an expected call of ListLibrary.
-func (mr *MockLibraryServiceMockRecorder) ListLibrary(ctx, city, page, size interface{}) *gomock.Call {
+func (mr *MockLibraryServiceMockRecorder) ListLibrary(ctx, city, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLibrary", reflect.TypeOf((*MockLibraryService)(nil).ListLibrary), ctx, city, req)
+}
+
+// ListTransfers mocks base method.
+func (m *MockLibraryService) ListTransfers(ctx context.Context, req model.ListTransfersRequest) (model.ListTransfers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", ctx, req)
+	ret0, _ := ret[0].(model.ListTransfers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockLibraryServiceMockRecorder) ListTransfers(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockLibraryService)(nil).ListTransfers), ctx, req)
+}
+
+// MergeAuthors mocks base method.
+func (m *MockLibraryService) MergeAuthors(ctx context.Context, actor string, req model.MergeAuthorsRequest) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeAuthors", ctx, actor, req)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeAuthors indicates an expected call of MergeAuthors.
+func (mr *MockLibraryServiceMockRecorder) MergeAuthors(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeAuthors", reflect.TypeOf((*MockLibraryService)(nil).MergeAuthors), ctx, actor, req)
+}
+
+// NearbyLibraries mocks base method.
+func (m *MockLibraryService) NearbyLibraries(ctx context.Context, req model.NearbyLibrariesRequest) (model.ListNearbyLibraries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NearbyLibraries", ctx, req)
+	ret0, _ := ret[0].(model.ListNearbyLibraries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NearbyLibraries indicates an expected call of NearbyLibraries.
+func (mr *MockLibraryServiceMockRecorder) NearbyLibraries(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NearbyLibraries", reflect.TypeOf((*MockLibraryService)(nil).NearbyLibraries), ctx, req)
+}
+
+// ReceiveTransfer mocks base method.
+func (m *MockLibraryService) ReceiveTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveTransfer", ctx, actor, transferUid)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReceiveTransfer indicates an expected call of ReceiveTransfer.
+func (mr *MockLibraryServiceMockRecorder) ReceiveTransfer(ctx, actor, transferUid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveTransfer", reflect.TypeOf((*MockLibraryService)(nil).ReceiveTransfer), ctx, actor, transferUid)
+}
+
+// RequestTransfer mocks base method.
+func (m *MockLibraryService) RequestTransfer(ctx context.Context, actor string, req model.TransferRequest) (model.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestTransfer", ctx, actor, req)
+	ret0, _ := ret[0].(model.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestTransfer indicates an expected call of RequestTransfer.
+func (mr *MockLibraryServiceMockRecorder) RequestTransfer(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestTransfer", reflect.TypeOf((*MockLibraryService)(nil).RequestTransfer), ctx, actor, req)
+}
+
+// SearchBooks mocks base method.
+func (m *MockLibraryService) SearchBooks(ctx context.Context, req model.SearchBooksRequest) (model.ListBookHits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchBooks", ctx, req)
+	ret0, _ := ret[0].(model.ListBookHits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchBooks indicates an expected call of SearchBooks.
+func (mr *MockLibraryServiceMockRecorder) SearchBooks(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchBooks", reflect.TypeOf((*MockLibraryService)(nil).SearchBooks), ctx, req)
+}
+
+// SetCopyStatus mocks base method.
+func (m *MockLibraryService) SetCopyStatus(ctx context.Context, req model.CopyStatusRequest) (model.BookCopy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCopyStatus", ctx, req)
+	ret0, _ := ret[0].(model.BookCopy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCopyStatus indicates an expected call of SetCopyStatus.
+func (mr *MockLibraryServiceMockRecorder) SetCopyStatus(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCopyStatus", reflect.TypeOf((*MockLibraryService)(nil).SetCopyStatus), ctx, req)
+}
+
+// SetSchedule mocks base method.
+func (m *MockLibraryService) SetSchedule(ctx context.Context, actor string, req model.ScheduleRequest) (calendar.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, actor, req)
+	ret0, _ := ret[0].(calendar.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockLibraryServiceMockRecorder) SetSchedule(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockLibraryService)(nil).SetSchedule), ctx, actor, req)
+}
+
+// SetStock mocks base method.
+func (m *MockLibraryService) SetStock(ctx context.Context, actor string, req model.StockRequest) (model.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStock", ctx, actor, req)
+	ret0, _ := ret[0].(model.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetStock indicates an expected call of SetStock.
+func (mr *MockLibraryServiceMockRecorder) SetStock(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStock", reflect.TypeOf((*MockLibraryService)(nil).SetStock), ctx, actor, req)
+}
+
+// UpdateBook mocks base method.
+func (m *MockLibraryService) UpdateBook(ctx context.Context, actor string, req model.UpdateBookRequest) (model.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, actor, req)
+	ret0, _ := ret[0].(model.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockLibraryServiceMockRecorder) UpdateBook(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockLibraryService)(nil).UpdateBook), ctx, actor, req)
+}
+
+// UpdateGenre mocks base method.
+func (m *MockLibraryService) UpdateGenre(ctx context.Context, actor string, req model.UpdateGenreRequest) (model.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenre", ctx, actor, req)
+	ret0, _ := ret[0].(model.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateGenre indicates an expected call of UpdateGenre.
+func (mr *MockLibraryServiceMockRecorder) UpdateGenre(ctx, actor, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenre", reflect.TypeOf((*MockLibraryService)(nil).UpdateGenre), ctx, actor, req)
+}
+
+// UpdateLibrary mocks base method.
+func (m *MockLibraryService) UpdateLibrary(ctx context.Context, actor string, req model.UpdateLibraryRequest) (model.Library, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLibrary", ctx, actor, req)
+	ret0, _ := ret[0].(model.Library)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLibrary indicates an expected call of UpdateLibrary.
+func (mr *MockLibraryServiceMockRecorder) UpdateLibrary(ctx, actor, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLibrary", reflect.TypeOf((*MockLibraryService)(nil).ListLibrary), ctx, city, page, size)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLibrary", reflect.TypeOf((*MockLibraryService)(nil).UpdateLibrary), ctx, actor, req)
 }
@@ -2,19 +2,50 @@ package handler
 
 import (
 	"context"
+	"io"
 
 	"github.com/Astemirdum/library-service/backend/library/internal/model"
 	"github.com/Astemirdum/library-service/backend/library/internal/service"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 )
 
 //go:generate go run github.com/golang/mock/mockgen -source=service.go -destination=mocks/mock.go
 
 type LibraryService interface {
-	ListLibrary(ctx context.Context, city string, page, size int) (model.ListLibraries, error)
-	ListBooks(ctx context.Context, libraryUid string, showAll bool, page, size int) (model.ListBooks, error)
+	ListLibrary(ctx context.Context, city string, req paging.Request) (model.ListLibraries, error)
+	ListBooks(ctx context.Context, libraryUid string, showAll bool, req paging.Request) (model.ListBooks, error)
+	SearchBooks(ctx context.Context, req model.SearchBooksRequest) (model.ListBookHits, error)
+	NearbyLibraries(ctx context.Context, req model.NearbyLibrariesRequest) (model.ListNearbyLibraries, error)
 	GetBook(ctx context.Context, libraryUid, bookUid string) (model.Book, error)
 	GetLibrary(ctx context.Context, libraryUid string) (model.Library, error)
-	AvailableCount(ctx context.Context, libraryID, bookID int, isReturn bool) error
+	AvailableCount(ctx context.Context, req model.AvailableCountRequest) (model.BookCopy, error)
+	ListCopies(ctx context.Context, libraryUid, bookUid string) ([]model.BookCopy, error)
+	SetCopyStatus(ctx context.Context, req model.CopyStatusRequest) (model.BookCopy, error)
+	CreateLibrary(ctx context.Context, actor string, req model.LibraryRequest) (model.Library, error)
+	UpdateLibrary(ctx context.Context, actor string, req model.UpdateLibraryRequest) (model.Library, error)
+	ArchiveLibrary(ctx context.Context, actor, libraryUid string) error
+	SetSchedule(ctx context.Context, actor string, req model.ScheduleRequest) (calendar.Schedule, error)
+	ListAuthors(ctx context.Context, req model.ListAuthorsRequest) (model.ListAuthors, error)
+	AddAuthorAlias(ctx context.Context, actor string, req model.AuthorAliasRequest) (model.Author, error)
+	MergeAuthors(ctx context.Context, actor string, req model.MergeAuthorsRequest) (model.Author, error)
+	BooksByAuthor(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error)
+	ListGenres(ctx context.Context) ([]model.Genre, error)
+	CreateGenre(ctx context.Context, actor string, req model.GenreRequest) (model.Genre, error)
+	UpdateGenre(ctx context.Context, actor string, req model.UpdateGenreRequest) (model.Genre, error)
+	BooksByGenre(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error)
+	CreateBook(ctx context.Context, actor string, req model.BookRequest) (model.Book, error)
+	UpdateBook(ctx context.Context, actor string, req model.UpdateBookRequest) (model.Book, error)
+	EnrichBook(ctx context.Context, actor string, req model.EnrichBookRequest) (model.Book, error)
+	SetStock(ctx context.Context, actor string, req model.StockRequest) (model.Stock, error)
+	RequestTransfer(ctx context.Context, actor string, req model.TransferRequest) (model.Transfer, error)
+	ReceiveTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error)
+	CancelTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error)
+	GetTransfer(ctx context.Context, transferUid string) (model.Transfer, error)
+	ListTransfers(ctx context.Context, req model.ListTransfersRequest) (model.ListTransfers, error)
+	ListAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, error)
+	Import(ctx context.Context, actor, libraryUid string, format model.ImportFormat, file io.ReadSeeker) (model.ImportReport, error)
+	GetImport(ctx context.Context, importUid string) (model.ImportReport, error)
 }
 
 var _ LibraryService = (*service.Service)(nil)
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// GetAuthors lists the authors with their aliases, a query narrows them to the aliases containing it.
+func (h *Handler) GetAuthors(c echo.Context) error {
+	var req model.ListAuthorsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	authors, err := h.librarySvc.ListAuthors(c.Request().Context(), req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, authors)
+}
+
+// GetAuthorBooks lists the books of an author across the libraries.
+func (h *Handler) GetAuthorBooks(c echo.Context) error {
+	req, err := bindCatalogBooks(c, "authorUid")
+	if err != nil {
+		return err
+	}
+	books, err := h.librarySvc.BooksByAuthor(c.Request().Context(), req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, books)
+}
+
+// GetGenres lists all the genres, the subgenres refer to their parents.
+func (h *Handler) GetGenres(c echo.Context) error {
+	genres, err := h.librarySvc.ListGenres(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, genres)
+}
+
+// GetGenreBooks lists the books of a genre and of its subgenres across the libraries.
+func (h *Handler) GetGenreBooks(c echo.Context) error {
+	req, err := bindCatalogBooks(c, "genreUid")
+	if err != nil {
+		return err
+	}
+	books, err := h.librarySvc.BooksByGenre(c.Request().Context(), req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, books)
+}
+
+func bindCatalogBooks(c echo.Context, param string) (model.CatalogBooksRequest, error) {
+	var req model.CatalogBooksRequest
+	if err := c.Bind(&req); err != nil {
+		return req, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	req.Uid = c.Param(param)
+	if err := c.Validate(req); err != nil {
+		return req, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return req, nil
+}
+
+// AddAuthorAlias adds a name the author is known by, in another script for one.
+func (h *Handler) AddAuthorAlias(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.AuthorAliasRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	author, err := h.librarySvc.AddAuthorAlias(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, author)
+}
+
+// MergeAuthors merges a duplicate author into the author of the path.
+func (h *Handler) MergeAuthors(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.MergeAuthorsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	author, err := h.librarySvc.MergeAuthors(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, author)
+}
+
+func (h *Handler) CreateGenre(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.GenreRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	genre, err := h.librarySvc.CreateGenre(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusCreated, genre)
+}
+
+func (h *Handler) UpdateGenre(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.UpdateGenreRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	genre, err := h.librarySvc.UpdateGenre(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, genre)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTransfer sends copies of a book on the shelf of a library to another library.
+func (h *Handler) RequestTransfer(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	var req model.TransferRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	transfer, err := h.librarySvc.RequestTransfer(c.Request().Context(), actor, req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusCreated, transfer)
+}
+
+// ReceiveTransfer puts the copies of the transfer on the shelf of the library they are sent to.
+func (h *Handler) ReceiveTransfer(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	transfer, err := h.librarySvc.ReceiveTransfer(c.Request().Context(), actor, c.Param("transferUid"))
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, transfer)
+}
+
+// CancelTransfer puts the copies of the transfer back on the shelf of the library they are sent from.
+func (h *Handler) CancelTransfer(c echo.Context) error {
+	actor, err := librarian(c)
+	if err != nil {
+		return err
+	}
+	transfer, err := h.librarySvc.CancelTransfer(c.Request().Context(), actor, c.Param("transferUid"))
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, transfer)
+}
+
+func (h *Handler) GetTransfer(c echo.Context) error {
+	if _, err := librarian(c); err != nil {
+		return err
+	}
+	transfer, err := h.librarySvc.GetTransfer(c.Request().Context(), c.Param("transferUid"))
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, transfer)
+}
+
+// GetTransfers lists the transfers from or to a library, the latest first.
+func (h *Handler) GetTransfers(c echo.Context) error {
+	if _, err := librarian(c); err != nil {
+		return err
+	}
+	var req model.ListTransfersRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	transfers, err := h.librarySvc.ListTransfers(c.Request().Context(), req)
+	if err != nil {
+		return catalogError(err)
+	}
+	return c.JSON(http.StatusOK, transfers)
+}
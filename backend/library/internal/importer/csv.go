@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/pkg/errors"
+)
+
+// csvReader reads CSV files with a header row. The title column, or name, is required, the isbn,
+// author, genre, condition and copies columns are optional. A book gets one copy if copies is empty.
+type csvReader struct {
+	r    *csv.Reader
+	cols map[string]int
+	row  int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "csv header")
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "name" {
+			name = "title"
+		}
+		cols[name] = i
+	}
+	if _, ok := cols["title"]; !ok {
+		return nil, errors.New("csv header has no title column")
+	}
+	return &csvReader{r: cr, cols: cols}, nil
+}
+
+func (r *csvReader) Next() (model.CatalogRecord, error) {
+	fields, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return model.CatalogRecord{}, io.EOF
+	}
+	r.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return model.CatalogRecord{}, &RowError{Row: r.row, Err: parseErr.Err}
+	}
+	if err != nil {
+		return model.CatalogRecord{}, err
+	}
+
+	field := func(name string) string {
+		if i, ok := r.cols[name]; ok && i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	rec := model.CatalogRecord{
+		Row:       r.row,
+		ISBN:      field("isbn"),
+		Title:     field("title"),
+		Author:    field("author"),
+		Genre:     field("genre"),
+		Condition: model.Condition(field("condition")),
+		Copies:    1,
+	}
+	if copies := strings.TrimSpace(field("copies")); copies != "" {
+		if rec.Copies, err = strconv.Atoi(copies); err != nil {
+			return model.CatalogRecord{}, &RowError{Row: r.row, Err: errors.Errorf("invalid copies %q", copies)}
+		}
+	}
+	return rec, nil
+}
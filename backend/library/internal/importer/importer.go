@@ -0,0 +1,132 @@
+// Package importer reads the books of the catalog import files.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/isbn"
+	"github.com/pkg/errors"
+)
+
+// Reader reads the records of an import file one by one.
+type Reader interface {
+	// Next returns the next record or io.EOF at the end of the file. A *RowError is the error of
+	// a single record, the next records can still be read.
+	Next() (model.CatalogRecord, error)
+}
+
+// RowError is the error of a single record of an import file.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// NewReader reads the records of r in format.
+func NewReader(format model.ImportFormat, r io.Reader) (Reader, error) {
+	switch format {
+	case model.ImportCSV:
+		return newCSVReader(r)
+	case model.ImportMARC21:
+		return newMARCReader(r), nil
+	}
+	return nil, errors.Errorf("unknown import format %q", format)
+}
+
+// FormatOf guesses the format of a file by its name.
+func FormatOf(name string) (model.ImportFormat, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return model.ImportCSV, true
+	case ".mrc", ".marc", ".iso":
+		return model.ImportMARC21, true
+	}
+	return "", false
+}
+
+const (
+	maxFieldLen = 255
+	maxCopies   = 1000
+)
+
+// Normalize validates the record and brings its fields into the form they are stored in.
+func Normalize(rec *model.CatalogRecord) error {
+	rec.Title = strings.TrimSpace(rec.Title)
+	rec.Author = strings.TrimSpace(rec.Author)
+	rec.Genre = strings.TrimSpace(rec.Genre)
+	rec.Condition = model.Condition(strings.ToUpper(strings.TrimSpace(string(rec.Condition))))
+	switch {
+	case rec.Title == "":
+		return errors.New("title is required")
+	case len(rec.Title) > maxFieldLen, len(rec.Author) > maxFieldLen, len(rec.Genre) > maxFieldLen:
+		return errors.Errorf("title, author and genre are limited to %d bytes", maxFieldLen)
+	case rec.Copies < 0 || rec.Copies > maxCopies:
+		return errors.Errorf("copies must be between 0 and %d", maxCopies)
+	}
+	switch rec.Condition {
+	case "", model.ConditionExcellent, model.ConditionGood, model.ConditionBad:
+	default:
+		return errors.Errorf("unknown condition %q", rec.Condition)
+	}
+	var err error
+	rec.ISBN, err = NormalizeISBN(rec.ISBN)
+	return err
+}
+
+// NormalizeISBN strips the qualifiers, as "(pbk.)", from an ISBN-10 or ISBN-13 and returns the ISBN-13.
+func NormalizeISBN(s string) (string, error) {
+	if i := strings.IndexAny(s, "(:;"); i >= 0 {
+		s = s[:i]
+	}
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	return isbn.Normalize(s)
+}
+
+// Key is the key books are deduplicated by: the ISBN or, without one, the title and the author.
+func Key(rec model.CatalogRecord) string {
+	if rec.ISBN != "" {
+		return "isbn:" + rec.ISBN
+	}
+	return "book:" + strings.ToLower(rec.Title) + "\x00" + strings.ToLower(rec.Author)
+}
+
+// Batch collects deduplicated records, the copies of the duplicates add up to the first record.
+type Batch struct {
+	records []model.CatalogRecord
+	index   map[string]int
+}
+
+func (b *Batch) Add(rec model.CatalogRecord) {
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+	key := Key(rec)
+	if i, ok := b.index[key]; ok {
+		b.records[i].Copies += rec.Copies
+		return
+	}
+	b.index[key] = len(b.records)
+	b.records = append(b.records, rec)
+}
+
+func (b *Batch) Records() []model.CatalogRecord {
+	return b.records
+}
+
+func (b *Batch) Reset() {
+	b.records = b.records[:0]
+	clear(b.index)
+}
@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, r Reader) ([]model.CatalogRecord, []*RowError) {
+	t.Helper()
+	var (
+		records []model.CatalogRecord
+		rowErrs []*RowError
+	)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return records, rowErrs
+		}
+		if rowErr, ok := err.(*RowError); ok {
+			rowErrs = append(rowErrs, rowErr)
+			continue
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	t.Parallel()
+	file := "\ufeffISBN,Name,Author,Genre,Condition,Copies\n" +
+		"978-5-00-000000-1,Краткий курс C++ в 7 томах,Бьерн Страуструп,Научная фантастика,good,2\n" +
+		",Without isbn,Someone,,,\n" +
+		"123,Broken,Someone,,,x\n"
+	r, err := NewReader(model.ImportCSV, strings.NewReader(file))
+	require.NoError(t, err)
+	records, rowErrs := readAll(t, r)
+
+	require.Equal(t, []model.CatalogRecord{
+		{Row: 1, ISBN: "978-5-00-000000-1", Title: "Краткий курс C++ в 7 томах", Author: "Бьерн Страуструп", Genre: "Научная фантастика", Condition: "good", Copies: 2},
+		{Row: 2, Title: "Without isbn", Author: "Someone", Copies: 1},
+	}, records)
+	require.Len(t, rowErrs, 1)
+	require.Equal(t, 3, rowErrs[0].Row)
+
+	_, err = NewReader(model.ImportCSV, strings.NewReader("isbn,author\n"))
+	require.Error(t, err, "no title column")
+}
+
+// marcRecord encodes the fields, tag and data without the field terminator, as an ISO 2709 record.
+func marcRecord(fields ...[2]string) []byte {
+	var directory, data bytes.Buffer
+	for _, f := range fields {
+		fmt.Fprintf(&directory, "%s%04d%05d", f[0], len(f[1])+1, data.Len())
+		data.WriteString(f[1])
+		data.WriteByte(fieldTerminator)
+	}
+	directory.WriteByte(fieldTerminator)
+	base := leaderLen + directory.Len()
+	length := base + data.Len() + 1
+	rec := []byte(fmt.Sprintf("%05dnam a22%05d   4500", length, base))
+	rec = append(rec, directory.Bytes()...)
+	rec = append(rec, data.Bytes()...)
+	return append(rec, recordTerminator)
+}
+
+func TestMARCReader(t *testing.T) {
+	t.Parallel()
+	sf := func(code byte, v string) string { return string([]byte{subfieldDelimiter, code}) + v }
+	var file bytes.Buffer
+	file.Write(marcRecord(
+		[2]string{"001", "ocm0001"},
+		[2]string{"020", "  " + sf('a', "0-306-40615-2 (pbk.)")},
+		[2]string{"100", "1 " + sf('a', "Knuth, Donald E.,")},
+		[2]string{"245", "10" + sf('a', "The art of computer programming :") + sf('b', "fundamental algorithms /") + sf('c', "Donald E. Knuth.")},
+		[2]string{"650", " 0" + sf('a', "Computer programming.")},
+		[2]string{"852", "  " + sf('b', "main")},
+		[2]string{"852", "  " + sf('b', "annex")},
+	))
+	file.WriteString("\n")
+	broken := marcRecord([2]string{"245", "10" + sf('a', "Broken")})
+	copy(broken[0:5], "99999")
+	file.Write(broken)
+	file.Write(marcRecord([2]string{"245", "10" + sf('a', "Only a title.")}))
+
+	r, err := NewReader(model.ImportMARC21, &file)
+	require.NoError(t, err)
+	records, rowErrs := readAll(t, r)
+
+	require.Equal(t, []model.CatalogRecord{
+		{Row: 1, ISBN: "0-306-40615-2 (pbk.)", Title: "The art of computer programming fundamental algorithms", Author: "Knuth, Donald E", Genre: "Computer programming", Copies: 2},
+		{Row: 3, Title: "Only a title.", Copies: 1},
+	}, records)
+	require.Len(t, rowErrs, 1)
+	require.Equal(t, 2, rowErrs[0].Row)
+}
+
+func TestParseMARC_InvalidNumbers(t *testing.T) {
+	t.Parallel()
+	valid := marcRecord([2]string{"245", "10" + string([]byte{subfieldDelimiter, 'a'}) + "Title"})
+	entry := leaderLen // the directory entry of 245
+	tests := []struct {
+		name  string
+		at    int
+		value string
+	}{
+		{name: "negative length of a field", at: entry + 3, value: "-001"},
+		{name: "negative start of a field", at: entry + 7, value: "-0009"},
+		{name: "signed start of a field", at: entry + 7, value: "+0000"},
+		{name: "signed base address", at: 12, value: "+0037"},
+		{name: "spaces in the record length", at: 0, value: "  047"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			raw := bytes.Clone(valid)
+			copy(raw[tt.at:], tt.value)
+			require.NotPanics(t, func() {
+				_, err := parseMARC(raw)
+				require.Error(t, err)
+			})
+		})
+	}
+	_, err := parseMARC(valid)
+	require.NoError(t, err)
+}
+
+func FuzzParseMARC(f *testing.F) {
+	f.Add(marcRecord([2]string{"245", "10" + string([]byte{subfieldDelimiter, 'a'}) + "Title"}))
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		_, _ = parseMARC(raw) //nolint:errcheck
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	rec := model.CatalogRecord{ISBN: "0-306-40615-2 (pbk.)", Title: " Title ", Condition: "good", Copies: 1}
+	require.NoError(t, Normalize(&rec))
+	require.Equal(t, model.CatalogRecord{ISBN: "9780306406157", Title: "Title", Condition: model.ConditionGood, Copies: 1}, rec)
+
+	for name, rec := range map[string]model.CatalogRecord{
+		"no title":          {ISBN: "0306406152"},
+		"invalid isbn":      {Title: "Title", ISBN: "12345"},
+		"isbn check digit":  {Title: "Title", ISBN: "0-306-40615-3"},
+		"unknown condition": {Title: "Title", Condition: "NEW"},
+		"negative copies":   {Title: "Title", Copies: -1},
+	} {
+		rec := rec
+		require.Error(t, Normalize(&rec), name)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	var b Batch
+	b.Add(model.CatalogRecord{Row: 1, ISBN: "0306406152", Title: "A", Copies: 1})
+	b.Add(model.CatalogRecord{Row: 2, ISBN: "0306406152", Title: "A, 2nd print", Copies: 2})
+	b.Add(model.CatalogRecord{Row: 3, Title: "B", Author: "X", Copies: 1})
+	b.Add(model.CatalogRecord{Row: 4, Title: "b", Author: "x", Copies: 1})
+	require.Equal(t, []model.CatalogRecord{
+		{Row: 1, ISBN: "0306406152", Title: "A", Copies: 3},
+		{Row: 3, Title: "B", Author: "X", Copies: 2},
+	}, b.Records())
+
+	b.Reset()
+	require.Empty(t, b.Records())
+}
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/pkg/errors"
+)
+
+// ISO 2709 structure of the MARC21 records.
+const (
+	recordTerminator  = 0x1D
+	fieldTerminator   = 0x1E
+	subfieldDelimiter = 0x1F
+
+	leaderLen         = 24
+	directoryEntryLen = 12
+)
+
+// marcReader reads MARC21 bibliographic records in ISO 2709. The book is taken from 020$a (ISBN),
+// 245$a$b (title), 100$a, 110$a or 700$a (author) and 650$a or 655$a (genre), every 852 holdings
+// field is a copy. Records are split by the record terminator, so a broken record does not break
+// the records after it. Only UTF-8 records are supported.
+type marcReader struct {
+	r   *bufio.Reader
+	row int
+}
+
+func newMARCReader(r io.Reader) *marcReader {
+	return &marcReader{r: bufio.NewReader(r)}
+}
+
+func (r *marcReader) Next() (model.CatalogRecord, error) {
+	for {
+		raw, err := r.r.ReadBytes(recordTerminator)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return model.CatalogRecord{}, err
+		}
+		raw = bytes.TrimLeft(raw, "\r\n ")
+		if len(raw) == 0 {
+			if err != nil {
+				return model.CatalogRecord{}, io.EOF
+			}
+			continue
+		}
+		r.row++
+		if err != nil {
+			return model.CatalogRecord{}, &RowError{Row: r.row, Err: errors.New("record is truncated")}
+		}
+		rec, err := parseMARC(raw)
+		if err != nil {
+			return model.CatalogRecord{}, &RowError{Row: r.row, Err: err}
+		}
+		rec.Row = r.row
+		return rec, nil
+	}
+}
+
+func parseMARC(raw []byte) (model.CatalogRecord, error) {
+	if len(raw) < leaderLen+1 {
+		return model.CatalogRecord{}, errors.New("record is too short")
+	}
+	recordLen, ok := digits(raw[0:5])
+	if !ok || recordLen != len(raw) {
+		return model.CatalogRecord{}, errors.Errorf("record length %q does not match %d bytes", raw[0:5], len(raw))
+	}
+	base, ok := digits(raw[12:17])
+	if !ok || base <= leaderLen || base > len(raw) || raw[base-1] != fieldTerminator {
+		return model.CatalogRecord{}, errors.Errorf("invalid base address of data %q", raw[12:17])
+	}
+	directory := raw[leaderLen : base-1]
+	if len(directory)%directoryEntryLen != 0 {
+		return model.CatalogRecord{}, errors.New("invalid directory")
+	}
+
+	var (
+		rec      model.CatalogRecord
+		authors  = map[string]string{}
+		genres   = map[string]string{}
+		holdings int
+	)
+	for i := 0; i < len(directory); i += directoryEntryLen {
+		entry := directory[i : i+directoryEntryLen]
+		tag := string(entry[0:3])
+		length, ok1 := digits(entry[3:7])
+		start, ok2 := digits(entry[7:12])
+		if !ok1 || !ok2 || base+start+length > len(raw) {
+			return model.CatalogRecord{}, errors.Errorf("invalid directory entry %q", entry)
+		}
+		field := bytes.TrimSuffix(raw[base+start:base+start+length], []byte{fieldTerminator})
+		if !utf8.Valid(field) {
+			return model.CatalogRecord{}, errors.Errorf("field %s is not UTF-8", tag)
+		}
+		if tag < "010" {
+			continue
+		}
+		switch tag {
+		case "020":
+			if rec.ISBN == "" {
+				rec.ISBN = subfield(field, 'a')
+			}
+		case "245":
+			if rec.Title == "" {
+				rec.Title = strings.TrimSpace(trimISBD(subfield(field, 'a')) + " " + trimISBD(subfield(field, 'b')))
+			}
+		case "100", "110", "700":
+			if _, ok := authors[tag]; !ok {
+				authors[tag] = strings.TrimRight(subfield(field, 'a'), " ,.")
+			}
+		case "650", "655":
+			if _, ok := genres[tag]; !ok {
+				genres[tag] = strings.TrimRight(subfield(field, 'a'), " .")
+			}
+		case "852":
+			holdings++
+		}
+	}
+	rec.Author = firstOf(authors, "100", "110", "700")
+	rec.Genre = firstOf(genres, "650", "655")
+	rec.Copies = max(holdings, 1)
+	return rec, nil
+}
+
+// digits parses the unsigned decimal number of a leader or directory field, unlike strconv.Atoi it
+// rejects a sign, so an offset is never negative.
+func digits(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+// subfield returns the first subfield code of a data field, the indicators are skipped.
+func subfield(field []byte, code byte) string {
+	if len(field) < 2 {
+		return ""
+	}
+	for _, sf := range bytes.Split(field[2:], []byte{subfieldDelimiter}) {
+		if len(sf) > 0 && sf[0] == code {
+			return strings.TrimSpace(string(sf[1:]))
+		}
+	}
+	return ""
+}
+
+// trimISBD trims the ISBD punctuation ending the title subfields.
+func trimISBD(s string) string {
+	return strings.TrimRight(s, " /:;,=")
+}
+
+func firstOf(values map[string]string, tags ...string) string {
+	for _, tag := range tags {
+		if v := values[tag]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
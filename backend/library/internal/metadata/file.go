@@ -0,0 +1,45 @@
+// Package metadata provides the metadata of books by ISBN to enrich the catalog from.
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/isbn"
+	"github.com/pkg/errors"
+)
+
+// FileProvider looks up the metadata in a JSON file of model.BookMetadata records, it is read once.
+type FileProvider struct {
+	books map[string]model.BookMetadata
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []model.BookMetadata
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, errors.Wrapf(err, "read %s", path)
+	}
+	books := make(map[string]model.BookMetadata, len(records))
+	for i, rec := range records {
+		if rec.ISBN, err = isbn.Normalize(rec.ISBN); err != nil {
+			return nil, errors.Wrapf(err, "record %d of %s", i+1, path)
+		}
+		books[rec.ISBN] = rec
+	}
+	return &FileProvider{books: books}, nil
+}
+
+func (p *FileProvider) Lookup(_ context.Context, isbn13 string) (model.BookMetadata, error) {
+	book, ok := p.books[isbn13]
+	if !ok {
+		return model.BookMetadata{}, errs.ErrNoMetadata
+	}
+	return book, nil
+}
@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/pkg/errors"
+)
+
+// isbnPlaceholder is replaced by the ISBN-13 in the URL of an HTTPProvider.
+const isbnPlaceholder = "{isbn}"
+
+// HTTPProvider looks up the metadata with a GET of the URL, which answers a model.BookMetadata
+// or 404 Not Found for the books it does not know.
+type HTTPProvider struct {
+	client *http.Client
+	url    string
+}
+
+// NewHTTPProvider makes the provider of the URL, {isbn} in it stands for the ISBN-13 of the book:
+// http://metadata/books/{isbn} for one.
+func NewHTTPProvider(url string, timeout time.Duration) (*HTTPProvider, error) {
+	if !strings.Contains(url, isbnPlaceholder) {
+		return nil, errors.Errorf("metadata url %q has no %s", url, isbnPlaceholder)
+	}
+	return &HTTPProvider{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+	}, nil
+}
+
+func (p *HTTPProvider) Lookup(ctx context.Context, isbn string) (model.BookMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(p.url, isbnPlaceholder, isbn), http.NoBody)
+	if err != nil {
+		return model.BookMetadata{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return model.BookMetadata{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return model.BookMetadata{}, errs.ErrNoMetadata
+	case resp.StatusCode != http.StatusOK:
+		return model.BookMetadata{}, errors.Errorf("metadata of %s: %s", isbn, resp.Status)
+	}
+	var book model.BookMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&book); err != nil {
+		return model.BookMetadata{}, errors.Wrapf(err, "metadata of %s", isbn)
+	}
+	book.ISBN = isbn
+	return book, nil
+}
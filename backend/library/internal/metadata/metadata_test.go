@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "books.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"isbn": "0-306-40615-2", "title": "Signals", "author": "Someone", "coverUrl": "http://covers/1.jpg"}
+	]`), 0o600))
+
+	p, err := NewFileProvider(path)
+	require.NoError(t, err)
+	book, err := p.Lookup(context.Background(), "9780306406157")
+	require.NoError(t, err)
+	require.Equal(t, model.BookMetadata{ISBN: "9780306406157", Title: "Signals", Author: "Someone", CoverURL: "http://covers/1.jpg"}, book)
+
+	_, err = p.Lookup(context.Background(), "9780804429573")
+	require.ErrorIs(t, err, errs.ErrNoMetadata)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"isbn": "12345"}]`), 0o600))
+	_, err = NewFileProvider(path)
+	require.Error(t, err)
+}
+
+func TestHTTPProvider(t *testing.T) {
+	t.Parallel()
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/books/9780306406157":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"title": "Signals", "author": "Someone", "description": "About signals"}`))
+		case "/books/9780804429573":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer stub.Close()
+
+	_, err := NewHTTPProvider(stub.URL+"/books", time.Second)
+	require.Error(t, err)
+
+	p, err := NewHTTPProvider(stub.URL+"/books/{isbn}", time.Second)
+	require.NoError(t, err)
+	book, err := p.Lookup(context.Background(), "9780306406157")
+	require.NoError(t, err)
+	require.Equal(t, model.BookMetadata{ISBN: "9780306406157", Title: "Signals", Author: "Someone", Description: "About signals"}, book)
+
+	_, err = p.Lookup(context.Background(), "9791090636071")
+	require.ErrorIs(t, err, errs.ErrNoMetadata)
+
+	_, err = p.Lookup(context.Background(), "9780804429573")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errs.ErrNoMetadata)
+}
@@ -1,13 +1,20 @@
 package model
 
+import (
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+)
+
 type ListLibraries struct {
-	Paging `json:",inline"`
-	Items  []Library `json:"items"`
+	paging.Page `json:",inline"`
+	Items       []Library `json:"items"`
 }
 
 type ListBooks struct {
-	Paging `json:",inline"`
-	Items  []Book `json:"items"`
+	paging.Page `json:",inline"`
+	Items       []Book `json:"items"`
 }
 
 type Paging struct {
@@ -23,6 +30,10 @@ type Book struct {
 	Author         string    `json:"author" db:"author"`
 	Genre          string    `json:"genre" db:"genre"`
 	Condition      Condition `json:"condition" db:"condition"`
+	ISBN           string    `json:"isbn,omitempty" db:"isbn"`
+	ISBN10         string    `json:"isbn10,omitempty" db:"isbn10"`
+	CoverURL       string    `json:"coverUrl,omitempty" db:"cover_url"`
+	Description    string    `json:"description,omitempty" db:"description"`
 	AvailableCount int       `json:"availableCount" db:"available_count"`
 }
 
@@ -40,10 +51,421 @@ type Library struct {
 	Name       string `json:"name" db:"name"`
 	Address    string `json:"address" db:"address"`
 	City       string `json:"city" db:"city"`
+	// ArchivedAt is set once the library is archived, archived libraries lend no copies.
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" db:"archived_at"`
+	// Latitude and Longitude locate the library, they are both set or both nil.
+	Latitude  *float64 `json:"latitude,omitempty" db:"latitude"`
+	Longitude *float64 `json:"longitude,omitempty" db:"longitude"`
+	// Schedule is the opening hours with the upcoming exception dates, it is loaded with a single library only.
+	Schedule *calendar.Schedule `json:"schedule,omitempty" db:"-"`
 }
 
 type AvailableCountRequest struct {
-	LibraryID int  `json:"libraryID"`
-	BookID    int  `json:"bookID"`
-	IsReturn  bool `json:"isReturn"`
+	EventID   string `json:"eventID"`
+	LibraryID int    `json:"libraryID"`
+	BookID    int    `json:"bookID"`
+	IsReturn  bool   `json:"isReturn"`
+	// ReservationUid is the reservation a copy is lent on or returned from.
+	ReservationUid string `json:"reservationUid" validate:"omitempty,uuid"`
+	// Condition is the condition of a returned copy.
+	Condition Condition `json:"condition" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	// CopyUid is the copy to lend, a lend of any copy on the shelf if empty.
+	CopyUid string `json:"copyUid" validate:"omitempty,uuid"`
+}
+
+type CopyStatus string
+
+const (
+	CopyOnShelf  CopyStatus = "ON_SHELF"
+	CopyOnLoan   CopyStatus = "ON_LOAN"
+	CopyInRepair CopyStatus = "IN_REPAIR"
+	CopyLost     CopyStatus = "LOST"
+	// CopyWithdrawn copies were taken out of the stock.
+	CopyWithdrawn CopyStatus = "WITHDRAWN"
+	// CopyInTransit copies are on the way to another library, they are counted in neither.
+	CopyInTransit CopyStatus = "IN_TRANSIT"
+)
+
+// BookCopy is a physical copy of a book in a library.
+type BookCopy struct {
+	ID             int        `json:"-" db:"id"`
+	CopyUid        string     `json:"copyUid" db:"copy_uid"`
+	Barcode        string     `json:"barcode" db:"barcode"`
+	BookID         int        `json:"bookId" db:"book_id"`
+	LibraryID      int        `json:"libraryId" db:"library_id"`
+	Condition      Condition  `json:"condition" db:"condition"`
+	Status         CopyStatus `json:"status" db:"status"`
+	AcquiredAt     time.Time  `json:"acquiredAt" db:"acquired_at"`
+	ReservationUid *string    `json:"reservationUid,omitempty" db:"reservation_uid"`
+	UpdatedAt      time.Time  `json:"updatedAt" db:"updated_at"`
+	// TransferID is the transfer that moved the copy last.
+	TransferID *int `json:"-" db:"transfer_id"`
+}
+
+// CopyStatusRequest takes a copy off the shelf or puts it back. Copies on loan change with reservations only.
+type CopyStatusRequest struct {
+	CopyUid   string     `param:"copyUid" validate:"required,uuid"`
+	Status    CopyStatus `json:"status" validate:"required,oneof=ON_SHELF IN_REPAIR LOST"`
+	Condition Condition  `json:"condition" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+}
+
+type LibraryRequest struct {
+	Name    string `json:"name" validate:"required,max=80"`
+	City    string `json:"city" validate:"required,max=255"`
+	Address string `json:"address" validate:"required,max=255"`
+	// Latitude and Longitude locate the library, they are set together.
+	Latitude  *float64 `json:"latitude,omitempty" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude,omitempty" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+}
+
+// UpdateLibraryRequest changes the fields that are set.
+type UpdateLibraryRequest struct {
+	LibraryUid string   `json:"-" param:"libraryUid" validate:"required,uuid"`
+	Name       *string  `json:"name,omitempty" validate:"omitempty,min=1,max=80"`
+	City       *string  `json:"city,omitempty" validate:"omitempty,min=1,max=255"`
+	Address    *string  `json:"address,omitempty" validate:"omitempty,min=1,max=255"`
+	Latitude   *float64 `json:"latitude,omitempty" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+	Longitude  *float64 `json:"longitude,omitempty" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+}
+
+// ScheduleRequest replaces the opening hours and the exception dates of a library.
+type ScheduleRequest struct {
+	LibraryUid        string `json:"-" param:"libraryUid" validate:"required,uuid"`
+	calendar.Schedule `json:",inline"`
+}
+
+// BookRequest adds a book. With the ISBN set the fields left empty are filled from the metadata
+// of the book, the name is then not required.
+type BookRequest struct {
+	ISBN        string    `json:"isbn,omitempty" validate:"omitempty,max=17"`
+	Name        string    `json:"name" validate:"required_without=ISBN,max=255"`
+	Author      string    `json:"author" validate:"max=255"`
+	Genre       string    `json:"genre" validate:"max=255"`
+	Condition   Condition `json:"condition" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	CoverURL    string    `json:"coverUrl,omitempty" validate:"omitempty,url,max=1024"`
+	Description string    `json:"description,omitempty" validate:"max=10000"`
+}
+
+// UpdateBookRequest changes the fields that are set, an empty ISBN, cover or description is removed.
+type UpdateBookRequest struct {
+	BookUid     string     `json:"-" param:"bookUid" validate:"required,uuid"`
+	ISBN        *string    `json:"isbn,omitempty" validate:"omitempty,max=17"`
+	Name        *string    `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Author      *string    `json:"author,omitempty" validate:"omitempty,max=255"`
+	Genre       *string    `json:"genre,omitempty" validate:"omitempty,max=255"`
+	Condition   *Condition `json:"condition,omitempty" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	CoverURL    *string    `json:"coverUrl,omitempty" validate:"omitempty,len=0|url,max=1024"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=10000"`
+}
+
+// EnrichBookRequest fills the book from the metadata of its ISBN. Only the empty fields are filled
+// unless Overwrite is set.
+type EnrichBookRequest struct {
+	BookUid   string `json:"-" param:"bookUid" validate:"required,uuid"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// BookMetadata is what a metadata provider knows of a book by its ISBN.
+type BookMetadata struct {
+	ISBN        string `json:"isbn"`
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	CoverURL    string `json:"coverUrl"`
+	Description string `json:"description"`
+}
+
+// StockRequest sets the number of copies of a book a library holds. New copies are registered in
+// Condition, surplus copies are withdrawn from the shelf.
+type StockRequest struct {
+	LibraryUid string    `json:"-" param:"libraryUid" validate:"required,uuid"`
+	BookUid    string    `json:"-" param:"bookUid" validate:"required,uuid"`
+	Stock      int       `json:"stock" validate:"gte=0,lte=1000"`
+	Condition  Condition `json:"condition" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+}
+
+// Stock counts the copies of a book a library holds, lost and withdrawn copies are not counted.
+type Stock struct {
+	LibraryUid     string `json:"libraryUid"`
+	BookUid        string `json:"bookUid"`
+	Stock          int    `json:"stock"`
+	AvailableCount int    `json:"availableCount"`
+}
+
+type (
+	AuditEntity string
+	AuditAction string
+)
+
+const (
+	AuditLibrary  AuditEntity = "LIBRARY"
+	AuditBook     AuditEntity = "BOOK"
+	AuditStock    AuditEntity = "STOCK"
+	AuditAuthor   AuditEntity = "AUTHOR"
+	AuditGenre    AuditEntity = "GENRE"
+	AuditTransfer AuditEntity = "TRANSFER"
+
+	AuditCreate   AuditAction = "CREATE"
+	AuditUpdate   AuditAction = "UPDATE"
+	AuditArchive  AuditAction = "ARCHIVE"
+	AuditSetStock AuditAction = "SET_STOCK"
+	AuditImport   AuditAction = "IMPORT"
+	AuditMerge    AuditAction = "MERGE"
+	AuditReceive  AuditAction = "RECEIVE"
+	AuditCancel   AuditAction = "CANCEL"
+)
+
+// AuditRecord is a change a librarian made to the catalog.
+type AuditRecord struct {
+	ID        int64          `json:"id" db:"id"`
+	Entity    AuditEntity    `json:"entity" db:"entity"`
+	EntityUid string         `json:"entityUid" db:"entity_uid"`
+	Action    AuditAction    `json:"action" db:"action"`
+	Actor     string         `json:"actor" db:"actor"`
+	Changes   map[string]any `json:"changes" db:"changes"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+}
+
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "CSV"
+	ImportMARC21 ImportFormat = "MARC21"
+)
+
+type ImportStatus string
+
+const (
+	ImportInProgress ImportStatus = "IN_PROGRESS"
+	ImportDone       ImportStatus = "DONE"
+)
+
+// CatalogRecord is a book read from an import file, Row is its number in the file.
+type CatalogRecord struct {
+	Row       int
+	ISBN      string
+	Title     string
+	Author    string
+	Genre     string
+	Condition Condition
+	// Copies is the number of copies of the book the library gets.
+	Copies int
+}
+
+// Import is an import of a file into the catalog of a library.
+type Import struct {
+	ID         int          `json:"-" db:"id"`
+	ImportUid  string       `json:"importUid" db:"import_uid"`
+	LibraryID  int          `json:"-" db:"library_id"`
+	Format     ImportFormat `json:"format" db:"format"`
+	Checksum   string       `json:"-" db:"checksum"`
+	Status     ImportStatus `json:"status" db:"status"`
+	RowsDone   int          `json:"rowsDone" db:"rows_done"`
+	RowsFailed int          `json:"rowsFailed" db:"rows_failed"`
+	CreatedAt  time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updatedAt" db:"updated_at"`
+}
+
+type ImportError struct {
+	Row   int    `json:"row" db:"row_num"`
+	Error string `json:"error" db:"error"`
+}
+
+// ImportReport is the progress of an import with the errors of the rows that were not imported.
+type ImportReport struct {
+	Import `json:",inline"`
+	Errors []ImportError `json:"errors"`
+}
+
+// SearchBooksRequest searches the books of all libraries by title, author and genre. Genre narrows
+// them to a genre by its uid or its name in any script. City and Available narrow the libraries a
+// book is held by, books held by none of them are not found.
+type SearchBooksRequest struct {
+	Query     string `query:"q" validate:"required,min=2,max=200"`
+	City      string `query:"city"`
+	Genre     string `query:"genre"`
+	Available bool   `query:"available"`
+	Page      int    `query:"page" validate:"gte=0"`
+	Size      int    `query:"size" validate:"gte=0,lte=100"`
+}
+
+type ListBookHits struct {
+	Paging `json:",inline"`
+	Items  []BookHit `json:"items"`
+}
+
+// BookHit is a found book with the libraries that hold it.
+type BookHit struct {
+	BookUid   string    `json:"bookUid" db:"book_uid"`
+	Name      string    `json:"name" db:"name"`
+	Author    string    `json:"author" db:"author"`
+	Genre     string    `json:"genre" db:"genre"`
+	Condition Condition `json:"condition" db:"condition"`
+	Rank      float64   `json:"rank" db:"rank"`
+	// Highlight is an HTML snippet of the title, author and genre with the matches in <mark> tags.
+	Highlight string        `json:"highlight" db:"highlight"`
+	Libraries []BookHolding `json:"libraries" db:"libraries"`
+	Total     int           `json:"-" db:"total"`
+}
+
+type BookHolding struct {
+	LibraryUid     string `json:"libraryUid"`
+	Name           string `json:"name"`
+	City           string `json:"city"`
+	Address        string `json:"address"`
+	AvailableCount int    `json:"availableCount"`
+}
+
+// NearbyLibrariesRequest looks up the libraries within Radius kilometers of a point.
+// With BookUid set only the libraries having the book in stock are found.
+type NearbyLibrariesRequest struct {
+	Lat     *float64 `query:"lat" validate:"required,gte=-90,lte=90"`
+	Lon     *float64 `query:"lon" validate:"required,gte=-180,lte=180"`
+	Radius  float64  `query:"radius" validate:"gte=0,lte=500"`
+	BookUid string   `query:"bookUid" validate:"omitempty,uuid"`
+	Size    int      `query:"size" validate:"gte=0,lte=100"`
+}
+
+type ListNearbyLibraries struct {
+	Items []NearbyLibrary `json:"items"`
+}
+
+// NearbyLibrary is a library with its distance from the point looked up.
+type NearbyLibrary struct {
+	Library
+	DistanceKm float64 `json:"distanceKm" db:"distance_km"`
+	// AvailableCount is the stock of the book looked up for.
+	AvailableCount *int `json:"availableCount,omitempty" db:"available_count"`
+}
+
+// Author is a writer of books, known by the aliases in any script and word order.
+type Author struct {
+	ID        int      `json:"-" db:"id"`
+	AuthorUid string   `json:"authorUid" db:"author_uid"`
+	Name      string   `json:"name" db:"name"`
+	Aliases   []string `json:"aliases" db:"aliases"`
+	BookCount int      `json:"bookCount" db:"book_count"`
+}
+
+type ListAuthors struct {
+	paging.Page `json:",inline"`
+	Items       []Author `json:"items"`
+}
+
+// ListAuthorsRequest lists the authors, with Query set only those having an alias containing it.
+type ListAuthorsRequest struct {
+	Query string `query:"q" validate:"max=200"`
+	paging.Request
+}
+
+// AuthorAliasRequest adds a name the author is known by.
+type AuthorAliasRequest struct {
+	AuthorUid string `json:"-" param:"authorUid" validate:"required,uuid"`
+	Alias     string `json:"alias" validate:"required,max=255"`
+}
+
+// MergeAuthorsRequest merges the author of SourceUid into the author of AuthorUid, the books and
+// the aliases of the source pass to the author.
+type MergeAuthorsRequest struct {
+	AuthorUid string `json:"-" param:"authorUid" validate:"required,uuid"`
+	SourceUid string `json:"authorUid" validate:"required,uuid,nefield=AuthorUid"`
+}
+
+// Genre is a genre of books, genres make a hierarchy by their parents.
+type Genre struct {
+	ID        int     `json:"-" db:"id"`
+	GenreUid  string  `json:"genreUid" db:"genre_uid"`
+	Name      string  `json:"name" db:"name"`
+	ParentUid *string `json:"parentUid,omitempty" db:"parent_uid"`
+	// BookCount counts the books of the genre, the books of its subgenres are not counted.
+	BookCount int `json:"bookCount" db:"book_count"`
+}
+
+type GenreRequest struct {
+	Name      string `json:"name" validate:"required,max=255"`
+	ParentUid string `json:"parentUid" validate:"omitempty,uuid"`
+}
+
+// UpdateGenreRequest changes the fields that are set, an empty ParentUid makes the genre a top one.
+type UpdateGenreRequest struct {
+	GenreUid  string  `json:"-" param:"genreUid" validate:"required,uuid"`
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	ParentUid *string `json:"parentUid,omitempty" validate:"omitempty,len=0|uuid"`
+}
+
+// CatalogBooksRequest lists the books of the author or the genre of Uid across the libraries.
+type CatalogBooksRequest struct {
+	Uid string `json:"-" validate:"required,uuid"`
+	paging.Request
+}
+
+type ListCatalogBooks struct {
+	paging.Page `json:",inline"`
+	Items       []CatalogBook `json:"items"`
+}
+
+// CatalogBook is a book with its authors and genres and the libraries that hold it.
+type CatalogBook struct {
+	ID        int           `json:"-" db:"id"`
+	BookUid   string        `json:"bookUid" db:"book_uid"`
+	Name      string        `json:"name" db:"name"`
+	Author    string        `json:"author" db:"author"`
+	Genre     string        `json:"genre" db:"genre"`
+	Condition Condition     `json:"condition" db:"condition"`
+	Authors   []AuthorRef   `json:"authors" db:"authors"`
+	Genres    []GenreRef    `json:"genres" db:"genres"`
+	Libraries []BookHolding `json:"libraries" db:"libraries"`
+}
+
+type AuthorRef struct {
+	AuthorUid string `json:"authorUid"`
+	Name      string `json:"name"`
+}
+
+type GenreRef struct {
+	GenreUid string `json:"genreUid"`
+	Name     string `json:"name"`
+}
+
+type TransferStatus string
+
+const (
+	TransferInTransit TransferStatus = "IN_TRANSIT"
+	TransferReceived  TransferStatus = "RECEIVED"
+	TransferCancelled TransferStatus = "CANCELLED"
+)
+
+// TransferRequest sends Copies copies of a book on the shelf of a library to another library.
+type TransferRequest struct {
+	BookUid        string `json:"bookUid" validate:"required,uuid"`
+	FromLibraryUid string `json:"fromLibraryUid" validate:"required,uuid"`
+	ToLibraryUid   string `json:"toLibraryUid" validate:"required,uuid,nefield=FromLibraryUid"`
+	Copies         int    `json:"copies" validate:"min=1,max=1000"`
+}
+
+// Transfer moves copies of a book between libraries. It is closed when the copies are received
+// or, if it is cancelled, put back on the shelf they were sent from.
+type Transfer struct {
+	ID             int            `json:"-" db:"id"`
+	TransferUid    string         `json:"transferUid" db:"transfer_uid"`
+	BookUid        string         `json:"bookUid" db:"book_uid"`
+	FromLibraryUid string         `json:"fromLibraryUid" db:"from_library_uid"`
+	ToLibraryUid   string         `json:"toLibraryUid" db:"to_library_uid"`
+	Copies         int            `json:"copies" db:"copies"`
+	Status         TransferStatus `json:"status" db:"status"`
+	RequestedBy    string         `json:"requestedBy" db:"requested_by"`
+	RequestedAt    time.Time      `json:"requestedAt" db:"requested_at"`
+	ClosedBy       *string        `json:"closedBy,omitempty" db:"closed_by"`
+	ClosedAt       *time.Time     `json:"closedAt,omitempty" db:"closed_at"`
+}
+
+// ListTransfersRequest lists the transfers from or to the library, all of them without one.
+type ListTransfersRequest struct {
+	LibraryUid string         `query:"libraryUid" validate:"omitempty,uuid"`
+	Status     TransferStatus `query:"status" validate:"omitempty,oneof=IN_TRANSIT RECEIVED CANCELLED"`
+	paging.Request
+}
+
+type ListTransfers struct {
+	paging.Page `json:",inline"`
+	Items       []Transfer `json:"items"`
 }
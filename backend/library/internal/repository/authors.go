@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	authorsTableName     = `authors`
+	aliasesTableName     = `author_aliases`
+	bookAuthorsTableName = `book_authors`
+)
+
+// authorKeyset is the order of the lists of authors.
+var authorKeyset = paging.Keyset[model.Author]{
+	Sorts: map[string]paging.Column[model.Author]{
+		"name": {Expr: "a.name", Type: "text", Value: func(a model.Author) any { return a.Name }},
+	},
+	Key: paging.Column[model.Author]{Expr: "a.id", Type: "int", Value: func(a model.Author) any { return a.ID }},
+}
+
+// ListAuthors lists the authors by name unless asked otherwise, with a query only those having an
+// alias containing it.
+func (r *repository) ListAuthors(ctx context.Context, req model.ListAuthorsRequest) (model.ListAuthors, error) {
+	if req.Sort == "" {
+		req.Sort = "name"
+	}
+	pager, err := paging.NewPager(req.Request, authorKeyset)
+	if err != nil {
+		return model.ListAuthors{}, err
+	}
+	q := qb.Select().From(authorsTableName + " a")
+	if req.Query != "" {
+		q = q.Where(fmt.Sprintf(`exists (select 1 from %s al where al.author_id = a.id and al.alias ilike '%%' || ? || '%%')`,
+			aliasesTableName), req.Query)
+	}
+
+	query, args, err := pager.Apply(q.Columns("a.id", "a.author_uid", "a.name",
+		fmt.Sprintf(`(select array_agg(al.alias order by al.alias) from %s al where al.author_id = a.id) aliases`, aliasesTableName),
+		fmt.Sprintf(`(select count(*) from %s ba where ba.author_id = a.id) book_count`, bookAuthorsTableName),
+	)).ToSql()
+	if err != nil {
+		return model.ListAuthors{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return model.ListAuthors{}, err
+	}
+	authors, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Author])
+	if err != nil {
+		return model.ListAuthors{}, err
+	}
+	authors, page := pager.Collect(authors)
+
+	if req.WithTotal() {
+		var totalElements int
+		query, args, err := q.Column("count(*)").ToSql()
+		if err != nil {
+			return model.ListAuthors{}, err
+		}
+		if err := r.db.QueryRow(ctx, query, args...).Scan(&totalElements); err != nil {
+			return model.ListAuthors{}, err
+		}
+		page.TotalElements = &totalElements
+	}
+	return model.ListAuthors{
+		Page:  page,
+		Items: authors,
+	}, nil
+}
+
+// AddAuthorAlias adds a name the author is known by. It fails with errs.ErrAliasTaken if the alias
+// names another author, those are merged instead.
+func (r *repository) AddAuthorAlias(ctx context.Context, actor string, req model.AuthorAliasRequest) (model.Author, error) {
+	var author model.Author
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		id, err := authorID(ctx, tx, req.AuthorUid)
+		if err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`insert into %s (author_id, alias) values ($1, $2)
+		on conflict (alias_key) do nothing`, aliasesTableName), id, req.Alias)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			var owner int
+			if err := tx.QueryRow(ctx, fmt.Sprintf(`select author_id from %s where alias_key = translit_key($1)`, aliasesTableName),
+				req.Alias).Scan(&owner); err != nil {
+				return err
+			}
+			if owner != id {
+				return errs.ErrAliasTaken
+			}
+		} else if err := putAudit(ctx, tx, model.AuditAuthor, req.AuthorUid, model.AuditUpdate, actor, req); err != nil {
+			return err
+		}
+		author, err = getAuthor(ctx, tx, id)
+		return err
+	})
+	return author, err
+}
+
+// MergeAuthors passes the books and the aliases of the source author to the author and deletes the source.
+func (r *repository) MergeAuthors(ctx context.Context, actor string, req model.MergeAuthorsRequest) (model.Author, error) {
+	var author model.Author
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`select id from %s where author_uid in ($1, $2) order by id for update`, authorsTableName),
+			req.AuthorUid, req.SourceUid); err != nil {
+			return err
+		}
+		targetID, err := authorID(ctx, tx, req.AuthorUid)
+		if err != nil {
+			return err
+		}
+		sourceID, err := authorID(ctx, tx, req.SourceUid)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`insert into %[1]s (book_id, author_id)
+		select book_id, $1 from %[1]s where author_id = $2
+		on conflict do nothing`, bookAuthorsTableName), targetID, sourceID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`delete from %s where author_id = $1`, bookAuthorsTableName), sourceID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set author_id = $1 where author_id = $2`, aliasesTableName),
+			targetID, sourceID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`delete from %s where id = $1`, authorsTableName), sourceID); err != nil {
+			return err
+		}
+
+		if author, err = getAuthor(ctx, tx, targetID); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditAuthor, req.AuthorUid, model.AuditMerge, actor, req)
+	})
+	return author, err
+}
+
+// BooksByAuthor lists the books of the author across the libraries.
+func (r *repository) BooksByAuthor(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	if _, err := authorID(ctx, r.db, req.Uid); err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	return r.listCatalogBooks(ctx, fmt.Sprintf(`b.id in (
+		select ba.book_id from %s ba join %s a on a.id = ba.author_id where a.author_uid = ?
+	)`, bookAuthorsTableName, authorsTableName), req)
+}
+
+func getAuthor(ctx context.Context, q querier, id int) (model.Author, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(`select a.id, a.author_uid, a.name,
+		(select array_agg(al.alias order by al.alias) from %[2]s al where al.author_id = a.id) aliases,
+		(select count(*) from %[3]s ba where ba.author_id = a.id) book_count
+	from %[1]s a where a.id = $1`, authorsTableName, aliasesTableName, bookAuthorsTableName), id)
+	if err != nil {
+		return model.Author{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Author])
+}
+
+// authorID fails with errs.ErrNotFound if there is no author of the uid.
+func authorID(ctx context.Context, q querier, authorUid string) (int, error) {
+	var id int
+	err := q.QueryRow(ctx, fmt.Sprintf(`select id from %s where author_uid = $1`, authorsTableName), authorUid).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, errs.ErrNotFound
+	}
+	return id, err
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+const auditTableName = `catalog_audit`
+
+// stockStatuses are the statuses of the copies counted in the stock.
+var stockStatuses = []string{string(model.CopyOnShelf), string(model.CopyOnLoan), string(model.CopyInRepair)}
+
+func (r *repository) CreateLibrary(ctx context.Context, actor string, req model.LibraryRequest) (model.Library, error) {
+	var lib model.Library
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`insert into %s (library_uid, name, city, address, latitude, longitude)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id, library_uid, name, city, address, archived_at, latitude, longitude`, libraryTableName),
+			uuid.New(), req.Name, req.City, req.Address, req.Latitude, req.Longitude)
+		if err != nil {
+			return err
+		}
+		lib, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Library])
+		if err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditLibrary, lib.LibraryUid, model.AuditCreate, actor, req)
+	})
+	return lib, err
+}
+
+// UpdateLibrary changes the fields of req that are set, archived libraries do not change.
+func (r *repository) UpdateLibrary(ctx context.Context, actor string, req model.UpdateLibraryRequest) (model.Library, error) {
+	var lib model.Library
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := lockLibrary(ctx, tx, req.LibraryUid); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, fmt.Sprintf(`update %s set
+			name = coalesce(@name, name),
+			city = coalesce(@city, city),
+			address = coalesce(@address, address),
+			latitude = coalesce(@latitude, latitude),
+			longitude = coalesce(@longitude, longitude)
+		where library_uid = @library_uid
+		returning id, library_uid, name, city, address, archived_at, latitude, longitude`, libraryTableName), pgx.NamedArgs{
+			"name":        req.Name,
+			"city":        req.City,
+			"address":     req.Address,
+			"latitude":    req.Latitude,
+			"longitude":   req.Longitude,
+			"library_uid": req.LibraryUid,
+		})
+		if err != nil {
+			return err
+		}
+		lib, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Library])
+		if err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditLibrary, lib.LibraryUid, model.AuditUpdate, actor, req)
+	})
+	return lib, err
+}
+
+// ArchiveLibrary archives the library, archiving it again changes nothing.
+func (r *repository) ArchiveLibrary(ctx context.Context, actor, libraryUid string) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := lockLibrary(ctx, tx, libraryUid)
+		if errors.Is(err, errs.ErrLibraryArchived) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set archived_at = now() where library_uid = $1`, libraryTableName), libraryUid); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditLibrary, libraryUid, model.AuditArchive, actor, nil)
+	})
+}
+
+// lockLibrary locks the library against changes, it fails with errs.ErrLibraryArchived if the library is archived.
+func lockLibrary(ctx context.Context, tx pgx.Tx, libraryUid string) error {
+	var archived bool
+	err := tx.QueryRow(ctx, fmt.Sprintf(`select archived_at is not null from %s where library_uid = $1 for update`, libraryTableName),
+		libraryUid).Scan(&archived)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errs.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if archived {
+		return errs.ErrLibraryArchived
+	}
+	return nil
+}
+
+func (r *repository) CreateBook(ctx context.Context, actor string, req model.BookRequest) (model.Book, error) {
+	var book model.Book
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`insert into %s (book_uid, isbn, name, author, genre, condition, cover_url, description)
+		values (@book_uid, nullif(@isbn, ''), @name, @author, @genre, coalesce(nullif(@condition, ''), 'EXCELLENT'),
+			nullif(@cover_url, ''), nullif(@description, ''))
+		returning %s`, booksTableName, bookColumns), pgx.NamedArgs{
+			"book_uid":    uuid.New(),
+			"isbn":        req.ISBN,
+			"name":        req.Name,
+			"author":      req.Author,
+			"genre":       req.Genre,
+			"condition":   req.Condition,
+			"cover_url":   req.CoverURL,
+			"description": req.Description,
+		})
+		if err != nil {
+			return bookError(err)
+		}
+		book, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Book])
+		if err != nil {
+			return bookError(err)
+		}
+		return putAudit(ctx, tx, model.AuditBook, book.BookUid, model.AuditCreate, actor, req)
+	})
+	return book, err
+}
+
+// UpdateBook changes the fields of req that are set.
+func (r *repository) UpdateBook(ctx context.Context, actor string, req model.UpdateBookRequest) (model.Book, error) {
+	var book model.Book
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`update %s set
+			isbn = case when @isbn::text is null then isbn else nullif(@isbn::text, '') end,
+			name = coalesce(@name, name),
+			author = coalesce(@author, author),
+			genre = coalesce(@genre, genre),
+			condition = coalesce(@condition, condition),
+			cover_url = case when @cover_url::text is null then cover_url else nullif(@cover_url::text, '') end,
+			description = case when @description::text is null then description else nullif(@description::text, '') end
+		where book_uid = @book_uid
+		returning %s`, booksTableName, bookColumns), pgx.NamedArgs{
+			"isbn":        req.ISBN,
+			"name":        req.Name,
+			"author":      req.Author,
+			"genre":       req.Genre,
+			"condition":   req.Condition,
+			"cover_url":   req.CoverURL,
+			"description": req.Description,
+			"book_uid":    req.BookUid,
+		})
+		if err != nil {
+			return bookError(err)
+		}
+		book, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Book])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		if err != nil {
+			return bookError(err)
+		}
+		return putAudit(ctx, tx, model.AuditBook, book.BookUid, model.AuditUpdate, actor, req)
+	})
+	return book, err
+}
+
+// FindBook gets the book whatever library holds it.
+func (r *repository) FindBook(ctx context.Context, bookUid string) (model.Book, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`select %s from %s where book_uid = $1`, bookColumns, booksTableName), bookUid)
+	if err != nil {
+		return model.Book{}, err
+	}
+	book, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Book])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Book{}, errs.ErrNotFound
+	}
+	return book, err
+}
+
+// bookColumns are the columns of a changed book, the available count of a book depends on the library.
+const bookColumns = `id, book_uid, name, coalesce(author, '') author, coalesce(genre, '') genre, condition,
+	coalesce(isbn, '') isbn, coalesce(isbn10(isbn), '') isbn10, coalesce(cover_url, '') cover_url,
+	coalesce(description, '') description, 0 available_count`
+
+// bookError tells errs.ErrISBNTaken from the other errors of writing a book.
+func bookError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return errs.ErrISBNTaken
+	}
+	return err
+}
+
+// SetStock registers new copies of the book in the library or withdraws the surplus ones. Copies in
+// repair are withdrawn first, then the copies on the shelf in the worst condition. Copies on loan
+// are never withdrawn, so the stock can not go below them.
+func (r *repository) SetStock(ctx context.Context, actor string, req model.StockRequest) (model.Stock, error) {
+	stock := model.Stock{LibraryUid: req.LibraryUid, BookUid: req.BookUid}
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := lockLibrary(ctx, tx, req.LibraryUid); err != nil {
+			return err
+		}
+		var libraryID, bookID int
+		err := tx.QueryRow(ctx, fmt.Sprintf(`select l.id, b.id from %s l, %s b where l.library_uid = $1 and b.book_uid = $2`,
+			libraryTableName, booksTableName), req.LibraryUid, req.BookUid).Scan(&libraryID, &bookID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `insert into library_books (book_id, library_id) values ($1, $2) on conflict do nothing`,
+			bookID, libraryID); err != nil {
+			return err
+		}
+
+		var current, availableBefore int
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`select count(*) filter (where status = any($3)),
+			count(*) filter (where status = $4)
+		from %s where library_id = $1 and book_id = $2`, copiesTableName),
+			libraryID, bookID, stockStatuses, model.CopyOnShelf).Scan(&current, &availableBefore); err != nil {
+			return err
+		}
+		switch {
+		case req.Stock > current:
+			_, err = tx.Exec(ctx, fmt.Sprintf(`insert into %s (copy_uid, barcode, book_id, library_id, condition)
+			select n.uid, format('%%s-%%s-%%s', @library_id::int, @book_id::int, left(n.uid::text, 8)), @book_id, @library_id,
+				coalesce(nullif(@condition, ''), b.condition)
+			from (select gen_random_uuid() uid from generate_series(1, @n::int)) n, %s b
+			where b.id = @book_id`, copiesTableName, booksTableName), pgx.NamedArgs{
+				"library_id": libraryID,
+				"book_id":    bookID,
+				"condition":  req.Condition,
+				"n":          req.Stock - current,
+			})
+		case req.Stock < current:
+			var tag pgconn.CommandTag
+			tag, err = tx.Exec(ctx, fmt.Sprintf(`update %[1]s set status = @withdrawn, updated_at = now()
+			where id in (
+				select id from %[1]s
+				where library_id = @library_id and book_id = @book_id and status in (@in_repair, @on_shelf)
+				order by status = @on_shelf, array_position(array['BAD', 'GOOD', 'EXCELLENT'], condition::text), acquired_at desc, id desc
+				limit @n
+				for update
+			)`, copiesTableName), pgx.NamedArgs{
+				"withdrawn":  model.CopyWithdrawn,
+				"in_repair":  model.CopyInRepair,
+				"on_shelf":   model.CopyOnShelf,
+				"library_id": libraryID,
+				"book_id":    bookID,
+				"n":          current - req.Stock,
+			})
+			if err == nil && tag.RowsAffected() < int64(current-req.Stock) {
+				err = errs.ErrStockOnLoan
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`select available_count from %s where library_id = $1 and book_id = $2`, availableBooksViewName),
+			libraryID, bookID).Scan(&stock.AvailableCount); err != nil {
+			return err
+		}
+		stock.Stock = req.Stock
+		if delta := stock.AvailableCount - availableBefore; delta != 0 {
+			if err := putCountChanged(ctx, tx, libraryID, bookID, delta); err != nil {
+				return err
+			}
+		}
+		return putAudit(ctx, tx, model.AuditStock, req.BookUid, model.AuditSetStock, actor, map[string]any{
+			"libraryUid": req.LibraryUid,
+			"from":       current,
+			"to":         req.Stock,
+		})
+	})
+	return stock, err
+}
+
+// ListAudit lists the changes made to the entity, the latest first.
+func (r *repository) ListAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`select id, entity, entity_uid, action, actor, changes, created_at
+	from %s where entity_uid = $1
+	order by id desc`, auditTableName), entityUid)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.AuditRecord])
+}
+
+// putAudit records the change of the entity made by actor, changes is stored as JSON.
+func putAudit(ctx context.Context, tx pgx.Tx, entity model.AuditEntity, entityUid string, action model.AuditAction, actor string, changes any) error {
+	if changes == nil {
+		changes = map[string]any{}
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf(`insert into %s (entity, entity_uid, action, actor, changes) values ($1, $2, $3, $4, $5)`, auditTableName),
+		entity, entityUid, action, actor, changes)
+	return err
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	copiesTableName        = `book_copies`
+	availableBooksViewName = `available_books`
+)
+
+// AvailableCount lends a copy of the book on the reservation of req or takes it back. A copy in
+// the best condition is lent first, archived libraries lend none. The returned copy is the changed one, it is empty if there
+// was nothing to change.
+func (r *repository) AvailableCount(ctx context.Context, req model.AvailableCountRequest) (model.BookCopy, error) {
+	var bookCopy model.BookCopy
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		ok, err := postgres.MarkProcessed(ctx, tx, req.EventID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// a retried lend answers with the copy lent the first time.
+			if !req.IsReturn && req.ReservationUid != "" {
+				bookCopy, err = r.copyOfReservation(ctx, tx, req.ReservationUid)
+			}
+			return err
+		}
+		if req.IsReturn {
+			bookCopy, err = r.returnCopy(ctx, tx, req)
+		} else {
+			bookCopy, err = r.tryReserveCopy(ctx, tx, req)
+		}
+		if err != nil || bookCopy.ID == 0 {
+			return err
+		}
+
+		delta := -1
+		if req.IsReturn {
+			delta = 1
+		}
+		return putCountChanged(ctx, tx, req.LibraryID, req.BookID, delta)
+	})
+	return bookCopy, err
+}
+
+// putCountChanged puts the change of the available count of a book into the outbox.
+func putCountChanged(ctx context.Context, tx pgx.Tx, libraryID, bookID, delta int) error {
+	var availableCount int
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`select available_count from %s where library_id = $1 and book_id = $2`, availableBooksViewName),
+		libraryID, bookID).Scan(&availableCount); err != nil {
+		return err
+	}
+	env, err := kafka.NewEnvelope(ctx, kafka.EventBookCountChanged, producerName, "", kafka.BookCountChanged{
+		Timestamp:      time.Now(),
+		LibraryID:      libraryID,
+		BookID:         bookID,
+		Delta:          delta,
+		AvailableCount: availableCount,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.PutEvent(ctx, tx, kafka.LibraryEventsTopic, fmt.Sprintf("%d:%d", libraryID, bookID), env)
+}
+
+func (r *repository) copyOfReservation(ctx context.Context, tx pgx.Tx, reservationUID string) (model.BookCopy, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf(`select * from %s where reservation_uid = $1`, copiesTableName), reservationUID)
+	if err != nil {
+		return model.BookCopy{}, err
+	}
+	bookCopy, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.BookCopy])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.BookCopy{}, nil
+	}
+	return bookCopy, err
+}
+
+// reserveAttempts is how many times a lend reads the stock again after a change raced with it.
+const reserveAttempts = 3
+
+// tryReserveCopy lends a copy of the book, or the copy asked for, on the reservation only if the
+// library has it on the shelf, it fails with errs.ErrOutOfStock otherwise. The stock is claimed by bumping the version of
+// the library book it was read at, a lend that loses the race to another change reads it again.
+func (r *repository) tryReserveCopy(ctx context.Context, tx pgx.Tx, req model.AvailableCountRequest) (model.BookCopy, error) {
+	for attempt := 0; attempt < reserveAttempts; attempt++ {
+		var (
+			availableCount int
+			version        int64
+		)
+		err := tx.QueryRow(ctx, fmt.Sprintf(`select a.available_count, a.version from %s a
+		join %s l on l.id = a.library_id
+		where a.library_id = $1 and a.book_id = $2 and l.archived_at is null`, availableBooksViewName, libraryTableName),
+			req.LibraryID, req.BookID).Scan(&availableCount, &version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.BookCopy{}, errs.ErrOutOfStock
+		}
+		if err != nil {
+			return model.BookCopy{}, err
+		}
+		if availableCount == 0 {
+			return model.BookCopy{}, errs.ErrOutOfStock
+		}
+		tag, err := tx.Exec(ctx, `update library_books set version = version + 1
+		where library_id = $1 and book_id = $2 and version = $3`, req.LibraryID, req.BookID, version)
+		if err != nil {
+			return model.BookCopy{}, err
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+
+		q := fmt.Sprintf(`update %[1]s set status = @on_loan, reservation_uid = nullif(@reservation_uid, '')::uuid, updated_at = now()
+		where id = (
+			select id from %[1]s
+			where library_id = @library_id and book_id = @book_id and status = @on_shelf
+				and (@copy_uid = '' or copy_uid = nullif(@copy_uid, '')::uuid)
+			order by array_position(array['EXCELLENT', 'GOOD', 'BAD'], condition::text), acquired_at, id
+			limit 1
+			for update skip locked
+		)
+		returning *`, copiesTableName)
+		rows, err := tx.Query(ctx, q, pgx.NamedArgs{
+			"on_loan":         model.CopyOnLoan,
+			"on_shelf":        model.CopyOnShelf,
+			"reservation_uid": req.ReservationUid,
+			"copy_uid":        req.CopyUid,
+			"library_id":      req.LibraryID,
+			"book_id":         req.BookID,
+		})
+		if err != nil {
+			return model.BookCopy{}, err
+		}
+		bookCopy, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.BookCopy])
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the only copies on the shelf, or the copy asked for, are being taken off it.
+			return model.BookCopy{}, errs.ErrOutOfStock
+		}
+		return bookCopy, err
+	}
+	return model.BookCopy{}, errs.ErrStockChanged
+}
+
+// returnCopy puts the copy lent on the reservation back on the shelf. A reservation without a lent
+// copy was never lent, so nothing changes. Without a reservation, as for the loans made before
+// copies were tracked, any copy lent without one is taken back, or a new copy is registered.
+func (r *repository) returnCopy(ctx context.Context, tx pgx.Tx, req model.AvailableCountRequest) (model.BookCopy, error) {
+	var match string
+	if req.ReservationUid != "" {
+		match = `reservation_uid = @reservation_uid::uuid`
+	} else {
+		match = `reservation_uid is null`
+	}
+	q := fmt.Sprintf(`update %[1]s set status = @on_shelf, reservation_uid = null,
+		condition = coalesce(nullif(@condition, ''), condition), updated_at = now()
+	where id = (
+		select id from %[1]s
+		where library_id = @library_id and book_id = @book_id and status = @on_loan and %[2]s
+		limit 1
+		for update
+	)
+	returning *`, copiesTableName, match)
+	args := pgx.NamedArgs{
+		"on_loan":         model.CopyOnLoan,
+		"on_shelf":        model.CopyOnShelf,
+		"reservation_uid": req.ReservationUid,
+		"condition":       req.Condition,
+		"library_id":      req.LibraryID,
+		"book_id":         req.BookID,
+	}
+	rows, err := tx.Query(ctx, q, args)
+	if err != nil {
+		return model.BookCopy{}, err
+	}
+	bookCopy, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.BookCopy])
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return bookCopy, err
+	}
+	if req.ReservationUid != "" {
+		return model.BookCopy{}, nil
+	}
+
+	q = fmt.Sprintf(`insert into %s (copy_uid, barcode, book_id, library_id, condition)
+	select @copy_uid, @barcode, @book_id, @library_id, coalesce(nullif(@condition, ''), b.condition)
+	from %s b where b.id = @book_id
+	returning *`, copiesTableName, booksTableName)
+	copyUID := uuid.New()
+	args["copy_uid"] = copyUID
+	args["barcode"] = fmt.Sprintf("%d-%d-%s", req.LibraryID, req.BookID, copyUID.String()[:8])
+	rows, err = tx.Query(ctx, q, args)
+	if err != nil {
+		return model.BookCopy{}, err
+	}
+	bookCopy, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.BookCopy])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.BookCopy{}, errs.ErrNotFound
+	}
+	return bookCopy, err
+}
+
+func (r *repository) ListCopies(ctx context.Context, libraryUid, bookUid string) ([]model.BookCopy, error) {
+	q := fmt.Sprintf(`select c.* from %s c
+	join %s l on l.id = c.library_id
+	join %s b on b.id = c.book_id
+	where l.library_uid = $1 and b.book_uid = $2
+	order by c.id`, copiesTableName, libraryTableName, booksTableName)
+	rows, err := r.db.Query(ctx, q, libraryUid, bookUid)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.BookCopy])
+}
+
+// SetCopyStatus takes a copy off the shelf, e.g. for repair, or puts it back.
+func (r *repository) SetCopyStatus(ctx context.Context, req model.CopyStatusRequest) (model.BookCopy, error) {
+	var bookCopy model.BookCopy
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var status model.CopyStatus
+		err := tx.QueryRow(ctx, fmt.Sprintf(`select status from %s where copy_uid = $1 for update`, copiesTableName), req.CopyUid).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch status {
+		case model.CopyOnLoan:
+			return errs.ErrCopyOnLoan
+		case model.CopyInTransit:
+			return errs.ErrCopyInTransit
+		}
+		rows, err := tx.Query(ctx, fmt.Sprintf(`update %s set status = @status,
+			condition = coalesce(nullif(@condition, ''), condition), updated_at = now()
+		where copy_uid = @copy_uid
+		returning *`, copiesTableName), pgx.NamedArgs{
+			"status":    req.Status,
+			"condition": req.Condition,
+			"copy_uid":  req.CopyUid,
+		})
+		if err != nil {
+			return err
+		}
+		bookCopy, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.BookCopy])
+		return err
+	})
+	return bookCopy, err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/library/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// racingTx changes the stock of the book from another connection before a lend claims it, as
+// many times as races.
+type racingTx struct {
+	pgx.Tx
+	db    *pgxpool.Pool
+	races int
+}
+
+func (tx *racingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx.races > 0 && strings.HasPrefix(sql, "update library_books set version") {
+		tx.races--
+		if _, err := tx.db.Exec(ctx, `update library_books set version = version + 1
+		where library_id = $1 and book_id = $2`, args[0], args[1]); err != nil {
+			return pgconn.CommandTag{}, err
+		}
+	}
+	return tx.Tx.Exec(ctx, sql, args...)
+}
+
+func TestRepository_TryReserveCopy(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// the first library has three copies of the first book on the shelf.
+	lend := func(races int) (model.BookCopy, error) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx) //nolint:errcheck
+		return r.tryReserveCopy(ctx, &racingTx{Tx: tx, db: db, races: races},
+			model.AvailableCountRequest{LibraryID: 1, BookID: 1, ReservationUid: uuid.NewString()})
+	}
+
+	t.Run("lent", func(t *testing.T) {
+		bookCopy, err := lend(0)
+		require.NoError(t, err)
+		require.Equal(t, model.CopyOnLoan, bookCopy.Status)
+	})
+	t.Run("version raced", func(t *testing.T) {
+		bookCopy, err := lend(reserveAttempts - 1)
+		require.NoError(t, err)
+		require.Equal(t, model.CopyOnLoan, bookCopy.Status)
+	})
+	t.Run("stock kept changing", func(t *testing.T) {
+		_, err := lend(reserveAttempts)
+		require.ErrorIs(t, err, errs.ErrStockChanged)
+	})
+	t.Run("out of stock", func(t *testing.T) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx) //nolint:errcheck
+		_, err = tx.Exec(ctx, `update book_copies set status = 'IN_REPAIR' where library_id = 1 and book_id = 1`)
+		require.NoError(t, err)
+		_, err = r.tryReserveCopy(ctx, tx, model.AvailableCountRequest{LibraryID: 1, BookID: 1})
+		require.ErrorIs(t, err, errs.ErrOutOfStock)
+	})
+	t.Run("database error", func(t *testing.T) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx) //nolint:errcheck
+		// the failed statement aborts the transaction, so the stock can not be read.
+		_, err = tx.Exec(ctx, `select 1 / 0`)
+		require.Error(t, err)
+		_, err = r.tryReserveCopy(ctx, tx, model.AvailableCountRequest{LibraryID: 1, BookID: 1})
+		require.Error(t, err)
+		require.NotErrorIs(t, err, errs.ErrOutOfStock)
+	})
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+const (
+	genresTableName     = `genres`
+	bookGenresTableName = `book_genres`
+)
+
+// genreQuery selects the genres g with their parents.
+var genreQuery = fmt.Sprintf(`select g.id, g.genre_uid, g.name, p.genre_uid parent_uid,
+	(select count(*) from %[2]s bg where bg.genre_id = g.id) book_count
+from %[1]s g
+left join %[1]s p on p.id = g.parent_id`, genresTableName, bookGenresTableName)
+
+// ListGenres lists all the genres by name, the hierarchy is told by their parents.
+func (r *repository) ListGenres(ctx context.Context) ([]model.Genre, error) {
+	rows, err := r.db.Query(ctx, genreQuery+` order by g.name, g.id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Genre])
+}
+
+// CreateGenre adds a genre, it fails with errs.ErrGenreExists if there is a genre of the same name.
+func (r *repository) CreateGenre(ctx context.Context, actor string, req model.GenreRequest) (model.Genre, error) {
+	var genre model.Genre
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var parentID *int
+		if req.ParentUid != "" {
+			id, err := genreID(ctx, tx, req.ParentUid)
+			if err != nil {
+				return err
+			}
+			parentID = &id
+		}
+		var id int
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`insert into %s (name, parent_id) values ($1, $2) returning id`, genresTableName),
+			req.Name, parentID).Scan(&id); err != nil {
+			return genreError(err)
+		}
+		var err error
+		if genre, err = getGenre(ctx, tx, id); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditGenre, genre.GenreUid, model.AuditCreate, actor, req)
+	})
+	return genre, err
+}
+
+// UpdateGenre changes the fields of req that are set. A genre can not move under itself or its subgenres.
+func (r *repository) UpdateGenre(ctx context.Context, actor string, req model.UpdateGenreRequest) (model.Genre, error) {
+	var genre model.Genre
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// the hierarchy changes one at a time, so that concurrent moves make no cycle.
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`lock table %s in share row exclusive mode`, genresTableName)); err != nil {
+			return err
+		}
+		id, err := genreID(ctx, tx, req.GenreUid)
+		if err != nil {
+			return err
+		}
+		if req.Name != nil {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set name = $1 where id = $2`, genresTableName), *req.Name, id); err != nil {
+				return genreError(err)
+			}
+		}
+		if req.ParentUid != nil {
+			var parentID *int
+			if *req.ParentUid != "" {
+				pid, err := genreID(ctx, tx, *req.ParentUid)
+				if err != nil {
+					return err
+				}
+				var cycle bool
+				if err := tx.QueryRow(ctx, fmt.Sprintf(`with recursive sub as (
+					select id from %[1]s where id = $1
+					union
+					select g.id from %[1]s g join sub on g.parent_id = sub.id
+				)
+				select exists(select 1 from sub where id = $2)`, genresTableName), id, pid).Scan(&cycle); err != nil {
+					return err
+				}
+				if cycle {
+					return errs.ErrGenreCycle
+				}
+				parentID = &pid
+			}
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set parent_id = $1 where id = $2`, genresTableName), parentID, id); err != nil {
+				return err
+			}
+		}
+		if genre, err = getGenre(ctx, tx, id); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditGenre, genre.GenreUid, model.AuditUpdate, actor, req)
+	})
+	return genre, err
+}
+
+// BooksByGenre lists the books of the genre and of its subgenres across the libraries.
+func (r *repository) BooksByGenre(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	if _, err := genreID(ctx, r.db, req.Uid); err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	return r.listCatalogBooks(ctx, fmt.Sprintf(`b.id in (
+		with recursive sub as (
+			select id from %[1]s where genre_uid = ?
+			union
+			select g.id from %[1]s g join sub on g.parent_id = sub.id
+		)
+		select bg.book_id from %[2]s bg join sub on sub.id = bg.genre_id
+	)`, genresTableName, bookGenresTableName), req)
+}
+
+func getGenre(ctx context.Context, q querier, id int) (model.Genre, error) {
+	rows, err := q.Query(ctx, genreQuery+` where g.id = $1`, id)
+	if err != nil {
+		return model.Genre{}, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Genre])
+}
+
+// genreID fails with errs.ErrNotFound if there is no genre of the uid.
+func genreID(ctx context.Context, q querier, genreUid string) (int, error) {
+	var id int
+	err := q.QueryRow(ctx, fmt.Sprintf(`select id from %s where genre_uid = $1`, genresTableName), genreUid).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, errs.ErrNotFound
+	}
+	return id, err
+}
+
+// genreError tells errs.ErrGenreExists from the other errors of writing a genre.
+func genreError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return errs.ErrGenreExists
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	hoursTableName      = `library_hours`
+	exceptionsTableName = `library_exceptions`
+)
+
+// querier runs the queries of a pool or of a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// getSchedule loads the opening hours of the library with the exception dates from today on.
+func getSchedule(ctx context.Context, q querier, libraryID int) (calendar.Schedule, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(`select weekday, to_char(opens, 'HH24:MI') opens, to_char(closes, 'HH24:MI') closes
+	from %s where library_id = $1 order by weekday`, hoursTableName), libraryID)
+	if err != nil {
+		return calendar.Schedule{}, err
+	}
+	hours, err := pgx.CollectRows(rows, pgx.RowToStructByName[calendar.OpeningHours])
+	if err != nil {
+		return calendar.Schedule{}, err
+	}
+
+	rows, err = q.Query(ctx, fmt.Sprintf(`select to_char(day, 'YYYY-MM-DD') "day",
+		to_char(opens, 'HH24:MI') opens, to_char(closes, 'HH24:MI') closes, note
+	from %s where library_id = $1 and day >= current_date order by day`, exceptionsTableName), libraryID)
+	if err != nil {
+		return calendar.Schedule{}, err
+	}
+	exceptions, err := pgx.CollectRows(rows, pgx.RowToStructByName[calendar.Exception])
+	if err != nil {
+		return calendar.Schedule{}, err
+	}
+	return calendar.Schedule{OpeningHours: hours, Exceptions: exceptions}, nil
+}
+
+// SetSchedule replaces the opening hours and the exception dates of the library.
+func (r *repository) SetSchedule(ctx context.Context, actor string, req model.ScheduleRequest) (calendar.Schedule, error) {
+	var schedule calendar.Schedule
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := lockLibrary(ctx, tx, req.LibraryUid); err != nil {
+			return err
+		}
+		var libraryID int
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`select id from %s where library_uid = $1`, libraryTableName),
+			req.LibraryUid).Scan(&libraryID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`delete from %s where library_id = $1`, hoursTableName), libraryID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`delete from %s where library_id = $1`, exceptionsTableName), libraryID); err != nil {
+			return err
+		}
+		batch := &pgx.Batch{}
+		for _, h := range req.OpeningHours {
+			batch.Queue(fmt.Sprintf(`insert into %s (library_id, weekday, opens, closes) values ($1, $2, $3::time, $4::time)`, hoursTableName),
+				libraryID, h.Weekday, h.Opens, h.Closes)
+		}
+		for _, e := range req.Exceptions {
+			batch.Queue(fmt.Sprintf(`insert into %s (library_id, day, opens, closes, note) values ($1, $2::date, $3::time, $4::time, $5)`, exceptionsTableName),
+				libraryID, e.Date, e.Opens, e.Closes, e.Note)
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+
+		var err error
+		if schedule, err = getSchedule(ctx, tx, libraryID); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditLibrary, req.LibraryUid, model.AuditUpdate, actor, req.Schedule)
+	})
+	return schedule, err
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	importsTableName      = `catalog_imports`
+	importErrorsTableName = `catalog_import_errors`
+	// importRowsTableName is the staging table the records of a batch are copied into.
+	importRowsTableName = `import_rows`
+)
+
+// StartImport starts the import of a file into the library, the import of a file started before is
+// returned to be resumed.
+func (r *repository) StartImport(ctx context.Context, libraryUid string, format model.ImportFormat, checksum string) (model.Import, error) {
+	var imp model.Import
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := lockLibrary(ctx, tx, libraryUid); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, fmt.Sprintf(`insert into %s (import_uid, library_id, format, checksum)
+		select $1, id, $3, $4 from %s where library_uid = $2
+		on conflict (library_id, format, checksum) do update set updated_at = excluded.updated_at
+		returning *`, importsTableName, libraryTableName), uuid.New(), libraryUid, format, checksum)
+		if err != nil {
+			return err
+		}
+		imp, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Import])
+		return err
+	})
+	return imp, err
+}
+
+// importBatchQueries resolve the books of the staged records, by the ISBN first and then by the title
+// and the author, add the missing books, fill in the metadata the existing books lack, and register
+// the copies in the library.
+var importBatchQueries = []string{
+	resolveByISBN,
+	resolveByTitle,
+	fmt.Sprintf(`insert into %s (book_uid, isbn, name, author, genre, condition)
+	select gen_random_uuid(), s.isbn, s.name, s.author, s.genre, coalesce(nullif(s.condition, ''), 'EXCELLENT')
+	from %s s where s.book_id is null and s.isbn is not null
+	on conflict (isbn) where isbn is not null do nothing`, booksTableName, importRowsTableName),
+	resolveByISBN,
+	resolveByTitle,
+	fmt.Sprintf(`insert into %s (book_uid, name, author, genre, condition)
+	select gen_random_uuid(), d.name, d.author, d.genre, d.condition
+	from (
+		select distinct on (lower(s.name), lower(s.author)) s.name, s.author, s.genre,
+			coalesce(nullif(s.condition, ''), 'EXCELLENT') condition
+		from %s s where s.book_id is null
+		order by lower(s.name), lower(s.author)
+	) d`, booksTableName, importRowsTableName),
+	resolveByTitle,
+	fmt.Sprintf(`update %s b set isbn = coalesce(b.isbn, s.isbn),
+		author = coalesce(nullif(b.author, ''), nullif(s.author, '')),
+		genre = coalesce(nullif(b.genre, ''), nullif(s.genre, ''))
+	from %s s where b.id = s.book_id`, booksTableName, importRowsTableName),
+	fmt.Sprintf(`insert into library_books (book_id, library_id)
+	select s.book_id, @library_id from %s s
+	on conflict do nothing`, importRowsTableName),
+	fmt.Sprintf(`insert into %s (copy_uid, barcode, book_id, library_id, condition)
+	select n.uid, format('%%s-%%s-%%s', @library_id::int, s.book_id, left(n.uid::text, 8)), s.book_id, @library_id,
+		coalesce(nullif(s.condition, ''), b.condition)
+	from %s s
+	join %s b on b.id = s.book_id
+	cross join lateral (select gen_random_uuid() uid from generate_series(1, s.copies)) n`,
+		copiesTableName, importRowsTableName, booksTableName),
+}
+
+var (
+	resolveByISBN = fmt.Sprintf(`update %s s set book_id = b.id from %s b
+	where s.book_id is null and s.isbn is not null and b.isbn = s.isbn`, importRowsTableName, booksTableName)
+	resolveByTitle = fmt.Sprintf(`update %s s set book_id = b.id from %s b
+	where s.book_id is null and lower(b.name) = lower(s.name) and lower(coalesce(b.author, '')) = lower(s.author)
+		and (s.isbn is null or b.isbn is null)`, importRowsTableName, booksTableName)
+)
+
+// ImportBatch imports the records read after the rows done by imp up to rowsDone along with the
+// errors of the rows that failed. The batch and the progress of the import commit together, so
+// a resumed import never imports a row twice.
+func (r *repository) ImportBatch(ctx context.Context, imp model.Import, rowsDone int, records []model.CatalogRecord, rowErrs []model.ImportError) (model.Import, error) {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`update %s set rows_done = @rows_done, rows_failed = rows_failed + @failed, updated_at = now()
+		where id = @id and rows_done = @from and status = @in_progress
+		returning *`, importsTableName), pgx.NamedArgs{
+			"rows_done":   rowsDone,
+			"failed":      len(rowErrs),
+			"id":          imp.ID,
+			"from":        imp.RowsDone,
+			"in_progress": model.ImportInProgress,
+		})
+		if err != nil {
+			return err
+		}
+		updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Import])
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrImportConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(records) > 0 {
+			if err := importRecords(ctx, tx, imp.LibraryID, records); err != nil {
+				return err
+			}
+		}
+		if len(rowErrs) > 0 {
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{importErrorsTableName}, []string{"import_id", "row_num", "error"},
+				pgx.CopyFromSlice(len(rowErrs), func(i int) ([]any, error) {
+					return []any{imp.ID, rowErrs[i].Row, rowErrs[i].Error}, nil
+				})); err != nil {
+				return err
+			}
+		}
+		imp = updated
+		return nil
+	})
+	return imp, err
+}
+
+func importRecords(ctx context.Context, tx pgx.Tx, libraryID int, records []model.CatalogRecord) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`create temp table %s (
+		isbn      varchar(13),
+		name      varchar(255) not null,
+		author    varchar(255) not null,
+		genre     varchar(255) not null,
+		condition varchar(20)  not null,
+		copies    int          not null,
+		book_id   int
+	) on commit drop`, importRowsTableName)); err != nil {
+		return err
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{importRowsTableName}, []string{"isbn", "name", "author", "genre", "condition", "copies"},
+		pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+			rec := records[i]
+			var isbn *string
+			if rec.ISBN != "" {
+				isbn = &rec.ISBN
+			}
+			return []any{isbn, rec.Title, rec.Author, rec.Genre, string(rec.Condition), rec.Copies}, nil
+		})); err != nil {
+		return err
+	}
+	for _, q := range importBatchQueries {
+		if _, err := tx.Exec(ctx, q, pgx.NamedArgs{"library_id": libraryID}); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`select book_id, copies from %s where copies > 0`, importRowsTableName))
+	if err != nil {
+		return err
+	}
+	type added struct {
+		BookID int `db:"book_id"`
+		Copies int `db:"copies"`
+	}
+	books, err := pgx.CollectRows(rows, pgx.RowToStructByName[added])
+	if err != nil {
+		return err
+	}
+	for _, b := range books {
+		if err := putCountChanged(ctx, tx, libraryID, b.BookID, b.Copies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FinishImport marks the import done and records it in the audit trail of the library.
+func (r *repository) FinishImport(ctx context.Context, actor string, imp model.Import) (model.Import, error) {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var libraryUid string
+		err := tx.QueryRow(ctx, fmt.Sprintf(`update %s i set status = $2, updated_at = now()
+		from %s l
+		where i.id = $1 and i.status = $3 and l.id = i.library_id
+		returning l.library_uid`, importsTableName, libraryTableName),
+			imp.ID, model.ImportDone, model.ImportInProgress).Scan(&libraryUid)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrImportConflict
+		}
+		if err != nil {
+			return err
+		}
+		imp.Status = model.ImportDone
+		return putAudit(ctx, tx, model.AuditLibrary, libraryUid, model.AuditImport, actor, map[string]any{
+			"importUid":  imp.ImportUid,
+			"format":     imp.Format,
+			"rowsDone":   imp.RowsDone,
+			"rowsFailed": imp.RowsFailed,
+		})
+	})
+	return imp, err
+}
+
+// GetImport returns the progress of the import with the errors of its rows.
+func (r *repository) GetImport(ctx context.Context, importUid string) (model.ImportReport, error) {
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`select * from %s where import_uid = $1`, importsTableName), importUid)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	imp, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Import])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ImportReport{}, errs.ErrNotFound
+	}
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	rows, err = r.db.Query(ctx, fmt.Sprintf(`select row_num, error from %s where import_id = $1 order by row_num`, importErrorsTableName), imp.ID)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	rowErrs, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.ImportError])
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	return model.ImportReport{Import: imp, Errors: rowErrs}, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/library/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_ImportBatch(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	imp, err := r.StartImport(ctx, "83575e12-7ce0-48ee-9931-51919ff3c9ee", model.ImportCSV, "checksum")
+	require.NoError(t, err)
+	// the rows without an ISBN name the same book twice, in different cases.
+	imp, err = r.ImportBatch(ctx, imp, 3, []model.CatalogRecord{
+		{Row: 1, Title: "Dune", Author: "Frank Herbert", Copies: 1},
+		{Row: 2, Title: "DUNE", Author: "frank herbert", Copies: 2},
+		{Row: 3, Title: "Dune Messiah", Author: "Frank Herbert", Copies: 1},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, imp.RowsDone)
+
+	var books, copies int
+	require.NoError(t, db.QueryRow(ctx, `select count(*) from books where lower(name) = 'dune'`).Scan(&books))
+	require.Equal(t, 1, books)
+	require.NoError(t, db.QueryRow(ctx, `select count(*) from book_copies c
+	join books b on b.id = c.book_id where lower(b.name) = 'dune'`).Scan(&copies))
+	require.Equal(t, 3, copies)
+}
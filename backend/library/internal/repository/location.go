@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// kmPerDegree is the length of a degree of latitude.
+	kmPerDegree = math.Pi * earthRadiusKm / 180
+)
+
+// NearbyLibraries finds the libraries within the radius of the point, the nearest first.
+// The bounding box of the circle narrows the libraries by the location index, the distance
+// is then the great-circle distance by the haversine formula.
+func (r *repository) NearbyLibraries(ctx context.Context, req model.NearbyLibrariesRequest) (model.ListNearbyLibraries, error) {
+	lat, lon := *req.Lat, *req.Lon
+	dLat := req.Radius / kmPerDegree
+	// near the poles the circle covers all the longitudes.
+	dLon := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLon = math.Min(dLat/cos, 180)
+	}
+	minLon, maxLon := lon-dLon, lon+dLon
+	if dLon == 180 {
+		minLon, maxLon = -180, 180
+	}
+	var bookUid *string
+	if req.BookUid != "" {
+		bookUid = &req.BookUid
+	}
+
+	q := fmt.Sprintf(`select l.id, l.library_uid, l.name, l.city, l.address, l.archived_at, l.latitude, l.longitude,
+		l.distance_km, ab.available_count
+	from (
+		select l.*, 2 * @earth_radius::float8 * asin(least(1, sqrt(
+			power(sin(radians(l.latitude - @lat::float8) / 2), 2) +
+			cos(radians(@lat::float8)) * cos(radians(l.latitude)) * power(sin(radians(l.longitude - @lon::float8) / 2), 2)
+		))) distance_km
+		from %[1]s l
+		where l.archived_at is null
+			and l.latitude between @min_lat::float8 and @max_lat::float8
+			-- the box may cross the antimeridian.
+			and (l.longitude between @min_lon::float8 and @max_lon::float8
+				or l.longitude - 360 between @min_lon::float8 and @max_lon::float8
+				or l.longitude + 360 between @min_lon::float8 and @max_lon::float8)
+	) l
+	left join lateral (
+		select ab.available_count
+		from %[2]s ab
+		join %[3]s b on b.id = ab.book_id
+		where ab.library_id = l.id and b.book_uid = @book_uid::uuid
+	) ab on true
+	where l.distance_km <= @radius::float8
+		and (@book_uid::uuid is null or ab.available_count > 0)
+	order by l.distance_km, l.name
+	limit @limit`, libraryTableName, availableBooksViewName, booksTableName)
+
+	rows, err := r.db.Query(ctx, q, pgx.NamedArgs{
+		"earth_radius": earthRadiusKm,
+		"lat":          lat,
+		"lon":          lon,
+		"min_lat":      lat - dLat,
+		"max_lat":      lat + dLat,
+		"min_lon":      minLon,
+		"max_lon":      maxLon,
+		"radius":       req.Radius,
+		"book_uid":     bookUid,
+		"limit":        req.Size,
+	})
+	if err != nil {
+		return model.ListNearbyLibraries{}, err
+	}
+	libs, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.NearbyLibrary])
+	if err != nil {
+		return model.ListNearbyLibraries{}, err
+	}
+	return model.ListNearbyLibraries{Items: libs}, nil
+}
@@ -14,15 +14,47 @@ import (
 	sq "github.com/Masterminds/squirrel"
 
 	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"go.uber.org/zap"
 )
 
 type Repository interface {
-	ListLibrary(ctx context.Context, city string, page, size int) (model.ListLibraries, error)
-	ListBooks(ctx context.Context, libraryUid string, showAll bool, page, size int) (model.ListBooks, error)
+	ListLibrary(ctx context.Context, city string, req paging.Request) (model.ListLibraries, error)
+	ListBooks(ctx context.Context, libraryUid string, showAll bool, req paging.Request) (model.ListBooks, error)
+	SearchBooks(ctx context.Context, req model.SearchBooksRequest) (model.ListBookHits, error)
+	NearbyLibraries(ctx context.Context, req model.NearbyLibrariesRequest) (model.ListNearbyLibraries, error)
 	GetBook(ctx context.Context, libraryUid, bookUid string) (model.Book, error)
+	FindBook(ctx context.Context, bookUid string) (model.Book, error)
 	GetLibrary(ctx context.Context, libraryUid string) (model.Library, error)
-	AvailableCount(ctx context.Context, libraryID, bookID int, isReturn bool) error
+	AvailableCount(ctx context.Context, req model.AvailableCountRequest) (model.BookCopy, error)
+	ListCopies(ctx context.Context, libraryUid, bookUid string) ([]model.BookCopy, error)
+	SetCopyStatus(ctx context.Context, req model.CopyStatusRequest) (model.BookCopy, error)
+	CreateLibrary(ctx context.Context, actor string, req model.LibraryRequest) (model.Library, error)
+	SetSchedule(ctx context.Context, actor string, req model.ScheduleRequest) (calendar.Schedule, error)
+	ListAuthors(ctx context.Context, req model.ListAuthorsRequest) (model.ListAuthors, error)
+	AddAuthorAlias(ctx context.Context, actor string, req model.AuthorAliasRequest) (model.Author, error)
+	MergeAuthors(ctx context.Context, actor string, req model.MergeAuthorsRequest) (model.Author, error)
+	BooksByAuthor(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error)
+	ListGenres(ctx context.Context) ([]model.Genre, error)
+	CreateGenre(ctx context.Context, actor string, req model.GenreRequest) (model.Genre, error)
+	UpdateGenre(ctx context.Context, actor string, req model.UpdateGenreRequest) (model.Genre, error)
+	BooksByGenre(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error)
+	UpdateLibrary(ctx context.Context, actor string, req model.UpdateLibraryRequest) (model.Library, error)
+	ArchiveLibrary(ctx context.Context, actor, libraryUid string) error
+	CreateBook(ctx context.Context, actor string, req model.BookRequest) (model.Book, error)
+	UpdateBook(ctx context.Context, actor string, req model.UpdateBookRequest) (model.Book, error)
+	SetStock(ctx context.Context, actor string, req model.StockRequest) (model.Stock, error)
+	RequestTransfer(ctx context.Context, actor string, req model.TransferRequest) (model.Transfer, error)
+	ReceiveTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error)
+	CancelTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error)
+	GetTransfer(ctx context.Context, transferUid string) (model.Transfer, error)
+	ListTransfers(ctx context.Context, req model.ListTransfersRequest) (model.ListTransfers, error)
+	ListAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, error)
+	StartImport(ctx context.Context, libraryUid string, format model.ImportFormat, checksum string) (model.Import, error)
+	ImportBatch(ctx context.Context, imp model.Import, rowsDone int, records []model.CatalogRecord, rowErrs []model.ImportError) (model.Import, error)
+	FinishImport(ctx context.Context, actor string, imp model.Import) (model.Import, error)
+	GetImport(ctx context.Context, importUid string) (model.ImportReport, error)
 }
 
 type repository struct {
@@ -37,18 +69,27 @@ func NewRepository(db *pgxpool.Pool, log *zap.Logger) (*repository, error) {
 	}, nil
 }
 
+// producerName is the producer of the events of the service.
+const producerName = "library"
+
 const (
-	libraryTableName      = `library`
-	booksTableName        = `books`
-	libraryBooksTableName = `library_books`
+	libraryTableName = `library`
+	booksTableName   = `books`
 )
 
 var qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
+var libraryColumns = []string{"id", "library_uid", "name", "city", "address", "archived_at", "latitude", "longitude"}
+
+// bookListColumns are the columns of the books b of a library, joined to the available counts.
+var bookListColumns = []string{"b.id", "book_uid", "b.name", "author", "genre", "condition",
+	"coalesce(b.isbn, '') isbn", "coalesce(isbn10(b.isbn), '') isbn10",
+	"coalesce(b.cover_url, '') cover_url", "coalesce(b.description, '') description", "available_count"}
+
 func (r *repository) GetBook(ctx context.Context, libraryUid, bookUid string) (model.Book, error) {
-	query, args, err := qb.Select("b.id", "book_uid", "b.name", "author", "genre", "condition", "available_count").
+	query, args, err := qb.Select(bookListColumns...).
 		From(booksTableName + " b").
-		Join(fmt.Sprintf("%s lb on b.id = lb.book_id", libraryBooksTableName)).
+		Join(fmt.Sprintf("%s lb on b.id = lb.book_id", availableBooksViewName)).
 		Join(fmt.Sprintf("%s l on l.id = lb.library_id", libraryTableName)).
 		Where(sq.Eq{"library_uid": libraryUid}).
 		Where(sq.Eq{"book_uid": bookUid}).
@@ -75,26 +116,8 @@ func (r *repository) GetBook(ctx context.Context, libraryUid, bookUid string) (m
 	return book, nil
 }
 
-func (r *repository) AvailableCount(ctx context.Context, libraryID, bookID int, isReturn bool) error {
-	q := `
-update library_books
-    set available_count = available_count + @inc
-where library_id = @library_id and book_id = @book_id`
-	inc := 1
-	if !isReturn {
-		inc = -1
-	}
-	args := pgx.NamedArgs{
-		"library_id": libraryID,
-		"book_id":    bookID,
-		"inc":        inc,
-	}
-	_, err := r.db.Exec(ctx, q, args)
-	return err
-}
-
 func (r *repository) GetLibrary(ctx context.Context, libraryUid string) (model.Library, error) {
-	query, args, err := qb.Select("id", "library_uid", "name", "city", "address").
+	query, args, err := qb.Select(libraryColumns...).
 		From(libraryTableName).
 		Where(sq.Eq{"library_uid": libraryUid}).
 		Limit(1).
@@ -116,20 +139,34 @@ func (r *repository) GetLibrary(ctx context.Context, libraryUid string) (model.L
 		}
 		return model.Library{}, err
 	}
+	schedule, err := getSchedule(ctx, r.db, lib.ID)
+	if err != nil {
+		return model.Library{}, err
+	}
+	lib.Schedule = &schedule
 
 	return lib, nil
 }
 
-func (r *repository) ListLibrary(ctx context.Context, city string, page, size int) (model.ListLibraries, error) {
-	q := qb.Select("id", "library_uid", "name", "city", "address").
-		From(libraryTableName).
-		Where(sq.Eq{"city": city})
+// libraryKeyset is the order of the lists of libraries.
+var libraryKeyset = paging.Keyset[model.Library]{
+	Sorts: map[string]paging.Column[model.Library]{
+		"name": {Expr: "name", Type: "text", Value: func(l model.Library) any { return l.Name }},
+	},
+	Key: paging.Column[model.Library]{Expr: "id", Type: "int", Value: func(l model.Library) any { return l.ID }},
+}
 
-	if page != 0 && size != 0 {
-		q = q.Limit(uint64(size)).Offset(uint64((page - 1) * size))
+func (r *repository) ListLibrary(ctx context.Context, city string, req paging.Request) (model.ListLibraries, error) {
+	pager, err := paging.NewPager(req, libraryKeyset)
+	if err != nil {
+		return model.ListLibraries{}, err
 	}
+	q := qb.Select(libraryColumns...).
+		From(libraryTableName).
+		Where(sq.Eq{"archived_at": nil}).
+		Where("city_key = city_key(?)", city)
 
-	query, args, err := q.ToSql()
+	query, args, err := pager.Apply(q).ToSql()
 	if err != nil {
 		return model.ListLibraries{}, err
 	}
@@ -144,12 +181,14 @@ func (r *repository) ListLibrary(ctx context.Context, city string, page, size in
 	if err != nil {
 		return model.ListLibraries{}, fmt.Errorf("pgx.CollectRows: %w", err)
 	}
+	libs, page := pager.Collect(libs)
 
-	var totalElements int
-	{
+	if req.WithTotal() {
+		var totalElements int
 		q := qb.Select("count(*)").
 			From(libraryTableName).
-			Where(sq.Eq{"city": city})
+			Where(sq.Eq{"archived_at": nil}).
+			Where("city_key = city_key(?)", city)
 		query, args, err := q.ToSql()
 		if err != nil {
 			return model.ListLibraries{}, err
@@ -157,22 +196,33 @@ func (r *repository) ListLibrary(ctx context.Context, city string, page, size in
 		if err := r.db.QueryRow(ctx, query, args...).Scan(&totalElements); err != nil {
 			return model.ListLibraries{}, err
 		}
+		page.TotalElements = &totalElements
 	}
 
 	return model.ListLibraries{
-		Paging: model.Paging{
-			Page:          page,
-			PageSize:      size,
-			TotalElements: totalElements,
-		},
+		Page:  page,
 		Items: libs,
 	}, nil
 }
 
-func (r *repository) ListBooks(ctx context.Context, libraryUid string, showAll bool, page, size int) (model.ListBooks, error) {
-	q := qb.Select("b.id", "book_uid", "b.name", "author", "genre", "condition", "available_count").
+// bookKeyset is the order of the lists of the books of a library.
+var bookKeyset = paging.Keyset[model.Book]{
+	Sorts: map[string]paging.Column[model.Book]{
+		"name":      {Expr: "b.name", Type: "text", Value: func(b model.Book) any { return b.Name }},
+		"author":    {Expr: "author", Type: "text", Value: func(b model.Book) any { return b.Author }},
+		"available": {Expr: "available_count", Type: "int", Value: func(b model.Book) any { return b.AvailableCount }},
+	},
+	Key: paging.Column[model.Book]{Expr: "b.id", Type: "int", Value: func(b model.Book) any { return b.ID }},
+}
+
+func (r *repository) ListBooks(ctx context.Context, libraryUid string, showAll bool, req paging.Request) (model.ListBooks, error) {
+	pager, err := paging.NewPager(req, bookKeyset)
+	if err != nil {
+		return model.ListBooks{}, err
+	}
+	q := qb.Select(bookListColumns...).
 		From(booksTableName + " b").
-		Join(fmt.Sprintf("%s lb on b.id = lb.book_id", libraryBooksTableName)).
+		Join(fmt.Sprintf("%s lb on b.id = lb.book_id", availableBooksViewName)).
 		Join(fmt.Sprintf("%s l on l.id = lb.library_id", libraryTableName)).
 		Where(sq.Eq{"library_uid": libraryUid})
 
@@ -180,11 +230,7 @@ func (r *repository) ListBooks(ctx context.Context, libraryUid string, showAll b
 		q = q.Where(sq.Gt{"available_count": 0})
 	}
 
-	if page != 0 && size != 0 {
-		q = q.Limit(uint64(size)).Offset(uint64((page - 1) * size))
-	}
-
-	query, args, err := q.ToSql()
+	query, args, err := pager.Apply(q).ToSql()
 	if err != nil {
 		return model.ListBooks{}, err
 	}
@@ -200,12 +246,13 @@ func (r *repository) ListBooks(ctx context.Context, libraryUid string, showAll b
 	if err != nil {
 		return model.ListBooks{}, err
 	}
+	books, page := pager.Collect(books)
 
-	var totalElements int
-	{
+	if req.WithTotal() {
+		var totalElements int
 		q := qb.Select("count(*)").
 			From(booksTableName + " b").
-			Join(fmt.Sprintf("%s lb on b.id = lb.book_id", libraryBooksTableName)).
+			Join(fmt.Sprintf("%s lb on b.id = lb.book_id", availableBooksViewName)).
 			Join(fmt.Sprintf("%s l on l.id = lb.library_id", libraryTableName)).
 			Where(sq.Eq{"library_uid": libraryUid})
 
@@ -220,14 +267,11 @@ func (r *repository) ListBooks(ctx context.Context, libraryUid string, showAll b
 		if err := r.db.QueryRow(ctx, query, args...).Scan(&totalElements); err != nil {
 			return model.ListBooks{}, err
 		}
+		page.TotalElements = &totalElements
 	}
 
 	return model.ListBooks{
-		Paging: model.Paging{
-			Page:          page,
-			PageSize:      size,
-			TotalElements: totalElements,
-		},
+		Page:  page,
 		Items: books,
 	}, nil
 }
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/jackc/pgx/v5"
+)
+
+// SearchBooks finds the books of all libraries by the words of the query. Every word matches as a
+// prefix of the stemmed words of the title, the author or the genre, in Russian or English, and
+// misspelled titles and authors match by trigram similarity. The best matches come first.
+func (r *repository) SearchBooks(ctx context.Context, req model.SearchBooksRequest) (model.ListBookHits, error) {
+	q := fmt.Sprintf(`with q as (
+		select to_tsquery('russian', @tsquery) || to_tsquery('english', @tsquery) || to_tsquery('simple', @tsquery) query
+	)
+	select b.book_uid, b.name, coalesce(b.author, '') author, coalesce(b.genre, '') genre, b.condition,
+		(ts_rank_cd(b.search, q.query) + greatest(word_similarity(@q, b.name), word_similarity(@q, coalesce(b.author, ''))))::float8 rank,
+		ts_headline('russian', translate(concat_ws(' · ', b.name, b.author, b.genre), @marks, ''), q.query,
+			@headline_options) highlight,
+		h.libraries,
+		count(*) over () total
+	from %[1]s b
+	cross join q
+	cross join lateral (
+		select json_agg(json_build_object(
+			'libraryUid', l.library_uid,
+			'name', l.name,
+			'city', l.city,
+			'address', l.address,
+			'availableCount', ab.available_count
+		) order by ab.available_count desc, l.name) libraries
+		from %[2]s ab
+		join %[3]s l on l.id = ab.library_id
+		where ab.book_id = b.id and l.archived_at is null
+			and (@city = '' or l.city_key = city_key(@city))
+			and (not @available or ab.available_count > 0)
+	) h
+	where (b.search @@ q.query or @q <%% b.name or @q <%% b.author)
+		and (@genre = '' or exists (
+			select 1 from %[4]s bg join %[5]s g on g.id = bg.genre_id
+			where bg.book_id = b.id and (g.genre_uid::text = @genre or g.name_key = translit_key(@genre))
+		))
+		and h.libraries is not null
+	order by rank desc, b.name
+	limit @limit offset @offset`, booksTableName, availableBooksViewName, libraryTableName, bookGenresTableName, genresTableName)
+
+	limit, offset := paging.Offset(req.Page, req.Size)
+	rows, err := r.db.Query(ctx, q, pgx.NamedArgs{
+		"tsquery":          prefixQuery(req.Query),
+		"q":                req.Query,
+		"marks":            markStart + markStop,
+		"headline_options": fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true", markStart, markStop),
+		"city":             req.City,
+		"genre":            req.Genre,
+		"available":        req.Available,
+		"limit":            limit,
+		"offset":           offset,
+	})
+	if err != nil {
+		return model.ListBookHits{}, err
+	}
+	hits, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.BookHit])
+	if err != nil {
+		return model.ListBookHits{}, err
+	}
+	for i := range hits {
+		hits[i].Highlight = highlight(hits[i].Highlight)
+	}
+
+	var totalElements int
+	if len(hits) > 0 {
+		totalElements = hits[0].Total
+	}
+	return model.ListBookHits{
+		Paging: model.Paging{
+			Page:          req.Page,
+			PageSize:      limit,
+			TotalElements: totalElements,
+		},
+		Items: hits,
+	}, nil
+}
+
+// ts_headline marks the matches with control characters, which are dropped from the text before,
+// so the text can be escaped as HTML before the marks become <mark> tags.
+const (
+	markStart = "\x02"
+	markStop  = "\x03"
+)
+
+var markReplacer = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+// highlight turns a snippet of ts_headline into HTML.
+func highlight(snippet string) string {
+	return markReplacer.Replace(html.EscapeString(snippet))
+}
+
+// prefixQuery makes a tsquery matching all the words of the search query as prefixes.
+// Everything but letters and digits is dropped, so the query can not break the tsquery syntax.
+func prefixQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// catalogBookKeyset is the order of the lists of the books of an author or a genre.
+var catalogBookKeyset = paging.Keyset[model.CatalogBook]{
+	Sorts: map[string]paging.Column[model.CatalogBook]{
+		"name": {Expr: "b.name", Type: "text", Value: func(b model.CatalogBook) any { return b.Name }},
+	},
+	Key: paging.Column[model.CatalogBook]{Expr: "b.id", Type: "int", Value: func(b model.CatalogBook) any { return b.ID }},
+}
+
+// listCatalogBooks lists the books matching the filter with their authors, genres and the libraries
+// holding them, by name unless asked otherwise. The filter is an SQL condition on books b, the uid
+// of req is its only argument.
+func (r *repository) listCatalogBooks(ctx context.Context, filter string, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	if req.Sort == "" {
+		req.Sort = "name"
+	}
+	pager, err := paging.NewPager(req.Request, catalogBookKeyset)
+	if err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	q := qb.Select().From(booksTableName+" b").Where(filter, req.Uid)
+
+	query, args, err := pager.Apply(q.Columns("b.id", "b.book_uid", "b.name",
+		"coalesce(b.author, '') author", "coalesce(b.genre, '') genre", "b.condition",
+		fmt.Sprintf(`coalesce((
+			select json_agg(json_build_object('authorUid', a.author_uid, 'name', a.name) order by a.name)
+			from %s ba join %s a on a.id = ba.author_id where ba.book_id = b.id
+		), '[]') authors`, bookAuthorsTableName, authorsTableName),
+		fmt.Sprintf(`coalesce((
+			select json_agg(json_build_object('genreUid', g.genre_uid, 'name', g.name) order by g.name)
+			from %s bg join %s g on g.id = bg.genre_id where bg.book_id = b.id
+		), '[]') genres`, bookGenresTableName, genresTableName),
+		fmt.Sprintf(`coalesce((
+			select json_agg(json_build_object(
+				'libraryUid', l.library_uid,
+				'name', l.name,
+				'city', l.city,
+				'address', l.address,
+				'availableCount', ab.available_count
+			) order by ab.available_count desc, l.name)
+			from %s ab
+			join %s l on l.id = ab.library_id
+			where ab.book_id = b.id and l.archived_at is null
+		), '[]') libraries`, availableBooksViewName, libraryTableName),
+	)).ToSql()
+	if err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	books, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.CatalogBook])
+	if err != nil {
+		return model.ListCatalogBooks{}, err
+	}
+	books, page := pager.Collect(books)
+
+	if req.WithTotal() {
+		var totalElements int
+		query, args, err := q.Column("count(*)").ToSql()
+		if err != nil {
+			return model.ListCatalogBooks{}, err
+		}
+		if err := r.db.QueryRow(ctx, query, args...).Scan(&totalElements); err != nil {
+			return model.ListCatalogBooks{}, err
+		}
+		page.TotalElements = &totalElements
+	}
+	return model.ListCatalogBooks{
+		Page:  page,
+		Items: books,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		snippet string
+		want    string
+	}{
+		{
+			snippet: "Краткий курс C++ · Бьерн " + markStart + "Страуструп" + markStop,
+			want:    "Краткий курс C++ · Бьерн <mark>Страуструп</mark>",
+		},
+		{
+			snippet: `<script>alert("x")</script> & ` + markStart + "Tom" + markStop + " & Jerry",
+			want:    "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; <mark>Tom</mark> &amp; Jerry",
+		},
+		{
+			snippet: "<mark>not a match</mark>",
+			want:    "&lt;mark&gt;not a match&lt;/mark&gt;",
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, highlight(tt.snippet))
+	}
+}
+
+func TestPrefixQuery(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "Страуструп:* & c:*", prefixQuery("Страуструп, c++"))
+	require.Equal(t, "", prefixQuery("<&|!>"))
+}
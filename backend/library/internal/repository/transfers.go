@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+const transfersTableName = `transfers`
+
+// transferQuery selects the transfers t with the uids of their book and libraries.
+var transferQuery = fmt.Sprintf(`select t.id, t.transfer_uid, b.book_uid, lf.library_uid from_library_uid,
+	lt.library_uid to_library_uid, t.copies, t.status, t.requested_by, t.requested_at, t.closed_by, t.closed_at
+from %[1]s t
+join %[2]s b on b.id = t.book_id
+join %[3]s lf on lf.id = t.from_library_id
+join %[3]s lt on lt.id = t.to_library_id`, transfersTableName, booksTableName, libraryTableName)
+
+// RequestTransfer takes the copies off the shelf of the library they are sent from, the copies in
+// the best condition first. It fails with errs.ErrNotEnoughCopies if fewer copies are on the shelf.
+func (r *repository) RequestTransfer(ctx context.Context, actor string, req model.TransferRequest) (model.Transfer, error) {
+	var transfer model.Transfer
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// the libraries are locked in the same order by every transfer.
+		first, second := req.FromLibraryUid, req.ToLibraryUid
+		if second < first {
+			first, second = second, first
+		}
+		if err := lockLibrary(ctx, tx, first); err != nil {
+			return err
+		}
+		if err := lockLibrary(ctx, tx, second); err != nil {
+			return err
+		}
+
+		var id, bookID, fromLibraryID int
+		err := tx.QueryRow(ctx, fmt.Sprintf(`insert into %s (book_id, from_library_id, to_library_id, copies, requested_by)
+		select b.id, lf.id, lt.id, @copies, @actor
+		from %s b, %[3]s lf, %[3]s lt
+		where b.book_uid = @book_uid and lf.library_uid = @from_library_uid and lt.library_uid = @to_library_uid
+		returning id, book_id, from_library_id`, transfersTableName, booksTableName, libraryTableName), pgx.NamedArgs{
+			"copies":           req.Copies,
+			"actor":            actor,
+			"book_uid":         req.BookUid,
+			"from_library_uid": req.FromLibraryUid,
+			"to_library_uid":   req.ToLibraryUid,
+		}).Scan(&id, &bookID, &fromLibraryID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`update %[1]s set status = @in_transit, transfer_id = @id, updated_at = now()
+		where id in (
+			select id from %[1]s
+			where library_id = @library_id and book_id = @book_id and status = @on_shelf
+			order by array_position(array['EXCELLENT', 'GOOD', 'BAD'], condition::text), acquired_at, id
+			limit @copies
+			for update skip locked
+		)`, copiesTableName), pgx.NamedArgs{
+			"in_transit": model.CopyInTransit,
+			"on_shelf":   model.CopyOnShelf,
+			"id":         id,
+			"library_id": fromLibraryID,
+			"book_id":    bookID,
+			"copies":     req.Copies,
+		})
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() < int64(req.Copies) {
+			return errs.ErrNotEnoughCopies
+		}
+
+		if transfer, err = getTransfer(ctx, tx, `t.id = $1`, id); err != nil {
+			return err
+		}
+		if err := putTransferChanged(ctx, tx, transfer, -transfer.Copies); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditTransfer, transfer.TransferUid, model.AuditCreate, actor, req)
+	})
+	return transfer, err
+}
+
+// ReceiveTransfer puts the copies in transit on the shelf of the library they are sent to.
+// Archived libraries receive no copies, it fails with errs.ErrLibraryArchived and the transfer stays
+// in transit until it is cancelled.
+func (r *repository) ReceiveTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	return r.closeTransfer(ctx, actor, transferUid, model.TransferReceived)
+}
+
+// CancelTransfer puts the copies in transit back on the shelf of the library they are sent from.
+func (r *repository) CancelTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	return r.closeTransfer(ctx, actor, transferUid, model.TransferCancelled)
+}
+
+func (r *repository) closeTransfer(ctx context.Context, actor, transferUid string, status model.TransferStatus) (model.Transfer, error) {
+	var transfer model.Transfer
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = getTransfer(ctx, tx, `t.transfer_uid = $1 for update of t`, transferUid)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != model.TransferInTransit {
+			return errs.ErrTransferClosed
+		}
+		libraryUid, action := transfer.FromLibraryUid, model.AuditCancel
+		if status == model.TransferReceived {
+			libraryUid, action = transfer.ToLibraryUid, model.AuditReceive
+			if err := lockLibrary(ctx, tx, libraryUid); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`insert into library_books (book_id, library_id)
+		select b.id, l.id from %s b, %s l where b.book_uid = $1 and l.library_uid = $2
+		on conflict do nothing`, booksTableName, libraryTableName), transfer.BookUid, libraryUid); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set status = $1, updated_at = now(),
+			library_id = (select id from %s where library_uid = $2)
+		where transfer_id = $3 and status = $4`, copiesTableName, libraryTableName),
+			model.CopyOnShelf, libraryUid, transfer.ID, model.CopyInTransit); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`update %s set status = $1, closed_by = $2, closed_at = now() where id = $3`, transfersTableName),
+			status, actor, transfer.ID); err != nil {
+			return err
+		}
+
+		if transfer, err = getTransfer(ctx, tx, `t.id = $1`, transfer.ID); err != nil {
+			return err
+		}
+		if err := putTransferChanged(ctx, tx, transfer, transfer.Copies); err != nil {
+			return err
+		}
+		return putAudit(ctx, tx, model.AuditTransfer, transfer.TransferUid, action, actor, nil)
+	})
+	return transfer, err
+}
+
+func (r *repository) GetTransfer(ctx context.Context, transferUid string) (model.Transfer, error) {
+	return getTransfer(ctx, r.db, `t.transfer_uid = $1`, transferUid)
+}
+
+// transferKeyset is the order of the lists of transfers.
+var transferKeyset = paging.Keyset[model.Transfer]{
+	Sorts: map[string]paging.Column[model.Transfer]{
+		"requestedAt": {Expr: "q.requested_at", Type: "timestamp", Value: func(t model.Transfer) any { return t.RequestedAt }},
+	},
+	Key: paging.Column[model.Transfer]{Expr: "q.id", Type: "int", Value: func(t model.Transfer) any { return t.ID }},
+}
+
+// ListTransfers lists the transfers, the latest first unless asked otherwise.
+func (r *repository) ListTransfers(ctx context.Context, req model.ListTransfersRequest) (model.ListTransfers, error) {
+	if req.Sort == "" {
+		req.Sort = "requestedAt"
+	}
+	if req.Order == "" {
+		req.Order = paging.Desc
+	}
+	pager, err := paging.NewPager(req.Request, transferKeyset)
+	if err != nil {
+		return model.ListTransfers{}, err
+	}
+	q := qb.Select().From("(" + transferQuery + ") q")
+	if req.LibraryUid != "" {
+		q = q.Where(sq.Or{sq.Eq{"q.from_library_uid": req.LibraryUid}, sq.Eq{"q.to_library_uid": req.LibraryUid}})
+	}
+	if req.Status != "" {
+		q = q.Where(sq.Eq{"q.status": req.Status})
+	}
+
+	query, args, err := pager.Apply(q.Columns("q.*")).ToSql()
+	if err != nil {
+		return model.ListTransfers{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return model.ListTransfers{}, err
+	}
+	transfers, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Transfer])
+	if err != nil {
+		return model.ListTransfers{}, err
+	}
+	transfers, page := pager.Collect(transfers)
+
+	if req.WithTotal() {
+		var totalElements int
+		query, args, err := q.Column("count(*)").ToSql()
+		if err != nil {
+			return model.ListTransfers{}, err
+		}
+		if err := r.db.QueryRow(ctx, query, args...).Scan(&totalElements); err != nil {
+			return model.ListTransfers{}, err
+		}
+		page.TotalElements = &totalElements
+	}
+	return model.ListTransfers{
+		Page:  page,
+		Items: transfers,
+	}, nil
+}
+
+// getTransfer gets the transfer matching where, it fails with errs.ErrNotFound if there is none.
+func getTransfer(ctx context.Context, q querier, where string, args ...any) (model.Transfer, error) {
+	rows, err := q.Query(ctx, `select q.* from (`+transferQuery+` where `+where+`) q`, args...)
+	if err != nil {
+		return model.Transfer{}, err
+	}
+	transfer, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Transfer])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Transfer{}, errs.ErrNotFound
+	}
+	return transfer, err
+}
+
+// putTransferChanged puts the change of the transfer into the outbox with the change of the available
+// count of the book in the library it is taken from or put into.
+func putTransferChanged(ctx context.Context, tx pgx.Tx, transfer model.Transfer, delta int) error {
+	libraryUid := transfer.FromLibraryUid
+	if transfer.Status == model.TransferReceived {
+		libraryUid = transfer.ToLibraryUid
+	}
+	var libraryID, bookID int
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`select l.id, b.id from %s l, %s b where l.library_uid = $1 and b.book_uid = $2`,
+		libraryTableName, booksTableName), libraryUid, transfer.BookUid).Scan(&libraryID, &bookID); err != nil {
+		return err
+	}
+	if err := putCountChanged(ctx, tx, libraryID, bookID, delta); err != nil {
+		return err
+	}
+
+	env, err := kafka.NewEnvelope(ctx, kafka.EventTransfer, producerName, "", kafka.TransferChanged{
+		Timestamp:      time.Now(),
+		TransferUid:    transfer.TransferUid,
+		BookUid:        transfer.BookUid,
+		FromLibraryUid: transfer.FromLibraryUid,
+		ToLibraryUid:   transfer.ToLibraryUid,
+		Copies:         transfer.Copies,
+		Status:         string(transfer.Status),
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.PutEvent(ctx, tx, kafka.LibraryEventsTopic, transfer.TransferUid, env)
+}
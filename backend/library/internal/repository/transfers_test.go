@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/library/migrations"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_Transfers(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	const (
+		from    = "83575e12-7ce0-48ee-9931-51919ff3c9ee"
+		to      = "93575e12-7ce0-48ee-9931-51919ff3c9ee"
+		bookUid = "f7cdc58f-2caf-4b15-9727-f89dcc629b27"
+	)
+	// the first library has three copies of the book on the shelf, the second none.
+	available := func(libraryUid string) int {
+		var count int
+		require.NoError(t, db.QueryRow(ctx, `select coalesce(sum(a.available_count), 0) from available_books a
+		join library l on l.id = a.library_id
+		join books b on b.id = a.book_id
+		where l.library_uid = $1 and b.book_uid = $2`, libraryUid, bookUid).Scan(&count))
+		return count
+	}
+	request := func(copies int) model.Transfer {
+		transfer, err := r.RequestTransfer(ctx, "librarian", model.TransferRequest{
+			BookUid: bookUid, FromLibraryUid: from, ToLibraryUid: to, Copies: copies,
+		})
+		require.NoError(t, err)
+		require.Equal(t, model.TransferInTransit, transfer.Status)
+		return transfer
+	}
+
+	received := request(2)
+	require.Equal(t, 1, available(from))
+	require.Equal(t, 0, available(to))
+	_, err = r.RequestTransfer(ctx, "librarian", model.TransferRequest{
+		BookUid: bookUid, FromLibraryUid: from, ToLibraryUid: to, Copies: 2,
+	})
+	require.ErrorIs(t, err, errs.ErrNotEnoughCopies)
+
+	transfer, err := r.ReceiveTransfer(ctx, "librarian", received.TransferUid)
+	require.NoError(t, err)
+	require.Equal(t, model.TransferReceived, transfer.Status)
+	require.Equal(t, 1, available(from))
+	require.Equal(t, 2, available(to))
+	_, err = r.CancelTransfer(ctx, "librarian", received.TransferUid)
+	require.ErrorIs(t, err, errs.ErrTransferClosed)
+
+	cancelled := request(1)
+	require.Equal(t, 0, available(from))
+	transfer, err = r.CancelTransfer(ctx, "librarian", cancelled.TransferUid)
+	require.NoError(t, err)
+	require.Equal(t, model.TransferCancelled, transfer.Status)
+	require.Equal(t, 1, available(from))
+	require.Equal(t, 2, available(to))
+
+	// an archived library receives no copies, the transfer is cancelled instead.
+	archived := request(1)
+	require.NoError(t, r.ArchiveLibrary(ctx, "librarian", to))
+	_, err = r.ReceiveTransfer(ctx, "librarian", archived.TransferUid)
+	require.ErrorIs(t, err, errs.ErrLibraryArchived)
+	transfer, err = r.GetTransfer(ctx, archived.TransferUid)
+	require.NoError(t, err)
+	require.Equal(t, model.TransferInTransit, transfer.Status)
+	require.Equal(t, 0, available(from))
+	_, err = r.CancelTransfer(ctx, "librarian", archived.TransferUid)
+	require.NoError(t, err)
+	require.Equal(t, 1, available(from))
+	require.Equal(t, 2, available(to))
+
+	// the transfers are paged by cursors, the latest first.
+	list, err := r.ListTransfers(ctx, model.ListTransfersRequest{LibraryUid: from, Request: paging.Request{Size: 2, Total: true}})
+	require.NoError(t, err)
+	require.Equal(t, []string{archived.TransferUid, cancelled.TransferUid}, transferUids(list.Items))
+	require.Equal(t, 3, *list.TotalElements)
+	require.NotEmpty(t, list.Next)
+	list, err = r.ListTransfers(ctx, model.ListTransfersRequest{LibraryUid: from, Request: paging.Request{Size: 2, Cursor: list.Next}})
+	require.NoError(t, err)
+	require.Equal(t, []string{received.TransferUid}, transferUids(list.Items))
+	require.Empty(t, list.Next)
+	require.NotEmpty(t, list.Prev)
+}
+
+func transferUids(transfers []model.Transfer) []string {
+	uids := make([]string, 0, len(transfers))
+	for _, t := range transfers {
+		uids = append(uids, t.TransferUid)
+	}
+	return uids
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/importer"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// importBatchSize is the number of rows imported in a transaction.
+const importBatchSize = 500
+
+// Import imports the books of the file into the library. The rows that fail are reported and skipped.
+// An import of the same file that was interrupted resumes after the last imported batch, the import
+// of a file that is done again only returns its report.
+func (s *Service) Import(ctx context.Context, actor, libraryUid string, format model.ImportFormat, file io.ReadSeeker) (model.ImportReport, error) {
+	if format != model.ImportCSV && format != model.ImportMARC21 {
+		return model.ImportReport{}, errors.Errorf("unknown import format %q", format)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return model.ImportReport{}, errors.Wrap(err, "read file")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return model.ImportReport{}, err
+	}
+	imp, err := s.repo.StartImport(ctx, libraryUid, format, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	if imp.Status == model.ImportDone {
+		return s.repo.GetImport(ctx, imp.ImportUid)
+	}
+	if imp.RowsDone > 0 {
+		s.log.Info("resume import", zap.String("importUid", imp.ImportUid), zap.Int("rowsDone", imp.RowsDone))
+	}
+
+	reader, err := importer.NewReader(format, file)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+	var (
+		batch   importer.Batch
+		rowErrs []model.ImportError
+		row     int
+	)
+	flush := func() error {
+		imp, err = s.repo.ImportBatch(ctx, imp, row, batch.Records(), rowErrs)
+		batch.Reset()
+		rowErrs = rowErrs[:0]
+		return err
+	}
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *importer.RowError
+		switch {
+		case errors.As(err, &rowErr):
+			row, err = rowErr.Row, rowErr.Err
+		case err != nil:
+			return model.ImportReport{}, err
+		default:
+			row = rec.Row
+		}
+		if row <= imp.RowsDone {
+			continue
+		}
+		if err == nil {
+			err = importer.Normalize(&rec)
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, model.ImportError{Row: row, Error: err.Error()})
+		} else {
+			batch.Add(rec)
+		}
+		if row-imp.RowsDone >= importBatchSize {
+			if err := flush(); err != nil {
+				return model.ImportReport{}, err
+			}
+		}
+	}
+	if row > imp.RowsDone {
+		if err := flush(); err != nil {
+			return model.ImportReport{}, err
+		}
+	}
+	if _, err := s.repo.FinishImport(ctx, actor, imp); err != nil {
+		return model.ImportReport{}, err
+	}
+	return s.repo.GetImport(ctx, imp.ImportUid)
+}
+
+func (s *Service) GetImport(ctx context.Context, importUid string) (model.ImportReport, error) {
+	return s.repo.GetImport(ctx, importUid)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Astemirdum/library-service/backend/library/internal/errs"
+	"github.com/Astemirdum/library-service/backend/library/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/isbn"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// MetadataProvider looks up the metadata of a book by its ISBN-13. It fails with errs.ErrNoMetadata
+// if it knows nothing of the book.
+type MetadataProvider interface {
+	Lookup(ctx context.Context, isbn string) (model.BookMetadata, error)
+}
+
+type noMetadata struct{}
+
+func (noMetadata) Lookup(context.Context, string) (model.BookMetadata, error) {
+	return model.BookMetadata{}, errs.ErrNoMetadata
+}
+
+// maxFieldLen is the length the name and the author of a book are limited to, the metadata is cut to it.
+const maxFieldLen = 255
+
+// CreateBook adds the book. With the ISBN set the empty fields are filled from the metadata of the
+// book, the book is added as it is when there is none unless its name is empty.
+func (s *Service) CreateBook(ctx context.Context, actor string, req model.BookRequest) (model.Book, error) {
+	if req.ISBN != "" {
+		var err error
+		if req.ISBN, err = isbn.Normalize(req.ISBN); err != nil {
+			return model.Book{}, err
+		}
+		meta, err := s.meta.Lookup(ctx, req.ISBN)
+		switch {
+		case err == nil:
+			fill(&req.Name, meta.Title, maxFieldLen)
+			fill(&req.Author, meta.Author, maxFieldLen)
+			fill(&req.CoverURL, meta.CoverURL, 0)
+			fill(&req.Description, meta.Description, 0)
+		case !errors.Is(err, errs.ErrNoMetadata):
+			s.log.Warn("metadata lookup", zap.String("isbn", req.ISBN), zap.Error(err))
+		}
+		if req.Name == "" {
+			return model.Book{}, errs.ErrNoMetadata
+		}
+	}
+	return s.repo.CreateBook(ctx, actor, req)
+}
+
+func (s *Service) UpdateBook(ctx context.Context, actor string, req model.UpdateBookRequest) (model.Book, error) {
+	if req.ISBN != nil && *req.ISBN != "" {
+		normalized, err := isbn.Normalize(*req.ISBN)
+		if err != nil {
+			return model.Book{}, err
+		}
+		req.ISBN = &normalized
+	}
+	return s.repo.UpdateBook(ctx, actor, req)
+}
+
+// EnrichBook fills the book from the metadata of its ISBN, with req.Overwrite the fields that are
+// set are replaced too. The book is not changed if the metadata adds nothing.
+func (s *Service) EnrichBook(ctx context.Context, actor string, req model.EnrichBookRequest) (model.Book, error) {
+	book, err := s.repo.FindBook(ctx, req.BookUid)
+	if err != nil {
+		return model.Book{}, err
+	}
+	if book.ISBN == "" {
+		return model.Book{}, errs.ErrNoISBN
+	}
+	meta, err := s.meta.Lookup(ctx, book.ISBN)
+	if err != nil {
+		return model.Book{}, err
+	}
+
+	update := model.UpdateBookRequest{BookUid: req.BookUid}
+	changed := false
+	set := func(field **string, current, value string, maxLen int) {
+		value = clip(value, maxLen)
+		if value == "" || value == current || (current != "" && !req.Overwrite) {
+			return
+		}
+		*field = &value
+		changed = true
+	}
+	set(&update.Name, book.Name, meta.Title, maxFieldLen)
+	set(&update.Author, book.Author, meta.Author, maxFieldLen)
+	set(&update.CoverURL, book.CoverURL, meta.CoverURL, 0)
+	set(&update.Description, book.Description, meta.Description, 0)
+	if !changed {
+		return book, nil
+	}
+	return s.repo.UpdateBook(ctx, actor, update)
+}
+
+// fill sets an empty field to the value cut to maxLen runes, 0 is no limit.
+func fill(field *string, value string, maxLen int) {
+	if *field == "" {
+		*field = clip(value, maxLen)
+	}
+}
+
+func clip(s string, maxLen int) string {
+	if r := []rune(s); maxLen > 0 && len(r) > maxLen {
+		return string(r[:maxLen])
+	}
+	return s
+}
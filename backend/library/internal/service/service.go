@@ -5,18 +5,26 @@ import (
 
 	"github.com/Astemirdum/library-service/backend/library/internal/model"
 	libraryRepo "github.com/Astemirdum/library-service/backend/library/internal/repository"
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"go.uber.org/zap"
 )
 
 type Service struct {
 	log  *zap.Logger
 	repo libraryRepo.Repository
+	meta MetadataProvider
 }
 
-func NewService(repo libraryRepo.Repository, log *zap.Logger) *Service {
+// NewService makes the service, without a metadata provider the books are not enriched.
+func NewService(repo libraryRepo.Repository, meta MetadataProvider, log *zap.Logger) *Service {
+	if meta == nil {
+		meta = noMetadata{}
+	}
 	return &Service{
 		log:  log,
 		repo: repo,
+		meta: meta,
 	}
 }
 
@@ -24,18 +32,122 @@ func (s *Service) GetBook(ctx context.Context, libraryUid, bookUid string) (mode
 	return s.repo.GetBook(ctx, libraryUid, bookUid)
 }
 
-func (s *Service) AvailableCount(ctx context.Context, libraryID, bookID int, isReturn bool) error {
-	return s.repo.AvailableCount(ctx, libraryID, bookID, isReturn)
+func (s *Service) AvailableCount(ctx context.Context, req model.AvailableCountRequest) (model.BookCopy, error) {
+	return s.repo.AvailableCount(ctx, req)
+}
+
+func (s *Service) ListCopies(ctx context.Context, libraryUid, bookUid string) ([]model.BookCopy, error) {
+	return s.repo.ListCopies(ctx, libraryUid, bookUid)
+}
+
+func (s *Service) SetCopyStatus(ctx context.Context, req model.CopyStatusRequest) (model.BookCopy, error) {
+	return s.repo.SetCopyStatus(ctx, req)
 }
 
 func (s *Service) GetLibrary(ctx context.Context, libraryUid string) (model.Library, error) {
 	return s.repo.GetLibrary(ctx, libraryUid)
 }
 
-func (s *Service) ListLibrary(ctx context.Context, city string, page, size int) (model.ListLibraries, error) {
-	return s.repo.ListLibrary(ctx, city, page, size)
+func (s *Service) ListLibrary(ctx context.Context, city string, req paging.Request) (model.ListLibraries, error) {
+	return s.repo.ListLibrary(ctx, city, req)
+}
+
+func (s *Service) ListBooks(ctx context.Context, libraryUid string, showAll bool, req paging.Request) (model.ListBooks, error) {
+	return s.repo.ListBooks(ctx, libraryUid, showAll, req)
+}
+
+func (s *Service) SearchBooks(ctx context.Context, req model.SearchBooksRequest) (model.ListBookHits, error) {
+	return s.repo.SearchBooks(ctx, req)
+}
+
+const (
+	defaultNearbyRadiusKm = 5
+	defaultNearbySize     = 20
+)
+
+// NearbyLibraries finds the libraries near a point, 5 km around and 20 libraries unless asked otherwise.
+func (s *Service) NearbyLibraries(ctx context.Context, req model.NearbyLibrariesRequest) (model.ListNearbyLibraries, error) {
+	if req.Radius == 0 {
+		req.Radius = defaultNearbyRadiusKm
+	}
+	if req.Size == 0 {
+		req.Size = defaultNearbySize
+	}
+	return s.repo.NearbyLibraries(ctx, req)
+}
+
+func (s *Service) CreateLibrary(ctx context.Context, actor string, req model.LibraryRequest) (model.Library, error) {
+	return s.repo.CreateLibrary(ctx, actor, req)
+}
+
+func (s *Service) UpdateLibrary(ctx context.Context, actor string, req model.UpdateLibraryRequest) (model.Library, error) {
+	return s.repo.UpdateLibrary(ctx, actor, req)
+}
+
+func (s *Service) SetSchedule(ctx context.Context, actor string, req model.ScheduleRequest) (calendar.Schedule, error) {
+	return s.repo.SetSchedule(ctx, actor, req)
+}
+
+func (s *Service) ListAuthors(ctx context.Context, req model.ListAuthorsRequest) (model.ListAuthors, error) {
+	return s.repo.ListAuthors(ctx, req)
+}
+
+func (s *Service) AddAuthorAlias(ctx context.Context, actor string, req model.AuthorAliasRequest) (model.Author, error) {
+	return s.repo.AddAuthorAlias(ctx, actor, req)
+}
+
+func (s *Service) MergeAuthors(ctx context.Context, actor string, req model.MergeAuthorsRequest) (model.Author, error) {
+	return s.repo.MergeAuthors(ctx, actor, req)
+}
+
+func (s *Service) BooksByAuthor(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	return s.repo.BooksByAuthor(ctx, req)
+}
+
+func (s *Service) ListGenres(ctx context.Context) ([]model.Genre, error) {
+	return s.repo.ListGenres(ctx)
+}
+
+func (s *Service) CreateGenre(ctx context.Context, actor string, req model.GenreRequest) (model.Genre, error) {
+	return s.repo.CreateGenre(ctx, actor, req)
+}
+
+func (s *Service) UpdateGenre(ctx context.Context, actor string, req model.UpdateGenreRequest) (model.Genre, error) {
+	return s.repo.UpdateGenre(ctx, actor, req)
+}
+
+func (s *Service) BooksByGenre(ctx context.Context, req model.CatalogBooksRequest) (model.ListCatalogBooks, error) {
+	return s.repo.BooksByGenre(ctx, req)
+}
+
+func (s *Service) ArchiveLibrary(ctx context.Context, actor, libraryUid string) error {
+	return s.repo.ArchiveLibrary(ctx, actor, libraryUid)
+}
+
+func (s *Service) SetStock(ctx context.Context, actor string, req model.StockRequest) (model.Stock, error) {
+	return s.repo.SetStock(ctx, actor, req)
+}
+
+func (s *Service) RequestTransfer(ctx context.Context, actor string, req model.TransferRequest) (model.Transfer, error) {
+	return s.repo.RequestTransfer(ctx, actor, req)
+}
+
+func (s *Service) ReceiveTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	return s.repo.ReceiveTransfer(ctx, actor, transferUid)
+}
+
+func (s *Service) CancelTransfer(ctx context.Context, actor, transferUid string) (model.Transfer, error) {
+	return s.repo.CancelTransfer(ctx, actor, transferUid)
+}
+
+func (s *Service) GetTransfer(ctx context.Context, transferUid string) (model.Transfer, error) {
+	return s.repo.GetTransfer(ctx, transferUid)
+}
+
+func (s *Service) ListTransfers(ctx context.Context, req model.ListTransfersRequest) (model.ListTransfers, error) {
+	return s.repo.ListTransfers(ctx, req)
 }
 
-func (s *Service) ListBooks(ctx context.Context, libraryUid string, showAll bool, page, size int) (model.ListBooks, error) {
-	return s.repo.ListBooks(ctx, libraryUid, showAll, page, size)
+func (s *Service) ListAudit(ctx context.Context, entityUid string) ([]model.AuditRecord, error) {
+	return s.repo.ListAudit(ctx, entityUid)
 }
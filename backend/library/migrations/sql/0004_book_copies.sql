@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS book_copies
+(
+    id              int generated always as identity PRIMARY KEY,
+    copy_uid        uuid UNIQUE NOT NULL,
+    barcode         VARCHAR(64) UNIQUE NOT NULL,
+    book_id         INT         NOT NULL REFERENCES books (id),
+    library_id      INT         NOT NULL REFERENCES library (id),
+    condition       VARCHAR(20) NOT NULL DEFAULT 'EXCELLENT'
+        CHECK (condition IN ('EXCELLENT', 'GOOD', 'BAD')),
+    status          VARCHAR(20) NOT NULL DEFAULT 'ON_SHELF'
+        CHECK (status IN ('ON_SHELF', 'ON_LOAN', 'IN_REPAIR', 'LOST')),
+    acquired_at     DATE        NOT NULL DEFAULT current_date,
+    -- reservation_uid is the reservation the copy is lent on.
+    reservation_uid uuid UNIQUE,
+    updated_at      TIMESTAMP   NOT NULL DEFAULT now(),
+    CHECK (reservation_uid IS NULL OR status = 'ON_LOAN')
+);
+
+CREATE INDEX IF NOT EXISTS book_copies_library_book_idx ON book_copies (library_id, book_id, status);
+
+-- every counted copy becomes a copy on the shelf.
+INSERT INTO book_copies (copy_uid, barcode, book_id, library_id, condition)
+SELECT gen_random_uuid(), format('%s-%s-%s', lb.library_id, lb.book_id, n), lb.book_id, lb.library_id, b.condition
+FROM library_books lb
+         JOIN books b ON b.id = lb.book_id
+         CROSS JOIN generate_series(1, lb.available_count) n;
+
+ALTER TABLE library_books DROP COLUMN available_count;
+ALTER TABLE library_books ADD PRIMARY KEY (library_id, book_id);
+
+-- available_books derives the available count of the books of a library from the status of their copies.
+CREATE VIEW available_books AS
+SELECT lb.library_id,
+       lb.book_id,
+       count(c.id) FILTER (WHERE c.status = 'ON_SHELF')::int AS available_count
+FROM library_books lb
+         LEFT JOIN book_copies c ON c.library_id = lb.library_id AND c.book_id = lb.book_id
+GROUP BY lb.library_id, lb.book_id;
+
+-- +goose Down
+DROP VIEW IF EXISTS available_books;
+ALTER TABLE library_books DROP CONSTRAINT IF EXISTS library_books_pkey;
+ALTER TABLE library_books ADD COLUMN available_count INT NOT NULL DEFAULT 0 CHECK (available_count >= 0);
+UPDATE library_books lb
+SET available_count = (SELECT count(*)
+                       FROM book_copies c
+                       WHERE c.library_id = lb.library_id
+                         AND c.book_id = lb.book_id
+                         AND c.status = 'ON_SHELF');
+DROP TABLE IF EXISTS book_copies CASCADE;
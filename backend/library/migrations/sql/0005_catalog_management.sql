@@ -0,0 +1,30 @@
+-- +goose Up
+-- archived libraries are kept for the reservations made in them but lend no more copies.
+ALTER TABLE library ADD COLUMN archived_at TIMESTAMP;
+
+-- withdrawn copies are taken out of the stock, they are kept for the reservations they were lent on.
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('ON_SHELF', 'ON_LOAN', 'IN_REPAIR', 'LOST', 'WITHDRAWN'));
+
+-- catalog_audit is the trail of the changes librarians made to the catalog.
+CREATE TABLE IF NOT EXISTS catalog_audit
+(
+    id         bigint generated always as identity PRIMARY KEY,
+    entity     VARCHAR(20)  NOT NULL CHECK (entity IN ('LIBRARY', 'BOOK', 'STOCK')),
+    entity_uid uuid         NOT NULL,
+    action     VARCHAR(20)  NOT NULL CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK')),
+    actor      VARCHAR(255) NOT NULL,
+    changes    jsonb        NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS catalog_audit_entity_idx ON catalog_audit (entity_uid, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS catalog_audit;
+UPDATE book_copies SET status = 'LOST' WHERE status = 'WITHDRAWN';
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('ON_SHELF', 'ON_LOAN', 'IN_REPAIR', 'LOST'));
+ALTER TABLE library DROP COLUMN IF EXISTS archived_at;
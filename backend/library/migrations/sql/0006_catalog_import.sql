@@ -0,0 +1,45 @@
+-- +goose Up
+-- isbn is the normalized ISBN of the book, imports deduplicate books by it or by the title and the author.
+ALTER TABLE books ADD COLUMN isbn VARCHAR(13);
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn_idx ON books (isbn) WHERE isbn IS NOT NULL;
+CREATE INDEX IF NOT EXISTS books_name_author_idx ON books (lower(name), lower(coalesce(author, '')));
+
+-- catalog_imports tracks the progress of the imports of a file into a library, an interrupted import
+-- of the same file resumes after rows_done.
+CREATE TABLE IF NOT EXISTS catalog_imports
+(
+    id          int generated always as identity PRIMARY KEY,
+    import_uid  uuid UNIQUE NOT NULL,
+    library_id  INT         NOT NULL REFERENCES library (id),
+    format      VARCHAR(10) NOT NULL CHECK (format IN ('CSV', 'MARC21')),
+    checksum    VARCHAR(64) NOT NULL,
+    status      VARCHAR(20) NOT NULL DEFAULT 'IN_PROGRESS' CHECK (status IN ('IN_PROGRESS', 'DONE')),
+    rows_done   INT         NOT NULL DEFAULT 0,
+    rows_failed INT         NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMP   NOT NULL DEFAULT now(),
+    UNIQUE (library_id, format, checksum)
+);
+
+CREATE TABLE IF NOT EXISTS catalog_import_errors
+(
+    import_id INT  NOT NULL REFERENCES catalog_imports (id) ON DELETE CASCADE,
+    row_num   INT  NOT NULL,
+    error     TEXT NOT NULL,
+    PRIMARY KEY (import_id, row_num)
+);
+
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK', 'IMPORT'));
+
+-- +goose Down
+DELETE FROM catalog_audit WHERE action = 'IMPORT';
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK'));
+DROP TABLE IF EXISTS catalog_import_errors;
+DROP TABLE IF EXISTS catalog_imports;
+DROP INDEX IF EXISTS books_name_author_idx;
+DROP INDEX IF EXISTS books_isbn_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn;
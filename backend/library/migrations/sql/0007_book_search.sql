@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- search is the full-text document of a book. Titles and genres are stemmed in Russian and English,
+-- author names are kept as they are.
+ALTER TABLE books ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(author, '')), 'B') ||
+    setweight(to_tsvector('russian', coalesce(genre, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(genre, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS books_search_idx ON books USING gin (search);
+-- the trigram indexes serve the fuzzy matching of misspelled titles and authors.
+CREATE INDEX IF NOT EXISTS books_name_trgm_idx ON books USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS books_author_trgm_idx ON books USING gin (author gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS books_author_trgm_idx;
+DROP INDEX IF EXISTS books_name_trgm_idx;
+DROP INDEX IF EXISTS books_search_idx;
+ALTER TABLE books DROP COLUMN IF EXISTS search;
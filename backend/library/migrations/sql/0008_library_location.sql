@@ -0,0 +1,35 @@
+-- +goose Up
+-- city_key normalizes a city name, so that 'Москва', ' москва ' and 'МОСКВА.' are the same city:
+-- case, ё, spaces and punctuation do not matter.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION city_key(city text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT
+AS $$
+    SELECT regexp_replace(translate(lower(city), 'ё', 'е'), '[^[:alnum:]]+', '', 'g')
+$$;
+-- +goose StatementEnd
+
+ALTER TABLE library
+    ADD COLUMN city_key  TEXT GENERATED ALWAYS AS (city_key(city)) STORED,
+    ADD COLUMN latitude  DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD CONSTRAINT library_location_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+CREATE INDEX IF NOT EXISTS library_city_key_idx ON library (city_key) WHERE archived_at IS NULL;
+-- the location index serves the bounding box of the nearby lookup.
+CREATE INDEX IF NOT EXISTS library_location_idx ON library (latitude, longitude) WHERE archived_at IS NULL;
+
+UPDATE library SET latitude = 55.765386, longitude = 37.684914
+WHERE library_uid = '83575e12-7ce0-48ee-9931-51919ff3c9ee';
+UPDATE library SET latitude = 55.772102, longitude = 37.686542
+WHERE library_uid = '93575e12-7ce0-48ee-9931-51919ff3c9ee';
+
+-- +goose Down
+DROP INDEX IF EXISTS library_location_idx;
+DROP INDEX IF EXISTS library_city_key_idx;
+ALTER TABLE library
+    DROP CONSTRAINT IF EXISTS library_location_check,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS city_key;
+DROP FUNCTION IF EXISTS city_key(text);
@@ -0,0 +1,34 @@
+-- +goose Up
+-- library_hours are the weekly opening hours, a library is closed on the weekdays it has no hours for.
+-- weekday is ISO: 1 is Monday, 7 is Sunday.
+CREATE TABLE IF NOT EXISTS library_hours
+(
+    library_id INT      NOT NULL REFERENCES library (id),
+    weekday    SMALLINT NOT NULL CHECK (weekday BETWEEN 1 AND 7),
+    opens      TIME     NOT NULL,
+    closes     TIME     NOT NULL CHECK (opens < closes),
+    PRIMARY KEY (library_id, weekday)
+);
+
+-- library_exceptions change the hours of a date, a library is closed on the date if it has no hours.
+CREATE TABLE IF NOT EXISTS library_exceptions
+(
+    library_id INT  NOT NULL REFERENCES library (id),
+    day        DATE NOT NULL,
+    opens      TIME,
+    closes     TIME,
+    note       VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (library_id, day),
+    CHECK ((opens IS NULL) = (closes IS NULL)),
+    CHECK (opens < closes)
+);
+
+INSERT INTO library_hours (library_id, weekday, opens, closes)
+SELECT l.id, d.weekday, '10:00', CASE WHEN d.weekday = 6 THEN '18:00'::time ELSE '20:00'::time END
+FROM library l
+CROSS JOIN generate_series(1, 6) d(weekday)
+WHERE l.library_uid IN ('83575e12-7ce0-48ee-9931-51919ff3c9ee', '93575e12-7ce0-48ee-9931-51919ff3c9ee');
+
+-- +goose Down
+DROP TABLE IF EXISTS library_exceptions;
+DROP TABLE IF EXISTS library_hours;
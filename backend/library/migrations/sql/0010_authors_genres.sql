@@ -0,0 +1,155 @@
+-- +goose Up
+-- translit_key normalizes a name across scripts and word orders: Cyrillic is transliterated to Latin,
+-- case and punctuation are dropped and the words are sorted, so 'Кнут, Дональд' and 'Donald Knut' match.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION translit_key(name text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT
+AS $$
+    SELECT coalesce(string_agg(w, ' ' ORDER BY w), '')
+    FROM regexp_split_to_table(
+        translate(
+            replace(replace(replace(replace(replace(replace(replace(replace(replace(
+                lower(name), 'щ', 'shch'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'),
+                'ю', 'yu'), 'я', 'ya'), 'ё', 'e'),
+            'абвгдезийклмнопрстуфыэъь', 'abvgdeziiklmnoprstufye'),
+        '[^[:alnum:]]+') w
+    WHERE w <> ''
+$$;
+-- +goose StatementEnd
+
+CREATE TABLE IF NOT EXISTS authors
+(
+    id         SERIAL PRIMARY KEY,
+    author_uid uuid UNIQUE  NOT NULL DEFAULT gen_random_uuid(),
+    name       VARCHAR(255) NOT NULL
+);
+
+-- author_aliases are the names an author is known by, the name of the author included.
+-- An alias names one author only, whatever the script or the word order.
+CREATE TABLE IF NOT EXISTS author_aliases
+(
+    author_id INT          NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+    alias     VARCHAR(255) NOT NULL,
+    alias_key TEXT GENERATED ALWAYS AS (translit_key(alias)) STORED,
+    PRIMARY KEY (author_id, alias)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS author_aliases_key_idx ON author_aliases (alias_key);
+
+CREATE TABLE IF NOT EXISTS genres
+(
+    id        SERIAL PRIMARY KEY,
+    genre_uid uuid UNIQUE  NOT NULL DEFAULT gen_random_uuid(),
+    name      VARCHAR(255) NOT NULL,
+    name_key  TEXT GENERATED ALWAYS AS (translit_key(name)) STORED UNIQUE,
+    parent_id INT REFERENCES genres (id),
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS genres_parent_idx ON genres (parent_id);
+
+CREATE TABLE IF NOT EXISTS book_authors
+(
+    book_id   INT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES authors (id),
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_idx ON book_authors (author_id);
+
+CREATE TABLE IF NOT EXISTS book_genres
+(
+    book_id  INT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id INT NOT NULL REFERENCES genres (id),
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_genres_genre_idx ON book_genres (genre_id);
+
+-- link_book links the book to the authors and the genres named by its author and genre columns,
+-- several of them are separated by ';'. Unknown authors and genres are added.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION link_book(p_book_id int, p_author text, p_genre text) RETURNS void
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    v_name      text;
+    v_author_id int;
+    v_genre_id  int;
+BEGIN
+    DELETE FROM book_authors WHERE book_id = p_book_id;
+    FOREACH v_name IN ARRAY coalesce(string_to_array(p_author, ';'), '{}') LOOP
+        v_name := trim(v_name);
+        CONTINUE WHEN translit_key(v_name) = '';
+        SELECT author_id INTO v_author_id FROM author_aliases WHERE alias_key = translit_key(v_name);
+        IF v_author_id IS NULL THEN
+            INSERT INTO authors (name) VALUES (v_name) RETURNING id INTO v_author_id;
+            INSERT INTO author_aliases (author_id, alias) VALUES (v_author_id, v_name) ON CONFLICT (alias_key) DO NOTHING;
+            IF NOT FOUND THEN
+                -- the author was added concurrently.
+                DELETE FROM authors WHERE id = v_author_id;
+                SELECT author_id INTO v_author_id FROM author_aliases WHERE alias_key = translit_key(v_name);
+            END IF;
+        END IF;
+        INSERT INTO book_authors (book_id, author_id) VALUES (p_book_id, v_author_id) ON CONFLICT DO NOTHING;
+    END LOOP;
+
+    DELETE FROM book_genres WHERE book_id = p_book_id;
+    FOREACH v_name IN ARRAY coalesce(string_to_array(p_genre, ';'), '{}') LOOP
+        v_name := trim(v_name);
+        CONTINUE WHEN translit_key(v_name) = '';
+        INSERT INTO genres (name) VALUES (v_name) ON CONFLICT (name_key) DO NOTHING RETURNING id INTO v_genre_id;
+        IF v_genre_id IS NULL THEN
+            SELECT id INTO v_genre_id FROM genres WHERE name_key = translit_key(v_name);
+        END IF;
+        INSERT INTO book_genres (book_id, genre_id) VALUES (p_book_id, v_genre_id) ON CONFLICT DO NOTHING;
+    END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION books_link() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+BEGIN
+    PERFORM link_book(NEW.id, NEW.author, NEW.genre);
+    RETURN NEW;
+END
+$$;
+-- +goose StatementEnd
+
+-- the author and genre columns of books stay as they are written, the links follow them.
+CREATE TRIGGER books_link AFTER INSERT OR UPDATE OF author, genre ON books
+    FOR EACH ROW EXECUTE FUNCTION books_link();
+
+SELECT link_book(id, author, genre) FROM books ORDER BY id;
+
+INSERT INTO author_aliases (author_id, alias)
+SELECT author_id, 'Bjarne Stroustrup' FROM author_aliases WHERE alias = 'Бьерн Страуструп'
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_entity_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_entity_check
+    CHECK (entity IN ('LIBRARY', 'BOOK', 'STOCK', 'AUTHOR', 'GENRE'));
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK', 'IMPORT', 'MERGE'));
+
+-- +goose Down
+DELETE FROM catalog_audit WHERE entity IN ('AUTHOR', 'GENRE');
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK', 'IMPORT'));
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_entity_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_entity_check
+    CHECK (entity IN ('LIBRARY', 'BOOK', 'STOCK'));
+DROP TRIGGER IF EXISTS books_link ON books;
+DROP FUNCTION IF EXISTS books_link();
+DROP FUNCTION IF EXISTS link_book(int, text, text);
+DROP TABLE IF EXISTS book_genres;
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS genres;
+DROP TABLE IF EXISTS author_aliases;
+DROP TABLE IF EXISTS authors;
+DROP FUNCTION IF EXISTS translit_key(text);
@@ -0,0 +1,48 @@
+-- +goose Up
+-- isbn13 converts a normalized ISBN-10 to the ISBN-13, an ISBN-13 stays as it is.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION isbn13(isbn text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT
+AS $$
+    SELECT CASE WHEN length(isbn) = 13 THEN isbn ELSE (
+        SELECT p || (10 - sum(substr(p, i, 1)::int * CASE WHEN i % 2 = 1 THEN 1 ELSE 3 END) % 10) % 10
+        FROM (SELECT '978' || left(isbn, 9) p) s
+        CROSS JOIN generate_series(1, 12) i
+        GROUP BY p
+    ) END
+$$;
+-- +goose StatementEnd
+
+-- isbn10 converts an ISBN-13 of prefix 978 to the ISBN-10, the other ones have none.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION isbn10(isbn text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE RETURNS NULL ON NULL INPUT
+AS $$
+    SELECT CASE WHEN left(isbn, 3) = '978' THEN (
+        SELECT p || CASE c WHEN 10 THEN 'X' ELSE c::text END
+        FROM (
+            SELECT p, (11 - sum(substr(p, i, 1)::int * (11 - i)) % 11) % 11 c
+            FROM (SELECT substr(isbn, 4, 9) p) s
+            CROSS JOIN generate_series(1, 9) i
+            GROUP BY p
+        ) t
+    ) END
+$$;
+-- +goose StatementEnd
+
+-- books are stored by the ISBN-13, the imports stored the ISBN-10 as they were written.
+-- A book whose ISBN-10 names the same book as another one's ISBN-13 loses it to that one.
+UPDATE books b SET isbn = NULL
+WHERE length(b.isbn) = 10 AND EXISTS (SELECT 1 FROM books o WHERE o.isbn = isbn13(b.isbn));
+UPDATE books SET isbn = isbn13(isbn) WHERE length(isbn) = 10;
+
+ALTER TABLE books ADD CONSTRAINT books_isbn_check CHECK (isbn ~ '^[0-9]{13}$');
+ALTER TABLE books ADD COLUMN cover_url VARCHAR(1024);
+ALTER TABLE books ADD COLUMN description TEXT;
+
+-- +goose Down
+ALTER TABLE books DROP COLUMN IF EXISTS description;
+ALTER TABLE books DROP COLUMN IF EXISTS cover_url;
+ALTER TABLE books DROP CONSTRAINT IF EXISTS books_isbn_check;
+DROP FUNCTION IF EXISTS isbn10(text);
+DROP FUNCTION IF EXISTS isbn13(text);
@@ -0,0 +1,57 @@
+-- +goose Up
+-- transfers move copies of a book from a library to another one. The copies in transit are
+-- counted in neither library until the transfer is received, or cancelled.
+CREATE TABLE IF NOT EXISTS transfers
+(
+    id              SERIAL PRIMARY KEY,
+    transfer_uid    uuid UNIQUE  NOT NULL DEFAULT gen_random_uuid(),
+    book_id         INT          NOT NULL REFERENCES books (id),
+    from_library_id INT          NOT NULL REFERENCES library (id),
+    to_library_id   INT          NOT NULL REFERENCES library (id),
+    copies          INT          NOT NULL CHECK (copies > 0),
+    status          VARCHAR(20)  NOT NULL DEFAULT 'IN_TRANSIT'
+        CHECK (status IN ('IN_TRANSIT', 'RECEIVED', 'CANCELLED')),
+    requested_by    VARCHAR(255) NOT NULL,
+    requested_at    TIMESTAMP    NOT NULL DEFAULT now(),
+    closed_by       VARCHAR(255),
+    closed_at       TIMESTAMP,
+    CHECK (from_library_id <> to_library_id),
+    CHECK ((status = 'IN_TRANSIT') = (closed_at IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS transfers_from_idx ON transfers (from_library_id, status);
+CREATE INDEX IF NOT EXISTS transfers_to_idx ON transfers (to_library_id, status);
+
+-- transfer_id is the transfer that moved the copy last.
+ALTER TABLE book_copies ADD COLUMN transfer_id INT REFERENCES transfers (id);
+CREATE INDEX IF NOT EXISTS book_copies_transfer_idx ON book_copies (transfer_id) WHERE transfer_id IS NOT NULL;
+
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('ON_SHELF', 'ON_LOAN', 'IN_REPAIR', 'LOST', 'WITHDRAWN', 'IN_TRANSIT'));
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_transfer_check
+    CHECK (status <> 'IN_TRANSIT' OR transfer_id IS NOT NULL);
+
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_entity_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_entity_check
+    CHECK (entity IN ('LIBRARY', 'BOOK', 'STOCK', 'AUTHOR', 'GENRE', 'TRANSFER'));
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK', 'IMPORT', 'MERGE', 'RECEIVE', 'CANCEL'));
+
+-- +goose Down
+DELETE FROM catalog_audit WHERE entity = 'TRANSFER';
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_action_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_action_check
+    CHECK (action IN ('CREATE', 'UPDATE', 'ARCHIVE', 'SET_STOCK', 'IMPORT', 'MERGE'));
+ALTER TABLE catalog_audit DROP CONSTRAINT IF EXISTS catalog_audit_entity_check;
+ALTER TABLE catalog_audit ADD CONSTRAINT catalog_audit_entity_check
+    CHECK (entity IN ('LIBRARY', 'BOOK', 'STOCK', 'AUTHOR', 'GENRE'));
+-- the copies in transit go back to the shelf of the library they were sent from.
+UPDATE book_copies SET status = 'ON_SHELF' WHERE status = 'IN_TRANSIT';
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_transfer_check;
+ALTER TABLE book_copies DROP CONSTRAINT IF EXISTS book_copies_status_check;
+ALTER TABLE book_copies ADD CONSTRAINT book_copies_status_check
+    CHECK (status IN ('ON_SHELF', 'ON_LOAN', 'IN_REPAIR', 'LOST', 'WITHDRAWN'));
+ALTER TABLE book_copies DROP COLUMN IF EXISTS transfer_id;
+DROP TABLE IF EXISTS transfers;
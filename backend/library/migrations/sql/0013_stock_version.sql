@@ -0,0 +1,47 @@
+-- +goose Up
+-- version counts the changes of the copies of a book in a library. A lend claims the stock it has
+-- read by bumping the version it read, so a lend racing with another change reads the stock again.
+ALTER TABLE library_books ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION book_copies_version() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE library_books SET version = version + 1
+        WHERE library_id = OLD.library_id AND book_id = OLD.book_id;
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND (NEW.library_id, NEW.book_id) IS DISTINCT FROM (OLD.library_id, OLD.book_id)) THEN
+        UPDATE library_books SET version = version + 1
+        WHERE library_id = NEW.library_id AND book_id = NEW.book_id;
+    END IF;
+    RETURN NULL;
+END
+$$;
+-- +goose StatementEnd
+
+CREATE TRIGGER book_copies_version AFTER INSERT OR DELETE OR UPDATE OF status, library_id, book_id ON book_copies
+    FOR EACH ROW EXECUTE FUNCTION book_copies_version();
+
+CREATE OR REPLACE VIEW available_books AS
+SELECT lb.library_id,
+       lb.book_id,
+       count(c.id) FILTER (WHERE c.status = 'ON_SHELF')::int AS available_count,
+       lb.version
+FROM library_books lb
+         LEFT JOIN book_copies c ON c.library_id = lb.library_id AND c.book_id = lb.book_id
+GROUP BY lb.library_id, lb.book_id;
+
+-- +goose Down
+DROP VIEW IF EXISTS available_books;
+CREATE VIEW available_books AS
+SELECT lb.library_id,
+       lb.book_id,
+       count(c.id) FILTER (WHERE c.status = 'ON_SHELF')::int AS available_count
+FROM library_books lb
+         LEFT JOIN book_copies c ON c.library_id = lb.library_id AND c.book_id = lb.book_id
+GROUP BY lb.library_id, lb.book_id;
+DROP TRIGGER IF EXISTS book_copies_version ON book_copies;
+DROP FUNCTION IF EXISTS book_copies_version();
+ALTER TABLE library_books DROP COLUMN IF EXISTS version;
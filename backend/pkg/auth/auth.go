@@ -13,7 +13,8 @@ const (
 	XUserNameHeader = "X-User-Name"
 	XUserRoleHeader = "X-User-Role"
 
-	adminRole = "admin"
+	adminRole     = "admin"
+	librarianRole = "librarian"
 )
 
 type CtxUserKey int
@@ -50,6 +51,15 @@ func IsAdmin(getter Get) bool {
 	return role == adminRole
 }
 
+// IsLibrarian reports whether the user may manage the catalog, admins may too.
+func IsLibrarian(getter Get) bool {
+	role, err := GetUserRole(getter)
+	if err != nil {
+		return false
+	}
+	return role == librarianRole || role == adminRole
+}
+
 func GetUserName(getter Get) (string, error) {
 	userName, ok := getter.Value(userNameKey).(string)
 	if !ok {
@@ -0,0 +1,99 @@
+// Package calendar tells the days a library is open by its weekly opening hours and exception dates.
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of the exception dates.
+const DateLayout = "2006-01-02"
+
+// lookAhead is how many days NextOpenDay looks for an open day.
+const lookAhead = 366
+
+// OpeningHours are the hours a library is open on a weekday, 1 is Monday and 7 is Sunday.
+type OpeningHours struct {
+	Weekday int    `json:"weekday" db:"weekday" validate:"min=1,max=7"`
+	Opens   string `json:"opens" db:"opens" validate:"required,datetime=15:04"`
+	Closes  string `json:"closes" db:"closes" validate:"required,datetime=15:04"`
+}
+
+// Exception changes the hours of a date, such as a holiday. The library is closed on the date
+// unless Opens and Closes are set.
+type Exception struct {
+	Date   string  `json:"date" db:"day" validate:"required,datetime=2006-01-02"`
+	Opens  *string `json:"opens,omitempty" db:"opens" validate:"required_with=Closes,omitempty,datetime=15:04"`
+	Closes *string `json:"closes,omitempty" db:"closes" validate:"required_with=Opens,omitempty,datetime=15:04"`
+	Note   string  `json:"note,omitempty" db:"note" validate:"max=255"`
+}
+
+// Schedule is the weekly opening hours of a library with the exception dates.
+// A library without opening hours is taken to be always open.
+type Schedule struct {
+	OpeningHours []OpeningHours `json:"openingHours,omitempty" validate:"dive"`
+	Exceptions   []Exception    `json:"exceptions,omitempty" validate:"dive"`
+}
+
+// Validate checks that every day opens before it closes and has its hours set once.
+// The fields are expected to pass the validate tags.
+func (s Schedule) Validate() error {
+	weekdays := make(map[int]bool, len(s.OpeningHours))
+	for _, h := range s.OpeningHours {
+		if weekdays[h.Weekday] {
+			return fmt.Errorf("weekday %d is set twice", h.Weekday)
+		}
+		weekdays[h.Weekday] = true
+		if h.Opens >= h.Closes {
+			return fmt.Errorf("weekday %d closes before it opens", h.Weekday)
+		}
+	}
+	dates := make(map[string]bool, len(s.Exceptions))
+	for _, e := range s.Exceptions {
+		if dates[e.Date] {
+			return fmt.Errorf("date %s is set twice", e.Date)
+		}
+		dates[e.Date] = true
+		if (e.Opens == nil) != (e.Closes == nil) {
+			return fmt.Errorf("date %s needs both opens and closes", e.Date)
+		}
+		if e.Opens != nil && *e.Opens >= *e.Closes {
+			return fmt.Errorf("date %s closes before it opens", e.Date)
+		}
+	}
+	return nil
+}
+
+// IsOpen reports whether the library is open on the date of day.
+func (s Schedule) IsOpen(day time.Time) bool {
+	if len(s.OpeningHours) == 0 {
+		return true
+	}
+	date := day.Format(DateLayout)
+	for _, e := range s.Exceptions {
+		if e.Date == date {
+			return e.Opens != nil
+		}
+	}
+	weekday := int(day.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	for _, h := range s.OpeningHours {
+		if h.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpenDay is day if the library is open on it, the next day it is open otherwise.
+// Day is returned as is if the library is not open within a year.
+func (s Schedule) NextOpenDay(day time.Time) time.Time {
+	for i := 0; i < lookAhead; i++ {
+		if next := day.AddDate(0, 0, i); s.IsOpen(next) {
+			return next
+		}
+	}
+	return day
+}
@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_NextOpenDay(t *testing.T) {
+	t.Parallel()
+	opens, closes := "12:00", "16:00"
+	weekdays := make([]OpeningHours, 0, 5)
+	for wd := 1; wd <= 5; wd++ {
+		weekdays = append(weekdays, OpeningHours{Weekday: wd, Opens: "10:00", Closes: "20:00"})
+	}
+	s := Schedule{
+		OpeningHours: weekdays,
+		Exceptions: []Exception{
+			{Date: "2024-05-01", Note: "Праздник Весны и Труда"},
+			{Date: "2024-05-04", Opens: &opens, Closes: &closes},
+		},
+	}
+	date := func(d string) time.Time {
+		day, err := time.Parse(DateLayout, d)
+		require.NoError(t, err)
+		return day
+	}
+
+	for _, tt := range []struct{ day, want string }{
+		{"2024-04-29", "2024-04-29"}, // monday
+		{"2024-05-01", "2024-05-02"}, // holiday
+		{"2024-05-04", "2024-05-04"}, // saturday with short hours
+		{"2024-05-05", "2024-05-06"}, // sunday
+	} {
+		require.Equal(t, date(tt.want), s.NextOpenDay(date(tt.day)), tt.day)
+	}
+
+	require.Equal(t, date("2024-05-05"), Schedule{}.NextOpenDay(date("2024-05-05")), "no hours")
+	closed := Schedule{OpeningHours: []OpeningHours{{Weekday: 1, Opens: "10:00", Closes: "20:00"}}}
+	for i := 0; i < lookAhead; i++ {
+		if d := date("2024-01-01").AddDate(0, 0, i); d.Weekday() == time.Monday {
+			closed.Exceptions = append(closed.Exceptions, Exception{Date: d.Format(DateLayout)})
+		}
+	}
+	require.Equal(t, date("2024-01-02"), closed.NextOpenDay(date("2024-01-02")), "never open")
+}
+
+func TestSchedule_Validate(t *testing.T) {
+	t.Parallel()
+	opens, closes := "12:00", "16:00"
+	require.NoError(t, Schedule{
+		OpeningHours: []OpeningHours{{Weekday: 1, Opens: "09:30", Closes: "21:00"}},
+		Exceptions:   []Exception{{Date: "2024-05-01"}, {Date: "2024-05-04", Opens: &opens, Closes: &closes}},
+	}.Validate())
+
+	for name, s := range map[string]Schedule{
+		"closes before it opens": {OpeningHours: []OpeningHours{{Weekday: 1, Opens: "20:00", Closes: "10:00"}}},
+		"weekday twice":          {OpeningHours: []OpeningHours{{Weekday: 1, Opens: "10:00", Closes: "12:00"}, {Weekday: 1, Opens: "13:00", Closes: "20:00"}}},
+		"date twice":             {Exceptions: []Exception{{Date: "2024-05-01"}, {Date: "2024-05-01"}}},
+		"date closes too early":  {Exceptions: []Exception{{Date: "2024-05-04", Opens: &closes, Closes: &opens}}},
+	} {
+		require.Error(t, s.Validate(), name)
+	}
+}
@@ -0,0 +1,84 @@
+// Package isbn validates ISBN-10 and ISBN-13 by their check digits and converts between them.
+// Books are stored by their ISBN-13, an ISBN-10 is the ISBN-13 of prefix 978 without it.
+package isbn
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalid is the error of a string that is not an ISBN-10 or an ISBN-13.
+var ErrInvalid = errors.New("invalid isbn")
+
+const prefix978 = "978"
+
+// Normalize strips the hyphens and the spaces from an ISBN-10 or an ISBN-13, checks its check
+// digit and returns the ISBN-13.
+func Normalize(s string) (string, error) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(s))
+	switch {
+	case len(isbn) == 10 && digits(isbn[:9]) && isbn[9] == check10(isbn[:9]):
+		return To13(isbn), nil
+	case len(isbn) == 13 && digits(isbn) && isbn[12] == check13(isbn[:12]):
+		return isbn, nil
+	}
+	return "", errors.Wrapf(ErrInvalid, "%q", strings.TrimSpace(s))
+}
+
+// Valid tells whether s is an ISBN-10 or an ISBN-13, with or without hyphens.
+func Valid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// To13 converts a valid ISBN-10 to the ISBN-13.
+func To13(isbn10 string) string {
+	isbn := prefix978 + isbn10[:9]
+	return isbn + string(check13(isbn))
+}
+
+// To10 converts a valid ISBN-13 to the ISBN-10, the ISBN-13 of prefix 979 have none.
+func To10(isbn13 string) (string, bool) {
+	if !strings.HasPrefix(isbn13, prefix978) {
+		return "", false
+	}
+	isbn := isbn13[3:12]
+	return isbn + string(check10(isbn)), true
+}
+
+// check10 is the check digit of the first 9 digits of an ISBN-10, X stands for 10.
+func check10(s string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(s[i]-'0') * (10 - i)
+	}
+	switch c := (11 - sum%11) % 11; c {
+	case 10:
+		return 'X'
+	default:
+		return byte('0' + c)
+	}
+}
+
+// check13 is the check digit of the first 12 digits of an ISBN-13.
+func check13(s string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(s[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
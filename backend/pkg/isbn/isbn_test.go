@@ -0,0 +1,42 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"978-0-306-40615-7": "9780306406157",
+		"080442957X":        "9780804429573",
+		"080442957x":        "9780804429573",
+		"979 10 90636 07 1": "9791090636071",
+	} {
+		got, err := Normalize(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "12345", "0-306-40615-3", "978-0-306-40615-8", "X306406152", "97803064061X7"} {
+		_, err := Normalize(in)
+		require.ErrorIs(t, err, ErrInvalid, in)
+		require.False(t, Valid(in), in)
+	}
+}
+
+func TestTo10(t *testing.T) {
+	t.Parallel()
+	isbn, ok := To10("9780804429573")
+	require.True(t, ok)
+	require.Equal(t, "080442957X", isbn)
+
+	isbn, ok = To10("9780306406157")
+	require.True(t, ok)
+	require.Equal(t, "0306406152", isbn)
+
+	_, ok = To10("9791090636071")
+	require.False(t, ok)
+}
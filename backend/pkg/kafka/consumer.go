@@ -2,16 +2,12 @@ package kafka
 
 import (
 	"context"
-	"log"
-	"log/slog"
-	"os"
-	"os/signal"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
-	"syscall"
 
 	"github.com/IBM/sarama"
-	"github.com/pkg/errors"
 )
 
 func NewConsumer(cfg Config, consumerGroup string) (sarama.ConsumerGroup, error) {
@@ -24,53 +20,46 @@ func NewConsumer(cfg Config, consumerGroup string) (sarama.ConsumerGroup, error)
 
 }
 
-func Consume(client sarama.ConsumerGroup, handler sarama.ConsumerGroupHandler, topic string) {
-	keepRunning := true
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for {
-			// `Consume` should be called inside an infinite loop, when a
-			// server-side rebalance happens, the consumer session will need to be
-			// recreated to get the new claims
-			if err := client.Consume(ctx, []string{topic}, handler); err != nil {
-				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
-					return
-				}
-				slog.Error("Error from consumer", "error", err)
-				return
-			}
-			// check if context was cancelled, signaling that the consumer should stop
-			if ctx.Err() != nil {
-				return
+// Consume runs the consumer group until ctx is done and closes it. ready is called once the first session
+// is set up. Messages in flight when ctx is done are finished and their offsets committed before Consume returns.
+// An error of the group is returned, the caller is expected to treat it as fatal.
+func Consume(ctx context.Context, client sarama.ConsumerGroup, handler sarama.ConsumerGroupHandler, ready func(), topics ...string) error {
+	h := &readyHandler{ConsumerGroupHandler: handler, ready: ready}
+	var err error
+	for {
+		// `Consume` should be called inside an infinite loop, when a
+		// server-side rebalance happens, the consumer session will need to be
+		// recreated to get the new claims
+		if err = client.Consume(ctx, topics, h); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				err = nil
 			}
+			break
 		}
-	}()
-
-	log.Println("Sarama consumer up and running!...")
-
-	sigusr1 := make(chan os.Signal, 1)
-	signal.Notify(sigusr1, syscall.SIGUSR1)
+		// check if context was cancelled, signaling that the consumer should stop
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if closeErr := client.Close(); closeErr != nil && !errors.Is(closeErr, sarama.ErrClosedConsumerGroup) {
+		err = errors.Join(err, fmt.Errorf("close consumer group: %w", closeErr))
+	}
+	return err
+}
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
+// readyHandler reports readiness on the first session setup.
+type readyHandler struct {
+	sarama.ConsumerGroupHandler
+	ready func()
+	once  sync.Once
+}
 
-	for keepRunning {
-		select {
-		case <-ctx.Done():
-			log.Println("terminating: context cancelled")
-			keepRunning = false
-		case <-sigterm:
-			log.Println("terminating: via signal")
-			keepRunning = false
-		}
+func (h *readyHandler) Setup(session sarama.ConsumerGroupSession) error {
+	if err := h.ConsumerGroupHandler.Setup(session); err != nil {
+		return err
 	}
-	wg.Wait()
-	if err := client.Close(); err != nil {
-		log.Panicf("Error closing client: %v", err)
+	if h.ready != nil {
+		h.once.Do(h.ready)
 	}
+	return nil
 }
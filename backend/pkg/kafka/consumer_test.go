@@ -0,0 +1,58 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConsume_Drain(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	p := b.SyncProducer()
+	for _, v := range []string{"a", "b"} {
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: kafka.StatsTopic, Value: sarama.StringEncoder(v)})
+		require.NoError(t, err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var handled []string
+	handle := func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		if string(msg.Value) == "a" {
+			close(started)
+			<-release
+		}
+		handled = append(handled, string(msg.Value))
+		// the message in flight is finished with a live context.
+		return ctx.Err()
+	}
+	handler := kafka.NewGroupHandler(handle, p, kafka.StatsConsumerGroup, kafka.RetryConfig{}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- kafka.Consume(ctx, b.ConsumerGroup(kafka.StatsConsumerGroup), handler, func() { close(ready) }, kafka.StatsTopic)
+	}()
+	<-ready
+	<-started
+	cancel()
+	close(release)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+	// "a" was in flight and is committed, "b" is left for the next owner.
+	require.Equal(t, []string{"a"}, handled)
+	require.EqualValues(t, 1, b.CommittedOffset(kafka.StatsConsumerGroup, kafka.StatsTopic, 0))
+	require.Empty(t, b.Messages(kafka.DLQTopic(kafka.StatsTopic)))
+}
@@ -0,0 +1,157 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// HeaderReplayedFrom is set on messages replayed from a dead-letter topic, as "<topic>/<partition>/<offset>".
+const HeaderReplayedFrom = "x-replayed-from"
+
+type DeadLetter struct {
+	Topic             string
+	Partition         int32
+	Offset            int64
+	Key               []byte
+	Value             []byte
+	Error             string
+	OriginalTopic     string
+	OriginalPartition int32
+	OriginalOffset    int64
+	Attempts          int
+	FailedAt          time.Time
+	ConsumerGroup     string
+	// Headers are the headers of the original message.
+	Headers []sarama.RecordHeader
+}
+
+func NewClient(cfg Config) (sarama.Client, error) {
+	defaultCfg := sarama.NewConfig()
+	defaultCfg.Producer.RequiredAcks = sarama.WaitForAll
+	defaultCfg.Producer.Return.Successes = true
+	return sarama.NewClient(strings.Split(cfg.Addrs, ","), defaultCfg)
+}
+
+// ReadDLQ calls fn for every message of the dead-letter topic that is in the topic when ReadDLQ is called.
+func ReadDLQ(ctx context.Context, client sarama.Client, topic string, fn func(DeadLetter) error) error {
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	return readDLQ(ctx, client, consumer, topic, fn)
+}
+
+// offsetReader is the part of sarama.Client that finds the messages of a topic.
+type offsetReader interface {
+	Partitions(topic string) ([]int32, error)
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+}
+
+func readDLQ(ctx context.Context, client offsetReader, consumer sarama.Consumer, topic string, fn func(DeadLetter) error) error {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return fmt.Errorf("partitions %s: %w", topic, err)
+	}
+	for _, partition := range partitions {
+		if err := readPartition(ctx, client, consumer, topic, partition, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPartition(ctx context.Context, client offsetReader, consumer sarama.Consumer, topic string, partition int32, fn func(DeadLetter) error) error {
+	oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return err
+	}
+	newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return err
+	}
+	if oldest >= newest {
+		return nil
+	}
+	pc, err := consumer.ConsumePartition(topic, partition, oldest)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-pc.Errors():
+			return err
+		case msg := <-pc.Messages():
+			if err := fn(parseDeadLetter(msg)); err != nil {
+				return err
+			}
+			if msg.Offset+1 >= newest {
+				return nil
+			}
+		}
+	}
+}
+
+func parseDeadLetter(msg *sarama.ConsumerMessage) DeadLetter {
+	dl := DeadLetter{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}
+	for _, hdr := range msg.Headers {
+		if hdr == nil {
+			continue
+		}
+		v := string(hdr.Value)
+		switch string(hdr.Key) {
+		case HeaderError:
+			dl.Error = v
+		case HeaderOriginalTopic:
+			dl.OriginalTopic = v
+		case HeaderOriginalPartition:
+			p, _ := strconv.ParseInt(v, 10, 32) //nolint:errcheck
+			dl.OriginalPartition = int32(p)
+		case HeaderOriginalOffset:
+			dl.OriginalOffset, _ = strconv.ParseInt(v, 10, 64) //nolint:errcheck
+		case HeaderAttempts:
+			dl.Attempts, _ = strconv.Atoi(v) //nolint:errcheck
+		case HeaderFailedAt:
+			dl.FailedAt, _ = time.Parse(time.RFC3339Nano, v) //nolint:errcheck
+		case HeaderConsumerGroup:
+			dl.ConsumerGroup = v
+		default:
+			dl.Headers = append(dl.Headers, *hdr)
+		}
+	}
+	if dl.OriginalTopic == "" {
+		dl.OriginalTopic = strings.TrimSuffix(msg.Topic, DLQSuffix)
+	}
+	return dl
+}
+
+// Replay publishes the dead-lettered message back to its original topic.
+func Replay(producer sarama.SyncProducer, dl DeadLetter) error {
+	headers := append(dl.Headers[:len(dl.Headers):len(dl.Headers)],
+		header(HeaderReplayedFrom, fmt.Sprintf("%s/%d/%d", dl.Topic, dl.Partition, dl.Offset)))
+	pm := &sarama.ProducerMessage{
+		Topic:   dl.OriginalTopic,
+		Value:   sarama.ByteEncoder(dl.Value),
+		Headers: headers,
+	}
+	if dl.Key != nil {
+		pm.Key = sarama.ByteEncoder(dl.Key)
+	}
+	_, _, err := producer.SendMessage(pm)
+	return err
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadDLQ_Replay(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker(kafkatest.WithPartitions(2))
+	p := b.SyncProducer()
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{
+			Topic:   StatsTopic,
+			Key:     sarama.StringEncoder(key),
+			Value:   sarama.StringEncoder("value " + key),
+			Headers: []sarama.RecordHeader{header("x-request-id", key)},
+		})
+		require.NoError(t, err)
+	}
+	h := NewGroupHandler(func(context.Context, *sarama.ConsumerMessage) error {
+		return errors.New("unavailable")
+	}, p, StatsConsumerGroup, RetryConfig{Attempts: 2, Backoff: time.Millisecond}, zap.NewNop())
+	for _, msg := range b.Messages(StatsTopic) {
+		require.NoError(t, h.processOne(context.Background(), msg))
+	}
+	dlq := DLQTopic(StatsTopic)
+	require.Len(t, b.Messages(dlq), 3)
+
+	var dls []DeadLetter
+	read := func(dl DeadLetter) error {
+		dls = append(dls, dl)
+		return nil
+	}
+	consumer := b.Consumer()
+	defer consumer.Close()
+	require.NoError(t, readDLQ(context.Background(), b, consumer, dlq, read))
+	require.Len(t, dls, 3)
+	originals := b.Messages(StatsTopic)
+	for i, dl := range dls {
+		orig := originals[i]
+		require.Equal(t, dlq, dl.Topic)
+		require.Equal(t, orig.Key, dl.Key)
+		require.Equal(t, orig.Value, dl.Value)
+		require.Equal(t, "unavailable", dl.Error)
+		require.Equal(t, StatsTopic, dl.OriginalTopic)
+		require.Equal(t, orig.Partition, dl.OriginalPartition)
+		require.Equal(t, orig.Offset, dl.OriginalOffset)
+		require.Equal(t, 2, dl.Attempts)
+		require.Equal(t, StatsConsumerGroup, dl.ConsumerGroup)
+		require.WithinDuration(t, time.Now(), dl.FailedAt, time.Minute)
+		require.Equal(t, []sarama.RecordHeader{header("x-request-id", string(orig.Key))}, dl.Headers)
+	}
+
+	// a message dead-lettered while reading is left for the next read.
+	dls = nil
+	require.NoError(t, readDLQ(context.Background(), b, consumer, dlq, func(dl DeadLetter) error {
+		if len(dls) == 0 {
+			_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: dlq, Key: sarama.StringEncoder("a"), Value: sarama.StringEncoder("late")})
+			require.NoError(t, err)
+		}
+		return read(dl)
+	}))
+	require.Len(t, dls, 3)
+	require.NoError(t, readDLQ(context.Background(), b, consumer, "empty.dlq", read))
+
+	next := make(map[int32]int64)
+	for _, msg := range originals {
+		next[msg.Partition] = msg.Offset + 1
+	}
+	for _, dl := range dls {
+		require.NoError(t, Replay(p, dl))
+	}
+	var replayed []*sarama.ConsumerMessage
+	for _, msg := range b.Messages(StatsTopic) {
+		if msg.Offset >= next[msg.Partition] {
+			replayed = append(replayed, msg)
+		}
+	}
+	require.Len(t, replayed, 3)
+	for _, msg := range replayed {
+		var dl DeadLetter
+		for _, d := range dls {
+			if string(d.Key) == string(msg.Key) {
+				dl = d
+			}
+		}
+		require.Equal(t, dl.Value, msg.Value)
+		require.Equal(t, dl.OriginalPartition, msg.Partition, "a replayed message keeps its partition")
+		require.Len(t, msg.Headers, 2)
+		require.Equal(t, header("x-request-id", string(msg.Key)), *msg.Headers[0])
+		require.Equal(t, header(HeaderReplayedFrom, fmt.Sprintf("%s/%d/%d", dlq, dl.Partition, dl.Offset)), *msg.Headers[1])
+	}
+}
@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+// Event types. Every type has a JSON schema per version in schemas/<type>.v<version>.json.
+const (
+	EventAvailableCount   = "library.available_count"
+	EventRatingChange     = "rating.change"
+	EventReservationStats = "stats.reservation"
+	EventOverdue          = "reservation.overdue"
+	EventFineAssess       = "fines.assess"
+
+	EventBookCountChanged = "library.book_count_changed"
+	EventTransfer         = "library.transfer_changed"
+	EventReservation      = "reservation.changed"
+	EventHold             = "reservation.hold_changed"
+	EventRatingChanged    = "rating.changed"
+)
+
+// Envelope wraps every payload published to Kafka.
+type Envelope struct {
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	EventID    string          `json:"eventId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Producer   string          `json:"producer"`
+	Trace      Trace           `json:"trace,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Event is a decoded envelope with the payload upcast to the latest version.
+type Event[T any] struct {
+	Envelope
+	Data T
+}
+
+// Trace carries the trace context of the request that caused the event, e.g. its request id.
+type Trace map[string]string
+
+type traceKey struct{}
+
+func ContextWithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+func TraceFromContext(ctx context.Context) Trace {
+	trace, _ := ctx.Value(traceKey{}).(Trace) //nolint:errcheck
+	return trace
+}
+
+// NewEnvelope validates payload against the latest schema of eventType and wraps it.
+// An empty eventID is replaced by a random one.
+func NewEnvelope(ctx context.Context, eventType, producer, eventID string, payload any) (Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+	registry := DefaultRegistry()
+	version := registry.Latest(eventType)
+	if err := registry.Validate(eventType, version, data); err != nil {
+		return Envelope{}, err
+	}
+	if eventID == "" {
+		eventID = uuid.NewString()
+	}
+	return Envelope{
+		Type:       eventType,
+		Version:    version,
+		EventID:    eventID,
+		OccurredAt: time.Now().UTC(),
+		Producer:   producer,
+		Trace:      TraceFromContext(ctx),
+		Payload:    data,
+	}, nil
+}
+
+// MarshalEvent returns the JSON of the envelope of payload.
+func MarshalEvent(ctx context.Context, eventType, producer, eventID string, payload any) ([]byte, error) {
+	env, err := NewEnvelope(ctx, eventType, producer, eventID, payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// UnmarshalEvent decodes an envelope of eventType, validates it and upcasts it to the latest version.
+// A bare payload without an envelope, as published before envelopes were introduced, is read as version 1.
+func UnmarshalEvent(data []byte, eventType string) (Envelope, error) {
+	return unmarshalEvent(data, eventType, "")
+}
+
+// unmarshalEvent is UnmarshalEvent that gives a bare payload without an id the id legacyID.
+func unmarshalEvent(data []byte, eventType, legacyID string) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, err
+	}
+	if env.Type == "" && env.Payload == nil {
+		env = legacyEnvelope(data, eventType, legacyID)
+	}
+	if env.Type != eventType {
+		return Envelope{}, fmt.Errorf("unexpected event type %q, want %q", env.Type, eventType)
+	}
+	if env.EventID == "" {
+		return Envelope{}, errors.New("event id is empty")
+	}
+	if err := DefaultRegistry().Upcast(&env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+func legacyEnvelope(data []byte, eventType, legacyID string) Envelope {
+	var ids struct {
+		EventID  string `json:"eventID"`
+		EventID2 string `json:"event_id"`
+	}
+	_ = json.Unmarshal(data, &ids) //nolint:errcheck
+	env := Envelope{
+		Type:    eventType,
+		Version: 1,
+		EventID: ids.EventID,
+		Payload: data,
+	}
+	if env.EventID == "" {
+		env.EventID = ids.EventID2
+	}
+	if env.EventID == "" {
+		env.EventID = legacyID
+	}
+	return env
+}
+
+// messageID derives the id of a message that carries none from its position in the topic, so a
+// redelivery of the message is recognized but an equal payload published again is not.
+func messageID(msg *sarama.ConsumerMessage) string {
+	pos := fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(pos)).String()
+}
+
+// Events returns a Decoder of enveloped events of eventType with payload T.
+func Events[T any](eventType string) Decoder[Event[T]] {
+	return func(msg *sarama.ConsumerMessage) (Event[T], error) {
+		env, err := unmarshalEvent(msg.Value, eventType, messageID(msg))
+		if err != nil {
+			return Event[T]{}, err
+		}
+		var data T
+		if err := json.Unmarshal(env.Payload, &data); err != nil {
+			return Event[T]{}, err
+		}
+		return Event[T]{Envelope: env, Data: data}, nil
+	}
+}
+
+func registerUpcasters(r *Registry) {
+	// v2 replaced isReturn with a signed delta.
+	r.RegisterUpcaster(EventAvailableCount, 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v1 struct {
+			LibraryID int  `json:"libraryID"`
+			BookID    int  `json:"bookID"`
+			IsReturn  bool `json:"isReturn"`
+		}
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		v2 := AvailableCount{LibraryID: v1.LibraryID, BookID: v1.BookID, Delta: -1}
+		if v1.IsReturn {
+			v2.Delta = 1
+		}
+		return json.Marshal(v2)
+	})
+	// v3 added the optional reservationUid and condition of the copy, a v2 payload is a valid v3 payload.
+	r.RegisterUpcaster(EventAvailableCount, 2, func(payload json.RawMessage) (json.RawMessage, error) {
+		return payload, nil
+	})
+	// v2 added the RENEWED type and the optional tillDate, a v1 payload is a valid v2 payload.
+	r.RegisterUpcaster(EventReservation, 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		return payload, nil
+	})
+	// v3 added the OVERDUE type.
+	r.RegisterUpcaster(EventReservation, 2, func(payload json.RawMessage) (json.RawMessage, error) {
+		return payload, nil
+	})
+}
@@ -0,0 +1,117 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalEvent(t *testing.T) {
+	t.Parallel()
+	ctx := kafka.ContextWithTrace(context.Background(), kafka.Trace{"requestId": "42"})
+
+	data, err := kafka.MarshalEvent(ctx, kafka.EventRatingChange, "gateway", "", kafka.RatingChange{Name: "user", Stars: 1})
+	require.NoError(t, err)
+
+	env, err := kafka.UnmarshalEvent(data, kafka.EventRatingChange)
+	require.NoError(t, err)
+	require.Equal(t, kafka.EventRatingChange, env.Type)
+	require.Equal(t, 1, env.Version)
+	require.Equal(t, "gateway", env.Producer)
+	require.Equal(t, kafka.Trace{"requestId": "42"}, env.Trace)
+	require.NotEmpty(t, env.EventID)
+
+	_, err = kafka.MarshalEvent(ctx, kafka.EventRatingChange, "gateway", "", kafka.RatingChange{Stars: 1})
+	require.ErrorContains(t, err, "name")
+
+	_, err = kafka.MarshalEvent(ctx, "unknown", "gateway", "", kafka.RatingChange{Name: "user"})
+	require.Error(t, err)
+}
+
+func TestEventsDecoder(t *testing.T) {
+	t.Parallel()
+	decode := kafka.Events[kafka.AvailableCount](kafka.EventAvailableCount)
+	tests := []struct {
+		name    string
+		value   string
+		want    kafka.AvailableCount
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "latest version",
+			value:  `{"type":"library.available_count","version":3,"eventId":"e1","occurredAt":"2024-01-01T00:00:00Z","producer":"gateway","payload":{"libraryID":1,"bookID":2,"delta":1,"reservationUid":"9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e","condition":"GOOD"}}`,
+			want:   kafka.AvailableCount{LibraryID: 1, BookID: 2, Delta: 1, ReservationUid: "9d8bb1b6-1ba4-4d1d-9c3b-1a0e2d4b4a7e", Condition: "GOOD"},
+			wantID: "e1",
+		},
+		{
+			name:   "v2 is upcast",
+			value:  `{"type":"library.available_count","version":2,"eventId":"e6","occurredAt":"2024-01-01T00:00:00Z","producer":"gateway","payload":{"libraryID":1,"bookID":2,"delta":-1}}`,
+			want:   kafka.AvailableCount{LibraryID: 1, BookID: 2, Delta: -1},
+			wantID: "e6",
+		},
+		{
+			name:   "v1 is upcast",
+			value:  `{"type":"library.available_count","version":1,"eventId":"e2","occurredAt":"2024-01-01T00:00:00Z","producer":"gateway","payload":{"libraryID":1,"bookID":2,"isReturn":true}}`,
+			want:   kafka.AvailableCount{LibraryID: 1, BookID: 2, Delta: 1},
+			wantID: "e2",
+		},
+		{
+			name:   "bare legacy payload",
+			value:  `{"eventID":"e3","libraryID":1,"bookID":2,"isReturn":false}`,
+			want:   kafka.AvailableCount{LibraryID: 1, BookID: 2, Delta: -1},
+			wantID: "e3",
+		},
+		{
+			name:    "schema violation",
+			value:   `{"type":"library.available_count","version":2,"eventId":"e4","payload":{"libraryID":"1","bookID":2,"delta":1}}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong type",
+			value:   `{"type":"rating.change","version":1,"eventId":"e5","payload":{"name":"user","stars":1}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			e, err := decode(&sarama.ConsumerMessage{Value: []byte(tt.value), Timestamp: time.Now()})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, e.Data)
+			require.Equal(t, tt.wantID, e.EventID)
+			require.Equal(t, 3, e.Version)
+		})
+	}
+}
+
+func TestEventsDecoder_LegacyWithoutID(t *testing.T) {
+	t.Parallel()
+	decode := kafka.Events[kafka.AvailableCount](kafka.EventAvailableCount)
+	value := []byte(`{"libraryID":1,"bookID":2,"isReturn":true}`)
+	msg := func(offset int64) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: kafka.LibraryTopic, Partition: 1, Offset: offset, Value: value}
+	}
+
+	first, err := decode(msg(10))
+	require.NoError(t, err)
+	require.NotEmpty(t, first.EventID)
+	redelivered, err := decode(msg(10))
+	require.NoError(t, err)
+	require.Equal(t, first.EventID, redelivered.EventID, "a redelivery is a duplicate")
+	again, err := decode(msg(11))
+	require.NoError(t, err)
+	require.NotEqual(t, first.EventID, again.EventID, "an equal payload published again is not")
+
+	_, err = kafka.UnmarshalEvent(value, kafka.EventAvailableCount)
+	require.Error(t, err, "the id of a bare payload comes from its message")
+}
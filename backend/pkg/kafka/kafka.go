@@ -1,43 +1,28 @@
 package kafka
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/IBM/sarama"
-)
-
 type Config struct {
-	Addrs string `yaml:"addrs" envconfig:"KAFKA_BROKERS"`
+	Addrs string      `yaml:"addrs" envconfig:"KAFKA_BROKERS"`
+	Retry RetryConfig `yaml:"retry"`
+	// Concurrency is the number of messages of a partition handled in parallel.
+	Concurrency int          `yaml:"concurrency" envconfig:"KAFKA_CONSUMER_CONCURRENCY" default:"1"`
+	Topics      TopicsConfig `yaml:"topics"`
 }
 
 const (
 	LibraryTopic = "library"
 	RatingTopic  = "rating"
 	StatsTopic   = "stats"
+	// OverdueTopic carries the overdue days of reservations, rating penalizes them.
+	OverdueTopic = "reservation.overdue"
+	FinesTopic   = "fines"
+
+	LibraryEventsTopic     = "library.events"
+	ReservationEventsTopic = "reservation.events"
+	RatingEventsTopic      = "rating.events"
 
 	LibraryConsumerGroup = "library"
 	RatingConsumerGroup  = "rating"
 	StatsConsumerGroup   = "stats"
+	OverdueConsumerGroup = "rating.overdue"
+	FinesConsumerGroup   = "fines"
 )
-
-func CreateTopics(cfg Config) error {
-	defaultCfg := sarama.NewConfig()
-	//defaultCfg.Version = sarama.V1_0_0_0
-	brokerAddrs := strings.Split(cfg.Addrs, ",")
-	admin, err := sarama.NewClusterAdmin(brokerAddrs, defaultCfg)
-	if err != nil {
-		return fmt.Errorf("creating cluster admin: %w", err)
-	}
-	topics, _ := admin.ListTopics() //nolint:errcheck
-	fmt.Println("list topics", topics)
-	defer admin.Close()
-	if err := admin.CreateTopic("test", &sarama.TopicDetail{
-		NumPartitions:     1,
-		ReplicationFactor: 1,
-	}, false); err != nil {
-		return fmt.Errorf("create topics %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,609 @@
+// Package kafkatest provides an in-process Kafka broker for tests of producers and consumers.
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrTransactions is returned by the transactional methods of the producers.
+var ErrTransactions = errors.New("kafkatest: transactions are not supported")
+
+// Broker keeps topics as in-memory logs of partitions. Its producers and consumer groups
+// follow sarama semantics: keys are hashed to partitions, offsets are per partition,
+// a group splits the partitions between its members, rebalances when a member joins or leaves,
+// and resumes every partition from the offset committed by the group.
+type Broker struct {
+	mu            sync.Mutex
+	partitions    int32
+	initialOffset int64
+	topics        map[string][][]*sarama.ConsumerMessage
+	produceErr    map[string]error
+	groups        map[string]*group
+	// changed is closed and replaced whenever a message is appended.
+	changed chan struct{}
+	members int
+}
+
+type Option func(b *Broker)
+
+// WithPartitions sets the number of partitions of topics that are created on first use, 1 by default.
+func WithPartitions(n int32) Option {
+	return func(b *Broker) {
+		if n > 0 {
+			b.partitions = n
+		}
+	}
+}
+
+// WithInitialOffset sets where a group without a committed offset starts,
+// sarama.OffsetOldest by default.
+func WithInitialOffset(offset int64) Option {
+	return func(b *Broker) {
+		b.initialOffset = offset
+	}
+}
+
+func NewBroker(opts ...Option) *Broker {
+	b := &Broker{
+		partitions:    1,
+		initialOffset: sarama.OffsetOldest,
+		topics:        make(map[string][][]*sarama.ConsumerMessage),
+		produceErr:    make(map[string]error),
+		groups:        make(map[string]*group),
+		changed:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// CreateTopic creates topic with the given number of partitions, it is a no-op if the topic exists.
+func (b *Broker) CreateTopic(topic string, partitions int32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.topics[topic]; !ok {
+		b.topics[topic] = make([][]*sarama.ConsumerMessage, partitions)
+	}
+}
+
+// FailProduce makes every produce to topic fail with err until it is called with a nil err.
+func (b *Broker) FailProduce(topic string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		delete(b.produceErr, topic)
+		return
+	}
+	b.produceErr[topic] = err
+}
+
+// Messages returns the messages of topic ordered by partition and offset.
+func (b *Broker) Messages(topic string) []*sarama.ConsumerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var msgs []*sarama.ConsumerMessage
+	for _, log := range b.topics[topic] {
+		msgs = append(msgs, log...)
+	}
+	return msgs
+}
+
+// CommittedOffset returns the next offset the group consumes from the partition, -1 if nothing was committed.
+func (b *Broker) CommittedOffset(groupID, topic string, partition int32) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.groups[groupID]
+	if !ok {
+		return -1
+	}
+	offset, ok := g.offsets[topicPartition{topic, partition}]
+	if !ok {
+		return -1
+	}
+	return offset
+}
+
+// Rebalance ends the current sessions of the group and reassigns its partitions.
+func (b *Broker) Rebalance(groupID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if g, ok := b.groups[groupID]; ok {
+		g.rebalance()
+	}
+}
+
+// topic returns the partitions of topic, creating it on first use. b.mu must be held.
+func (b *Broker) topic(name string) [][]*sarama.ConsumerMessage {
+	parts, ok := b.topics[name]
+	if !ok {
+		parts = make([][]*sarama.ConsumerMessage, b.partitions)
+		b.topics[name] = parts
+	}
+	return parts
+}
+
+func (b *Broker) produce(pm *sarama.ProducerMessage) error {
+	if pm.Topic == "" {
+		return sarama.ErrInvalidTopic
+	}
+	var key, value []byte
+	var err error
+	if pm.Key != nil {
+		if key, err = pm.Key.Encode(); err != nil {
+			return err
+		}
+	}
+	if pm.Value != nil {
+		if value, err = pm.Value.Encode(); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.produceErr[pm.Topic]; err != nil {
+		return err
+	}
+	parts := b.topic(pm.Topic)
+	partition, err := sarama.NewHashPartitioner(pm.Topic).Partition(pm, int32(len(parts)))
+	if err != nil {
+		return err
+	}
+	headers := make([]*sarama.RecordHeader, 0, len(pm.Headers))
+	for i := range pm.Headers {
+		h := pm.Headers[i]
+		headers = append(headers, &h)
+	}
+	ts := pm.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	msg := &sarama.ConsumerMessage{
+		Topic:     pm.Topic,
+		Partition: partition,
+		Offset:    int64(len(parts[partition])),
+		Key:       key,
+		Value:     value,
+		Headers:   headers,
+		Timestamp: ts,
+	}
+	parts[partition] = append(parts[partition], msg)
+	pm.Partition, pm.Offset = msg.Partition, msg.Offset
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return nil
+}
+
+// SyncProducer returns a producer that appends messages to the broker.
+func (b *Broker) SyncProducer() sarama.SyncProducer {
+	return &syncProducer{broker: b}
+}
+
+type syncProducer struct {
+	broker *Broker
+	txn
+}
+
+func (p *syncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if err := p.broker.produce(msg); err != nil {
+		return -1, -1, err
+	}
+	return msg.Partition, msg.Offset, nil
+}
+
+func (p *syncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var errs sarama.ProducerErrors
+	for _, msg := range msgs {
+		if err := p.broker.produce(msg); err != nil {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *syncProducer) Close() error {
+	return nil
+}
+
+// AsyncProducer returns a producer that appends messages to the broker in the background.
+// Successes and errors are reported as configured by cfg.Producer.Return, cfg may be nil.
+func (b *Broker) AsyncProducer(cfg *sarama.Config) sarama.AsyncProducer {
+	if cfg == nil {
+		cfg = sarama.NewConfig()
+	}
+	p := &asyncProducer{
+		broker:          b,
+		input:           make(chan *sarama.ProducerMessage, cfg.ChannelBufferSize),
+		successes:       make(chan *sarama.ProducerMessage, cfg.ChannelBufferSize),
+		errors:          make(chan *sarama.ProducerError, cfg.ChannelBufferSize),
+		returnSuccesses: cfg.Producer.Return.Successes,
+		returnErrors:    cfg.Producer.Return.Errors,
+	}
+	go p.run()
+	return p
+}
+
+type asyncProducer struct {
+	broker    *Broker
+	input     chan *sarama.ProducerMessage
+	successes chan *sarama.ProducerMessage
+	errors    chan *sarama.ProducerError
+	closeOnce sync.Once
+
+	returnSuccesses bool
+	returnErrors    bool
+	txn
+}
+
+func (p *asyncProducer) run() {
+	defer close(p.errors)
+	defer close(p.successes)
+	for msg := range p.input {
+		if err := p.broker.produce(msg); err != nil {
+			if p.returnErrors {
+				p.errors <- &sarama.ProducerError{Msg: msg, Err: err}
+			}
+			continue
+		}
+		if p.returnSuccesses {
+			p.successes <- msg
+		}
+	}
+}
+
+func (p *asyncProducer) Input() chan<- *sarama.ProducerMessage { return p.input }
+
+func (p *asyncProducer) Successes() <-chan *sarama.ProducerMessage { return p.successes }
+
+func (p *asyncProducer) Errors() <-chan *sarama.ProducerError { return p.errors }
+
+func (p *asyncProducer) AsyncClose() {
+	p.closeOnce.Do(func() { close(p.input) })
+}
+
+// Close flushes the buffered messages and returns the errors nobody has read, like sarama does.
+func (p *asyncProducer) Close() error {
+	p.AsyncClose()
+	go func() {
+		for range p.successes { //nolint:revive
+		}
+	}()
+	var errs sarama.ProducerErrors
+	for err := range p.errors {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// txn implements the transactional part of the producer interfaces, the fake producers are not transactional.
+type txn struct{}
+
+func (txn) TxnStatus() sarama.ProducerTxnStatusFlag { return sarama.ProducerTxnFlagReady }
+func (txn) IsTransactional() bool                   { return false }
+func (txn) BeginTxn() error                         { return ErrTransactions }
+func (txn) CommitTxn() error                        { return ErrTransactions }
+func (txn) AbortTxn() error                         { return ErrTransactions }
+
+func (txn) AddOffsetsToTxn(map[string][]*sarama.PartitionOffsetMetadata, string) error {
+	return ErrTransactions
+}
+
+func (txn) AddMessageToTxn(*sarama.ConsumerMessage, string, *string) error {
+	return ErrTransactions
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// group is a consumer group. A member joins on its first Consume and leaves on Close.
+type group struct {
+	broker     *Broker
+	id         string
+	generation int32
+	members    []*consumerGroup
+	assigned   map[*consumerGroup]map[string][]int32
+	offsets    map[topicPartition]int64
+	// genCtx is cancelled when the generation ends.
+	genCtx    context.Context
+	genCancel context.CancelFunc
+}
+
+// rebalance starts a new generation and assigns the partitions round-robin. b.mu must be held.
+func (g *group) rebalance() {
+	if g.genCancel != nil {
+		g.genCancel()
+	}
+	g.generation++
+	g.genCtx, g.genCancel = context.WithCancel(context.Background())
+	g.assigned = make(map[*consumerGroup]map[string][]int32, len(g.members))
+
+	var topics []string
+	for _, m := range g.members {
+		for _, t := range m.topics {
+			if !slices.Contains(topics, t) {
+				topics = append(topics, t)
+			}
+		}
+	}
+	sort.Strings(topics)
+	for _, t := range topics {
+		var subscribed []*consumerGroup
+		for _, m := range g.members {
+			if slices.Contains(m.topics, t) {
+				subscribed = append(subscribed, m)
+			}
+		}
+		for p := range g.broker.topic(t) {
+			m := subscribed[p%len(subscribed)]
+			if g.assigned[m] == nil {
+				g.assigned[m] = make(map[string][]int32)
+			}
+			g.assigned[m][t] = append(g.assigned[m][t], int32(p))
+		}
+	}
+}
+
+// ConsumerGroup returns a new member of the group. Pause and Resume are no-ops.
+func (b *Broker) ConsumerGroup(groupID string) sarama.ConsumerGroup {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.groups[groupID]
+	if !ok {
+		g = &group{broker: b, id: groupID, offsets: make(map[topicPartition]int64)}
+		b.groups[groupID] = g
+	}
+	b.members++
+	return &consumerGroup{
+		broker: b,
+		group:  g,
+		id:     fmt.Sprintf("%s-%d", groupID, b.members),
+		errors: make(chan error, 16),
+	}
+}
+
+type consumerGroup struct {
+	broker *Broker
+	group  *group
+	id     string
+	errors chan error
+
+	topics  []string
+	joined  bool
+	closed  bool
+	running sync.WaitGroup
+}
+
+// Consume joins the group and runs a session until the generation ends or ctx is done.
+func (c *consumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	if len(topics) == 0 {
+		return errors.New("kafkatest: no topics provided")
+	}
+	b := c.broker
+	b.mu.Lock()
+	if c.closed {
+		b.mu.Unlock()
+		return sarama.ErrClosedConsumerGroup
+	}
+	c.running.Add(1)
+	defer c.running.Done()
+	if !c.joined || !slices.Equal(c.topics, topics) {
+		c.topics = slices.Clone(topics)
+		if !c.joined {
+			c.joined = true
+			c.group.members = append(c.group.members, c)
+		}
+		c.group.rebalance()
+	}
+	sess := &session{
+		member:     c,
+		generation: c.group.generation,
+		claims:     make(map[string][]int32),
+	}
+	var claims []*claim
+	for t, parts := range c.group.assigned[c] {
+		sess.claims[t] = slices.Clone(parts)
+		for _, p := range parts {
+			claims = append(claims, &claim{
+				topic:     t,
+				partition: p,
+				offset:    b.startOffset(c.group, t, p),
+				messages:  make(chan *sarama.ConsumerMessage, 16),
+				broker:    b,
+			})
+		}
+	}
+	genCtx := c.group.genCtx
+	b.mu.Unlock()
+
+	var cancel context.CancelFunc
+	sess.ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(genCtx, cancel)
+	defer stop()
+
+	if err := handler.Setup(sess); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	for _, cl := range claims {
+		cl := cl
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cl.feed(sess.ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			// like sarama, the session ends as soon as one claim is done.
+			defer cancel()
+			if err := handler.ConsumeClaim(sess, cl); err != nil {
+				c.sendError(err)
+			}
+		}()
+	}
+	<-sess.ctx.Done()
+	wg.Wait()
+	return handler.Cleanup(sess)
+}
+
+func (c *consumerGroup) sendError(err error) {
+	select {
+	case c.errors <- err:
+	default:
+	}
+}
+
+func (c *consumerGroup) Errors() <-chan error { return c.errors }
+
+// Close leaves the group, waits for the running session and closes Errors.
+func (c *consumerGroup) Close() error {
+	b := c.broker
+	b.mu.Lock()
+	if c.closed {
+		b.mu.Unlock()
+		return sarama.ErrClosedConsumerGroup
+	}
+	c.closed = true
+	if c.joined {
+		c.group.members = slices.DeleteFunc(c.group.members, func(m *consumerGroup) bool { return m == c })
+		c.group.rebalance()
+	}
+	b.mu.Unlock()
+	c.running.Wait()
+	close(c.errors)
+	return nil
+}
+
+func (c *consumerGroup) Pause(map[string][]int32)  {}
+func (c *consumerGroup) Resume(map[string][]int32) {}
+func (c *consumerGroup) PauseAll()                 {}
+func (c *consumerGroup) ResumeAll()                {}
+
+// startOffset returns the committed offset of the partition or the initial offset. b.mu must be held.
+func (b *Broker) startOffset(g *group, topic string, partition int32) int64 {
+	if offset, ok := g.offsets[topicPartition{topic, partition}]; ok {
+		return offset
+	}
+	if b.initialOffset == sarama.OffsetNewest {
+		return int64(len(b.topic(topic)[partition]))
+	}
+	return 0
+}
+
+type session struct {
+	member     *consumerGroup
+	generation int32
+	claims     map[string][]int32
+	ctx        context.Context
+}
+
+func (s *session) Claims() map[string][]int32 { return s.claims }
+func (s *session) MemberID() string           { return s.member.id }
+func (s *session) GenerationID() int32        { return s.generation }
+func (s *session) Context() context.Context   { return s.ctx }
+
+// MarkOffset commits right away, as if sarama auto-committed after every mark.
+func (s *session) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	s.commit(topic, partition, offset, false)
+}
+
+func (s *session) ResetOffset(topic string, partition int32, offset int64, _ string) {
+	s.commit(topic, partition, offset, true)
+}
+
+func (s *session) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+// Commit is a no-op, marked offsets are already committed.
+func (s *session) Commit() {}
+
+func (s *session) commit(topic string, partition int32, offset int64, reset bool) {
+	b := s.member.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tp := topicPartition{topic, partition}
+	if cur, ok := s.member.group.offsets[tp]; ok && offset <= cur && !reset {
+		return
+	}
+	s.member.group.offsets[tp] = offset
+}
+
+type claim struct {
+	broker    *Broker
+	topic     string
+	partition int32
+	offset    int64
+	messages  chan *sarama.ConsumerMessage
+}
+
+func (c *claim) Topic() string                            { return c.topic }
+func (c *claim) Partition() int32                         { return c.partition }
+func (c *claim) InitialOffset() int64                     { return c.offset }
+func (c *claim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func (c *claim) HighWaterMarkOffset() int64 {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	return int64(len(c.broker.topic(c.topic)[c.partition]))
+}
+
+// feed sends the messages of the partition from the initial offset on and closes Messages when ctx is done.
+func (c *claim) feed(ctx context.Context) {
+	defer close(c.messages)
+	offset := c.offset
+	for {
+		c.broker.mu.Lock()
+		log := c.broker.topic(c.topic)[c.partition]
+		changed := c.broker.changed
+		c.broker.mu.Unlock()
+
+		if offset < int64(len(log)) {
+			select {
+			case c.messages <- log[offset]:
+				offset++
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Consume runs the consumer loop of a service: it calls cg.Consume again after every rebalance
+// until ctx is done or the group is closed. The returned channel is closed when the loop exits.
+func Consume(ctx context.Context, cg sarama.ConsumerGroup, topics []string, handler sarama.ConsumerGroupHandler) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			if err := cg.Consume(ctx, topics, handler); err != nil {
+				return
+			}
+		}
+	}()
+	return done
+}
@@ -0,0 +1,214 @@
+package kafkatest_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	member map[string]string // value -> member id
+	mark   bool
+}
+
+func (r *recorder) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (r *recorder) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (r *recorder) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		r.mu.Lock()
+		r.member[string(msg.Value)] = sess.MemberID()
+		mark := r.mark
+		r.mu.Unlock()
+		if mark {
+			sess.MarkMessage(msg, "")
+		}
+	}
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.member)
+}
+
+func (r *recorder) members() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := make(map[string]int)
+	for _, m := range r.member {
+		members[m]++
+	}
+	return members
+}
+
+func produce(t *testing.T, p sarama.SyncProducer, topic string, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{
+			Topic: topic,
+			Key:   sarama.StringEncoder(strconv.Itoa(i % 7)),
+			Value: sarama.StringEncoder(strconv.Itoa(i)),
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestBroker_Produce(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker(kafkatest.WithPartitions(4))
+	p := b.SyncProducer()
+	produce(t, p, "topic", 0, 20)
+
+	msgs := b.Messages("topic")
+	require.Len(t, msgs, 20)
+	partitions := make(map[string]int32)
+	offsets := make(map[int32]int64)
+	for _, msg := range msgs {
+		// a key always lands in the same partition, offsets are consecutive per partition.
+		if p, ok := partitions[string(msg.Key)]; ok {
+			require.Equal(t, p, msg.Partition)
+		}
+		partitions[string(msg.Key)] = msg.Partition
+		require.Equal(t, offsets[msg.Partition], msg.Offset)
+		offsets[msg.Partition]++
+	}
+
+	boom := errors.New("boom")
+	b.FailProduce("topic", boom)
+	_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: "topic", Value: sarama.StringEncoder("x")})
+	require.ErrorIs(t, err, boom)
+
+	cfg := sarama.NewConfig()
+	cfg.Producer.Return.Successes = true
+	ap := b.AsyncProducer(cfg)
+	ap.Input() <- &sarama.ProducerMessage{Topic: "topic", Value: sarama.StringEncoder("x")}
+	require.ErrorIs(t, (<-ap.Errors()).Err, boom)
+	b.FailProduce("topic", nil)
+	ap.Input() <- &sarama.ProducerMessage{Topic: "topic", Value: sarama.StringEncoder("y")}
+	require.Equal(t, sarama.StringEncoder("y"), (<-ap.Successes()).Value)
+	require.NoError(t, ap.Close())
+	require.Len(t, b.Messages("topic"), 21)
+}
+
+func TestBroker_ConsumerGroupRebalance(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker(kafkatest.WithPartitions(4))
+	p := b.SyncProducer()
+	produce(t, p, "topic", 0, 40)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &recorder{member: make(map[string]string), mark: true}
+	first, second := b.ConsumerGroup("group"), b.ConsumerGroup("group")
+	kafkatest.Consume(ctx, first, []string{"topic"}, rec)
+	secondDone := kafkatest.Consume(ctx, second, []string{"topic"}, rec)
+
+	require.Eventually(t, func() bool { return rec.count() == 40 }, 5*time.Second, 10*time.Millisecond)
+
+	// the second member leaves, the first one takes over all partitions from the committed offsets.
+	require.NoError(t, second.Close())
+	<-secondDone
+	produce(t, p, "topic", 40, 60)
+	require.Eventually(t, func() bool { return rec.count() == 60 }, 5*time.Second, 10*time.Millisecond)
+	rec.mu.Lock()
+	for i := 40; i < 60; i++ {
+		require.Equal(t, "group-1", rec.member[strconv.Itoa(i)])
+	}
+	rec.mu.Unlock()
+
+	cancel()
+	require.NoError(t, first.Close())
+	var total int64
+	for partition := int32(0); partition < 4; partition++ {
+		total += b.CommittedOffset("group", "topic", partition)
+	}
+	require.EqualValues(t, 60, total)
+}
+
+func TestBroker_Redelivery(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	produce(t, b.SyncProducer(), "topic", 0, 5)
+
+	// nothing is marked, so the next session of the group starts over.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &recorder{member: make(map[string]string)}
+	cg := b.ConsumerGroup("group")
+	kafkatest.Consume(ctx, cg, []string{"topic"}, rec)
+	require.Eventually(t, func() bool { return rec.count() == 5 }, 5*time.Second, 10*time.Millisecond)
+	require.EqualValues(t, -1, b.CommittedOffset("group", "topic", 0))
+
+	rec.mu.Lock()
+	rec.member, rec.mark = make(map[string]string), true
+	rec.mu.Unlock()
+	b.Rebalance("group")
+	require.Eventually(t, func() bool { return rec.count() == 5 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, cg.Close())
+	require.EqualValues(t, 5, b.CommittedOffset("group", "topic", 0))
+	require.Equal(t, map[string]int{"group-1": 5}, rec.members())
+}
+
+func TestGroupHandler_DeadLetter(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	p := b.SyncProducer()
+	produce(t, p, kafka.LibraryTopic, 0, 3)
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+	handle := func(_ context.Context, msg *sarama.ConsumerMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[string(msg.Value)]++
+		switch string(msg.Value) {
+		case "1":
+			return errors.New("unavailable")
+		case "2":
+			return kafka.Permanent(errors.New("bad payload"))
+		}
+		return nil
+	}
+	handler := kafka.NewGroupHandler(handle, p, kafka.LibraryConsumerGroup,
+		kafka.RetryConfig{Attempts: 3, Backoff: time.Millisecond}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cg := b.ConsumerGroup(kafka.LibraryConsumerGroup)
+	kafkatest.Consume(ctx, cg, []string{kafka.LibraryTopic}, handler)
+	require.Eventually(t, func() bool {
+		return b.CommittedOffset(kafka.LibraryConsumerGroup, kafka.LibraryTopic, 0) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, cg.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, map[string]int{"0": 1, "1": 3, "2": 1}, attempts)
+	dlq := b.Messages(kafka.DLQTopic(kafka.LibraryTopic))
+	require.Len(t, dlq, 2)
+	headers := func(msg *sarama.ConsumerMessage) map[string]string {
+		m := make(map[string]string)
+		for _, h := range msg.Headers {
+			m[string(h.Key)] = string(h.Value)
+		}
+		return m
+	}
+	require.Equal(t, "1", string(dlq[0].Value))
+	require.Equal(t, "3", headers(dlq[0])[kafka.HeaderAttempts])
+	require.Equal(t, "1", headers(dlq[0])[kafka.HeaderOriginalOffset])
+	require.Equal(t, "bad payload", headers(dlq[1])[kafka.HeaderError])
+}
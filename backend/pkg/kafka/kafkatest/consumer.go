@@ -0,0 +1,166 @@
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// Partitions returns the partitions of topic like sarama.Client does, the topic is created on first use.
+func (b *Broker) Partitions(topic string) ([]int32, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	parts := make([]int32, len(b.topic(topic)))
+	for i := range parts {
+		parts[i] = int32(i)
+	}
+	return parts, nil
+}
+
+// GetOffset returns sarama.OffsetOldest or sarama.OffsetNewest of the partition like sarama.Client does.
+// Nothing is ever deleted, so the oldest offset is 0. Offsets by time are not supported.
+func (b *Broker) GetOffset(topic string, partition int32, t int64) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	parts := b.topic(topic)
+	if partition < 0 || int(partition) >= len(parts) {
+		return -1, sarama.ErrUnknownTopicOrPartition
+	}
+	switch t {
+	case sarama.OffsetOldest:
+		return 0, nil
+	case sarama.OffsetNewest:
+		return int64(len(parts[partition])), nil
+	}
+	return -1, errors.New("kafkatest: offsets by time are not supported")
+}
+
+// Consumer returns a consumer of single partitions. Pause and Resume are no-ops.
+func (b *Broker) Consumer() sarama.Consumer {
+	return &consumer{broker: b}
+}
+
+type consumer struct {
+	broker *Broker
+	mu     sync.Mutex
+	open   []*partitionConsumer
+}
+
+func (c *consumer) Topics() ([]string, error) {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	topics := make([]string, 0, len(c.broker.topics))
+	for t := range c.broker.topics {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+func (c *consumer) Partitions(topic string) ([]int32, error) {
+	return c.broker.Partitions(topic)
+}
+
+// ConsumePartition feeds the messages of the partition from offset on, which may be sarama.OffsetOldest
+// or sarama.OffsetNewest.
+func (c *consumer) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	newest, err := c.broker.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case offset == sarama.OffsetOldest:
+		offset = 0
+	case offset == sarama.OffsetNewest:
+		offset = newest
+	case offset < 0 || offset > newest:
+		return nil, sarama.ErrOffsetOutOfRange
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pc := &partitionConsumer{
+		claim: &claim{
+			broker:    c.broker,
+			topic:     topic,
+			partition: partition,
+			offset:    offset,
+			messages:  make(chan *sarama.ConsumerMessage, 16),
+		},
+		errors: make(chan *sarama.ConsumerError),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(pc.done)
+		pc.claim.feed(ctx)
+	}()
+	c.mu.Lock()
+	c.open = append(c.open, pc)
+	c.mu.Unlock()
+	return pc, nil
+}
+
+func (c *consumer) HighWaterMarks() map[string]map[int32]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	marks := make(map[string]map[int32]int64)
+	for _, pc := range c.open {
+		if marks[pc.claim.topic] == nil {
+			marks[pc.claim.topic] = make(map[int32]int64)
+		}
+		marks[pc.claim.topic][pc.claim.partition] = pc.HighWaterMarkOffset()
+	}
+	return marks
+}
+
+// Close closes the partition consumers that are still open.
+func (c *consumer) Close() error {
+	c.mu.Lock()
+	open := c.open
+	c.open = nil
+	c.mu.Unlock()
+	for _, pc := range open {
+		_ = pc.Close() //nolint:errcheck
+	}
+	return nil
+}
+
+func (c *consumer) Pause(map[string][]int32)  {}
+func (c *consumer) Resume(map[string][]int32) {}
+func (c *consumer) PauseAll()                 {}
+func (c *consumer) ResumeAll()                {}
+
+type partitionConsumer struct {
+	claim     *claim
+	errors    chan *sarama.ConsumerError
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (pc *partitionConsumer) Messages() <-chan *sarama.ConsumerMessage { return pc.claim.messages }
+func (pc *partitionConsumer) Errors() <-chan *sarama.ConsumerError     { return pc.errors }
+func (pc *partitionConsumer) HighWaterMarkOffset() int64               { return pc.claim.HighWaterMarkOffset() }
+
+func (pc *partitionConsumer) AsyncClose() {
+	pc.closeOnce.Do(func() {
+		pc.cancel()
+		go func() {
+			<-pc.done
+			close(pc.errors)
+		}()
+	})
+}
+
+// Close stops feeding messages and closes Messages and Errors, like sarama does.
+func (pc *partitionConsumer) Close() error {
+	pc.AsyncClose()
+	<-pc.done
+	return nil
+}
+
+func (pc *partitionConsumer) Pause()         {}
+func (pc *partitionConsumer) Resume()        {}
+func (pc *partitionConsumer) IsPaused() bool { return false }
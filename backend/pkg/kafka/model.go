@@ -2,6 +2,23 @@ package kafka
 
 import "time"
 
+// AvailableCount asks library to lend a copy of a book, Delta -1, or to take it back, Delta 1.
+type AvailableCount struct {
+	LibraryID int `json:"libraryID"`
+	BookID    int `json:"bookID"`
+	Delta     int `json:"delta"`
+	// ReservationUid is the reservation the copy is lent on.
+	ReservationUid string `json:"reservationUid,omitempty"`
+	// Condition is the condition of a returned copy.
+	Condition string `json:"condition,omitempty"`
+}
+
+// RatingChange asks rating to add Stars to the rating of the user.
+type RatingChange struct {
+	Name  string `json:"name"`
+	Stars int    `json:"stars"`
+}
+
 type EventStats struct {
 	Timestamp     time.Time `json:"timestamp"`
 	UserName      string    `json:"username"`
@@ -20,3 +37,88 @@ const (
 	SimplexUp      Simplex = "UP"
 	SimplexDown    Simplex = "DOWN"
 )
+
+// BookCountChanged is emitted by library when the available count of a book changes.
+type BookCountChanged struct {
+	Timestamp      time.Time `json:"timestamp"`
+	LibraryID      int       `json:"libraryId"`
+	BookID         int       `json:"bookId"`
+	Delta          int       `json:"delta"`
+	AvailableCount int       `json:"availableCount"`
+}
+
+// TransferChanged is emitted by library on every change of a transfer of copies between libraries.
+type TransferChanged struct {
+	Timestamp      time.Time `json:"timestamp"`
+	TransferUid    string    `json:"transferUid"`
+	BookUid        string    `json:"bookUid"`
+	FromLibraryUid string    `json:"fromLibraryUid"`
+	ToLibraryUid   string    `json:"toLibraryUid"`
+	Copies         int       `json:"copies"`
+	Status         string    `json:"status"`
+}
+
+type ReservationEventType string
+
+const (
+	ReservationCreated    ReservationEventType = "CREATED"
+	ReservationReturned   ReservationEventType = "RETURNED"
+	ReservationCancelled  ReservationEventType = "CANCELLED"
+	ReservationReinstated ReservationEventType = "REINSTATED"
+	ReservationRenewed    ReservationEventType = "RENEWED"
+	ReservationOverdue    ReservationEventType = "OVERDUE"
+)
+
+// ReservationEvent is emitted by reservation on every change of a reservation.
+type ReservationEvent struct {
+	Timestamp      time.Time            `json:"timestamp"`
+	Type           ReservationEventType `json:"type"`
+	ReservationUid string               `json:"reservationUid"`
+	UserName       string               `json:"username"`
+	BookUid        string               `json:"bookUid"`
+	LibraryUid     string               `json:"libraryUid"`
+	Status         string               `json:"status"`
+	TillDate       time.Time            `json:"tillDate"`
+}
+
+// Overdue asks rating to penalize Days more overdue days of a reservation.
+type Overdue struct {
+	Timestamp      time.Time `json:"timestamp"`
+	ReservationUid string    `json:"reservationUid"`
+	UserName       string    `json:"username"`
+	BookUid        string    `json:"bookUid"`
+	LibraryUid     string    `json:"libraryUid"`
+	TillDate       time.Time `json:"tillDate"`
+	Days           int       `json:"days"`
+}
+
+// FineAssess asks fines to fine the user for a late or damaged return of a reservation.
+type FineAssess struct {
+	ReservationUid string    `json:"reservationUid"`
+	UserName       string    `json:"username"`
+	LibraryUid     string    `json:"libraryUid"`
+	TillDate       time.Time `json:"tillDate"`
+	ReturnDate     time.Time `json:"returnDate"`
+	ConditionFrom  string    `json:"conditionFrom"`
+	ConditionTo    string    `json:"conditionTo"`
+}
+
+// HoldEvent is emitted by reservation on every change of a hold. ExpiresAt is the end of the pickup window of a READY hold.
+type HoldEvent struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	HoldUid    string     `json:"holdUid"`
+	UserName   string     `json:"username"`
+	BookUid    string     `json:"bookUid"`
+	LibraryUid string     `json:"libraryUid"`
+	Status     string     `json:"status"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+// RatingChanged is emitted by rating when the stars of a user change.
+type RatingChanged struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserName  string    `json:"username"`
+	// Delta is the change that took effect, the stars are kept within 0 to 100.
+	Delta int `json:"delta"`
+	Stars int `json:"stars"`
+}
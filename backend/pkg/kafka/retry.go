@@ -0,0 +1,302 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const DLQSuffix = ".dlq"
+
+// Headers set on dead-lettered messages.
+const (
+	HeaderError             = "x-error"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderAttempts          = "x-attempts"
+	HeaderFailedAt          = "x-failed-at"
+	HeaderConsumerGroup     = "x-consumer-group"
+)
+
+// DLQTopic returns the dead-letter topic of topic.
+func DLQTopic(topic string) string {
+	return topic + DLQSuffix
+}
+
+type RetryConfig struct {
+	Attempts   int           `envconfig:"KAFKA_RETRY_ATTEMPTS" default:"5"`
+	Backoff    time.Duration `envconfig:"KAFKA_RETRY_BACKOFF" default:"100ms"`
+	MaxBackoff time.Duration `envconfig:"KAFKA_RETRY_MAX_BACKOFF" default:"10s"`
+}
+
+// HandlerFunc processes a single message. A returned error is retried unless it is Permanent.
+type HandlerFunc func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a message that cannot be decoded.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// GroupHandler is a sarama.ConsumerGroupHandler that retries failed messages with exponential backoff
+// and moves messages that still fail to the dead-letter topic. A message is marked only once it was
+// handled or dead-lettered, so nothing is skipped silently.
+type GroupHandler struct {
+	handle      HandlerFunc
+	producer    sarama.SyncProducer
+	group       string
+	cfg         RetryConfig
+	concurrency int
+	hooks       Hooks
+	log         *zap.Logger
+}
+
+// Hooks observe message processing, e.g. for metrics. Hooks are called concurrently
+// when the consumer handles partitions or keys in parallel.
+type Hooks struct {
+	// OnMessage is called once per message with the number of attempts and the final error.
+	OnMessage func(msg *sarama.ConsumerMessage, attempts int, took time.Duration, err error)
+	// OnDeadLetter is called after a message was moved to the dead-letter topic.
+	OnDeadLetter func(msg *sarama.ConsumerMessage, err error)
+}
+
+type ConsumerOption func(h *GroupHandler)
+
+// WithConcurrency lets up to n messages of a partition be handled in parallel.
+// Messages with the same key are still handled in order.
+func WithConcurrency(n int) ConsumerOption {
+	return func(h *GroupHandler) {
+		if n > 0 {
+			h.concurrency = n
+		}
+	}
+}
+
+func WithHooks(hooks Hooks) ConsumerOption {
+	return func(h *GroupHandler) {
+		h.hooks = hooks
+	}
+}
+
+func NewGroupHandler(handle HandlerFunc, producer sarama.SyncProducer, group string, cfg RetryConfig, log *zap.Logger, opts ...ConsumerOption) *GroupHandler {
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 1
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = cfg.Backoff
+	}
+	h := &GroupHandler{
+		handle:      handle,
+		producer:    producer,
+		group:       group,
+		cfg:         cfg,
+		concurrency: 1,
+		log:         log.Named("consumer"),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *GroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup commits the offsets marked by the claims right away, instead of waiting for the auto-commit.
+func (h *GroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	session.Commit()
+	return nil
+}
+
+// ConsumeClaim is called by sarama in a goroutine per partition.
+func (h *GroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for ctx.Err() == nil {
+		var batch []*sarama.ConsumerMessage
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				h.log.Warn("message channel was closed")
+				return nil
+			}
+			batch = append(batch, msg)
+		case <-ctx.Done():
+			return nil
+		}
+	fill:
+		for len(batch) < h.concurrency {
+			select {
+			case msg, ok := <-claim.Messages():
+				if !ok {
+					break fill
+				}
+				batch = append(batch, msg)
+			default:
+				break fill
+			}
+		}
+
+		err := h.processBatch(ctx, batch)
+		if errors.Is(err, errInterrupted) {
+			// the session is over, unmarked messages are redelivered to the next owner of the partition.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// offsets are committed up to the last message, which is safe because the whole batch is done.
+		session.MarkMessage(batch[len(batch)-1], "")
+	}
+	return nil
+}
+
+// errInterrupted means the session ended before a message was handled or dead-lettered.
+var errInterrupted = errors.New("interrupted")
+
+// processBatch handles messages with the same key in order and everything else in parallel.
+func (h *GroupHandler) processBatch(ctx context.Context, batch []*sarama.ConsumerMessage) error {
+	if len(batch) == 1 {
+		return h.processOne(ctx, batch[0])
+	}
+	var (
+		keys   = make(map[string]int)
+		groups [][]*sarama.ConsumerMessage
+	)
+	for _, msg := range batch {
+		if msg.Key == nil {
+			groups = append(groups, []*sarama.ConsumerMessage{msg})
+			continue
+		}
+		i, ok := keys[string(msg.Key)]
+		if !ok {
+			i = len(groups)
+			keys[string(msg.Key)] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], msg)
+	}
+
+	var gg errgroup.Group
+	for _, group := range groups {
+		group := group
+		gg.Go(func() error {
+			for _, msg := range group {
+				if err := h.processOne(ctx, msg); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return gg.Wait()
+}
+
+// processOne handles msg or moves it to the dead-letter topic.
+// It fails only if the message could be neither handled nor dead-lettered, or the session ended while it was retried.
+func (h *GroupHandler) processOne(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	start := time.Now()
+	attempts, err := h.process(ctx, msg)
+	if h.hooks.OnMessage != nil {
+		h.hooks.OnMessage(msg, attempts, time.Since(start), err)
+	}
+	if err == nil {
+		h.log.Debug("Message claimed:", zap.String("topic", msg.Topic), zap.Int32("partition", msg.Partition), zap.Int64("offset", msg.Offset))
+		return nil
+	}
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	if err := h.deadLetter(msg, attempts, err); err != nil {
+		h.log.Error("dead letter", zap.String("topic", msg.Topic), zap.Int64("offset", msg.Offset), zap.Error(err))
+		return err
+	}
+	if h.hooks.OnDeadLetter != nil {
+		h.hooks.OnDeadLetter(msg, err)
+	}
+	return nil
+}
+
+// process runs the handler until it succeeds, fails permanently or runs out of attempts.
+// The handler is not cancelled when the session ends, so a message in flight is finished on shutdown;
+// only the backoff between attempts is.
+func (h *GroupHandler) process(ctx context.Context, msg *sarama.ConsumerMessage) (int, error) {
+	handleCtx := context.WithoutCancel(ctx)
+	backoff := h.cfg.Backoff
+	var attempt int
+	for {
+		attempt++
+		err := h.handle(handleCtx, msg)
+		if err == nil {
+			return attempt, nil
+		}
+		h.log.Warn("handle", zap.String("topic", msg.Topic), zap.Int64("offset", msg.Offset), zap.Int("attempt", attempt), zap.Error(err))
+		if IsPermanent(err) || attempt >= h.cfg.Attempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, h.cfg.MaxBackoff)
+	}
+}
+
+func (h *GroupHandler) deadLetter(msg *sarama.ConsumerMessage, attempts int, cause error) error {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+7)
+	for _, hdr := range msg.Headers {
+		if hdr != nil {
+			headers = append(headers, *hdr)
+		}
+	}
+	headers = append(headers,
+		header(HeaderError, cause.Error()),
+		header(HeaderOriginalTopic, msg.Topic),
+		header(HeaderOriginalPartition, strconv.Itoa(int(msg.Partition))),
+		header(HeaderOriginalOffset, strconv.FormatInt(msg.Offset, 10)),
+		header(HeaderAttempts, strconv.Itoa(attempts)),
+		header(HeaderFailedAt, time.Now().UTC().Format(time.RFC3339Nano)),
+		header(HeaderConsumerGroup, h.group),
+	)
+	pm := &sarama.ProducerMessage{
+		Topic:   DLQTopic(msg.Topic),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	_, _, err := h.producer.SendMessage(pm)
+	if err == nil {
+		h.log.Error("message dead-lettered", zap.String("topic", msg.Topic), zap.Int64("offset", msg.Offset), zap.Error(cause))
+	}
+	return err
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
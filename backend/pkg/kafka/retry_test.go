@@ -0,0 +1,131 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// consume runs handler in group until the test ends.
+func consume(t *testing.T, b *kafkatest.Broker, group string, handler sarama.ConsumerGroupHandler, topics ...string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- kafka.Consume(ctx, b.ConsumerGroup(group), handler, nil, topics...)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+}
+
+func headers(msg *sarama.ConsumerMessage) map[string]string {
+	m := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		m[string(h.Key)] = string(h.Value)
+	}
+	return m
+}
+
+func TestGroupHandler_Backoff(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	p := b.SyncProducer()
+	_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: kafka.StatsTopic, Value: sarama.StringEncoder("a")})
+	require.NoError(t, err)
+
+	var (
+		mu       sync.Mutex
+		attempts []time.Time
+	)
+	handle := func(context.Context, *sarama.ConsumerMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, time.Now())
+		return errors.New("unavailable")
+	}
+	cfg := kafka.RetryConfig{Attempts: 4, Backoff: 50 * time.Millisecond, MaxBackoff: 60 * time.Millisecond}
+	consume(t, b, kafka.StatsConsumerGroup, kafka.NewGroupHandler(handle, p, kafka.StatsConsumerGroup, cfg, zap.NewNop()), kafka.StatsTopic)
+
+	require.Eventually(t, func() bool {
+		return len(b.Messages(kafka.DLQTopic(kafka.StatsTopic))) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, attempts, cfg.Attempts)
+	// the backoff doubles up to the max: 50ms, 60ms, 60ms instead of 50ms, 100ms, 200ms.
+	for i, want := range []time.Duration{50 * time.Millisecond, 60 * time.Millisecond, 60 * time.Millisecond} {
+		took := attempts[i+1].Sub(attempts[i])
+		require.GreaterOrEqual(t, took, want, "backoff %d", i+1)
+		require.Less(t, took, 2*want+50*time.Millisecond, "backoff %d", i+1)
+	}
+	require.EqualValues(t, 1, b.CommittedOffset(kafka.StatsConsumerGroup, kafka.StatsTopic, 0))
+}
+
+func TestGroupHandler_DeadLetter(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	p := b.SyncProducer()
+	for _, v := range []string{"retried", "permanent", "ok"} {
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{
+			Topic:   kafka.StatsTopic,
+			Key:     sarama.StringEncoder("key"),
+			Value:   sarama.StringEncoder(v),
+			Headers: []sarama.RecordHeader{{Key: []byte("x-request-id"), Value: []byte(v)}},
+		})
+		require.NoError(t, err)
+	}
+
+	var handled atomic.Int32
+	handle := func(_ context.Context, msg *sarama.ConsumerMessage) error {
+		handled.Add(1)
+		switch string(msg.Value) {
+		case "retried":
+			return errors.New("unavailable")
+		case "permanent":
+			return kafka.Permanent(errors.New("invalid"))
+		}
+		return nil
+	}
+	cfg := kafka.RetryConfig{Attempts: 3, Backoff: time.Millisecond}
+	consume(t, b, kafka.StatsConsumerGroup, kafka.NewGroupHandler(handle, p, kafka.StatsConsumerGroup, cfg, zap.NewNop()), kafka.StatsTopic)
+
+	require.Eventually(t, func() bool {
+		return b.CommittedOffset(kafka.StatsConsumerGroup, kafka.StatsTopic, 0) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.EqualValues(t, 3+1+1, handled.Load(), "a permanent error is not retried")
+
+	dlq := b.Messages(kafka.DLQTopic(kafka.StatsTopic))
+	require.Len(t, dlq, 2)
+	for i, want := range []struct {
+		value, err, attempts string
+	}{
+		{value: "retried", err: "unavailable", attempts: "3"},
+		{value: "permanent", err: "invalid", attempts: "1"},
+	} {
+		msg := dlq[i]
+		require.Equal(t, want.value, string(msg.Value))
+		require.Equal(t, "key", string(msg.Key))
+		h := headers(msg)
+		require.Equal(t, want.value, h["x-request-id"], "the headers of the original message are kept")
+		require.Equal(t, want.err, h[kafka.HeaderError])
+		require.Equal(t, kafka.StatsTopic, h[kafka.HeaderOriginalTopic])
+		require.Equal(t, "0", h[kafka.HeaderOriginalPartition])
+		require.Equal(t, strconv.Itoa(i), h[kafka.HeaderOriginalOffset])
+		require.Equal(t, want.attempts, h[kafka.HeaderAttempts])
+		require.Equal(t, kafka.StatsConsumerGroup, h[kafka.HeaderConsumerGroup])
+		_, err := time.Parse(time.RFC3339Nano, h[kafka.HeaderFailedAt])
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,291 @@
+package kafka
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Schema is the subset of JSON Schema used by the event schemas:
+// type, properties, required, additionalProperties, items, enum, format (uuid, date-time),
+// minLength, minimum and maximum.
+type Schema struct {
+	Type                 typeList           `json:"type"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Format               string             `json:"format"`
+	MinLength            *int               `json:"minLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// typeList accepts both "type": "string" and "type": ["string", "null"].
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = typeList{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// Validate checks the JSON document against the schema.
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return s.validate(v, "$")
+}
+
+func (s *Schema) validate(v any, at string) error {
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return isType(v, t) }) {
+		return fmt.Errorf("%s: want %s, got %s", at, strings.Join(s.Type, "|"), typeOf(v))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		return fmt.Errorf("%s: %v is not one of %v", at, v, s.Enum)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: %q is required", at, name)
+			}
+		}
+		for name, val := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", at, name)
+				}
+				continue
+			}
+			if err := prop.validate(val, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, at+"["+strconv.Itoa(i)+"]"); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(v)) < *s.MinLength {
+			return fmt.Errorf("%s: shorter than %d", at, *s.MinLength)
+		}
+		if err := checkFormat(s.Format, v); err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: %w", at, err)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", at, f, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: %v is greater than %v", at, f, *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func isType(v any, typ string) bool {
+	switch typ {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	default:
+		return typeOf(v) == typ
+	}
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func checkFormat(format, v string) error {
+	switch format {
+	case "uuid":
+		_, err := uuid.Parse(v)
+		return err
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, v)
+		return err
+	}
+	return nil
+}
+
+// Upcaster converts a payload of one version into the payload of the next version.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+type schemaKey struct {
+	eventType string
+	version   int
+}
+
+// Registry holds the schemas of every event type and version, and the upcasters between versions.
+type Registry struct {
+	mu        sync.RWMutex
+	schemas   map[schemaKey]*Schema
+	latest    map[string]int
+	upcasters map[schemaKey]Upcaster
+}
+
+var schemaFileName = regexp.MustCompile(`^(.+)\.v(\d+)\.json$`)
+
+// NewRegistry loads schemas named <event type>.v<version>.json from the root of fsys.
+func NewRegistry(fsys fs.FS) (*Registry, error) {
+	r := &Registry{
+		schemas:   make(map[schemaKey]*Schema),
+		latest:    make(map[string]int),
+		upcasters: make(map[schemaKey]Upcaster),
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		m := schemaFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[2]) //nolint:errcheck
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		var s Schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", entry.Name(), err)
+		}
+		r.schemas[schemaKey{m[1], version}] = &s
+		r.latest[m[1]] = max(r.latest[m[1]], version)
+	}
+	return r, nil
+}
+
+var (
+	defaultRegistryOnce sync.Once
+	defaultRegistry     *Registry
+)
+
+// DefaultRegistry is the registry of the schemas embedded in this package.
+func DefaultRegistry() *Registry {
+	defaultRegistryOnce.Do(func() {
+		sub, err := fs.Sub(schemaFiles, "schemas")
+		if err == nil {
+			defaultRegistry, err = NewRegistry(sub)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("kafka schemas: %v", err))
+		}
+		registerUpcasters(defaultRegistry)
+	})
+	return defaultRegistry
+}
+
+// Latest returns the latest version of the event type, 0 if the type is unknown.
+func (r *Registry) Latest(eventType string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.latest[eventType]
+}
+
+// Validate checks the payload against the schema of the event version.
+func (r *Registry) Validate(eventType string, version int, payload []byte) error {
+	r.mu.RLock()
+	s, ok := r.schemas[schemaKey{eventType, version}]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no schema for %s v%d", eventType, version)
+	}
+	if err := s.Validate(payload); err != nil {
+		return fmt.Errorf("%s v%d: %w", eventType, version, err)
+	}
+	return nil
+}
+
+// RegisterUpcaster registers the conversion of eventType from version from to from+1.
+func (r *Registry) RegisterUpcaster(eventType string, from int, up Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upcasters[schemaKey{eventType, from}] = up
+}
+
+// Upcast validates the payload of env and converts it to the latest version step by step.
+func (r *Registry) Upcast(env *Envelope) error {
+	if err := r.Validate(env.Type, env.Version, env.Payload); err != nil {
+		return err
+	}
+	latest := r.Latest(env.Type)
+	for env.Version < latest {
+		r.mu.RLock()
+		up, ok := r.upcasters[schemaKey{env.Type, env.Version}]
+		r.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("no upcaster for %s v%d", env.Type, env.Version)
+		}
+		payload, err := up(env.Payload)
+		if err != nil {
+			return fmt.Errorf("upcast %s v%d: %w", env.Type, env.Version, err)
+		}
+		env.Payload, env.Version = payload, env.Version+1
+		if err := r.Validate(env.Type, env.Version, env.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+const (
+	CleanupDelete  = "delete"
+	CleanupCompact = "compact"
+
+	configRetention = "retention.ms"
+	configCleanup   = "cleanup.policy"
+)
+
+// TopicsConfig holds the defaults of the topic specs and the reconciliation mode.
+type TopicsConfig struct {
+	Partitions        int32         `envconfig:"KAFKA_TOPIC_PARTITIONS" default:"3"`
+	ReplicationFactor int16         `envconfig:"KAFKA_TOPIC_REPLICATION" default:"1"`
+	Retention         time.Duration `envconfig:"KAFKA_TOPIC_RETENTION" default:"168h"`
+	DLQRetention      time.Duration `envconfig:"KAFKA_TOPIC_DLQ_RETENTION" default:"720h"`
+	// DryRun only reports what reconciliation would change.
+	DryRun bool `envconfig:"KAFKA_TOPICS_DRY_RUN" default:"false"`
+}
+
+type TopicSpec struct {
+	Name              string
+	Partitions        int32
+	ReplicationFactor int16
+	Retention         time.Duration
+	CleanupPolicy     string
+}
+
+func (s TopicSpec) detail() *sarama.TopicDetail {
+	retention := strconv.FormatInt(s.Retention.Milliseconds(), 10)
+	cleanup := s.CleanupPolicy
+	return &sarama.TopicDetail{
+		NumPartitions:     s.Partitions,
+		ReplicationFactor: s.ReplicationFactor,
+		ConfigEntries: map[string]*string{
+			configRetention: &retention,
+			configCleanup:   &cleanup,
+		},
+	}
+}
+
+// TopicSpecs returns the specs of the topics, and of the dead-letter topics of topics with a consumer group.
+func TopicSpecs(cfg TopicsConfig) []TopicSpec {
+	spec := func(name string) TopicSpec {
+		return TopicSpec{
+			Name:              name,
+			Partitions:        cfg.Partitions,
+			ReplicationFactor: cfg.ReplicationFactor,
+			Retention:         cfg.Retention,
+			CleanupPolicy:     CleanupDelete,
+		}
+	}
+	var specs []TopicSpec
+	for _, name := range []string{LibraryTopic, RatingTopic, StatsTopic, OverdueTopic, FinesTopic} {
+		dlq := spec(DLQTopic(name))
+		// a dead-letter topic is replayed by hand, it needs neither parallelism nor a short retention.
+		dlq.Partitions = 1
+		dlq.Retention = cfg.DLQRetention
+		specs = append(specs, spec(name), dlq)
+	}
+	for _, name := range []string{LibraryEventsTopic, ReservationEventsTopic, RatingEventsTopic} {
+		specs = append(specs, spec(name))
+	}
+	return specs
+}
+
+// SelectTopics returns the specs of the named topics.
+func SelectTopics(specs []TopicSpec, names ...string) []TopicSpec {
+	return slices.DeleteFunc(slices.Clone(specs), func(s TopicSpec) bool {
+		return !slices.Contains(names, s.Name)
+	})
+}
+
+// Drift is a difference between a spec and the topic in the cluster.
+type Drift struct {
+	Topic string
+	Field string
+	Want  string
+	Got   string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s: want %s, got %s", d.Topic, d.Field, d.Want, d.Got)
+}
+
+type TopicsReport struct {
+	// Created are the missing topics, only planned in dry-run mode.
+	Created []string
+	Drift   []Drift
+}
+
+// DiffTopics compares the specs with the topics in the cluster.
+func DiffTopics(specs []TopicSpec, existing map[string]sarama.TopicDetail) (missing []TopicSpec, drift []Drift) {
+	for _, spec := range specs {
+		got, ok := existing[spec.Name]
+		if !ok {
+			missing = append(missing, spec)
+			continue
+		}
+		add := func(field, want, got string) {
+			if want != got {
+				drift = append(drift, Drift{Topic: spec.Name, Field: field, Want: want, Got: got})
+			}
+		}
+		add("partitions", strconv.Itoa(int(spec.Partitions)), strconv.Itoa(int(got.NumPartitions)))
+		add("replication", strconv.Itoa(int(spec.ReplicationFactor)), strconv.Itoa(int(got.ReplicationFactor)))
+		// sarama does not list configs left at the broker default, those are not reported.
+		if v, ok := got.ConfigEntries[configRetention]; ok && v != nil {
+			add(configRetention, strconv.FormatInt(spec.Retention.Milliseconds(), 10), *v)
+		}
+		if v, ok := got.ConfigEntries[configCleanup]; ok && v != nil {
+			add(configCleanup, spec.CleanupPolicy, *v)
+		}
+	}
+	return missing, drift
+}
+
+// ReconcileTopics creates the missing topics and reports the drift of the existing ones.
+// Drift is never corrected automatically, as shrinking partitions or changing retention may lose data.
+func ReconcileTopics(admin sarama.ClusterAdmin, specs []TopicSpec, dryRun bool, log *zap.Logger) (TopicsReport, error) {
+	existing, err := admin.ListTopics()
+	if err != nil {
+		return TopicsReport{}, fmt.Errorf("list topics: %w", err)
+	}
+	missing, drift := DiffTopics(specs, existing)
+	report := TopicsReport{Drift: drift}
+	for _, d := range drift {
+		log.Warn("topic drift", zap.String("drift", d.String()))
+	}
+
+	var errs []error
+	for _, spec := range missing {
+		if dryRun {
+			log.Info("topic would be created", zap.String("topic", spec.Name), zap.Int32("partitions", spec.Partitions))
+			report.Created = append(report.Created, spec.Name)
+			continue
+		}
+		err := admin.CreateTopic(spec.Name, spec.detail(), false)
+		if err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+			errs = append(errs, fmt.Errorf("create topic %s: %w", spec.Name, err))
+			continue
+		}
+		log.Info("topic created", zap.String("topic", spec.Name), zap.Int32("partitions", spec.Partitions))
+		report.Created = append(report.Created, spec.Name)
+	}
+	return report, errors.Join(errs...)
+}
+
+// EnsureTopics reconciles the named topics of the config.
+func EnsureTopics(cfg Config, log *zap.Logger, names ...string) (TopicsReport, error) {
+	admin, err := sarama.NewClusterAdmin(strings.Split(cfg.Addrs, ","), sarama.NewConfig())
+	if err != nil {
+		return TopicsReport{}, fmt.Errorf("creating cluster admin: %w", err)
+	}
+	defer admin.Close()
+	return ReconcileTopics(admin, SelectTopics(TopicSpecs(cfg.Topics), names...), cfg.Topics.DryRun, log.Named("topics"))
+}
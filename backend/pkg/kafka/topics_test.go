@@ -0,0 +1,68 @@
+package kafka_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeAdmin struct {
+	sarama.ClusterAdmin
+	topics map[string]sarama.TopicDetail
+}
+
+func (a *fakeAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	return a.topics, nil
+}
+
+func (a *fakeAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, _ bool) error {
+	if _, ok := a.topics[topic]; ok {
+		return &sarama.TopicError{Err: sarama.ErrTopicAlreadyExists}
+	}
+	a.topics[topic] = *detail
+	return nil
+}
+
+func TestReconcileTopics(t *testing.T) {
+	t.Parallel()
+	cfg := kafka.TopicsConfig{Partitions: 3, ReplicationFactor: 1, Retention: time.Hour, DLQRetention: 24 * time.Hour}
+	specs := kafka.SelectTopics(kafka.TopicSpecs(cfg), kafka.LibraryTopic, kafka.DLQTopic(kafka.LibraryTopic), kafka.StatsTopic)
+	require.Len(t, specs, 3)
+
+	day := "86400000"
+	newAdmin := func() *fakeAdmin {
+		return &fakeAdmin{topics: map[string]sarama.TopicDetail{
+			kafka.LibraryTopic: {NumPartitions: 1, ReplicationFactor: 1},
+			kafka.StatsTopic:   {NumPartitions: 3, ReplicationFactor: 1, ConfigEntries: map[string]*string{"retention.ms": &day}},
+		}}
+	}
+	wantDrift := []kafka.Drift{
+		{Topic: kafka.LibraryTopic, Field: "partitions", Want: "3", Got: "1"},
+		{Topic: kafka.StatsTopic, Field: "retention.ms", Want: "3600000", Got: day},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+		admin := newAdmin()
+		report, err := kafka.ReconcileTopics(admin, specs, true, zap.NewNop())
+		require.NoError(t, err)
+		require.Equal(t, []string{kafka.DLQTopic(kafka.LibraryTopic)}, report.Created)
+		require.Equal(t, wantDrift, report.Drift)
+		require.Len(t, admin.topics, 2)
+	})
+	t.Run("create missing", func(t *testing.T) {
+		t.Parallel()
+		admin := newAdmin()
+		report, err := kafka.ReconcileTopics(admin, specs, false, zap.NewNop())
+		require.NoError(t, err)
+		require.Equal(t, []string{kafka.DLQTopic(kafka.LibraryTopic)}, report.Created)
+		require.Equal(t, wantDrift, report.Drift)
+		dlq := admin.topics[kafka.DLQTopic(kafka.LibraryTopic)]
+		require.EqualValues(t, 1, dlq.NumPartitions)
+		require.Equal(t, day, *dlq.ConfigEntries["retention.ms"])
+	})
+}
@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// Decoder turns a message into an event.
+type Decoder[T any] func(msg *sarama.ConsumerMessage) (T, error)
+
+// JSON decodes the message value as JSON.
+func JSON[T any](msg *sarama.ConsumerMessage) (T, error) {
+	var v T
+	err := json.Unmarshal(msg.Value, &v)
+	return v, err
+}
+
+// TypedConsumer decodes messages into T and passes them to the handler.
+// Messages that cannot be decoded go straight to the dead-letter topic, failed handlers are retried.
+type TypedConsumer[T any] struct {
+	*GroupHandler
+}
+
+func NewTypedConsumer[T any](
+	decode Decoder[T],
+	handle func(ctx context.Context, event T) error,
+	producer sarama.SyncProducer,
+	group string,
+	cfg Config,
+	log *zap.Logger,
+	opts ...ConsumerOption,
+) *TypedConsumer[T] {
+	handler := func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		event, err := decode(msg)
+		if err != nil {
+			return Permanent(err)
+		}
+		return handle(ctx, event)
+	}
+	opts = append([]ConsumerOption{WithConcurrency(cfg.Concurrency)}, opts...)
+	return &TypedConsumer[T]{
+		GroupHandler: NewGroupHandler(handler, producer, group, cfg.Retry, log, opts...),
+	}
+}
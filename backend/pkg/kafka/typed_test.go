@@ -0,0 +1,147 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka/kafkatest"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTypedConsumer(t *testing.T) {
+	t.Parallel()
+	type event struct {
+		Key string `json:"key"`
+		Seq int    `json:"seq"`
+	}
+	const (
+		keys        = 4
+		perKey      = 5
+		concurrency = 3
+	)
+	b := kafkatest.NewBroker(kafkatest.WithPartitions(2))
+	p := b.SyncProducer()
+	for i := 0; i < keys*perKey; i++ {
+		e := event{Key: "key" + strconv.Itoa(i%keys), Seq: i / keys}
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		_, _, err = p.SendMessage(&sarama.ProducerMessage{Topic: kafka.StatsTopic, Key: sarama.StringEncoder(e.Key), Value: sarama.ByteEncoder(data)})
+		require.NoError(t, err)
+	}
+	_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: kafka.StatsTopic, Key: sarama.StringEncoder("key0"), Value: sarama.StringEncoder("not json")})
+	require.NoError(t, err)
+
+	var (
+		mu       sync.Mutex
+		seqs     = make(map[string][]int)
+		inFlight = make(map[int32]int)
+		maxed    = make(map[int32]int)
+	)
+	handle := func(_ context.Context, e event) error {
+		partition := sarama.NewHashPartitioner("")
+		part, err := partition.Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder(e.Key)}, 2)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		inFlight[part]++
+		maxed[part] = max(maxed[part], inFlight[part])
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight[part]--
+		seqs[e.Key] = append(seqs[e.Key], e.Seq)
+		return nil
+	}
+	cfg := kafka.Config{Concurrency: concurrency, Retry: kafka.RetryConfig{Attempts: 3, Backoff: time.Millisecond}}
+	consume(t, b, kafka.StatsConsumerGroup,
+		kafka.NewTypedConsumer(kafka.JSON[event], handle, p, kafka.StatsConsumerGroup, cfg, zap.NewNop()), kafka.StatsTopic)
+
+	require.Eventually(t, func() bool {
+		return len(b.Messages(kafka.DLQTopic(kafka.StatsTopic))) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, s := range seqs {
+			n += len(s)
+		}
+		return n == keys*perKey
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for key, s := range seqs {
+		require.Equal(t, []int{0, 1, 2, 3, 4}, s, "the events of %s are handled in order", key)
+	}
+	// every partition gets two of the keys, which are handled in parallel.
+	require.Len(t, maxed, 2)
+	for part, n := range maxed {
+		require.Equal(t, 2, n, "partition %d", part)
+	}
+	require.Equal(t, "1", headers(b.Messages(kafka.DLQTopic(kafka.StatsTopic))[0])[kafka.HeaderAttempts],
+		"a message that cannot be decoded is not retried")
+}
+
+func TestTypedConsumer_Hooks(t *testing.T) {
+	t.Parallel()
+	b := kafkatest.NewBroker()
+	p := b.SyncProducer()
+	for _, v := range []string{`{"n":1}`, "not json", `{"n":2}`} {
+		_, _, err := p.SendMessage(&sarama.ProducerMessage{Topic: kafka.StatsTopic, Value: sarama.StringEncoder(v)})
+		require.NoError(t, err)
+	}
+
+	type call struct {
+		offset   int64
+		attempts int
+		err      bool
+	}
+	var (
+		mu          sync.Mutex
+		messages    []call
+		deadLetters []int64
+	)
+	hooks := kafka.Hooks{
+		OnMessage: func(msg *sarama.ConsumerMessage, attempts int, took time.Duration, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			require.GreaterOrEqual(t, took, time.Duration(0))
+			messages = append(messages, call{offset: msg.Offset, attempts: attempts, err: err != nil})
+		},
+		OnDeadLetter: func(msg *sarama.ConsumerMessage, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			require.Error(t, err)
+			deadLetters = append(deadLetters, msg.Offset)
+		},
+	}
+	handle := func(context.Context, struct{ N int }) error { return nil }
+	cfg := kafka.Config{Retry: kafka.RetryConfig{Attempts: 3, Backoff: time.Millisecond}}
+	consume(t, b, kafka.StatsConsumerGroup,
+		kafka.NewTypedConsumer(kafka.JSON[struct{ N int }], handle, p, kafka.StatsConsumerGroup, cfg, zap.NewNop(), kafka.WithHooks(hooks)),
+		kafka.StatsTopic)
+
+	require.Eventually(t, func() bool {
+		return b.CommittedOffset(kafka.StatsConsumerGroup, kafka.StatsTopic, 0) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []call{
+		{offset: 0, attempts: 1},
+		{offset: 1, attempts: 1, err: true},
+		{offset: 2, attempts: 1},
+	}, messages, "OnMessage fires once per message")
+	require.Equal(t, []int64{1}, deadLetters, "OnDeadLetter fires once per dead-lettered message")
+}
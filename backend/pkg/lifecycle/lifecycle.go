@@ -0,0 +1,161 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// RunFunc runs a component until ctx is done. It calls ready once it serves, e.g. once a consumer joined its group.
+// Returning before ctx is done, with or without an error, is fatal for the service.
+type RunFunc func(ctx context.Context, ready func()) error
+
+// Loop adapts a function that runs until ctx is done and serves right away, e.g. a polling loop.
+func Loop(run func(ctx context.Context)) RunFunc {
+	return func(ctx context.Context, ready func()) error {
+		ready()
+		run(ctx)
+		return nil
+	}
+}
+
+// Runner runs the background components of a service, e.g. Kafka consumers and the outbox relay,
+// and stops them before the resources they use are closed.
+type Runner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	log    *zap.Logger
+
+	fatal     chan error
+	fatalOnce sync.Once
+
+	mu      sync.Mutex
+	running map[string]struct{}
+	pending map[string]struct{}
+	ready   chan struct{}
+}
+
+func New(log *zap.Logger) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		ctx:     ctx,
+		cancel:  cancel,
+		log:     log.Named("lifecycle"),
+		fatal:   make(chan error, 1),
+		running: make(map[string]struct{}),
+		pending: make(map[string]struct{}),
+		ready:   make(chan struct{}),
+	}
+}
+
+// Go starts the component in a goroutine.
+func (r *Runner) Go(name string, run RunFunc) {
+	r.mu.Lock()
+	if len(r.pending) == 0 && isClosed(r.ready) {
+		r.ready = make(chan struct{})
+	}
+	r.running[name] = struct{}{}
+	r.pending[name] = struct{}{}
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		var once sync.Once
+		err := run(r.ctx, func() { once.Do(func() { r.setReady(name) }) })
+
+		r.mu.Lock()
+		delete(r.running, name)
+		r.mu.Unlock()
+		if r.ctx.Err() != nil {
+			if err != nil {
+				r.log.Warn("stopped with error", zap.String("component", name), zap.Error(err))
+			}
+			return
+		}
+		if err == nil {
+			err = errors.New("stopped unexpectedly")
+		}
+		r.setFatal(fmt.Errorf("%s: %w", name, err))
+	}()
+}
+
+func (r *Runner) setReady(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, name)
+	r.log.Info("ready", zap.String("component", name))
+	if len(r.pending) == 0 && !isClosed(r.ready) {
+		close(r.ready)
+	}
+}
+
+func (r *Runner) setFatal(err error) {
+	r.fatalOnce.Do(func() {
+		r.fatal <- err
+	})
+}
+
+// Ready is closed once every started component is ready.
+func (r *Runner) Ready() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready
+}
+
+// Check returns an error naming the components that are not ready yet, e.g. for a health check.
+func (r *Runner) Check() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	return fmt.Errorf("not ready: %s", sortedKeys(r.pending))
+}
+
+// Fatal receives the first component that stopped on its own. The service is expected to shut down.
+func (r *Runner) Fatal() <-chan error {
+	return r.fatal
+}
+
+// Stop cancels the components and waits until they have returned or ctx is done.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return fmt.Errorf("still running: %s: %w", sortedKeys(r.running), ctx.Err())
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func sortedKeys(m map[string]struct{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
@@ -0,0 +1,75 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+	r := lifecycle.New(zap.NewNop())
+	stopped := make(chan struct{})
+	r.Go("loop", lifecycle.Loop(func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}))
+	release := make(chan struct{})
+	r.Go("consumer", func(ctx context.Context, ready func()) error {
+		<-release
+		ready()
+		<-ctx.Done()
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		err := r.Check()
+		return err != nil && err.Error() == "not ready: consumer"
+	}, time.Second, time.Millisecond)
+	close(release)
+	select {
+	case <-r.Ready():
+	case <-time.After(time.Second):
+		t.Fatal("not ready")
+	}
+	require.NoError(t, r.Check())
+
+	require.NoError(t, r.Stop(context.Background()))
+	<-stopped
+	select {
+	case err := <-r.Fatal():
+		t.Fatalf("unexpected fatal error: %v", err)
+	default:
+	}
+}
+
+func TestRunner_Fatal(t *testing.T) {
+	t.Parallel()
+	r := lifecycle.New(zap.NewNop())
+	r.Go("consumer", func(context.Context, func()) error {
+		return errors.New("group closed")
+	})
+	require.EqualError(t, <-r.Fatal(), "consumer: group closed")
+	require.NoError(t, r.Stop(context.Background()))
+}
+
+func TestRunner_StopTimeout(t *testing.T) {
+	t.Parallel()
+	r := lifecycle.New(zap.NewNop())
+	block := make(chan struct{})
+	defer close(block)
+	r.Go("stuck", func(context.Context, func()) error {
+		<-block
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := r.Stop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "stuck")
+}
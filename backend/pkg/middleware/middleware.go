@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/Astemirdum/library-service/backend/pkg/auth"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -70,6 +71,32 @@ func AuthContext(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// TraceContext puts the request id into the trace context of the events published while handling the request.
+// It must run after middleware.RequestID.
+func TraceContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+		if requestID == "" {
+			return next(c)
+		}
+		req := c.Request()
+		ctx := kafka.ContextWithTrace(req.Context(), kafka.Trace{"requestId": requestID})
+		c.SetRequest(req.WithContext(ctx))
+		return next(c)
+	}
+}
+
+// Readiness answers http.StatusServiceUnavailable with the error of check until it passes, e.g. until
+// the background components of lifecycle.Runner.Check are ready.
+func Readiness(check func() error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := check(); err != nil {
+			return c.String(http.StatusServiceUnavailable, err.Error())
+		}
+		return c.String(http.StatusOK, "OK")
+	}
+}
+
 func NewRateLimiter(rps rate.Limit) echo.MiddlewareFunc {
 	return middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(rps))
 }
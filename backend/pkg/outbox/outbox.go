@@ -0,0 +1,178 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/IBM/sarama"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// tableName is created by the outbox migration of every service that publishes through the outbox:
+//
+//	CREATE TABLE IF NOT EXISTS outbox
+//	(
+//	    id         bigint generated always as identity PRIMARY KEY,
+//	    topic      VARCHAR(255) NOT NULL,
+//	    key        VARCHAR(255) NOT NULL DEFAULT '',
+//	    payload    jsonb        NOT NULL,
+//	    created_at TIMESTAMP    NOT NULL DEFAULT now()
+//	);
+const tableName = `outbox`
+
+// relayLockID serializes relays of the same database, which keeps messages in insertion order.
+const relayLockID = 0x6f7574626f78
+
+type Config struct {
+	Interval  time.Duration `envconfig:"OUTBOX_INTERVAL" default:"1s"`
+	BatchSize int           `envconfig:"OUTBOX_BATCH_SIZE" default:"100"`
+}
+
+type Message struct {
+	Topic   string
+	Key     string
+	Payload []byte
+}
+
+// Execer is satisfied by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Put stores msg in the outbox. Pass the transaction of the state change,
+// so the message is published if and only if the change is committed.
+func Put(ctx context.Context, db Execer, msg Message) error {
+	q := fmt.Sprintf(`insert into %s (topic, key, payload) values (@topic, @key, @payload)`, tableName)
+	_, err := db.Exec(ctx, q, pgx.NamedArgs{
+		"topic":   msg.Topic,
+		"key":     msg.Key,
+		"payload": msg.Payload,
+	})
+	return err
+}
+
+// PutEvent stores the enveloped event in the outbox.
+func PutEvent(ctx context.Context, db Execer, topic, key string, env kafka.Envelope) error {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return Put(ctx, db, Message{Topic: topic, Key: key, Payload: payload})
+}
+
+// Relay publishes outbox messages to Kafka in insertion order.
+// A message is deleted only after the broker acknowledged it, so delivery is at-least-once.
+type Relay struct {
+	db       *pgxpool.Pool
+	producer sarama.SyncProducer
+	cfg      Config
+	log      *zap.Logger
+}
+
+func NewRelay(db *pgxpool.Pool, producer sarama.SyncProducer, cfg Config, log *zap.Logger) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Relay{
+		db:       db,
+		producer: producer,
+		cfg:      cfg,
+		log:      log.Named("outbox"),
+	}
+}
+
+// Run publishes pending messages until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := r.Publish(ctx)
+			if err != nil {
+				r.log.Error("publish", zap.Error(err))
+				break
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish sends one batch and returns the number of published messages.
+func (r *Relay) Publish(ctx context.Context) (int, error) {
+	var published int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, `select pg_try_advisory_xact_lock($1)`, relayLockID).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		rows, err := tx.Query(ctx,
+			fmt.Sprintf(`select id, topic, key, payload from %s order by id limit $1`, tableName), r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		type row struct {
+			id  int64
+			msg Message
+		}
+		batch, err := pgx.CollectRows(rows, func(rows pgx.CollectableRow) (row, error) {
+			var rr row
+			err := rows.Scan(&rr.id, &rr.msg.Topic, &rr.msg.Key, &rr.msg.Payload)
+			return rr, err
+		})
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(batch))
+		var sendErr error
+		for _, rr := range batch {
+			if sendErr = r.send(rr.msg); sendErr != nil {
+				// keep the order: everything after the failed message waits for the next round.
+				break
+			}
+			ids = append(ids, rr.id)
+		}
+		if len(ids) > 0 {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`delete from %s where id = any($1)`, tableName), ids); err != nil {
+				return err
+			}
+		}
+		published = len(ids)
+		if sendErr != nil {
+			r.log.Warn("send", zap.Int("published", published), zap.Error(sendErr))
+		}
+		return nil
+	})
+	return published, err
+}
+
+func (r *Relay) send(msg Message) error {
+	pm := &sarama.ProducerMessage{
+		Topic: msg.Topic,
+		Value: sarama.ByteEncoder(msg.Payload),
+	}
+	if msg.Key != "" {
+		pm.Key = sarama.StringEncoder(msg.Key)
+	}
+	_, _, err := r.producer.SendMessage(pm)
+	return err
+}
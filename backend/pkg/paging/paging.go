@@ -0,0 +1,297 @@
+// Package paging pages the lists by opaque keyset cursors. The page numbers of the clients that
+// still ask for them are served by offset.
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	// DefaultSize is the size of a page that is not asked for.
+	DefaultSize = 20
+	// MaxSize is the size of the largest page, larger pages are cut to it.
+	MaxSize = 100
+)
+
+// ErrInvalid is the error of a cursor or a sort that does not fit the list.
+var ErrInvalid = errors.New("invalid paging")
+
+// Order is the direction a list is sorted in.
+type Order string
+
+const (
+	Asc  Order = "asc"
+	Desc Order = "desc"
+)
+
+// Request asks for a page of a list: the page after or before Cursor, or the page number Page.
+type Request struct {
+	Page   int    `query:"page" validate:"gte=0"`
+	Size   int    `query:"size" validate:"gte=0"`
+	Cursor string `query:"cursor"`
+	Sort   string `query:"sort"`
+	Order  Order  `query:"order" validate:"omitempty,oneof=asc desc"`
+	// Total asks for the count of the whole list, a page number always gets it.
+	Total bool `query:"total"`
+}
+
+// Paged tells if the request asks for a page, or an order, at all. The lists that were never
+// paged serve a request that does not with the whole list.
+func (r Request) Paged() bool {
+	return r.Page != 0 || r.Size != 0 || r.Cursor != "" || r.Sort != "" || r.Order != "" || r.Total
+}
+
+// WithTotal tells if the count of the whole list is asked for.
+func (r Request) WithTotal() bool {
+	return r.Total || r.offset()
+}
+
+// Limit is the size of the page, cut to MaxSize.
+func (r Request) Limit() int {
+	switch {
+	case r.Size <= 0:
+		return DefaultSize
+	case r.Size > MaxSize:
+		return MaxSize
+	}
+	return r.Size
+}
+
+// Query is the request as the query of a url.
+func (r Request) Query() url.Values {
+	q := url.Values{}
+	if r.Page != 0 {
+		q.Set("page", strconv.Itoa(r.Page))
+	}
+	if r.Size != 0 {
+		q.Set("size", strconv.Itoa(r.Size))
+	}
+	if r.Cursor != "" {
+		q.Set("cursor", r.Cursor)
+	}
+	if r.Sort != "" {
+		q.Set("sort", r.Sort)
+	}
+	if r.Order != "" {
+		q.Set("order", string(r.Order))
+	}
+	if r.Total {
+		q.Set("total", "true")
+	}
+	return q
+}
+
+func (r Request) offset() bool {
+	return r.Page > 0 && r.Cursor == ""
+}
+
+// Page tells where a page is in its list. Next and Prev are the cursors of the pages around it,
+// they are empty at the ends of the list.
+type Page struct {
+	Number        int    `json:"page,omitempty"`
+	PageSize      int    `json:"pageSize"`
+	TotalElements *int   `json:"totalElements,omitempty"`
+	Next          string `json:"next,omitempty"`
+	Prev          string `json:"prev,omitempty"`
+}
+
+// The headers carry the page of the lists answered with a bare array.
+const (
+	HeaderNext  = "X-Next-Cursor"
+	HeaderPrev  = "X-Prev-Cursor"
+	HeaderTotal = "X-Total-Count"
+)
+
+// SetHeaders puts the page into the headers h.
+func (p Page) SetHeaders(h http.Header) {
+	if p.Next != "" {
+		h.Set(HeaderNext, p.Next)
+	}
+	if p.Prev != "" {
+		h.Set(HeaderPrev, p.Prev)
+	}
+	if p.TotalElements != nil {
+		h.Set(HeaderTotal, strconv.Itoa(*p.TotalElements))
+	}
+}
+
+// FromHeaders reads the page put into the headers h by SetHeaders.
+func FromHeaders(h http.Header) Page {
+	p := Page{Next: h.Get(HeaderNext), Prev: h.Get(HeaderPrev)}
+	if total, err := strconv.Atoi(h.Get(HeaderTotal)); err == nil {
+		p.TotalElements = &total
+	}
+	return p
+}
+
+// Column is a column a list of T is sorted by. Expr is its expression in the query, Type its SQL
+// type and Value its value in an item of the list.
+type Column[T any] struct {
+	Expr  string
+	Type  string
+	Value func(T) any
+}
+
+// Keyset is the order of a list of T: the columns it can be sorted by, by their names in the
+// requests, and the unique key that breaks the ties. A list that is not sorted is in the order of the key.
+type Keyset[T any] struct {
+	Sorts map[string]Column[T]
+	Key   Column[T]
+}
+
+// cursor is the position of a page in a list, the values of the sort and key columns of the
+// item next to it. Sort is the order the cursor was made in, a cursor fits no other one.
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	Back   bool     `json:"b,omitempty"`
+}
+
+// Pager pages a list of T as the request asks.
+type Pager[T any] struct {
+	req     Request
+	sort    string
+	columns []Column[T]
+	desc    bool
+	cur     *cursor
+}
+
+// NewPager makes the pager of the request of a list in the order ks. It fails with ErrInvalid
+// if the list can not be sorted as asked or the cursor is not of it.
+func NewPager[T any](req Request, ks Keyset[T]) (*Pager[T], error) {
+	p := &Pager[T]{req: req, columns: []Column[T]{ks.Key}, desc: req.Order == Desc}
+	if req.Sort != "" {
+		col, ok := ks.Sorts[req.Sort]
+		if !ok {
+			return nil, fmt.Errorf("%w: can not sort by %q", ErrInvalid, req.Sort)
+		}
+		p.columns = []Column[T]{col, ks.Key}
+	}
+	p.sort = req.Sort + ":" + string(Asc)
+	if p.desc {
+		p.sort = req.Sort + ":" + string(Desc)
+	}
+	if req.Cursor == "" {
+		return p, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(req.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cursor: %v", ErrInvalid, err)
+	}
+	var cur cursor
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, fmt.Errorf("%w: cursor: %v", ErrInvalid, err)
+	}
+	if cur.Sort != p.sort || len(cur.Values) != len(p.columns) {
+		return nil, fmt.Errorf("%w: cursor is of another order", ErrInvalid)
+	}
+	p.cur = &cur
+	return p, nil
+}
+
+// Apply orders and limits the query q. It asks for an item more than fits the page, so that
+// Collect tells if the list goes on.
+func (p *Pager[T]) Apply(q sq.SelectBuilder) sq.SelectBuilder {
+	// a page before the cursor is read backwards from it.
+	desc := p.desc
+	if p.cur != nil && p.cur.Back {
+		desc = !desc
+	}
+	dir, op := "asc", ">"
+	if desc {
+		dir, op = "desc", "<"
+	}
+
+	exprs := make([]string, 0, len(p.columns))
+	orderBy := make([]string, 0, len(p.columns))
+	for _, col := range p.columns {
+		exprs = append(exprs, col.Expr)
+		orderBy = append(orderBy, col.Expr+" "+dir)
+	}
+	if p.cur != nil {
+		params := make([]string, 0, len(p.columns))
+		args := make([]any, 0, len(p.columns))
+		for i, col := range p.columns {
+			params = append(params, fmt.Sprintf("cast(?::text as %s)", col.Type))
+			args = append(args, p.cur.Values[i])
+		}
+		q = q.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), op, strings.Join(params, ", ")), args...)
+	}
+	q = q.OrderBy(orderBy...).Limit(uint64(p.req.Limit() + 1))
+	if p.req.offset() {
+		q = q.Offset(uint64((p.req.Page - 1) * p.req.Limit()))
+	}
+	return q
+}
+
+// Collect cuts the items read by the query of Apply to the page, and tells where the page is.
+// The total count is left for the caller to set if it is asked for.
+func (p *Pager[T]) Collect(items []T) ([]T, Page) {
+	limit := p.req.Limit()
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	back := p.cur != nil && p.cur.Back
+	if back {
+		slices.Reverse(items)
+	}
+
+	page := Page{PageSize: limit}
+	if p.req.offset() {
+		page.Number = p.req.Page
+	}
+	if len(items) == 0 {
+		return items, page
+	}
+	first, last := items[0], items[len(items)-1]
+	if more || back {
+		page.Next = p.encode(last, false)
+	}
+	if (more && back) || (!back && (p.cur != nil || p.req.Page > 1)) {
+		page.Prev = p.encode(first, true)
+	}
+	return items, page
+}
+
+func (p *Pager[T]) encode(item T, back bool) string {
+	cur := cursor{Sort: p.sort, Values: make([]string, 0, len(p.columns)), Back: back}
+	for _, col := range p.columns {
+		cur.Values = append(cur.Values, format(col.Value(item)))
+	}
+	b, _ := json.Marshal(cur) //nolint:errcheck
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// format writes v as the text the database reads it from.
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	}
+	return fmt.Sprint(v)
+}
+
+// Offset is the limit and the offset of the page number page of the size asked for, for the lists
+// ranked in an order no cursor can follow. Page 0 is the first page.
+func Offset(page, size int) (limit, offset int) {
+	limit = Request{Size: size}.Limit()
+	if page > 1 {
+		offset = (page - 1) * limit
+	}
+	return limit, offset
+}
@@ -0,0 +1,108 @@
+package paging
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+var keyset = Keyset[item]{
+	Sorts: map[string]Column[item]{
+		"name": {Expr: "name", Type: "text", Value: func(i item) any { return i.Name }},
+	},
+	Key: Column[item]{Expr: "id", Type: "int", Value: func(i item) any { return i.ID }},
+}
+
+func TestPager(t *testing.T) {
+	t.Parallel()
+	list := []item{{1, "a"}, {2, "b"}, {3, "b"}, {4, "c"}, {5, "d"}}
+	// page reads the list as the query of Apply would.
+	page := func(req Request) ([]item, Page, string) {
+		p, err := NewPager(req, keyset)
+		require.NoError(t, err)
+		query, _, err := p.Apply(sq.Select("*").From("items")).ToSql()
+		require.NoError(t, err)
+
+		var read []item
+		if p.cur == nil {
+			read = list
+		} else if p.cur.Back {
+			for i := len(list) - 1; i >= 0; i-- {
+				if list[i].Name < p.cur.Values[0] || (list[i].Name == p.cur.Values[0] && format(list[i].ID) < p.cur.Values[1]) {
+					read = append(read, list[i])
+				}
+			}
+		} else {
+			for _, it := range list {
+				if it.Name > p.cur.Values[0] || (it.Name == p.cur.Values[0] && format(it.ID) > p.cur.Values[1]) {
+					read = append(read, it)
+				}
+			}
+		}
+		if len(read) > req.Limit()+1 {
+			read = read[:req.Limit()+1]
+		}
+		items, pg := p.Collect(read)
+		return items, pg, query
+	}
+
+	items, first, query := page(Request{Size: 2, Sort: "name"})
+	require.Equal(t, "SELECT * FROM items ORDER BY name asc, id asc LIMIT 3", query)
+	require.Equal(t, []item{{1, "a"}, {2, "b"}}, items)
+	require.NotEmpty(t, first.Next)
+	require.Empty(t, first.Prev)
+
+	items, second, query := page(Request{Size: 2, Sort: "name", Cursor: first.Next})
+	require.Equal(t, "SELECT * FROM items WHERE (name, id) > (cast(?::text as text), cast(?::text as int)) ORDER BY name asc, id asc LIMIT 3", query)
+	require.Equal(t, []item{{3, "b"}, {4, "c"}}, items)
+	require.NotEmpty(t, second.Next)
+	require.NotEmpty(t, second.Prev)
+
+	items, last, _ := page(Request{Size: 2, Sort: "name", Cursor: second.Next})
+	require.Equal(t, []item{{5, "d"}}, items)
+	require.Empty(t, last.Next)
+
+	items, back, query := page(Request{Size: 2, Sort: "name", Cursor: second.Prev})
+	require.Equal(t, "SELECT * FROM items WHERE (name, id) < (cast(?::text as text), cast(?::text as int)) ORDER BY name desc, id desc LIMIT 3", query)
+	require.Equal(t, []item{{1, "a"}, {2, "b"}}, items)
+	require.Empty(t, back.Prev)
+	require.Equal(t, first.Next, back.Next)
+}
+
+func TestNewPager(t *testing.T) {
+	t.Parallel()
+	_, err := NewPager(Request{Sort: "author"}, keyset)
+	require.ErrorIs(t, err, ErrInvalid)
+	_, err = NewPager(Request{Cursor: "not a cursor"}, keyset)
+	require.ErrorIs(t, err, ErrInvalid)
+
+	p, err := NewPager(Request{Size: 2, Sort: "name"}, keyset)
+	require.NoError(t, err)
+	_, pg := p.Collect([]item{{1, "a"}, {2, "b"}, {3, "c"}})
+	// a cursor fits only the order it was made in.
+	require.NotEmpty(t, pg.Next)
+	_, err = NewPager(Request{Size: 2, Sort: "name", Order: Desc, Cursor: pg.Next}, keyset)
+	require.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestRequest_Offset(t *testing.T) {
+	t.Parallel()
+	req := Request{Page: 3, Size: 500}
+	require.Equal(t, MaxSize, req.Limit())
+	require.True(t, req.WithTotal())
+	p, err := NewPager(req, keyset)
+	require.NoError(t, err)
+	query, _, err := p.Apply(sq.Select("*").From("items")).ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM items ORDER BY id asc LIMIT 101 OFFSET 200", query)
+
+	require.Equal(t, DefaultSize, Request{}.Limit())
+	require.False(t, Request{}.Paged())
+	require.True(t, Request{Order: Desc}.Paged())
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// RunAsLeader runs run while the instance holds the session advisory lock lockID, so that one instance
+// of the service runs it at a time. The other instances try to take the lock every interval,
+// and take over once the leader stops or loses its connection. It returns when ctx is done.
+func RunAsLeader(ctx context.Context, db *pgxpool.Pool, lockID int64, interval time.Duration, log *zap.Logger, run func(ctx context.Context)) {
+	log = log.With(zap.Int64("lock_id", lockID))
+	for {
+		if err := lead(ctx, db, lockID, interval, log, run); err != nil && ctx.Err() == nil {
+			log.Warn("leader election", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// lead runs run if it takes the lock, until ctx is done or the connection holding the lock is lost.
+func lead(ctx context.Context, db *pgxpool.Pool, lockID int64, interval time.Duration, log *zap.Logger, run func(ctx context.Context)) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	var locked bool
+	if err := conn.QueryRow(ctx, `select pg_try_advisory_lock($1)`, lockID).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	log.Info("became leader")
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(leadCtx)
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lost error
+watch:
+	for {
+		select {
+		case <-ctx.Done():
+			break watch
+		case <-done:
+			break watch
+		case <-ticker.C:
+			// the lock goes with the session, a broken connection means another instance may lead already.
+			if lost = conn.Ping(ctx); lost != nil {
+				break watch
+			}
+		}
+	}
+	cancel()
+	<-done
+	log.Info("stepped down")
+
+	unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), interval)
+	defer cancelUnlock()
+	if _, err := conn.Exec(unlockCtx, `select pg_advisory_unlock($1)`, lockID); err != nil {
+		// never return a connection that may still hold the lock to the pool.
+		_ = conn.Conn().Close(unlockCtx) //nolint:errcheck
+		if lost == nil {
+			lost = err
+		}
+	}
+	return lost
+}
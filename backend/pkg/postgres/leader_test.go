@@ -0,0 +1,48 @@
+package postgres_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRunAsLeader(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs, running atomic.Int32
+	run := func(ctx context.Context) {
+		runs.Add(1)
+		if running.Add(1) > 1 {
+			t.Error("two contenders run at once")
+		}
+		<-ctx.Done()
+		running.Add(-1)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			postgres.RunAsLeader(ctx, db, 42, 10*time.Millisecond, zap.NewNop(), run)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+	// the other contender keeps trying to take the lock while the leader runs.
+	time.Sleep(200 * time.Millisecond)
+	require.EqualValues(t, 1, runs.Load())
+
+	cancel()
+	wg.Wait()
+	require.Zero(t, running.Load())
+}
@@ -24,6 +24,9 @@ type DB struct {
 var (
 	pgOnce     sync.Once
 	pgInstance *pgxpool.Pool
+
+	// gooseMu guards the global state of goose.
+	gooseMu sync.Mutex
 )
 
 func NewPostgresDB(ctx context.Context, cfg *DB, fsys fs.FS) (*pgxpool.Pool, error) {
@@ -68,12 +71,18 @@ func migrateSchema(cfg *DB, fsys fs.FS) error {
 	if err = db.Ping(); err != nil {
 		return fmt.Errorf("migrateSchema ping: %w", err)
 	}
+	return Migrate(db, fsys)
+}
 
+// Migrate applies the migrations of the sql directory of fsys to db.
+func Migrate(db *sql.DB, fsys fs.FS) error {
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
 	goose.SetBaseFS(fsys)
 	if err := goose.SetDialect("postgres"); err != nil {
 		return err
 	}
-	if err = goose.Up(db, "sql"); err != nil {
+	if err := goose.Up(db, "sql"); err != nil {
 		return errors.Wrap(err, "goose run()")
 	}
 	return nil
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MarkProcessed records eventID in the processed_events table of the service:
+//
+//	CREATE TABLE IF NOT EXISTS processed_events
+//	(
+//	    event_id     VARCHAR(64) PRIMARY KEY,
+//	    processed_at TIMESTAMP   NOT NULL DEFAULT now()
+//	);
+//
+// Call it in the transaction of the effect. It reports false if the event was processed before,
+// in which case the caller skips the effect. Events without an ID are always processed.
+func MarkProcessed(ctx context.Context, tx pgx.Tx, eventID string) (bool, error) {
+	if eventID == "" {
+		return true, nil
+	}
+	tag, err := tx.Exec(ctx, `insert into processed_events (event_id) values ($1) on conflict do nothing`, eventID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
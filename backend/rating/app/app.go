@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"github.com/Astemirdum/library-service/backend/rating/config"
 	"github.com/Astemirdum/library-service/backend/rating/internal/handler"
@@ -34,14 +36,36 @@ func Run(cfg *config.Config) error {
 	}
 	svc := service.NewService(repo, log)
 
+	if _, err := kafka.EnsureTopics(cfg.Kafka, log, kafka.RatingTopic, kafka.DLQTopic(kafka.RatingTopic), kafka.RatingEventsTopic,
+		kafka.OverdueTopic, kafka.DLQTopic(kafka.OverdueTopic)); err != nil {
+		log.Error("ensure topics", zap.Error(err))
+	}
+	producer, err := kafka.NewSyncProducer(cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("kafka.NewSyncProducer %w", err)
+	}
+	defer producer.Close()
+
 	consumer, err := kafka.NewConsumer(cfg.Kafka, kafka.RatingConsumerGroup)
 	if err != nil {
 		return fmt.Errorf("kafka.NewConsumer %w", err)
 	}
-	go kafka.Consume(consumer, handler.NewConsumer(svc.Rating, log), kafka.RatingTopic)
+	overdueConsumer, err := kafka.NewConsumer(cfg.Kafka, kafka.OverdueConsumerGroup)
+	if err != nil {
+		return fmt.Errorf("kafka.NewConsumer %w", err)
+	}
+	workers := lifecycle.New(log)
+	workers.Go("consumer", func(ctx context.Context, ready func()) error {
+		return kafka.Consume(ctx, consumer, handler.NewConsumer(svc.Rating, producer, cfg.Kafka, log), ready, kafka.RatingTopic)
+	})
+	workers.Go("overdue consumer", func(ctx context.Context, ready func()) error {
+		return kafka.Consume(ctx, overdueConsumer,
+			handler.NewOverdueConsumer(svc.Rating, cfg.Penalty.OverdueDay, producer, cfg.Kafka, log), ready, kafka.OverdueTopic)
+	})
+	workers.Go("outbox relay", lifecycle.Loop(outbox.NewRelay(db, producer, cfg.Outbox, log).Run))
 
 	h := handler.New(svc, log)
-	srv := server.NewServer(cfg.Server, h.NewRouter())
+	srv := server.NewServer(cfg.Server, h.NewRouter(workers.Check))
 	log.Info("http server start ON: ",
 		zap.String("addr",
 			net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)))
@@ -53,9 +77,13 @@ func Run(cfg *config.Config) error {
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	termSig := <-sig
-
-	log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	var fatal error
+	select {
+	case termSig := <-sig:
+		log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	case fatal = <-workers.Fatal():
+		log.Error("Graceful shutdown", zap.Error(fatal))
+	}
 
 	closeCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -63,7 +91,10 @@ func Run(cfg *config.Config) error {
 	if err = srv.Stop(closeCtx); err != nil {
 		log.DPanic("srv.Stop", zap.Error(err))
 	}
+	if err := workers.Stop(closeCtx); err != nil {
+		log.Error("workers.Stop", zap.Error(err))
+	}
 	db.Close()
 	log.Info("Graceful shutdown finished")
-	return nil
+	return fatal
 }
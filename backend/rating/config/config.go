@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 
@@ -23,11 +24,19 @@ type HTTPServer struct {
 	WriteTimeout time.Duration
 }
 
+// Penalty configures the penalties applied to the rating.
+type Penalty struct {
+	// OverdueDay is the stars taken for every day a book is overdue.
+	OverdueDay int `yaml:"overdueDay" envconfig:"RATING_OVERDUE_DAY_PENALTY" default:"1"`
+}
+
 type Config struct {
-	Server   HTTPServer   `yaml:"server"`
-	Kafka    kafka.Config `yaml:"kafka"`
-	Database postgres.DB  `yaml:"db"`
-	Log      logger.Log   `yaml:"log"`
+	Server   HTTPServer    `yaml:"server"`
+	Kafka    kafka.Config  `yaml:"kafka"`
+	Database postgres.DB   `yaml:"db"`
+	Outbox   outbox.Config `yaml:"outbox"`
+	Penalty  Penalty       `yaml:"penalty"`
+	Log      logger.Log    `yaml:"log"`
 }
 
 var (
@@ -2,65 +2,27 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 
-	"github.com/Astemirdum/library-service/backend/rating/internal/model"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
 	"github.com/IBM/sarama"
 	"go.uber.org/zap"
 )
 
-type rating func(ctx context.Context, name string, stars int) error
+type rating func(ctx context.Context, eventID, name string, stars int) (int, error)
 
-type Consumer struct {
-	ratingHandler rating
-	log           *zap.Logger
-	ready         chan bool
+func NewConsumer(rating rating, producer sarama.SyncProducer, cfg kafka.Config, log *zap.Logger) *kafka.TypedConsumer[kafka.Event[kafka.RatingChange]] {
+	return kafka.NewTypedConsumer(kafka.Events[kafka.RatingChange](kafka.EventRatingChange),
+		func(ctx context.Context, e kafka.Event[kafka.RatingChange]) error {
+			_, err := rating(ctx, e.EventID, e.Data.Name, e.Data.Stars)
+			return err
+		}, producer, kafka.RatingConsumerGroup, cfg, log)
 }
 
-func NewConsumer(rating rating, log *zap.Logger) *Consumer {
-	return &Consumer{
-		ratingHandler: rating,
-		log:           log.Named("consumer"),
-		ready:         make(chan bool),
-	}
-}
-
-func (consumer *Consumer) Setup(sarama.ConsumerGroupSession) error {
-	// Mark the consumer as ready
-	close(consumer.ready)
-	return nil
-}
-
-// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited.
-func (consumer *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
-	return nil
-}
-
-func (consumer *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for {
-		select {
-		case message, ok := <-claim.Messages():
-			if !ok {
-				consumer.log.Warn("message channel was closed")
-				return nil
-			}
-			var req model.RatingMsg
-			if err := json.Unmarshal(message.Value, &req); err != nil {
-				consumer.log.Error("", zap.Error(err))
-				session.MarkMessage(message, "")
-				continue
-			}
-
-			if err := consumer.ratingHandler(context.Background(), req.Name, req.Stars); err != nil {
-				consumer.log.Error("consumer.ratingHandler", zap.Error(err))
-				//session.MarkMessage(message, "")
-				continue
-			}
-
-			consumer.log.Debug("Message claimed:", zap.String("value", string(message.Value)), zap.Time("timestamp", message.Timestamp), zap.String("topic", message.Topic))
-			session.MarkMessage(message, "")
-		case <-session.Context().Done():
-			return nil
-		}
-	}
+// NewOverdueConsumer penalizes the user by penalty stars per overdue day of a reservation.
+func NewOverdueConsumer(rating rating, penalty int, producer sarama.SyncProducer, cfg kafka.Config, log *zap.Logger) *kafka.TypedConsumer[kafka.Event[kafka.Overdue]] {
+	return kafka.NewTypedConsumer(kafka.Events[kafka.Overdue](kafka.EventOverdue),
+		func(ctx context.Context, e kafka.Event[kafka.Overdue]) error {
+			_, err := rating(ctx, e.EventID, e.Data.UserName, -penalty*e.Data.Days)
+			return err
+		}, producer, kafka.OverdueConsumerGroup, cfg, log)
 }
@@ -35,7 +35,8 @@ func New(ratingSvc RatingService, log *zap.Logger) *Handler {
 	return h
 }
 
-func (h *Handler) NewRouter() *echo.Echo {
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
 	e := echo.New()
 	const (
 		baseRPS = 10
@@ -53,12 +54,14 @@ func (h *Handler) NewRouter() *echo.Echo {
 
 	base := e.Group("", md.NewRateLimiter(baseRPS))
 	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
 
 	e.Validator = validate.NewCustomValidator()
 
 	api := e.Group("/api/v1",
 		middleware.RequestLoggerWithConfig(md.RequestLoggerConfig()),
 		middleware.RequestID(),
+		md.TraceContext,
 		md.NewRateLimiter(apiRPS),
 	)
 	api.POST("/rating", h.CreateRating)
@@ -85,14 +88,15 @@ func (h *Handler) Rating(c echo.Context) error {
 	if err := c.Bind(&ratingReq); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	if err := h.ratingSvc.Rating(ctx, userName, ratingReq.Stars); err != nil {
+	delta, err := h.ratingSvc.Rating(ctx, ratingReq.EventID, userName, ratingReq.Stars)
+	if err != nil {
 		if errors.Is(err, errs.ErrNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, model.RatingChange{Delta: delta})
 }
 
 func (h *Handler) CreateRating(c echo.Context) error {
@@ -12,7 +12,7 @@ import (
 
 type RatingService interface {
 	GetRating(ctx context.Context, name string) (ratingModel.Rating, error)
-	Rating(ctx context.Context, name string, stars int) error
+	Rating(ctx context.Context, eventID, name string, stars int) (int, error)
 	CreateRating(ctx context.Context, name string, stars int) error
 }
 
@@ -1,12 +1,13 @@
 package model
 
 type Rating struct {
-	Stars int `json:"stars" db:"stars"`
+	EventID string `json:"eventID,omitempty" db:"-"`
+	Stars   int    `json:"stars" db:"stars"`
 }
 
-type RatingMsg struct {
-	Name  string `json:"name"`
-	Stars int    `json:"stars"`
+// RatingChange is the change of the stars that took effect, the stars are kept within 0 to 100.
+type RatingChange struct {
+	Delta int `json:"delta"`
 }
 
 type CreateRating struct {
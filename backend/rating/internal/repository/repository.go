@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"github.com/Astemirdum/library-service/backend/rating/internal/errs"
 	"github.com/pkg/errors"
 
@@ -18,7 +22,7 @@ import (
 
 type Repository interface {
 	GetRating(ctx context.Context, name string) (model.Rating, error)
-	Rating(ctx context.Context, name string, stars int) error
+	Rating(ctx context.Context, eventID, name string, stars int) (int, error)
 	CreateRating(ctx context.Context, name string, stars int) error
 }
 
@@ -34,33 +38,82 @@ func NewRepository(db *pgxpool.Pool, log *zap.Logger) (*repository, error) {
 	}, nil
 }
 
+// producerName is the producer of the events of the service.
+const producerName = "rating"
+
 const (
-	ratingTableName = `rating`
+	ratingTableName        = `rating`
+	ratingChangesTableName = `rating_changes`
 )
 
 var qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-func (r *repository) Rating(ctx context.Context, name string, stars int) error {
-	q := `
-update rating 
-set stars = stars + @stars
-where username=@username`
-	args := pgx.NamedArgs{
-		"username": name,
-		"stars":    stars,
-	}
-	_, err := r.db.Exec(ctx, q, args)
-	return err
+// Rating changes the stars of the user by stars, within the 0 to 100 the stars are kept in. It returns
+// the change that took effect, the same one for an event processed before.
+func (r *repository) Rating(ctx context.Context, eventID, name string, stars int) (int, error) {
+	q := fmt.Sprintf(`
+with old as (select id, stars from %[1]s where username = @username for update)
+update %[1]s r
+set stars = greatest(0, least(100, old.stars + @stars))
+from old
+where r.id = old.id
+returning r.stars, r.stars - old.stars`, ratingTableName)
+	return r.changeRating(ctx, q, eventID, name, stars)
 }
 
 func (r *repository) CreateRating(ctx context.Context, name string, stars int) error {
-	q := `insert into rating (username, stars) values (@username, @stars) on conflict do nothing`
+	q := `insert into rating (username, stars) values (@username, @stars) on conflict do nothing returning stars, stars`
+	_, err := r.changeRating(ctx, q, "", name, stars)
+	return err
+}
+
+// changeRating runs q, which returns the new stars and the change that took effect, and emits
+// RatingChanged with the change if a row was changed. The change of the event is kept, so a
+// duplicate of it returns the change again.
+func (r *repository) changeRating(ctx context.Context, q, eventID, name string, stars int) (int, error) {
 	args := pgx.NamedArgs{
 		"username": name,
 		"stars":    stars,
 	}
-	_, err := r.db.Exec(ctx, q, args)
-	return err
+	var delta int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		ok, err := postgres.MarkProcessed(ctx, tx, eventID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			err := tx.QueryRow(ctx, fmt.Sprintf(`select delta from %s where event_id = $1`, ratingChangesTableName),
+				eventID).Scan(&delta)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		var newStars int
+		if err := tx.QueryRow(ctx, q, args).Scan(&newStars, &delta); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if eventID != "" {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`insert into %s (event_id, username, delta) values ($1, $2, $3)`,
+				ratingChangesTableName), eventID, name, delta); err != nil {
+				return err
+			}
+		}
+		env, err := kafka.NewEnvelope(ctx, kafka.EventRatingChanged, producerName, "", kafka.RatingChanged{
+			Timestamp: time.Now(),
+			UserName:  name,
+			Delta:     delta,
+			Stars:     newStars,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.PutEvent(ctx, tx, kafka.RatingEventsTopic, name, env)
+	})
+	return delta, err
 }
 
 func (r *repository) GetRating(ctx context.Context, name string) (model.Rating, error) {
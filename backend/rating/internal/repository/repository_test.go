@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/Astemirdum/library-service/backend/rating/migrations"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_Rating(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// the seeded user has 75 stars.
+	tests := []struct {
+		name    string
+		stars   int
+		want    int
+		applied int
+	}{
+		{name: "up to the top", stars: 50, want: 100, applied: 25},
+		{name: "down", stars: -10, want: 90, applied: -10},
+		{name: "down to zero", stars: -200, want: 0, applied: -90},
+	}
+	for _, tt := range tests {
+		eventID := uuid.NewString()
+		applied, err := r.Rating(ctx, eventID, "Test Max", tt.stars)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.applied, applied, tt.name)
+		rating, err := r.GetRating(ctx, "Test Max")
+		require.NoError(t, err)
+		require.Equal(t, tt.want, rating.Stars, tt.name)
+
+		applied, err = r.Rating(ctx, eventID, "Test Max", tt.stars)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.applied, applied, "a duplicate returns the change of the event")
+	}
+}
@@ -24,8 +24,9 @@ func (s *Service) GetRating(ctx context.Context, name string) (ratingModel.Ratin
 	return s.repo.GetRating(ctx, name)
 }
 
-func (s *Service) Rating(ctx context.Context, name string, stars int) error {
-	return s.repo.Rating(ctx, name, stars)
+// Rating changes the stars of the user by stars and returns the change that took effect.
+func (s *Service) Rating(ctx context.Context, eventID, name string, stars int) (int, error) {
+	return s.repo.Rating(ctx, eventID, name, stars)
 }
 
 func (s *Service) CreateRating(ctx context.Context, name string, stars int) error {
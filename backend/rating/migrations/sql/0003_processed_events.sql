@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS processed_events
+(
+    event_id     VARCHAR(64) PRIMARY KEY,
+    processed_at TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS processed_events;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- the change of the stars an event took effect with, the stars are kept within 0 to 100.
+CREATE TABLE IF NOT EXISTS rating_changes
+(
+    event_id   VARCHAR(64) PRIMARY KEY,
+    username   VARCHAR(80) NOT NULL,
+    delta      INT         NOT NULL,
+    created_at TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS rating_changes;
@@ -9,10 +9,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/lifecycle"
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"github.com/Astemirdum/library-service/backend/reservation/config"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/handler"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/repository"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/server"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/service"
@@ -20,20 +24,47 @@ import (
 	"go.uber.org/zap"
 )
 
+// overdueLockID elects the instance that runs the overdue scanner.
+const overdueLockID = 0x6f766572647565
+
 func Run(cfg *config.Config) error {
 	log := logger.NewLogger(cfg.Log, "reservation")
 	db, err := postgres.NewPostgresDB(context.Background(), &cfg.Database, migrations.MigrationFiles)
 	if err != nil {
 		return fmt.Errorf("db init %v", err)
 	}
-	repo, err := repository.NewRepository(db, log)
+	repo, err := repository.NewRepository(db, cfg.Holds.PickupWindow, log)
 	if err != nil {
 		return fmt.Errorf("repo users %v", err)
 	}
-	svc := service.NewService(repo, log)
+	svc := service.NewService(repo, model.RenewalPolicy{
+		Period:      cfg.Renewal.Period,
+		MaxRenewals: cfg.Renewal.MaxRenewals,
+		MaxDuration: cfg.Renewal.MaxDuration,
+		MinStars:    cfg.Renewal.MinStars,
+	}, log)
 	h := handler.New(svc, log)
 
-	srv := server.NewServer(cfg.Server, h.NewRouter())
+	if _, err := kafka.EnsureTopics(cfg.Kafka, log, kafka.ReservationEventsTopic, kafka.OverdueTopic, kafka.StatsTopic); err != nil {
+		log.Error("ensure topics", zap.Error(err))
+	}
+	producer, err := kafka.NewSyncProducer(cfg.Kafka)
+	if err != nil {
+		return fmt.Errorf("kafka.NewSyncProducer %v", err)
+	}
+	defer producer.Close()
+	workers := lifecycle.New(log)
+	workers.Go("outbox relay", lifecycle.Loop(outbox.NewRelay(db, producer, cfg.Outbox, log).Run))
+	workers.Go("holds expiry", lifecycle.Loop(func(ctx context.Context) {
+		svc.ExpireHolds(ctx, cfg.Holds.ExpiryInterval, cfg.Holds.ExpiryBatch)
+	}))
+	workers.Go("overdue scanner", lifecycle.Loop(func(ctx context.Context) {
+		postgres.RunAsLeader(ctx, db, overdueLockID, cfg.Overdue.Interval, log.Named("overdue"), func(ctx context.Context) {
+			svc.ScanOverdue(ctx, cfg.Overdue.Interval, cfg.Overdue.Batch)
+		})
+	}))
+
+	srv := server.NewServer(cfg.Server, h.NewRouter(workers.Check))
 	log.Info("http server start ON: ",
 		zap.String("addr",
 			net.JoinHostPort(cfg.Server.Host, cfg.Server.Port)))
@@ -45,9 +76,13 @@ func Run(cfg *config.Config) error {
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	termSig := <-sig
-
-	log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	var fatal error
+	select {
+	case termSig := <-sig:
+		log.Debug("Graceful shutdown", zap.Any("signal", termSig))
+	case fatal = <-workers.Fatal():
+		log.Error("Graceful shutdown", zap.Error(fatal))
+	}
 
 	closeCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -55,7 +90,10 @@ func Run(cfg *config.Config) error {
 	if err = srv.Stop(closeCtx); err != nil {
 		log.Error("srv.Stop", zap.Error(err))
 	}
+	if err := workers.Stop(closeCtx); err != nil {
+		log.Error("workers.Stop", zap.Error(err))
+	}
 	db.Close()
 	log.Info("Graceful shutdown finished")
-	return nil
+	return fatal
 }
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 
 	"github.com/Astemirdum/library-service/backend/pkg/logger"
@@ -19,10 +21,38 @@ type HTTPServer struct {
 	WriteTimeout time.Duration
 }
 
+// Holds configures the holds queue.
+type Holds struct {
+	// PickupWindow is how long a returned copy is kept for the next hold in the queue.
+	PickupWindow   time.Duration `yaml:"pickupWindow" envconfig:"HOLDS_PICKUP_WINDOW" default:"48h"`
+	ExpiryInterval time.Duration `yaml:"expiryInterval" envconfig:"HOLDS_EXPIRY_INTERVAL" default:"1m"`
+	ExpiryBatch    int           `yaml:"expiryBatch" envconfig:"HOLDS_EXPIRY_BATCH" default:"100"`
+}
+
+// Overdue configures the overdue scanner. Interval is also how often the other instances try to take it over.
+type Overdue struct {
+	Interval time.Duration `yaml:"interval" envconfig:"OVERDUE_SCAN_INTERVAL" default:"1m"`
+	Batch    int           `yaml:"batch" envconfig:"OVERDUE_SCAN_BATCH" default:"100"`
+}
+
+// Renewal configures the renewal policy of reservations.
+type Renewal struct {
+	Period      time.Duration `yaml:"period" envconfig:"RENEWAL_PERIOD" default:"168h"`
+	MaxRenewals int           `yaml:"maxRenewals" envconfig:"RENEWAL_MAX_RENEWALS" default:"2"`
+	// MaxDuration caps the till date of a renewed reservation, counted from its start.
+	MaxDuration time.Duration `yaml:"maxDuration" envconfig:"RENEWAL_MAX_DURATION" default:"720h"`
+	MinStars    int           `yaml:"minStars" envconfig:"RENEWAL_MIN_STARS" default:"1"`
+}
+
 type Config struct {
-	Server   HTTPServer  `yaml:"server"`
-	Database postgres.DB `yaml:"db"`
-	Log      logger.Log  `yaml:"log"`
+	Server   HTTPServer    `yaml:"server"`
+	Kafka    kafka.Config  `yaml:"kafka"`
+	Database postgres.DB   `yaml:"db"`
+	Outbox   outbox.Config `yaml:"outbox"`
+	Holds    Holds         `yaml:"holds"`
+	Renewal  Renewal       `yaml:"renewal"`
+	Overdue  Overdue       `yaml:"overdue"`
+	Log      logger.Log    `yaml:"log"`
 }
 
 var (
@@ -8,6 +8,18 @@ var (
 	ErrNotFound = errors.New("not found")
 	ErrUserName = errors.New("username is required")
 	ErrNoStars  = errors.New("stars <= rented books")
+
+	ErrReturnDate = errors.New("return date is before the start or in the future")
+
+	ErrHoldExists   = errors.New("book is already on hold")
+	ErrHoldNotReady = errors.New("hold is not ready for pickup")
+	ErrOnHold       = errors.New("returned copies are kept for the holds queue")
+	ErrAvailable    = errors.New("the book is available, reserve it instead")
+
+	ErrRenewalLimit = errors.New("reservation can not be renewed any further")
+	ErrOverdue      = errors.New("reservation is overdue")
+	ErrHeldByOthers = errors.New("book is on hold for other readers")
+	ErrLowRating    = errors.New("rating is too low to renew")
 )
 
 type ValidationErrorResponse struct {
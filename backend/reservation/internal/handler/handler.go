@@ -7,6 +7,7 @@ import (
 	"github.com/Astemirdum/library-service/backend/pkg/auth"
 	md "github.com/Astemirdum/library-service/backend/pkg/middleware"
 
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/pkg/validate"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
@@ -33,7 +34,8 @@ func New(reservationSrv ReservationService, log *zap.Logger) *Handler {
 	return h
 }
 
-func (h *Handler) NewRouter() *echo.Echo {
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
 	e := echo.New()
 	const (
 		baseRPS = 10
@@ -51,19 +53,28 @@ func (h *Handler) NewRouter() *echo.Echo {
 
 	base := e.Group("", md.NewRateLimiter(baseRPS))
 	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
 
 	e.Validator = validate.NewCustomValidator()
 	api := e.Group("/api/v1",
 		middleware.RequestLoggerWithConfig(md.RequestLoggerConfig()),
 		middleware.RequestID(),
+		md.TraceContext,
 		md.NewRateLimiter(apiRPS),
 	)
 	api.POST("/reservations/rollback", h.RollbackReservation)
+	api.POST("/reservations/rollback-return", h.RollbackReturn)
 
 	api = api.Group("", md.AuthContext)
 	api.GET("/reservations", h.GetReservations)
 	api.POST("/reservations", h.CreateReservation)
 	api.POST("/reservations/:reservationUid/return", h.ReservationsReturn)
+	api.POST("/reservations/:reservationUid/renew", h.RenewReservation)
+
+	api.GET("/holds", h.GetHolds)
+	api.POST("/holds", h.CreateHold)
+	api.GET("/holds/:holdUid", h.GetHold)
+	api.DELETE("/holds/:holdUid", h.CancelHold)
 
 	return e
 }
@@ -93,6 +104,9 @@ func (h *Handler) CreateReservation(c echo.Context) error {
 		if errors.Is(err, errs.ErrNoStars) {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
+		if errors.Is(err, errs.ErrHoldNotReady) || errors.Is(err, errs.ErrOnHold) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -105,10 +119,22 @@ func (h *Handler) GetReservations(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
 	}
-	rsv, err := h.reservationSvc.GetReservations(ctx, userName)
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	rsv, page, err := h.reservationSvc.GetReservations(ctx, userName, req)
 	if err != nil {
+		if errors.Is(err, paging.ErrInvalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	// the list stays a bare array, its page is told by the headers.
+	page.SetHeaders(c.Response().Header())
 	return c.JSON(http.StatusOK, rsv)
 }
 
@@ -126,14 +152,62 @@ func (h *Handler) ReservationsReturn(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+	// the return date decides the status and the fine, so a borrower returns as of now. A librarian
+	// returns the reservation of any borrower, and may date the return and tell who took the copy back.
+	borrower := userName
+	if auth.IsLibrarian(ctx) {
+		borrower = ""
+		if req.ProcessedBy == "" {
+			req.ProcessedBy = userName
+		}
+		if req.Date.IsZero() {
+			req.Date = model.Date{Time: time.Now()}
+		}
+	} else {
+		req.ProcessedBy = userName
+		req.Date = model.Date{Time: time.Now()}
+	}
 	if err := c.Validate(req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	resp, err := h.reservationSvc.ReservationsReturn(ctx, userName, reservationUid)
+	resp, err := h.reservationSvc.ReservationsReturn(ctx, borrower, reservationUid, req)
 	if err != nil {
 		if errors.Is(err, errs.ErrNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, err.Error())
 		}
+		if errors.Is(err, errs.ErrReturnDate) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) RenewReservation(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.RenewReservationRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	req.UserName = userName
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.reservationSvc.RenewReservation(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, errs.ErrLowRating):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, errs.ErrRenewalLimit), errors.Is(err, errs.ErrOverdue), errors.Is(err, errs.ErrHeldByOthers):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, resp)
@@ -153,3 +227,87 @@ func (h *Handler) RollbackReservation(c echo.Context) error {
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+func (h *Handler) RollbackReturn(c echo.Context) error {
+	ctx := c.Request().Context()
+	type req struct {
+		Uuid string `json:"uuid"`
+	}
+	var r req
+	if err := c.Bind(&r); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := h.reservationSvc.RollbackReturn(ctx, r.Uuid); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) CreateHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CreateHoldRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	req.UserName = userName
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	hold, err := h.reservationSvc.CreateHold(ctx, req)
+	if err != nil {
+		if errors.Is(err, errs.ErrHoldExists) || errors.Is(err, errs.ErrAvailable) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusCreated, hold)
+}
+
+func (h *Handler) GetHolds(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	holds, err := h.reservationSvc.GetHolds(ctx, userName)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, holds)
+}
+
+func (h *Handler) GetHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	hold, err := h.reservationSvc.GetHold(ctx, userName, c.Param("holdUid"))
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, hold)
+}
+
+func (h *Handler) CancelHold(c echo.Context) error {
+	ctx := c.Request().Context()
+	userName, err := auth.GetUserName(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	if err := h.reservationSvc.CancelHold(ctx, userName, c.Param("holdUid")); err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusNoContent)
+}
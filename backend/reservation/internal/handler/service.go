@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/service"
 )
@@ -11,9 +12,16 @@ import (
 
 type ReservationService interface {
 	CreateReservation(ctx context.Context, req model.CreateReservationRequest) (model.Reservation, error)
-	GetReservations(ctx context.Context, username string) ([]model.Reservation, error)
-	ReservationsReturn(ctx context.Context, username, reservationUid string) (model.ReservationReturnResponse, error)
+	GetReservations(ctx context.Context, username string, req paging.Request) ([]model.Reservation, paging.Page, error)
+	ReservationsReturn(ctx context.Context, username, reservationUid string, req model.ReservationReturnRequest) (model.ReservationReturnResponse, error)
+	RenewReservation(ctx context.Context, req model.RenewReservationRequest) (model.RenewReservationResponse, error)
 	RollbackReservation(ctx context.Context, uid string) error
+	RollbackReturn(ctx context.Context, uid string) error
+
+	CreateHold(ctx context.Context, req model.CreateHoldRequest) (model.Hold, error)
+	GetHolds(ctx context.Context, username string) ([]model.Hold, error)
+	GetHold(ctx context.Context, username, holdUID string) (model.Hold, error)
+	CancelHold(ctx context.Context, username, holdUID string) error
 }
 
 var _ ReservationService = (*service.Service)(nil)
@@ -2,14 +2,24 @@ package model
 
 import (
 	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
 )
 
 type CreateReservationRequest struct {
+	ReservationUid string `json:"reservationUid" validate:"omitempty,uuid"`
+	// HoldUid is the READY hold the reservation fulfills.
+	HoldUid    string `json:"holdUid" validate:"omitempty,uuid"`
 	BookUid    string `json:"bookUid" validate:"required"`
 	LibraryUid string `json:"libraryUid" validate:"required"`
 	TillDate   Date   `json:"tillDate" validate:"required"`
-	UserName   string `validate:"required"`
-	Stars      int    `json:"rating"`
+	// CopyUid is the copy lent on the reservation, Condition is its condition at checkout.
+	CopyUid   string `json:"copyUid" validate:"omitempty,uuid"`
+	Condition string `json:"condition" validate:"omitempty,oneof=EXCELLENT GOOD BAD"`
+	UserName  string `validate:"required"`
+	Stars     int    `json:"rating"`
+	// OnShelf is the number of copies the library had on the shelf, the copies kept for READY holds among them.
+	OnShelf int `json:"onShelf" validate:"gte=0"`
 }
 
 type Date struct {
@@ -20,6 +30,7 @@ type Status string
 
 const (
 	StatusRented   Status = "RENTED"
+	StatusOverdue  Status = "OVERDUE"
 	StatusReturned Status = "RETURNED"
 	StatusExpired  Status = "EXPIRED"
 )
@@ -33,14 +44,92 @@ type Reservation struct {
 	Status         Status    `json:"status" db:"status"`
 	StartDate      time.Time `json:"startDate" db:"start_date"`
 	TillDate       time.Time `json:"tillDate" db:"till_date"`
+	CopyUID        *string   `json:"copyUid,omitempty" db:"copy_uid"`
+	// PenalizedOn is the last day the overdue days were passed to rating.
+	PenalizedOn       *time.Time `json:"-" db:"penalized_on"`
+	CheckoutCondition *string    `json:"checkoutCondition,omitempty" db:"checkout_condition"`
+	ReturnCondition   *string    `json:"returnCondition,omitempty" db:"return_condition"`
+	ReturnDate        *time.Time `json:"returnDate,omitempty" db:"return_date"`
+	// ProcessedBy is the user who processed the return.
+	ProcessedBy *string `json:"processedBy,omitempty" db:"processed_by"`
+}
+
+// RenewalPolicy limits the renewals of a reservation.
+type RenewalPolicy struct {
+	// Period is how long a renewal extends the reservation.
+	Period      time.Duration
+	MaxRenewals int
+	// MaxDuration caps the till date of a renewed reservation, counted from its start.
+	MaxDuration time.Duration
+	MinStars    int
+}
+
+type RenewReservationRequest struct {
+	ReservationUid string `param:"reservationUid" validate:"required,uuid"`
+	UserName       string `validate:"required"`
+	Stars          int    `json:"rating"`
+	// Schedule of the library, a renewed due date falling on a closed day moves to the next open day.
+	Schedule calendar.Schedule `json:"schedule"`
+}
+
+// RenewReservationResponse is the renewed reservation with the number of its renewals so far.
+type RenewReservationResponse struct {
+	Reservation
+	Renewals int `json:"renewals"`
 }
 
 type ReservationReturnRequest struct {
 	Condition string `json:"condition" validate:"required,oneof=EXCELLENT GOOD BAD"`
-	Date      Date   `json:"date" validate:"required"`
+	// Date is the day the copy was handed back. Only a librarian processing the return later at the
+	// desk back-dates it, a return made by the borrower is dated the day it is made.
+	Date Date `json:"date"`
+	// ProcessedBy is who took the copy back, only a librarian tells somebody else than the caller.
+	ProcessedBy string `json:"processedBy" validate:"required"`
 }
 
+// ReservationReturnResponse is what the return of a reservation decided, from the stored return details.
 type ReservationReturnResponse struct {
-	BookUid    string `json:"bookUid" db:"book_uid"`
-	LibraryUid string `json:"libraryUid" db:"library_uid"`
+	Username          string    `json:"username" db:"username"`
+	BookUid           string    `json:"bookUid" db:"book_uid"`
+	LibraryUid        string    `json:"libraryUid" db:"library_uid"`
+	Status            Status    `json:"status" db:"status"`
+	TillDate          time.Time `json:"tillDate" db:"till_date"`
+	ReturnDate        time.Time `json:"returnDate" db:"return_date"`
+	CheckoutCondition string    `json:"checkoutCondition,omitempty" db:"checkout_condition"`
+	ReturnCondition   string    `json:"returnCondition" db:"return_condition"`
+}
+
+type HoldStatus string
+
+const (
+	HoldWaiting   HoldStatus = "WAITING"
+	HoldReady     HoldStatus = "READY"
+	HoldFulfilled HoldStatus = "FULFILLED"
+	HoldExpired   HoldStatus = "EXPIRED"
+	HoldCancelled HoldStatus = "CANCELLED"
+)
+
+type CreateHoldRequest struct {
+	BookUid    string `json:"bookUid" validate:"required,uuid"`
+	LibraryUid string `json:"libraryUid" validate:"required,uuid"`
+	UserName   string `json:"-" validate:"required"`
+	// OnShelf is the number of copies the library has on the shelf, the copies kept for READY holds among them.
+	OnShelf int `json:"onShelf" validate:"gte=0"`
+}
+
+// Hold is a place in the queue for a book of a library. A READY hold may be turned into
+// a reservation until ExpiresAt, after that the next hold in the queue gets the copy.
+type Hold struct {
+	ID             int        `json:"-" db:"id"`
+	HoldUID        string     `json:"holdUid" db:"hold_uid"`
+	Username       string     `json:"username" db:"username"`
+	BookUID        string     `json:"bookUid" db:"book_uid"`
+	LibraryUID     string     `json:"libraryUid" db:"library_uid"`
+	Status         HoldStatus `json:"status" db:"status"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+	ReadyAt        *time.Time `json:"readyAt,omitempty" db:"ready_at"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	ReservationUID *string    `json:"reservationUid,omitempty" db:"reservation_uid"`
+	// Position is the place in the queue starting at 1, 0 once the hold is closed.
+	Position int `json:"position" db:"position"`
 }
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const holdsTableName = `holds`
+
+// holdColumns selects a hold with its position in the queue of the book.
+const holdColumns = `h.id, h.hold_uid, h.username, h.book_uid, h.library_uid, h.status,
+	h.created_at, h.ready_at, h.expires_at, h.reservation_uid,
+	case when h.status in ('WAITING', 'READY') then (
+		select count(*) from holds q
+		where q.library_uid = h.library_uid and q.book_uid = h.book_uid
+			and q.status in ('WAITING', 'READY') and (q.created_at, q.id) <= (h.created_at, h.id)
+	) else 0 end as position`
+
+// CreateHold queues the user for the book. A book with more copies on the shelf than are kept for
+// READY holds is available, it is reserved instead.
+func (r *repository) CreateHold(ctx context.Context, req model.CreateHoldRequest) (model.Hold, error) {
+	q := fmt.Sprintf(`insert into %s (hold_uid, username, book_uid, library_uid, status)
+	values (@hold_uid, @username, @book_uid, @library_uid, @status)
+	returning *, 0 as position`, holdsTableName)
+	args := pgx.NamedArgs{
+		"hold_uid":    uuid.New(),
+		"username":    req.UserName,
+		"book_uid":    req.BookUid,
+		"library_uid": req.LibraryUid,
+		"status":      model.HoldWaiting,
+	}
+	var hold model.Hold
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		ready, err := r.readyHolds(ctx, tx, "", req.LibraryUid, req.BookUid)
+		if err != nil {
+			return err
+		}
+		if req.OnShelf > ready {
+			return errs.ErrAvailable
+		}
+		rows, err := tx.Query(ctx, q, args)
+		if err != nil {
+			return err
+		}
+		hold, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Hold])
+		if err != nil {
+			return err
+		}
+		return putHoldEvent(ctx, tx, hold)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return model.Hold{}, errs.ErrHoldExists
+		}
+		return model.Hold{}, err
+	}
+	return r.GetHold(ctx, req.UserName, hold.HoldUID)
+}
+
+func (r *repository) GetHolds(ctx context.Context, username string) ([]model.Hold, error) {
+	q := fmt.Sprintf(`select %s from %s h where h.username = $1 order by h.created_at, h.id`, holdColumns, holdsTableName)
+	rows, err := r.db.Query(ctx, q, username)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Hold])
+}
+
+func (r *repository) GetHold(ctx context.Context, username, holdUID string) (model.Hold, error) {
+	q := fmt.Sprintf(`select %s from %s h where h.hold_uid = $1 and h.username = $2`, holdColumns, holdsTableName)
+	rows, err := r.db.Query(ctx, q, holdUID, username)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	hold, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Hold])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Hold{}, errs.ErrNotFound
+		}
+		return model.Hold{}, err
+	}
+	return hold, nil
+}
+
+// CancelHold leaves the queue. A READY hold passes its copy to the next one.
+func (r *repository) CancelHold(ctx context.Context, username, holdUID string) error {
+	q := fmt.Sprintf(`update %s set status = @status
+	where hold_uid = @hold_uid and username = @username and status in ('WAITING', 'READY')
+	returning *, 0 as position`, holdsTableName)
+	args := pgx.NamedArgs{
+		"hold_uid": holdUID,
+		"username": username,
+		"status":   model.HoldCancelled,
+	}
+	var wasReady bool
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// the old status is gone after the update, read it under the row lock first.
+		err := tx.QueryRow(ctx, fmt.Sprintf(`select status = 'READY' from %s
+		where hold_uid = $1 and username = $2 and status in ('WAITING', 'READY') for update`, holdsTableName),
+			holdUID, username).Scan(&wasReady)
+		if err != nil {
+			return err
+		}
+		hold, err := r.changeHold(ctx, tx, q, args)
+		if err != nil {
+			return err
+		}
+		if wasReady {
+			return r.promoteHold(ctx, tx, hold.LibraryUID, hold.BookUID)
+		}
+		return nil
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errs.ErrNotFound
+	}
+	return err
+}
+
+// ExpireHolds closes up to limit READY holds whose pickup window is over and passes their copies on.
+// Holds locked by another instance are skipped, so several instances may run it at once.
+func (r *repository) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	q := fmt.Sprintf(`update %[1]s set status = @status
+	where id in (
+		select id from %[1]s where status = 'READY' and expires_at < now()
+		order by expires_at limit @limit for update skip locked
+	)
+	returning *, 0 as position`, holdsTableName)
+	var expired int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, pgx.NamedArgs{"status": model.HoldExpired, "limit": limit})
+		if err != nil {
+			return err
+		}
+		holds, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Hold])
+		if err != nil {
+			return err
+		}
+		for _, hold := range holds {
+			if err := putHoldEvent(ctx, tx, hold); err != nil {
+				return err
+			}
+			if err := r.promoteHold(ctx, tx, hold.LibraryUID, hold.BookUID); err != nil {
+				return err
+			}
+		}
+		expired = len(holds)
+		return nil
+	})
+	return expired, err
+}
+
+// promoteHold offers a copy of the book to the first WAITING hold in the queue, if any.
+func (r *repository) promoteHold(ctx context.Context, tx pgx.Tx, libraryUID, bookUID string) error {
+	q := fmt.Sprintf(`update %[1]s set status = @status, ready_at = now(), expires_at = now() + make_interval(secs => @window)
+	where id = (
+		select id from %[1]s
+		where library_uid = @library_uid and book_uid = @book_uid and status = 'WAITING'
+		order by created_at, id limit 1 for update skip locked
+	)
+	returning *, 0 as position`, holdsTableName)
+	hold, err := r.changeHold(ctx, tx, q, pgx.NamedArgs{
+		"status":      model.HoldReady,
+		"window":      r.pickupWindow.Seconds(),
+		"library_uid": libraryUID,
+		"book_uid":    bookUID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.log.Info("hold is ready for pickup", zap.String("hold_uid", hold.HoldUID), zap.String("username", hold.Username))
+	return nil
+}
+
+// demoteHold takes the copy back from the hold promoted last, when the return that freed it is rolled back.
+func (r *repository) demoteHold(ctx context.Context, tx pgx.Tx, libraryUID, bookUID string) error {
+	q := fmt.Sprintf(`update %[1]s set status = @status, ready_at = null, expires_at = null
+	where id = (
+		select id from %[1]s
+		where library_uid = @library_uid and book_uid = @book_uid and status = 'READY'
+		order by ready_at desc limit 1 for update
+	)
+	returning *, 0 as position`, holdsTableName)
+	_, err := r.changeHold(ctx, tx, q, pgx.NamedArgs{
+		"status":      model.HoldWaiting,
+		"library_uid": libraryUID,
+		"book_uid":    bookUID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// fulfillHold marks the READY hold of the user as fulfilled by the reservation.
+// Fulfilling it again with the same reservation is a no-op, so a retried reservation succeeds.
+func (r *repository) fulfillHold(ctx context.Context, tx pgx.Tx, username, holdUID string, reservationUID uuid.UUID) (model.Hold, error) {
+	q := fmt.Sprintf(`update %s set status = @status, reservation_uid = @reservation_uid
+	where hold_uid = @hold_uid and username = @username
+		and (status = 'READY' or (status = @status and reservation_uid = @reservation_uid))
+	returning *, 0 as position`, holdsTableName)
+	hold, err := r.changeHold(ctx, tx, q, pgx.NamedArgs{
+		"status":          model.HoldFulfilled,
+		"reservation_uid": reservationUID,
+		"hold_uid":        holdUID,
+		"username":        username,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Hold{}, errs.ErrHoldNotReady
+	}
+	return hold, err
+}
+
+// reopenHold makes the hold fulfilled by a reservation READY again when the reservation is rolled back.
+func (r *repository) reopenHold(ctx context.Context, tx pgx.Tx, reservationUID string) error {
+	q := fmt.Sprintf(`update %s set status = @status, reservation_uid = null
+	where reservation_uid = @reservation_uid and status = @fulfilled
+	returning *, 0 as position`, holdsTableName)
+	_, err := r.changeHold(ctx, tx, q, pgx.NamedArgs{
+		"status":          model.HoldReady,
+		"fulfilled":       model.HoldFulfilled,
+		"reservation_uid": reservationUID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// readyHolds counts the READY holds of the book of other users than username, each of them keeps
+// a copy on the shelf. An empty username counts the holds of all users.
+func (r *repository) readyHolds(ctx context.Context, tx pgx.Tx, username, libraryUID, bookUID string) (int, error) {
+	q := fmt.Sprintf(`select count(*) from %s
+	where library_uid = $1 and book_uid = $2 and status = 'READY' and username <> $3`, holdsTableName)
+	var ready int
+	err := tx.QueryRow(ctx, q, libraryUID, bookUID, username).Scan(&ready)
+	return ready, err
+}
+
+// changeHold runs q, which returns exactly one changed hold, and emits its event.
+func (r *repository) changeHold(ctx context.Context, tx pgx.Tx, q string, args pgx.NamedArgs) (model.Hold, error) {
+	rows, err := tx.Query(ctx, q, args)
+	if err != nil {
+		return model.Hold{}, err
+	}
+	hold, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Hold])
+	if err != nil {
+		return model.Hold{}, err
+	}
+	return hold, putHoldEvent(ctx, tx, hold)
+}
+
+func putHoldEvent(ctx context.Context, tx pgx.Tx, hold model.Hold) error {
+	env, err := kafka.NewEnvelope(ctx, kafka.EventHold, producerName, "", kafka.HoldEvent{
+		Timestamp:  time.Now(),
+		HoldUid:    hold.HoldUID,
+		UserName:   hold.Username,
+		BookUid:    hold.BookUID,
+		LibraryUid: hold.LibraryUID,
+		Status:     string(hold.Status),
+		ExpiresAt:  hold.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.PutEvent(ctx, tx, kafka.ReservationEventsTopic, hold.HoldUID, env)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
+	"github.com/Astemirdum/library-service/backend/reservation/migrations"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_ReadyHolds(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	libraryUid, bookUid := uuid.NewString(), uuid.NewString()
+	// a copy on the shelf is kept for the READY hold of "other".
+	_, err = db.Exec(ctx, `insert into holds (hold_uid, username, book_uid, library_uid, status, ready_at, expires_at)
+	values ($1, 'other', $2, $3, 'READY', now(), now() + interval '1 day')`, uuid.New(), bookUid, libraryUid)
+	require.NoError(t, err)
+
+	t.Run("hold", func(t *testing.T) {
+		_, err := r.CreateHold(ctx, model.CreateHoldRequest{BookUid: bookUid, LibraryUid: libraryUid, UserName: "available", OnShelf: 2})
+		require.ErrorIs(t, err, errs.ErrAvailable, "a copy is left beyond the one kept")
+
+		hold, err := r.CreateHold(ctx, model.CreateHoldRequest{BookUid: bookUid, LibraryUid: libraryUid, UserName: "held", OnShelf: 1})
+		require.NoError(t, err, "the only copy is kept")
+		require.Equal(t, model.HoldWaiting, hold.Status)
+	})
+	t.Run("reserve", func(t *testing.T) {
+		reserve := func(username string, onShelf int) error {
+			_, err := r.CreateReservation(ctx, model.CreateReservationRequest{
+				BookUid:    bookUid,
+				LibraryUid: libraryUid,
+				TillDate:   model.Date{Time: time.Now().AddDate(0, 0, 14)},
+				UserName:   username,
+				OnShelf:    onShelf,
+			})
+			return err
+		}
+		require.ErrorIs(t, reserve("user", 1), errs.ErrOnHold, "the only copy is kept for another user")
+		require.NoError(t, reserve("user", 2), "a copy is left beyond the one kept")
+		require.NoError(t, reserve("other", 1), "the copy is kept for the user")
+	})
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// MarkOverdue moves up to limit RENTED reservations past their till date to OVERDUE and reports them to stats.
+func (r *repository) MarkOverdue(ctx context.Context, limit int) (int, error) {
+	q := fmt.Sprintf(`update %[1]s set status = @status
+	where id in (
+		select id from %[1]s where status = 'RENTED' and till_date < date(now())
+		order by till_date limit @limit for update skip locked
+	)
+	returning *`, reservationTableName)
+	var marked int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, pgx.NamedArgs{"status": model.StatusOverdue, "limit": limit})
+		if err != nil {
+			return err
+		}
+		overdue, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Reservation])
+		if err != nil {
+			return err
+		}
+		for _, rsv := range overdue {
+			if err := putEvent(ctx, tx, kafka.ReservationOverdue, rsv); err != nil {
+				return err
+			}
+			env, err := kafka.NewEnvelope(ctx, kafka.EventReservationStats, producerName, "", kafka.EventStats{
+				Timestamp:     time.Now(),
+				UserName:      rsv.Username,
+				ReservationID: rsv.ReservationUID,
+				BookID:        rsv.BookUID,
+				LibraryID:     rsv.LibraryUID,
+				EventType:     string(model.StatusOverdue),
+				Simplex:       kafka.SimplexUnknown,
+			})
+			if err != nil {
+				return err
+			}
+			if err := outbox.PutEvent(ctx, tx, kafka.StatsTopic, rsv.ReservationUID, env); err != nil {
+				return err
+			}
+		}
+		marked = len(overdue)
+		return nil
+	})
+	return marked, err
+}
+
+// PenalizeOverdue passes the overdue days of up to limit OVERDUE reservations, that were not passed yet, to rating.
+// The event of a reservation and a day has a fixed id, so rating penalizes it once even if it is sent again.
+func (r *repository) PenalizeOverdue(ctx context.Context, limit int) (int, error) {
+	q := fmt.Sprintf(`update %[1]s r set penalized_on = date(now())
+	from (
+		select id, coalesce(penalized_on, date(till_date)) as since from %[1]s
+		where status = 'OVERDUE' and coalesce(penalized_on, date(till_date)) < date(now())
+		order by id limit @limit for update skip locked
+	) p
+	where r.id = p.id
+	returning r.*, date(now()) - p.since as days`, reservationTableName)
+	type penalty struct {
+		model.Reservation
+		Days int `db:"days"`
+	}
+	var penalized int
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, pgx.NamedArgs{"limit": limit})
+		if err != nil {
+			return err
+		}
+		penalties, err := pgx.CollectRows(rows, pgx.RowToStructByName[penalty])
+		if err != nil {
+			return err
+		}
+		for _, p := range penalties {
+			eventID := fmt.Sprintf("overdue:%s:%s", p.ReservationUID, p.PenalizedOn.Format(time.DateOnly))
+			env, err := kafka.NewEnvelope(ctx, kafka.EventOverdue, producerName, eventID, kafka.Overdue{
+				Timestamp:      time.Now(),
+				ReservationUid: p.ReservationUID,
+				UserName:       p.Username,
+				BookUid:        p.BookUID,
+				LibraryUid:     p.LibraryUID,
+				TillDate:       p.TillDate,
+				Days:           p.Days,
+			})
+			if err != nil {
+				return err
+			}
+			if err := outbox.PutEvent(ctx, tx, kafka.OverdueTopic, p.Username, env); err != nil {
+				return err
+			}
+		}
+		penalized = len(penalties)
+		return nil
+	})
+	return penalized, err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const historyTableName = `reservation_history`
+
+const actionRenewed = "RENEWED"
+
+// RenewReservation extends the rented reservation of the user by policy.Period and records the renewal in its history.
+// The till date never goes past policy.MaxDuration from the start of the reservation, but for the days
+// the library is closed on: a till date falling on one of them moves to the next open day.
+func (r *repository) RenewReservation(ctx context.Context, username, reservationUID string, policy model.RenewalPolicy, schedule calendar.Schedule) (model.RenewReservationResponse, error) {
+	var resp model.RenewReservationResponse
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`select * from %s
+		where reservation_uid = $1 and username = $2 and status in ('RENTED', 'OVERDUE') for update`, reservationTableName),
+			reservationUID, username)
+		if err != nil {
+			return err
+		}
+		rsv, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Reservation])
+		if err != nil {
+			return err
+		}
+
+		var (
+			overdue  bool
+			renewals int
+			held     bool
+		)
+		err = tx.QueryRow(ctx, fmt.Sprintf(`select date(now()) > $1::timestamp,
+			(select count(*) from %s where reservation_uid = $2 and action = $3),
+			exists(
+				select 1 from %s where library_uid = $4 and book_uid = $5
+					and status in ('WAITING', 'READY') and username <> $6
+			)`, historyTableName, holdsTableName),
+			rsv.TillDate, rsv.ReservationUID, actionRenewed, rsv.LibraryUID, rsv.BookUID, rsv.Username).
+			Scan(&overdue, &renewals, &held)
+		if err != nil {
+			return err
+		}
+		switch {
+		case overdue:
+			return errs.ErrOverdue
+		case held:
+			return errs.ErrHeldByOthers
+		case renewals >= policy.MaxRenewals:
+			return errs.ErrRenewalLimit
+		}
+		tillDate := rsv.TillDate.Add(policy.Period)
+		if maxDate := rsv.StartDate.Add(policy.MaxDuration).Truncate(24 * time.Hour); tillDate.After(maxDate) {
+			tillDate = maxDate
+		}
+		tillDate = schedule.NextOpenDay(tillDate)
+		if !tillDate.After(rsv.TillDate) {
+			return errs.ErrRenewalLimit
+		}
+
+		rows, err = tx.Query(ctx, fmt.Sprintf(`update %s set till_date = $1 where id = $2 returning *`, reservationTableName),
+			tillDate, rsv.ID)
+		if err != nil {
+			return err
+		}
+		renewed, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Reservation])
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`insert into %s (reservation_uid, action, old_till_date, new_till_date)
+		values ($1, $2, $3, $4)`, historyTableName), rsv.ReservationUID, actionRenewed, rsv.TillDate, renewed.TillDate); err != nil {
+			return err
+		}
+		resp = model.RenewReservationResponse{Reservation: renewed, Renewals: renewals + 1}
+		return putEvent(ctx, tx, kafka.ReservationRenewed, renewed)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.RenewReservationResponse{}, errs.ErrNotFound
+		}
+		return model.RenewReservationResponse{}, err
+	}
+	return resp, nil
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -12,8 +11,12 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/outbox"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
 
+	"github.com/Astemirdum/library-service/backend/pkg/calendar"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
 	"github.com/google/uuid"
 
@@ -25,71 +28,156 @@ type Repository interface {
 	CreateReservation(ctx context.Context, req model.CreateReservationRequest) (model.Reservation, error)
 	GetRented(ctx context.Context, username string) (int, error)
 	DeleteReservation(ctx context.Context, uid string) error
-	GetReservations(ctx context.Context, username string) ([]model.Reservation, error)
-	ReservationsReturn(ctx context.Context, username, reservationUID string) (model.ReservationReturnResponse, error)
+	RestoreReservation(ctx context.Context, uid string) error
+	GetReservations(ctx context.Context, username string, req paging.Request) ([]model.Reservation, paging.Page, error)
+	ReservationsReturn(ctx context.Context, username, reservationUID string, req model.ReservationReturnRequest) (model.ReservationReturnResponse, error)
+	RenewReservation(ctx context.Context, username, reservationUID string, policy model.RenewalPolicy, schedule calendar.Schedule) (model.RenewReservationResponse, error)
+
+	CreateHold(ctx context.Context, req model.CreateHoldRequest) (model.Hold, error)
+	GetHolds(ctx context.Context, username string) ([]model.Hold, error)
+	GetHold(ctx context.Context, username, holdUID string) (model.Hold, error)
+	CancelHold(ctx context.Context, username, holdUID string) error
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+
+	MarkOverdue(ctx context.Context, limit int) (int, error)
+	PenalizeOverdue(ctx context.Context, limit int) (int, error)
 }
 
 type repository struct {
 	db  *pgxpool.Pool
 	log *zap.Logger
+	// pickupWindow is how long a returned copy is kept for the next hold in the queue.
+	pickupWindow time.Duration
 }
 
-func NewRepository(db *pgxpool.Pool, log *zap.Logger) (*repository, error) {
+func NewRepository(db *pgxpool.Pool, pickupWindow time.Duration, log *zap.Logger) (*repository, error) {
 	return &repository{
-		db:  db,
-		log: log.Named("repo"),
+		db:           db,
+		log:          log.Named("repo"),
+		pickupWindow: pickupWindow,
 	}, nil
 }
 
+// producerName is the producer of the events of the service.
+const producerName = "reservation"
+
 const (
 	reservationTableName = `reservation`
 )
 
 var qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
 
-func (r *repository) ReservationsReturn(ctx context.Context, username, reservationUID string) (model.ReservationReturnResponse, error) {
+// ReservationsReturn stores the return details and decides the status by the return date, not the day it is processed.
+// An empty username returns the reservation of any borrower, as a librarian does.
+func (r *repository) ReservationsReturn(ctx context.Context, username, reservationUID string, req model.ReservationReturnRequest) (model.ReservationReturnResponse, error) {
 	q := `
 	update reservation
-	set status = case when date(now()) > till_date then 'EXPIRED' else 'RETURNED' end
-	where reservation_uid = @reservation_uid and username = @username and status='RENTED'
-	returning library_uid, book_uid`
+	set status = case when @return_date::date > till_date then 'EXPIRED' else 'RETURNED' end,
+		return_date = @return_date, return_condition = @return_condition, processed_by = @processed_by
+	where reservation_uid = @reservation_uid and (@username = '' or username = @username) and status in ('RENTED', 'OVERDUE')
+	returning *`
 
 	args := pgx.NamedArgs{
-		"reservation_uid": reservationUID,
-		"username":        username,
+		"reservation_uid":  reservationUID,
+		"username":         username,
+		"return_date":      req.Date.Format(time.DateOnly),
+		"return_condition": req.Condition,
+		"processed_by":     req.ProcessedBy,
 	}
 	var resp model.ReservationReturnResponse
-	err := r.db.QueryRow(ctx, q, args).Scan(&resp.LibraryUid, &resp.BookUid)
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, args)
+		if err != nil {
+			return err
+		}
+		rsv, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Reservation])
+		if err != nil {
+			return err
+		}
+		resp = model.ReservationReturnResponse{
+			Username:        rsv.Username,
+			BookUid:         rsv.BookUID,
+			LibraryUid:      rsv.LibraryUID,
+			Status:          rsv.Status,
+			TillDate:        rsv.TillDate,
+			ReturnDate:      *rsv.ReturnDate,
+			ReturnCondition: *rsv.ReturnCondition,
+		}
+		if rsv.CheckoutCondition != nil {
+			resp.CheckoutCondition = *rsv.CheckoutCondition
+		}
+		if err := putEvent(ctx, tx, kafka.ReservationReturned, rsv); err != nil {
+			return err
+		}
+		return r.promoteHold(ctx, tx, rsv.LibraryUID, rsv.BookUID)
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
 			return model.ReservationReturnResponse{}, errs.ErrNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation:
+			return model.ReservationReturnResponse{}, errs.ErrReturnDate
 		}
 		return model.ReservationReturnResponse{}, err
 	}
 	return resp, nil
 }
 
-func (r *repository) GetReservations(ctx context.Context, username string) ([]model.Reservation, error) {
-	q, args, err := qb.Select("id", "reservation_uid", "username", "book_uid", "library_uid", "status", "start_date", "till_date").
+// reservationKeyset is the order of the lists of reservations.
+var reservationKeyset = paging.Keyset[model.Reservation]{
+	Sorts: map[string]paging.Column[model.Reservation]{
+		"start": {Expr: "start_date", Type: "timestamp", Value: func(r model.Reservation) any { return r.StartDate }},
+		"till":  {Expr: "till_date", Type: "timestamp", Value: func(r model.Reservation) any { return r.TillDate }},
+	},
+	Key: paging.Column[model.Reservation]{Expr: "id", Type: "int", Value: func(r model.Reservation) any { return r.ID }},
+}
+
+// GetReservations lists the reservations of the user. A request that asks for no page gets all of them.
+func (r *repository) GetReservations(ctx context.Context, username string, req paging.Request) ([]model.Reservation, paging.Page, error) {
+	pager, err := paging.NewPager(req, reservationKeyset)
+	if err != nil {
+		return nil, paging.Page{}, err
+	}
+	q := qb.Select("id", "reservation_uid", "username", "book_uid", "library_uid", "status", "start_date", "till_date", "penalized_on",
+		"checkout_condition", "return_condition", "return_date", "processed_by", "copy_uid").
 		From(reservationTableName).
-		Where(sq.Eq{"username": username}).
-		ToSql()
+		Where(sq.Eq{"username": username})
+	if req.Paged() {
+		q = pager.Apply(q)
+	} else {
+		q = q.OrderBy("id")
+	}
+	query, args, err := q.ToSql()
 	if err != nil {
-		return nil, err
+		return nil, paging.Page{}, err
 	}
-	rows, err := r.db.Query(ctx, q, args...)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, paging.Page{}, err
 	}
 	defer rows.Close()
 
-	return pgx.CollectRows(rows, pgx.RowToStructByName[model.Reservation])
+	rsv, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Reservation])
+	if err != nil || !req.Paged() {
+		return rsv, paging.Page{}, err
+	}
+	rsv, page := pager.Collect(rsv)
+
+	if req.WithTotal() {
+		var totalElements int
+		if err := r.db.QueryRow(ctx, `select count(*) from reservation where username = $1`, username).Scan(&totalElements); err != nil {
+			return nil, paging.Page{}, err
+		}
+		page.TotalElements = &totalElements
+	}
+	return rsv, page, nil
 }
 
 func (r *repository) GetRented(ctx context.Context, username string) (int, error) {
 	q := `
 	select count(*) from reservation
-	where username = $1 and status = 'RENTED'
+	where username = $1 and status in ('RENTED', 'OVERDUE')
 `
 	var count int
 	if err := r.db.QueryRow(ctx, q, username).Scan(&count); err != nil {
@@ -99,42 +187,125 @@ func (r *repository) GetRented(ctx context.Context, username string) (int, error
 }
 
 func (r *repository) DeleteReservation(ctx context.Context, uid string) error {
-	_, err := r.db.Exec(ctx, fmt.Sprintf("delete from %s where reservation_uid = $1", reservationTableName), uid)
-	return err
+	q := fmt.Sprintf("delete from %s where reservation_uid = $1 returning *", reservationTableName)
+	return r.changeReservation(ctx, kafka.ReservationCancelled, func(tx pgx.Tx, rsv model.Reservation) error {
+		return r.reopenHold(ctx, tx, rsv.ReservationUID)
+	}, q, uid)
+}
+
+func (r *repository) RestoreReservation(ctx context.Context, uid string) error {
+	q := fmt.Sprintf(`update %s set status = @status, return_date = null, return_condition = null, processed_by = null
+	where reservation_uid = @reservation_uid and status in ('RETURNED', 'EXPIRED')
+	returning *`, reservationTableName)
+	return r.changeReservation(ctx, kafka.ReservationReinstated, func(tx pgx.Tx, rsv model.Reservation) error {
+		return r.demoteHold(ctx, tx, rsv.LibraryUID, rsv.BookUID)
+	}, q, pgx.NamedArgs{
+		"reservation_uid": uid,
+		"status":          model.StatusRented,
+	})
+}
+
+// changeReservation runs q, which returns the changed rows, and emits an event for every one of them.
+// then is called for every changed reservation in the same transaction, e.g. to update its holds.
+func (r *repository) changeReservation(ctx context.Context, typ kafka.ReservationEventType, then func(tx pgx.Tx, rsv model.Reservation) error, q string, args ...any) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+		changed, err := pgx.CollectRows(rows, pgx.RowToStructByName[model.Reservation])
+		if err != nil {
+			return err
+		}
+		for _, rsv := range changed {
+			if err := putEvent(ctx, tx, typ, rsv); err != nil {
+				return err
+			}
+			if err := then(tx, rsv); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func putEvent(ctx context.Context, tx pgx.Tx, typ kafka.ReservationEventType, rsv model.Reservation) error {
+	env, err := kafka.NewEnvelope(ctx, kafka.EventReservation, producerName, "", kafka.ReservationEvent{
+		Timestamp:      time.Now(),
+		Type:           typ,
+		ReservationUid: rsv.ReservationUID,
+		UserName:       rsv.Username,
+		BookUid:        rsv.BookUID,
+		LibraryUid:     rsv.LibraryUID,
+		Status:         string(rsv.Status),
+		TillDate:       rsv.TillDate,
+	})
+	if err != nil {
+		return err
+	}
+	return outbox.PutEvent(ctx, tx, kafka.ReservationEventsTopic, rsv.ReservationUID, env)
 }
 
 func (r *repository) CreateReservation(ctx context.Context, req model.CreateReservationRequest) (model.Reservation, error) {
-	q := fmt.Sprintf(`insert into %s (reservation_uid, username, book_uid, library_uid, status, start_date, till_date) 
-	values (@reservation_uid, @username, @book_uid, @library_uid, @status, now(), @till_date) 
+	q := fmt.Sprintf(`insert into %s (reservation_uid, username, book_uid, library_uid, status, start_date, till_date, checkout_condition, copy_uid) 
+	values (@reservation_uid, @username, @book_uid, @library_uid, @status, now(), @till_date, nullif(@checkout_condition, ''), nullif(@copy_uid, '')::uuid) 
 	returning *`, reservationTableName)
 	batch := &pgx.Batch{}
 	id := uuid.New()
+	if req.ReservationUid != "" {
+		var err error
+		if id, err = uuid.Parse(req.ReservationUid); err != nil {
+			return model.Reservation{}, err
+		}
+	}
 	args := pgx.NamedArgs{
-		"reservation_uid": id,
-		"username":        req.UserName,
-		"book_uid":        req.BookUid,
-		"library_uid":     req.LibraryUid,
-		"status":          model.StatusRented,
-		"till_date":       req.TillDate.Format(time.DateOnly),
+		"reservation_uid":    id,
+		"username":           req.UserName,
+		"book_uid":           req.BookUid,
+		"library_uid":        req.LibraryUid,
+		"status":             model.StatusRented,
+		"till_date":          req.TillDate.Format(time.DateOnly),
+		"checkout_condition": req.Condition,
+		"copy_uid":           req.CopyUid,
 	}
 	batch.Queue(q, args)
 
-	result := r.db.SendBatch(ctx, batch)
-	defer result.Close()
-
-	rows, err := result.Query()
-	var pgErr *pgconn.PgError
-	if err != nil {
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			r.log.Warn("reservation_uid already exists", zap.Any("reservation_uid", id))
+	var res model.Reservation
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if req.HoldUid != "" {
+			if _, err := r.fulfillHold(ctx, tx, req.UserName, req.HoldUid, id); err != nil {
+				return err
+			}
 		} else {
-			return model.Reservation{}, fmt.Errorf("unable to insert row: %w", err)
+			// the copies on the shelf are kept for the READY holds of others first.
+			ready, err := r.readyHolds(ctx, tx, req.UserName, req.LibraryUid, req.BookUid)
+			if err != nil {
+				return err
+			}
+			if ready > 0 && req.OnShelf <= ready {
+				return errs.ErrOnHold
+			}
 		}
-	}
-	defer rows.Close()
-	res, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Reservation])
+		result := tx.SendBatch(ctx, batch)
+		rows, err := result.Query()
+		var pgErr *pgconn.PgError
+		if err != nil {
+			if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+				r.log.Warn("reservation_uid already exists", zap.Any("reservation_uid", id))
+			} else {
+				result.Close()
+				return fmt.Errorf("unable to insert row: %w", err)
+			}
+		}
+		res, err = pgx.CollectOneRow(rows, pgx.RowToStructByName[model.Reservation])
+		result.Close()
+		if err != nil {
+			return fmt.Errorf("pgx.CollectRows: %w", err)
+		}
+		return putEvent(ctx, tx, kafka.ReservationCreated, res)
+	})
 	if err != nil {
-		return model.Reservation{}, fmt.Errorf("pgx.CollectRows: %w", err)
+		return model.Reservation{}, err
 	}
 
 	return res, nil
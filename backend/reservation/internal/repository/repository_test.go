@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres/postgrestest"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
+	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
+	"github.com/Astemirdum/library-service/backend/reservation/migrations"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepository_GetReservations(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// two of the reservations start on the same day.
+	starts := []time.Time{
+		time.Date(2024, time.March, 1, 15, 30, 0, 0, time.UTC),
+		time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 2, 9, 0, 0, 0, time.UTC),
+	}
+	for _, start := range starts {
+		_, err := db.Exec(ctx, `insert into reservation (reservation_uid, username, book_uid, library_uid, status, start_date, till_date)
+		values ($1, 'user', $2, $3, 'RENTED', $4, $5)`, uuid.New(), uuid.New(), uuid.New(), start, start.AddDate(0, 0, 14))
+		require.NoError(t, err)
+	}
+
+	var read []time.Time
+	req := paging.Request{Size: 1, Sort: "start"}
+	for {
+		rsv, page, err := r.GetReservations(ctx, "user", req)
+		require.NoError(t, err)
+		for _, rs := range rsv {
+			read = append(read, rs.StartDate.UTC())
+		}
+		if page.Next == "" {
+			break
+		}
+		require.Less(t, len(read), len(starts), "the pages go on past the list")
+		req.Cursor = page.Next
+	}
+	require.Equal(t, []time.Time{starts[1], starts[0], starts[2]}, read)
+}
+
+func TestRepository_ReservationsReturn(t *testing.T) {
+	t.Parallel()
+	db := postgrestest.New(t, migrations.MigrationFiles)
+	r, err := NewRepository(db, time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	reserve := func() string {
+		uid := uuid.NewString()
+		_, err := db.Exec(ctx, `insert into reservation (reservation_uid, username, book_uid, library_uid, status, start_date, till_date)
+		values ($1, 'user', $2, $3, 'RENTED', $4, $5)`, uid, uuid.New(), uuid.New(), start, start.AddDate(0, 0, 14))
+		require.NoError(t, err)
+		return uid
+	}
+	req := model.ReservationReturnRequest{
+		Condition:   "GOOD",
+		Date:        model.Date{Time: start.AddDate(0, 0, 20)},
+		ProcessedBy: "librarian",
+	}
+
+	_, err = r.ReservationsReturn(ctx, "other", reserve(), req)
+	require.ErrorIs(t, err, errs.ErrNotFound, "a borrower returns only their own reservations")
+
+	// a librarian returns the reservation of any borrower, as of the day the copy was handed back.
+	resp, err := r.ReservationsReturn(ctx, "", reserve(), req)
+	require.NoError(t, err)
+	require.Equal(t, "user", resp.Username)
+	require.Equal(t, model.StatusExpired, resp.Status)
+	var processedBy string
+	require.NoError(t, db.QueryRow(ctx, `select processed_by from reservation where status = 'EXPIRED'`).Scan(&processedBy))
+	require.Equal(t, "librarian", processedBy)
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/reservation/internal/errs"
 
 	"github.com/Astemirdum/library-service/backend/reservation/internal/model"
@@ -12,14 +14,16 @@ import (
 )
 
 type Service struct {
-	log  *zap.Logger
-	repo repository.Repository
+	log     *zap.Logger
+	repo    repository.Repository
+	renewal model.RenewalPolicy
 }
 
-func NewService(repo repository.Repository, log *zap.Logger) *Service {
+func NewService(repo repository.Repository, renewal model.RenewalPolicy, log *zap.Logger) *Service {
 	return &Service{
-		log:  log,
-		repo: repo,
+		log:     log,
+		repo:    repo,
+		renewal: renewal,
 	}
 }
 
@@ -34,14 +38,94 @@ func (s *Service) CreateReservation(ctx context.Context, req model.CreateReserva
 	return s.repo.CreateReservation(ctx, req)
 }
 
-func (s *Service) GetReservations(ctx context.Context, username string) ([]model.Reservation, error) {
-	return s.repo.GetReservations(ctx, username)
+func (s *Service) GetReservations(ctx context.Context, username string, req paging.Request) ([]model.Reservation, paging.Page, error) {
+	return s.repo.GetReservations(ctx, username, req)
 }
 
-func (s *Service) ReservationsReturn(ctx context.Context, username, reservationUID string) (model.ReservationReturnResponse, error) {
-	return s.repo.ReservationsReturn(ctx, username, reservationUID)
+func (s *Service) ReservationsReturn(ctx context.Context, username, reservationUID string, req model.ReservationReturnRequest) (model.ReservationReturnResponse, error) {
+	if req.Date.After(time.Now()) {
+		return model.ReservationReturnResponse{}, errs.ErrReturnDate
+	}
+	return s.repo.ReservationsReturn(ctx, username, reservationUID, req)
+}
+
+func (s *Service) RenewReservation(ctx context.Context, req model.RenewReservationRequest) (model.RenewReservationResponse, error) {
+	if req.Stars < s.renewal.MinStars {
+		return model.RenewReservationResponse{}, errs.ErrLowRating
+	}
+	return s.repo.RenewReservation(ctx, req.UserName, req.ReservationUid, s.renewal, req.Schedule)
 }
 
 func (s *Service) RollbackReservation(ctx context.Context, uid string) error {
 	return s.repo.DeleteReservation(ctx, uid)
 }
+
+func (s *Service) RollbackReturn(ctx context.Context, uid string) error {
+	return s.repo.RestoreReservation(ctx, uid)
+}
+
+func (s *Service) CreateHold(ctx context.Context, req model.CreateHoldRequest) (model.Hold, error) {
+	return s.repo.CreateHold(ctx, req)
+}
+
+func (s *Service) GetHolds(ctx context.Context, username string) ([]model.Hold, error) {
+	return s.repo.GetHolds(ctx, username)
+}
+
+func (s *Service) GetHold(ctx context.Context, username, holdUID string) (model.Hold, error) {
+	return s.repo.GetHold(ctx, username, holdUID)
+}
+
+func (s *Service) CancelHold(ctx context.Context, username, holdUID string) error {
+	return s.repo.CancelHold(ctx, username, holdUID)
+}
+
+// ExpireHolds expires the unclaimed holds every interval until ctx is done.
+func (s *Service) ExpireHolds(ctx context.Context, interval time.Duration, batch int) {
+	s.every(ctx, interval, func() {
+		s.drain(ctx, "holds expired", batch, s.repo.ExpireHolds)
+	})
+}
+
+// ScanOverdue marks the reservations past their till date as OVERDUE and passes
+// their overdue days to rating every interval until ctx is done.
+// Run it on the leader instance only, see postgres.RunAsLeader.
+func (s *Service) ScanOverdue(ctx context.Context, interval time.Duration, batch int) {
+	s.every(ctx, interval, func() {
+		s.drain(ctx, "reservations overdue", batch, s.repo.MarkOverdue)
+		s.drain(ctx, "overdue days penalized", batch, s.repo.PenalizeOverdue)
+	})
+}
+
+// every runs fn right away and then every interval until ctx is done.
+func (s *Service) every(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain runs fn with batch until it handles less than a full batch.
+func (s *Service) drain(ctx context.Context, what string, batch int, fn func(ctx context.Context, limit int) (int, error)) {
+	for {
+		n, err := fn(ctx, batch)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error(what, zap.Error(err))
+			}
+			return
+		}
+		if n > 0 {
+			s.log.Info(what, zap.Int("count", n))
+		}
+		if n < batch {
+			return
+		}
+	}
+}
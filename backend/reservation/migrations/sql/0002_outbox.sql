@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id         bigint generated always as identity PRIMARY KEY,
+    topic      VARCHAR(255) NOT NULL,
+    key        VARCHAR(255) NOT NULL DEFAULT '',
+    payload    jsonb        NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox CASCADE;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS holds
+(
+    id              SERIAL PRIMARY KEY,
+    hold_uid        uuid UNIQUE NOT NULL,
+    username        VARCHAR(80) NOT NULL,
+    book_uid        uuid        NOT NULL,
+    library_uid     uuid        NOT NULL,
+    status          VARCHAR(20) NOT NULL
+        CHECK (status IN ('WAITING', 'READY', 'FULFILLED', 'EXPIRED', 'CANCELLED')),
+    created_at      TIMESTAMP   NOT NULL DEFAULT now(),
+    ready_at        TIMESTAMP,
+    expires_at      TIMESTAMP,
+    reservation_uid uuid
+);
+
+-- a user holds a book of a library at most once at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS holds_active_idx ON holds (username, library_uid, book_uid)
+    WHERE status IN ('WAITING', 'READY');
+CREATE INDEX IF NOT EXISTS holds_queue_idx ON holds (library_uid, book_uid, created_at, id)
+    WHERE status IN ('WAITING', 'READY');
+CREATE INDEX IF NOT EXISTS holds_expires_at_idx ON holds (expires_at)
+    WHERE status = 'READY';
+
+-- +goose Down
+DROP TABLE IF EXISTS holds CASCADE;
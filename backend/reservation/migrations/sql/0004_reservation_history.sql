@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS reservation_history
+(
+    id              SERIAL PRIMARY KEY,
+    reservation_uid uuid        NOT NULL REFERENCES reservation (reservation_uid) ON DELETE CASCADE,
+    action          VARCHAR(20) NOT NULL
+        CHECK (action IN ('RENEWED')),
+    old_till_date   TIMESTAMP   NOT NULL,
+    new_till_date   TIMESTAMP   NOT NULL,
+    created_at      TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS reservation_history_reservation_uid_idx ON reservation_history (reservation_uid, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS reservation_history CASCADE;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE reservation DROP CONSTRAINT IF EXISTS reservation_status_check;
+ALTER TABLE reservation ADD CONSTRAINT reservation_status_check
+    CHECK (status IN ('RENTED', 'OVERDUE', 'RETURNED', 'EXPIRED'));
+-- penalized_on is the last day the overdue days of the reservation were passed to rating.
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS penalized_on DATE;
+
+CREATE INDEX IF NOT EXISTS reservation_overdue_idx ON reservation (till_date)
+    WHERE status IN ('RENTED', 'OVERDUE');
+
+-- +goose Down
+DROP INDEX IF EXISTS reservation_overdue_idx;
+ALTER TABLE reservation DROP COLUMN IF EXISTS penalized_on;
+UPDATE reservation SET status = 'RENTED' WHERE status = 'OVERDUE';
+ALTER TABLE reservation DROP CONSTRAINT IF EXISTS reservation_status_check;
+ALTER TABLE reservation ADD CONSTRAINT reservation_status_check
+    CHECK (status IN ('RENTED', 'RETURNED', 'EXPIRED'));
@@ -0,0 +1,18 @@
+-- +goose Up
+-- checkout_condition is the condition of the copy when it was lent, it is null for the loans made before.
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS checkout_condition VARCHAR(20)
+    CHECK (checkout_condition IN ('EXCELLENT', 'GOOD', 'BAD'));
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS return_condition VARCHAR(20)
+    CHECK (return_condition IN ('EXCELLENT', 'GOOD', 'BAD'));
+-- return_date is the day the copy was handed back, which may be earlier than the day the return was processed.
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS return_date DATE;
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS processed_by VARCHAR(80);
+ALTER TABLE reservation ADD CONSTRAINT reservation_return_date_check
+    CHECK (return_date >= date(start_date));
+
+-- +goose Down
+ALTER TABLE reservation DROP CONSTRAINT IF EXISTS reservation_return_date_check;
+ALTER TABLE reservation DROP COLUMN IF EXISTS processed_by;
+ALTER TABLE reservation DROP COLUMN IF EXISTS return_date;
+ALTER TABLE reservation DROP COLUMN IF EXISTS return_condition;
+ALTER TABLE reservation DROP COLUMN IF EXISTS checkout_condition;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- copy_uid is the copy of the book lent on the reservation, it is null for the loans made before copies were tracked.
+ALTER TABLE reservation ADD COLUMN IF NOT EXISTS copy_uid uuid;
+
+-- +goose Down
+ALTER TABLE reservation DROP COLUMN IF EXISTS copy_uid;
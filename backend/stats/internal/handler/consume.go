@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
 
@@ -10,57 +9,11 @@ import (
 	"go.uber.org/zap"
 )
 
-type stats func(ctx context.Context, event kafka.EventStats) error
+type stats func(ctx context.Context, eventID string, event kafka.EventStats) error
 
-type Consumer struct {
-	statsHandler stats
-	log          *zap.Logger
-	ready        chan bool
-}
-
-func NewConsumer(stats stats, log *zap.Logger) *Consumer {
-	return &Consumer{
-		statsHandler: stats,
-		log:          log.Named("consumer"),
-		ready:        make(chan bool),
-	}
-}
-
-func (consumer *Consumer) Setup(sarama.ConsumerGroupSession) error {
-	// Mark the consumer as ready
-	close(consumer.ready)
-	return nil
-}
-
-func (consumer *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
-	return nil
-}
-
-func (consumer *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for {
-		select {
-		case message, ok := <-claim.Messages():
-			if !ok {
-				consumer.log.Warn("message channel was closed")
-				return nil
-			}
-			var event kafka.EventStats
-			if err := json.Unmarshal(message.Value, &event); err != nil {
-				consumer.log.Error("", zap.Error(err))
-				session.MarkMessage(message, "")
-				continue
-			}
-
-			if err := consumer.statsHandler(context.Background(), event); err != nil {
-				consumer.log.Error("consumer.statsHandler", zap.Error(err))
-				//session.MarkMessage(message, "")
-				continue
-			}
-
-			consumer.log.Debug("Message claimed:", zap.String("value", string(message.Value)), zap.Time("timestamp", message.Timestamp), zap.String("topic", message.Topic))
-			session.MarkMessage(message, "")
-		case <-session.Context().Done():
-			return nil
-		}
-	}
+func NewConsumer(stats stats, producer sarama.SyncProducer, cfg kafka.Config, log *zap.Logger) *kafka.TypedConsumer[kafka.Event[kafka.EventStats]] {
+	return kafka.NewTypedConsumer(kafka.Events[kafka.EventStats](kafka.EventReservationStats),
+		func(ctx context.Context, e kafka.Event[kafka.EventStats]) error {
+			return stats(ctx, e.EventID, e.Data)
+		}, producer, kafka.StatsConsumerGroup, cfg, log)
 }
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/Astemirdum/library-service/backend/pkg/auth0"
 	md "github.com/Astemirdum/library-service/backend/pkg/middleware"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 	"github.com/Astemirdum/library-service/backend/pkg/validate"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -31,7 +33,8 @@ func New(statsSvc StatsService, log *zap.Logger) *Handler {
 	return h
 }
 
-func (h *Handler) NewRouter() *echo.Echo {
+// NewRouter routes the API, /readyz answers whether ready passes.
+func (h *Handler) NewRouter(ready func() error) *echo.Echo {
 	e := echo.New()
 	const (
 		baseRPS = 10
@@ -47,6 +50,7 @@ func (h *Handler) NewRouter() *echo.Echo {
 
 	base := e.Group("", md.NewRateLimiter(baseRPS))
 	base.GET("/manage/health", h.Health)
+	base.GET("/readyz", md.Readiness(ready))
 
 	e.Validator = validate.NewCustomValidator()
 	api := e.Group("/api/v1",
@@ -70,8 +74,18 @@ func (h *Handler) GetStats(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "no admin")
 	}
 
-	stat, err := h.statsSvc.GetStats(ctx)
+	var req paging.Request
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	stat, err := h.statsSvc.GetStats(ctx, req)
 	if err != nil {
+		if errors.Is(err, paging.ErrInvalid) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 
 	statsModel "github.com/Astemirdum/library-service/backend/stats/internal/model"
 	"github.com/Astemirdum/library-service/backend/stats/internal/service"
@@ -12,8 +13,8 @@ import (
 //go:generate go run github.com/golang/mock/mockgen -source=service.go -destination=mocks/mock.go
 
 type StatsService interface {
-	GetStats(ctx context.Context) (statsModel.StatsInfo, error)
-	Stats(ctx context.Context, eventStats kafka.EventStats) error
+	GetStats(ctx context.Context, req paging.Request) (statsModel.StatsInfo, error)
+	Stats(ctx context.Context, eventID string, eventStats kafka.EventStats) error
 }
 
 var _ StatsService = (*service.Service)(nil)
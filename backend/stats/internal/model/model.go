@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+)
 
 type Stats struct {
 	UserName    string    `json:"username" db:"username"`
@@ -9,8 +13,11 @@ type Stats struct {
 	CountReserv int       `json:"cnt_reserv" db:"cnt_reserv"`
 	CountBooks  int       `json:"cnt_books" db:"cnt_books"`
 	CountLibs   int       `json:"cnt_libs" db:"cnt_libs"`
+	// CountOverdue is the number of reservations that went overdue.
+	CountOverdue int `json:"cnt_overdue" db:"cnt_overdue"`
 }
 
 type StatsInfo struct {
-	Data []Stats `json:"data"`
+	paging.Page `json:",inline"`
+	Data        []Stats `json:"data"`
 }
@@ -4,17 +4,22 @@ import (
 	"context"
 	"fmt"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	statsModel "github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
+	"github.com/Astemirdum/library-service/backend/pkg/postgres"
 	"github.com/Astemirdum/library-service/backend/stats/internal/model"
 	"go.uber.org/zap"
 )
 
+var qb = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
 type Repository interface {
-	GetStats(ctx context.Context) (model.StatsInfo, error)
-	Stats(ctx context.Context, events statsModel.EventStats) error
+	GetStats(ctx context.Context, req paging.Request) (model.StatsInfo, error)
+	Stats(ctx context.Context, eventID string, event statsModel.EventStats) error
 }
 
 type repository struct {
@@ -29,7 +34,7 @@ func NewRepository(db *pgxpool.Pool, log *zap.Logger) (*repository, error) {
 	}, nil
 }
 
-func (r *repository) Stats(ctx context.Context, event statsModel.EventStats) error {
+func (r *repository) Stats(ctx context.Context, eventID string, event statsModel.EventStats) error {
 	q := `insert into events (timestamp, username, reservation_uid, book_uid, library_uid, event_type, simplex, rating) 
 	values (@timestamp, @username, @reservation_uid, @book_uid, @library_uid, @event_type, @simplex, @rating)`
 	args := pgx.NamedArgs{
@@ -42,21 +47,37 @@ func (r *repository) Stats(ctx context.Context, event statsModel.EventStats) err
 		"event_type":      event.EventType,
 		"simplex":         event.Simplex,
 	}
-	_, err := r.db.Exec(ctx, q, args)
-	return err
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if ok, err := postgres.MarkProcessed(ctx, tx, eventID); err != nil || !ok {
+			return err
+		}
+		_, err := tx.Exec(ctx, q, args)
+		return err
+	})
+}
+
+// statsKeyset is the order of the stats, by the user.
+var statsKeyset = paging.Keyset[model.Stats]{
+	Key: paging.Column[model.Stats]{Expr: "username", Type: "text", Value: func(s model.Stats) any { return s.UserName }},
 }
 
-func (r *repository) GetStats(ctx context.Context) (model.StatsInfo, error) {
-	const q = `
-	select username, max(timestamp) as last_updated, (avg(rating) filter(where rating > 0))::int as rating,  
-	       coalesce(count(distinct reservation_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(distinct reservation_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_reserv, 
-	       coalesce(count(book_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(book_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_books,  
-	       coalesce(count(library_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(library_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_libs 
-	from events
-	group by username
-	order by username
-`
-	rows, err := r.db.Query(ctx, q)
+func (r *repository) GetStats(ctx context.Context, req paging.Request) (model.StatsInfo, error) {
+	pager, err := paging.NewPager(req, statsKeyset)
+	if err != nil {
+		return model.StatsInfo{}, err
+	}
+	q := qb.Select("username", "max(timestamp) as last_updated", "(avg(rating) filter(where rating > 0))::int as rating",
+		"coalesce(count(distinct reservation_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(distinct reservation_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_reserv",
+		"coalesce(count(book_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(book_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_books",
+		"coalesce(count(library_uid) filter ( where  simplex = 'UP'), 0) - coalesce(count(library_uid) filter ( where  simplex = 'DOWN'), 0) as cnt_libs",
+		"count(distinct reservation_uid) filter ( where event_type = 'OVERDUE') as cnt_overdue").
+		From("events").
+		GroupBy("username")
+	query, args, err := pager.Apply(q).ToSql()
+	if err != nil {
+		return model.StatsInfo{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return model.StatsInfo{}, err
 	}
@@ -65,5 +86,14 @@ func (r *repository) GetStats(ctx context.Context) (model.StatsInfo, error) {
 	if err != nil {
 		return model.StatsInfo{}, fmt.Errorf("pgx.CollectRows: %w", err)
 	}
-	return model.StatsInfo{Data: stats}, nil
+	stats, page := pager.Collect(stats)
+
+	if req.WithTotal() {
+		var totalElements int
+		if err := r.db.QueryRow(ctx, `select count(distinct username) from events`).Scan(&totalElements); err != nil {
+			return model.StatsInfo{}, err
+		}
+		page.TotalElements = &totalElements
+	}
+	return model.StatsInfo{Page: page, Data: stats}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	statsModel "github.com/Astemirdum/library-service/backend/pkg/kafka"
+	"github.com/Astemirdum/library-service/backend/pkg/paging"
 
 	"github.com/Astemirdum/library-service/backend/stats/internal/model"
 	statsRepo "github.com/Astemirdum/library-service/backend/stats/internal/repository"
@@ -23,11 +24,11 @@ func NewService(repo statsRepo.Repository, log *zap.Logger) *Service {
 }
 
 // GetStats get stats by user.
-func (s *Service) GetStats(ctx context.Context) (model.StatsInfo, error) {
-	return s.repo.GetStats(ctx)
+func (s *Service) GetStats(ctx context.Context, req paging.Request) (model.StatsInfo, error) {
+	return s.repo.GetStats(ctx, req)
 }
 
 // Stats used by kafka consumer.
-func (s *Service) Stats(ctx context.Context, event statsModel.EventStats) error {
-	return s.repo.Stats(ctx, event)
+func (s *Service) Stats(ctx context.Context, eventID string, event statsModel.EventStats) error {
+	return s.repo.Stats(ctx, eventID, event)
 }
@@ -70,6 +70,17 @@ Create chart name and version as used by the chart label.
 {{- else }}
 {{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" }}
 {{- end }}
+
+{{- define "fines.fullname" -}}
+{{- if .Values.fines.fullname }}
+{{- .Values.fines.fullname | trunc 63 | trimSuffix "-" }}
+{{- else }}
+{{- $name := default .Chart.Name .Values.fines.name }}
+{{- if contains $name .Release.Name }}
+{{- .Release.Name | trunc 63 | trimSuffix "-" }}
+{{- else }}
+{{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" }}
+{{- end }}
 {{- end }}
 {{- end }}
 
@@ -168,6 +179,11 @@ app.kubernetes.io/name: {{ include "stats.fullname" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
 
+{{- define "fines.selectorLabels" -}}
+app.kubernetes.io/name: {{ include "fines.fullname" . }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end }}
+
 
 {{- define "provider.selectorLabels" -}}
 app.kubernetes.io/name: {{ include "provider.fullname" . }}
@@ -198,8 +214,8 @@ type: {{ .Values.app.strategy.type}}
 {{/*health*/}}
 {{- define "library.health" -}}
 readinessProbe:
-  httpGet: &health
-    path: /manage/health
+  httpGet:
+    path: /readyz
     port: {{ .Values.configData.library.http.port }}
     scheme: HTTP
   initialDelaySeconds: 20
@@ -207,7 +223,10 @@ readinessProbe:
   periodSeconds: 30
   timeoutSeconds: 5
 livenessProbe:
-  httpGet: *health
+  httpGet: &health
+    path: /manage/health
+    port: {{ .Values.configData.library.http.port }}
+    scheme: HTTP
   failureThreshold: 5
   periodSeconds: 60
   timeoutSeconds: 5
@@ -224,8 +243,8 @@ startupProbe:
 {{/*health*/}}
 {{- define "gateway.health" -}}
 readinessProbe:
-  httpGet: &health
-    path: /manage/health
+  httpGet:
+    path: /readyz
     port: {{ .Values.configData.gateway.http.port }}
     scheme: HTTP
   initialDelaySeconds: 20
@@ -233,7 +252,10 @@ readinessProbe:
   periodSeconds: 30
   timeoutSeconds: 5
 livenessProbe:
-  httpGet: *health
+  httpGet: &health
+    path: /manage/health
+    port: {{ .Values.configData.gateway.http.port }}
+    scheme: HTTP
   failureThreshold: 5
   periodSeconds: 60
   timeoutSeconds: 5
@@ -252,16 +274,47 @@ startupProbe:
 {{/*health*/}}
 {{- define "stats.health" -}}
 readinessProbe:
+  httpGet:
+    path: /readyz
+    port: {{ .Values.configData.stats.http.port }}
+    scheme: HTTP
+  initialDelaySeconds: 20
+  failureThreshold: 3
+  periodSeconds: 30
+  timeoutSeconds: 5
+livenessProbe:
   httpGet: &health
     path: /manage/health
     port: {{ .Values.configData.stats.http.port }}
     scheme: HTTP
+  failureThreshold: 5
+  periodSeconds: 60
+  timeoutSeconds: 5
+  successThreshold: 1
+  initialDelaySeconds: 10
+startupProbe:
+  failureThreshold: 10
+  httpGet: *health
+  periodSeconds: 10
+  timeoutSeconds: 5
+  successThreshold: 1
+{{- end }}
+
+{{- define "fines.health" -}}
+readinessProbe:
+  httpGet:
+    path: /readyz
+    port: {{ .Values.configData.fines.http.port }}
+    scheme: HTTP
   initialDelaySeconds: 20
   failureThreshold: 3
   periodSeconds: 30
   timeoutSeconds: 5
 livenessProbe:
-  httpGet: *health
+  httpGet: &health
+    path: /manage/health
+    port: {{ .Values.configData.fines.http.port }}
+    scheme: HTTP
   failureThreshold: 5
   periodSeconds: 60
   timeoutSeconds: 5
@@ -304,8 +357,8 @@ startupProbe:
 {{/*health*/}}
 {{- define "rating.health" -}}
 readinessProbe:
-  httpGet: &health
-    path: /manage/health
+  httpGet:
+    path: /readyz
     port: {{ .Values.configData.rating.http.port }}
     scheme: HTTP
   initialDelaySeconds: 20
@@ -313,7 +366,10 @@ readinessProbe:
   periodSeconds: 30
   timeoutSeconds: 5
 livenessProbe:
-  httpGet: *health
+  httpGet: &health
+    path: /manage/health
+    port: {{ .Values.configData.rating.http.port }}
+    scheme: HTTP
   failureThreshold: 5
   periodSeconds: 60
   timeoutSeconds: 5
@@ -330,8 +386,8 @@ startupProbe:
 {{/*health*/}}
 {{- define "reservation.health" -}}
 readinessProbe:
-  httpGet: &health
-    path: /manage/health
+  httpGet:
+    path: /readyz
     port: {{ .Values.configData.reservation.http.port }}
     scheme: HTTP
   initialDelaySeconds: 20
@@ -339,7 +395,10 @@ readinessProbe:
   periodSeconds: 30
   timeoutSeconds: 5
 livenessProbe:
-  httpGet: *health
+  httpGet: &health
+    path: /manage/health
+    port: {{ .Values.configData.reservation.http.port }}
+    scheme: HTTP
   failureThreshold: 5
   periodSeconds: 60
   timeoutSeconds: 5
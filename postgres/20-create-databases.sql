@@ -11,5 +11,11 @@ GRANT ALL PRIVILEGES ON DATABASE ratings TO program;
 CREATE DATABASE stats;
 GRANT ALL PRIVILEGES ON DATABASE stats TO program;
 
+CREATE DATABASE fines;
+GRANT ALL PRIVILEGES ON DATABASE fines TO program;
+
 CREATE DATABASE users;
-GRANT ALL PRIVILEGES ON DATABASE users TO program;
\ No newline at end of file
+GRANT ALL PRIVILEGES ON DATABASE users TO program;
+
+CREATE DATABASE gateway;
+GRANT ALL PRIVILEGES ON DATABASE gateway TO program;
\ No newline at end of file